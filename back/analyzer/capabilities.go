@@ -0,0 +1,86 @@
+package analyzer
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"karto/crds/calico"
+	"karto/crds/cilium"
+	"karto/crds/istio"
+	"karto/crds/mcs"
+	"karto/crds/policyapi"
+	"karto/types"
+	"sort"
+)
+
+const (
+	podAnalysis           = "pods"
+	trafficAnalysis       = "traffic"
+	meshAnalysis          = "mesh"
+	workloadAnalysis      = "workloads"
+	healthAnalysis        = "health"
+	configurationAnalysis = "configuration"
+	lintAnalysis          = "lint"
+	complianceAnalysis    = "compliance"
+	crossClusterAnalysis  = "crossCluster"
+	exposureAnalysis      = "exposure"
+	podSecurityAnalysis   = "podSecurity"
+)
+
+var analysesByResource = map[string][]string{
+	"namespaces": {trafficAnalysis, meshAnalysis, configurationAnalysis, lintAnalysis, complianceAnalysis,
+		crossClusterAnalysis, exposureAnalysis, podSecurityAnalysis},
+	"pods": {podAnalysis, trafficAnalysis, meshAnalysis, workloadAnalysis, healthAnalysis, configurationAnalysis,
+		lintAnalysis, complianceAnalysis, crossClusterAnalysis, exposureAnalysis, podSecurityAnalysis},
+	"services": {trafficAnalysis, workloadAnalysis, healthAnalysis, configurationAnalysis,
+		crossClusterAnalysis, exposureAnalysis},
+	"events":                      {healthAnalysis},
+	"ingresses.networking.k8s.io": {workloadAnalysis, exposureAnalysis},
+	"replicasets.apps":            {workloadAnalysis, configurationAnalysis},
+	"statefulsets.apps":           {workloadAnalysis, healthAnalysis, configurationAnalysis},
+	"daemonsets.apps":             {workloadAnalysis, healthAnalysis, configurationAnalysis},
+	"deployments.apps":            {workloadAnalysis, healthAnalysis, configurationAnalysis},
+	"networkpolicies.networking.k8s.io": {trafficAnalysis, meshAnalysis, lintAnalysis, complianceAnalysis,
+		crossClusterAnalysis, exposureAnalysis},
+	resourceName(cilium.NetworkPolicyResource): {trafficAnalysis, meshAnalysis, crossClusterAnalysis,
+		exposureAnalysis},
+	resourceName(cilium.ClusterwideNetworkPolicyResource): {trafficAnalysis, meshAnalysis, crossClusterAnalysis,
+		exposureAnalysis},
+	resourceName(calico.NetworkPolicyResource): {trafficAnalysis, meshAnalysis, crossClusterAnalysis,
+		exposureAnalysis},
+	resourceName(calico.GlobalNetworkPolicyResource): {trafficAnalysis, meshAnalysis, crossClusterAnalysis,
+		exposureAnalysis},
+	resourceName(calico.TierResource): {trafficAnalysis, meshAnalysis, crossClusterAnalysis, exposureAnalysis},
+	resourceName(policyapi.AdminNetworkPolicyResource): {trafficAnalysis, meshAnalysis, crossClusterAnalysis,
+		exposureAnalysis},
+	resourceName(policyapi.BaselineAdminNetworkPolicyResource): {trafficAnalysis, meshAnalysis,
+		crossClusterAnalysis, exposureAnalysis},
+	resourceName(istio.AuthorizationPolicyResource): {meshAnalysis},
+	resourceName(istio.PeerAuthenticationResource):  {meshAnalysis},
+	resourceName(istio.ServiceEntryResource):        {trafficAnalysis},
+	resourceName(mcs.ServiceExportResource):         {crossClusterAnalysis},
+	resourceName(mcs.ServiceImportResource):         {crossClusterAnalysis},
+}
+
+func resourceName(resource schema.GroupVersionResource) string {
+	return resource.GroupResource().String()
+}
+
+func capabilities(forbiddenResources []types.ForbiddenResource) *types.Capabilities {
+	if len(forbiddenResources) == 0 {
+		return nil
+	}
+	partialAnalyses := make(map[string]bool)
+	for _, forbiddenResource := range forbiddenResources {
+		for _, analysis := range analysesByResource[forbiddenResource.Resource] {
+			partialAnalyses[analysis] = true
+		}
+	}
+	result := &types.Capabilities{
+		ForbiddenResources: forbiddenResources,
+		PartialAnalyses:    make([]string, 0, len(partialAnalyses)),
+	}
+	for analysis := range partialAnalyses {
+		result.PartialAnalyses = append(result.PartialAnalyses, analysis)
+	}
+	sort.Strings(result.PartialAnalyses)
+	return result
+}
@@ -0,0 +1,344 @@
+package compliance
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"karto/analyzer/shared"
+	"karto/types"
+	"sort"
+)
+
+const (
+	fullCoverage   = 100
+	anyIPv4Address = "0.0.0.0/0"
+	anyIPv6Address = "::/0"
+	worldEntity    = "world"
+)
+
+type ClusterState struct {
+	Namespaces      []*corev1.Namespace
+	NetworkPolicies []*networkingv1.NetworkPolicy
+	PodIsolations   []*shared.PodIsolation
+}
+
+type AnalysisResult struct {
+	Report *types.ComplianceReport
+}
+
+type Analyzer interface {
+	Analyze(clusterState ClusterState) AnalysisResult
+}
+
+type analyzerImpl struct{}
+
+func NewAnalyzer() Analyzer {
+	return analyzerImpl{}
+}
+
+type peerCoverage struct {
+	pods            []*corev1.Pod
+	namespaceLabels map[string]map[string]string
+	coversAll       map[*shared.SourcePeer]bool
+	coversAny       map[*shared.SourcePeer]bool
+}
+
+type podCompliance struct {
+	pod                          *corev1.Pod
+	isIngressIsolated            bool
+	isEgressIsolated             bool
+	isReachableFromAllNamespaces bool
+	hasUnrestrictedEgress        bool
+}
+
+func (analyzer analyzerImpl) Analyze(clusterState ClusterState) AnalysisResult {
+	namespaces := make([]*corev1.Namespace, len(clusterState.Namespaces))
+	copy(namespaces, clusterState.Namespaces)
+	sort.Slice(namespaces, func(i, j int) bool {
+		return namespaces[i].Name < namespaces[j].Name
+	})
+	coverage := analyzer.newPeerCoverage(clusterState.PodIsolations, clusterState.Namespaces)
+	pods := make([]*podCompliance, 0, len(clusterState.PodIsolations))
+	for _, podIsolation := range clusterState.PodIsolations {
+		pods = append(pods, analyzer.podComplianceOf(podIsolation, coverage))
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		if pods[i].pod.Namespace != pods[j].pod.Namespace {
+			return pods[i].pod.Namespace < pods[j].pod.Namespace
+		}
+		return pods[i].pod.Name < pods[j].pod.Name
+	})
+	report := &types.ComplianceReport{
+		Coverage:                            analyzer.coverageOf(pods),
+		NamespacesWithoutIngressDefaultDeny: make([]string, 0),
+		NamespacesWithoutEgressDefaultDeny:  make([]string, 0),
+		Namespaces:                          make([]*types.NamespaceCompliance, 0, len(namespaces)),
+	}
+	for _, namespace := range namespaces {
+		namespaceCompliance := analyzer.namespaceComplianceOf(namespace, pods, clusterState.NetworkPolicies)
+		if !namespaceCompliance.HasIngressDefaultDeny {
+			report.NamespacesWithoutIngressDefaultDeny = append(report.NamespacesWithoutIngressDefaultDeny,
+				namespace.Name)
+		}
+		if !namespaceCompliance.HasEgressDefaultDeny {
+			report.NamespacesWithoutEgressDefaultDeny = append(report.NamespacesWithoutEgressDefaultDeny,
+				namespace.Name)
+		}
+		report.Namespaces = append(report.Namespaces, namespaceCompliance)
+	}
+	return AnalysisResult{
+		Report: report,
+	}
+}
+
+func (analyzer analyzerImpl) newPeerCoverage(podIsolations []*shared.PodIsolation,
+	namespaces []*corev1.Namespace) *peerCoverage {
+	result := &peerCoverage{
+		pods:            make([]*corev1.Pod, 0, len(podIsolations)),
+		namespaceLabels: make(map[string]map[string]string),
+		coversAll:       make(map[*shared.SourcePeer]bool),
+		coversAny:       make(map[*shared.SourcePeer]bool),
+	}
+	for _, podIsolation := range podIsolations {
+		pod := podIsolation.Pod
+		if pod.Spec.HostNetwork {
+			continue
+		}
+		result.pods = append(result.pods, pod)
+		if _, ok := result.namespaceLabels[pod.Namespace]; !ok {
+			result.namespaceLabels[pod.Namespace] = shared.NamespaceLabels(pod.Namespace, namespaces)
+		}
+	}
+	return result
+}
+
+func (analyzer analyzerImpl) podComplianceOf(podIsolation *shared.PodIsolation,
+	coverage *peerCoverage) *podCompliance {
+	result := &podCompliance{
+		pod:               podIsolation.Pod,
+		isIngressIsolated: podIsolation.IsIngressIsolated(),
+		isEgressIsolated:  podIsolation.IsEgressIsolated(),
+	}
+	if !result.isIngressIsolated {
+		result.isReachableFromAllNamespaces = true
+	} else if len(podIsolation.IngressSourcePolicies) == 0 {
+		for _, policy := range podIsolation.IngressPolicies {
+			for _, ingressRule := range policy.Spec.Ingress {
+				result.isReachableFromAllNamespaces = result.isReachableFromAllNamespaces ||
+					analyzer.peersIncludeAllNamespaces(ingressRule.From)
+			}
+		}
+	} else {
+		result.isReachableFromAllNamespaces = analyzer.allows(podIsolation.IngressOrderedPolicies(),
+			shared.HasTieredPolicies(podIsolation.IngressSourcePolicies),
+			shared.ResolvedRules(shared.IngressRules, podIsolation.Pod), func(rule shared.SourceRule) bool {
+				if analyzer.isAllowRule(rule) {
+					return analyzer.includesAnyAddress(rule) || coverage.coversAllPods(rule)
+				}
+				return len(rule.Externals()) != 0 || coverage.coversAnyPod(rule)
+			}, false)
+	}
+	if !result.isEgressIsolated {
+		result.hasUnrestrictedEgress = true
+	} else if len(podIsolation.EgressSourcePolicies) == 0 {
+		for _, policy := range podIsolation.EgressPolicies {
+			for _, egressRule := range policy.Spec.Egress {
+				result.hasUnrestrictedEgress = result.hasUnrestrictedEgress ||
+					(len(egressRule.Ports) == 0 && analyzer.peersIncludeAnyDestination(egressRule.To))
+			}
+		}
+	} else {
+		result.hasUnrestrictedEgress = analyzer.allows(podIsolation.EgressOrderedPolicies(),
+			shared.HasTieredPolicies(podIsolation.EgressSourcePolicies), shared.EgressRules,
+			func(rule shared.SourceRule) bool {
+				if analyzer.isAllowRule(rule) {
+					return analyzer.includesAnyAddress(rule)
+				}
+				return len(rule.Externals()) != 0 || coverage.coversAnyPod(rule)
+			}, true)
+	}
+	return result
+}
+
+func (analyzer analyzerImpl) allows(policies []*shared.SourcePolicy, tiered bool, rulesOf shared.RulesOf,
+	matches shared.RuleMatcher, onAllPorts bool) bool {
+	var allowsPort func(port int32) bool
+	if tiered {
+		tiers := shared.OrderedTiers(policies)
+		allowsPort = func(port int32) bool {
+			return shared.Evaluate(tiers, rulesOf, matches, port).Allowed
+		}
+	} else {
+		allowsPort = func(port int32) bool {
+			return analyzer.unorderedAllows(policies, rulesOf, matches, port)
+		}
+	}
+	for _, interval := range shared.PortIntervals(shared.CandidatePorts(policies, rulesOf)) {
+		allowed := allowsPort(interval.Start)
+		if allowed && !onAllPorts {
+			return true
+		}
+		if !allowed && onAllPorts {
+			return false
+		}
+	}
+	return onAllPorts
+}
+
+func (analyzer analyzerImpl) unorderedAllows(policies []*shared.SourcePolicy, rulesOf shared.RulesOf,
+	matches shared.RuleMatcher, port int32) bool {
+	allowed := false
+	for _, policy := range policies {
+		for _, rule := range rulesOf(policy) {
+			if !rule.MatchesPort(port) || !matches(rule) {
+				continue
+			}
+			if !analyzer.isAllowRule(rule) {
+				return false
+			}
+			allowed = true
+		}
+	}
+	return allowed
+}
+
+func (analyzer analyzerImpl) isAllowRule(rule shared.SourceRule) bool {
+	return rule.Action == "" || rule.Action == shared.RuleActionAllow
+}
+
+func (analyzer analyzerImpl) includesAnyAddress(rule shared.SourceRule) bool {
+	for _, external := range rule.Externals() {
+		if (external.Kind == types.ExternalPeerEntity && external.Name == worldEntity) ||
+			(external.Kind == types.ExternalPeerCIDR &&
+				(external.Name == anyIPv4Address || external.Name == anyIPv6Address)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (coverage *peerCoverage) coversAllPods(rule shared.SourceRule) bool {
+	if len(rule.Peers) == 0 || len(coverage.pods) == 0 {
+		return false
+	}
+	key := &rule.Peers[0]
+	covers, ok := coverage.coversAll[key]
+	if !ok {
+		covers = true
+		for _, pod := range coverage.pods {
+			if !rule.AllowsPod(pod, coverage.namespaceLabels[pod.Namespace]) {
+				covers = false
+				break
+			}
+		}
+		coverage.coversAll[key] = covers
+	}
+	return covers
+}
+
+func (coverage *peerCoverage) coversAnyPod(rule shared.SourceRule) bool {
+	if len(rule.Peers) == 0 {
+		return false
+	}
+	key := &rule.Peers[0]
+	covers, ok := coverage.coversAny[key]
+	if !ok {
+		for _, pod := range coverage.pods {
+			if rule.AllowsPod(pod, coverage.namespaceLabels[pod.Namespace]) {
+				covers = true
+				break
+			}
+		}
+		coverage.coversAny[key] = covers
+	}
+	return covers
+}
+
+func (analyzer analyzerImpl) peersIncludeAllNamespaces(peers []networkingv1.NetworkPolicyPeer) bool {
+	if len(peers) == 0 {
+		return true
+	}
+	for _, peer := range peers {
+		if analyzer.isAnyAddress(peer) || (peer.NamespaceSelector != nil &&
+			analyzer.isEmptySelector(peer.NamespaceSelector) && analyzer.isEmptySelector(peer.PodSelector)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (analyzer analyzerImpl) peersIncludeAnyDestination(peers []networkingv1.NetworkPolicyPeer) bool {
+	if len(peers) == 0 {
+		return true
+	}
+	for _, peer := range peers {
+		if analyzer.isAnyAddress(peer) {
+			return true
+		}
+	}
+	return false
+}
+
+func (analyzer analyzerImpl) isAnyAddress(peer networkingv1.NetworkPolicyPeer) bool {
+	return peer.IPBlock != nil && len(peer.IPBlock.Except) == 0 &&
+		(peer.IPBlock.CIDR == anyIPv4Address || peer.IPBlock.CIDR == anyIPv6Address)
+}
+
+func (analyzer analyzerImpl) isEmptySelector(selector *metav1.LabelSelector) bool {
+	return selector == nil || (len(selector.MatchLabels) == 0 && len(selector.MatchExpressions) == 0)
+}
+
+func (analyzer analyzerImpl) namespaceComplianceOf(namespace *corev1.Namespace, pods []*podCompliance,
+	policies []*networkingv1.NetworkPolicy) *types.NamespaceCompliance {
+	result := &types.NamespaceCompliance{Namespace: namespace.Name}
+	for _, policy := range policies {
+		if policy.Namespace != namespace.Name {
+			continue
+		}
+		isIngressDefaultDeny, isEgressDefaultDeny := shared.DefaultDenyTypes(policy)
+		result.HasIngressDefaultDeny = result.HasIngressDefaultDeny || isIngressDefaultDeny
+		result.HasEgressDefaultDeny = result.HasEgressDefaultDeny || isEgressDefaultDeny
+	}
+	namespacePods := make([]*podCompliance, 0)
+	for _, pod := range pods {
+		if pod.pod.Namespace == namespace.Name {
+			namespacePods = append(namespacePods, pod)
+		}
+	}
+	result.Coverage = analyzer.coverageOf(namespacePods)
+	return result
+}
+
+func (analyzer analyzerImpl) coverageOf(pods []*podCompliance) types.IsolationCoverage {
+	coverage := types.IsolationCoverage{
+		Pods:                           len(pods),
+		PodsReachableFromAllNamespaces: make([]types.PodRef, 0),
+		PodsWithUnrestrictedEgress:     make([]types.PodRef, 0),
+	}
+	for _, pod := range pods {
+		if pod.isIngressIsolated {
+			coverage.IngressIsolatedPods++
+		}
+		if pod.isEgressIsolated {
+			coverage.EgressIsolatedPods++
+		}
+		if pod.isReachableFromAllNamespaces {
+			coverage.PodsReachableFromAllNamespaces = append(coverage.PodsReachableFromAllNamespaces,
+				shared.ToPodRef(pod.pod))
+		}
+		if pod.hasUnrestrictedEgress {
+			coverage.PodsWithUnrestrictedEgress = append(coverage.PodsWithUnrestrictedEgress,
+				shared.ToPodRef(pod.pod))
+		}
+	}
+	coverage.IngressIsolatedPercentage = analyzer.percentage(coverage.IngressIsolatedPods, coverage.Pods)
+	coverage.EgressIsolatedPercentage = analyzer.percentage(coverage.EgressIsolatedPods, coverage.Pods)
+	return coverage
+}
+
+func (analyzer analyzerImpl) percentage(count int, total int) float64 {
+	if total == 0 {
+		return fullCoverage
+	}
+	return float64(count) * fullCoverage / float64(total)
+}
@@ -0,0 +1,288 @@
+package compliance
+
+import (
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"karto/analyzer/shared"
+	"karto/analyzer/traffic/podisolation"
+	"karto/testutils"
+	"karto/types"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	type args struct {
+		namespaces      []*corev1.Namespace
+		pods            []*corev1.Pod
+		networkPolicies []*networkingv1.NetworkPolicy
+		sourcePolicies  []*shared.SourcePolicy
+	}
+	port53 := intstr.FromInt(53)
+	adminTierOrder := 1000.0
+	podNamed := func(name string) shared.PodMatcher {
+		return func(pod *corev1.Pod, namespaceLabels map[string]string) bool { return pod.Name == name }
+	}
+	podsOfNamespace := func(namespace string) shared.PodMatcher {
+		return func(pod *corev1.Pod, namespaceLabels map[string]string) bool { return pod.Namespace == namespace }
+	}
+	allPods := func(pod *corev1.Pod, namespaceLabels map[string]string) bool { return true }
+	tests := []struct {
+		name           string
+		args           args
+		expectedResult AnalysisResult
+	}{
+		{
+			name: "computes isolation coverage and default-deny policies per namespace and cluster-wide",
+			args: args{
+				namespaces: []*corev1.Namespace{
+					testutils.NewNamespaceBuilder().WithName("ns2").Build(),
+					testutils.NewNamespaceBuilder().WithName("ns1").Build(),
+				},
+				pods: []*corev1.Pod{
+					testutils.NewPodBuilder().WithName("pod1").WithNamespace("ns1").Build(),
+					testutils.NewPodBuilder().WithName("pod2").WithNamespace("ns2").Build(),
+				},
+				networkPolicies: []*networkingv1.NetworkPolicy{
+					testutils.NewNetworkPolicyBuilder().WithName("deny-all").WithNamespace("ns1").
+						WithTypes("Ingress", "Egress").
+						WithPodSelector(testutils.NewLabelSelectorBuilder().Build()).Build(),
+				},
+			},
+			expectedResult: AnalysisResult{
+				Report: &types.ComplianceReport{
+					Coverage: types.IsolationCoverage{
+						Pods:                           2,
+						IngressIsolatedPods:            1,
+						EgressIsolatedPods:             1,
+						IngressIsolatedPercentage:      50,
+						EgressIsolatedPercentage:       50,
+						PodsReachableFromAllNamespaces: []types.PodRef{{Name: "pod2", Namespace: "ns2"}},
+						PodsWithUnrestrictedEgress:     []types.PodRef{{Name: "pod2", Namespace: "ns2"}},
+					},
+					NamespacesWithoutIngressDefaultDeny: []string{"ns2"},
+					NamespacesWithoutEgressDefaultDeny:  []string{"ns2"},
+					Namespaces: []*types.NamespaceCompliance{
+						{
+							Namespace:             "ns1",
+							HasIngressDefaultDeny: true,
+							HasEgressDefaultDeny:  true,
+							Coverage: types.IsolationCoverage{
+								Pods:                           1,
+								IngressIsolatedPods:            1,
+								EgressIsolatedPods:             1,
+								IngressIsolatedPercentage:      100,
+								EgressIsolatedPercentage:       100,
+								PodsReachableFromAllNamespaces: []types.PodRef{},
+								PodsWithUnrestrictedEgress:     []types.PodRef{},
+							},
+						},
+						{
+							Namespace:             "ns2",
+							HasIngressDefaultDeny: false,
+							HasEgressDefaultDeny:  false,
+							Coverage: types.IsolationCoverage{
+								Pods:                           1,
+								IngressIsolatedPods:            0,
+								EgressIsolatedPods:             0,
+								IngressIsolatedPercentage:      0,
+								EgressIsolatedPercentage:       0,
+								PodsReachableFromAllNamespaces: []types.PodRef{{Name: "pod2", Namespace: "ns2"}},
+								PodsWithUnrestrictedEgress:     []types.PodRef{{Name: "pod2", Namespace: "ns2"}},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "detects isolated pods still reachable from all namespaces or with unrestricted egress",
+			args: args{
+				namespaces: []*corev1.Namespace{
+					testutils.NewNamespaceBuilder().WithName("ns").Build(),
+				},
+				pods: []*corev1.Pod{
+					testutils.NewPodBuilder().WithName("open").WithNamespace("ns").
+						WithLabel("app", "open").Build(),
+					testutils.NewPodBuilder().WithName("restricted").WithNamespace("ns").
+						WithLabel("app", "restricted").Build(),
+				},
+				networkPolicies: []*networkingv1.NetworkPolicy{
+					testutils.NewNetworkPolicyBuilder().WithName("open").WithNamespace("ns").
+						WithTypes("Ingress", "Egress").
+						WithPodSelector(testutils.NewLabelSelectorBuilder().WithMatchLabel("app", "open").
+							Build()).
+						WithIngressRule(networkingv1.NetworkPolicyIngressRule{
+							From: []networkingv1.NetworkPolicyPeer{
+								{NamespaceSelector: testutils.NewLabelSelectorBuilder().Build()},
+							},
+						}).
+						WithEgressRule(networkingv1.NetworkPolicyEgressRule{}).Build(),
+					testutils.NewNetworkPolicyBuilder().WithName("restricted").WithNamespace("ns").
+						WithTypes("Ingress", "Egress").
+						WithPodSelector(testutils.NewLabelSelectorBuilder().
+							WithMatchLabel("app", "restricted").Build()).
+						WithIngressRule(networkingv1.NetworkPolicyIngressRule{
+							From: []networkingv1.NetworkPolicyPeer{
+								{PodSelector: testutils.NewLabelSelectorBuilder().Build()},
+							},
+						}).
+						WithEgressRule(networkingv1.NetworkPolicyEgressRule{
+							Ports: []networkingv1.NetworkPolicyPort{{Port: &port53}},
+						}).Build(),
+				},
+			},
+			expectedResult: AnalysisResult{
+				Report: &types.ComplianceReport{
+					Coverage: types.IsolationCoverage{
+						Pods:                           2,
+						IngressIsolatedPods:            2,
+						EgressIsolatedPods:             2,
+						IngressIsolatedPercentage:      100,
+						EgressIsolatedPercentage:       100,
+						PodsReachableFromAllNamespaces: []types.PodRef{{Name: "open", Namespace: "ns"}},
+						PodsWithUnrestrictedEgress:     []types.PodRef{{Name: "open", Namespace: "ns"}},
+					},
+					NamespacesWithoutIngressDefaultDeny: []string{"ns"},
+					NamespacesWithoutEgressDefaultDeny:  []string{"ns"},
+					Namespaces: []*types.NamespaceCompliance{
+						{
+							Namespace: "ns",
+							Coverage: types.IsolationCoverage{
+								Pods:                           2,
+								IngressIsolatedPods:            2,
+								EgressIsolatedPods:             2,
+								IngressIsolatedPercentage:      100,
+								EgressIsolatedPercentage:       100,
+								PodsReachableFromAllNamespaces: []types.PodRef{{Name: "open", Namespace: "ns"}},
+								PodsWithUnrestrictedEgress:     []types.PodRef{{Name: "open", Namespace: "ns"}},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "evaluates policies of other sources and never isolates host network pods",
+			args: args{
+				namespaces: []*corev1.Namespace{
+					testutils.NewNamespaceBuilder().WithName("ns").Build(),
+					testutils.NewNamespaceBuilder().WithName("other").Build(),
+				},
+				pods: []*corev1.Pod{
+					testutils.NewPodBuilder().WithName("api").WithNamespace("ns").Build(),
+					testutils.NewPodBuilder().WithName("web").WithNamespace("ns").Build(),
+					testutils.NewPodBuilder().WithName("node-agent").WithNamespace("ns").WithHostNetwork().Build(),
+					testutils.NewPodBuilder().WithName("client").WithNamespace("other").Build(),
+				},
+				networkPolicies: []*networkingv1.NetworkPolicy{
+					testutils.NewNetworkPolicyBuilder().WithName("deny-all").WithNamespace("ns").
+						WithTypes("Ingress", "Egress").
+						WithPodSelector(testutils.NewLabelSelectorBuilder().Build()).Build(),
+				},
+				sourcePolicies: []*shared.SourcePolicy{
+					{
+						Ref:       types.NetworkPolicy{Kind: "CiliumNetworkPolicy.cilium.io", Name: "api", Namespace: "ns"},
+						Selects:   podNamed("api"),
+						IsIngress: true,
+						IsEgress:  true,
+						Ingress:   []shared.SourceRule{{Peers: []shared.SourcePeer{{Pods: podsOfNamespace("ns")}}}},
+						Egress: []shared.SourceRule{{Peers: []shared.SourcePeer{
+							{External: &types.ExternalPeer{Kind: types.ExternalPeerEntity, Name: "world"}},
+						}}},
+					},
+					{
+						Ref: types.NetworkPolicy{Kind: "AdminNetworkPolicy.policy.networking.k8s.io", Name: "web"},
+						Tier: &shared.PolicyTier{Name: "adminnetworkpolicy", Order: &adminTierOrder,
+							DefaultAction: shared.RuleActionPass},
+						Selects:   podNamed("web"),
+						IsIngress: true,
+						IsEgress:  true,
+						Ingress: []shared.SourceRule{
+							{Action: shared.RuleActionDeny, Peers: []shared.SourcePeer{{Pods: podsOfNamespace("other")}}},
+							{Action: shared.RuleActionAllow, Peers: []shared.SourcePeer{{Pods: allPods}}},
+						},
+						Egress: []shared.SourceRule{
+							{Action: shared.RuleActionAllow, Ports: []types.PortRange{{Start: 443, End: 443}},
+								Peers: []shared.SourcePeer{
+									{External: &types.ExternalPeer{Kind: types.ExternalPeerCIDR, Name: "0.0.0.0/0"}},
+								}},
+						},
+					},
+				},
+			},
+			expectedResult: AnalysisResult{
+				Report: &types.ComplianceReport{
+					Coverage: types.IsolationCoverage{
+						Pods:                      4,
+						IngressIsolatedPods:       2,
+						EgressIsolatedPods:        2,
+						IngressIsolatedPercentage: 50,
+						EgressIsolatedPercentage:  50,
+						PodsReachableFromAllNamespaces: []types.PodRef{{Name: "node-agent", Namespace: "ns"},
+							{Name: "client", Namespace: "other"}},
+						PodsWithUnrestrictedEgress: []types.PodRef{{Name: "api", Namespace: "ns"},
+							{Name: "node-agent", Namespace: "ns"}, {Name: "client", Namespace: "other"}},
+					},
+					NamespacesWithoutIngressDefaultDeny: []string{"other"},
+					NamespacesWithoutEgressDefaultDeny:  []string{"other"},
+					Namespaces: []*types.NamespaceCompliance{
+						{
+							Namespace:             "ns",
+							HasIngressDefaultDeny: true,
+							HasEgressDefaultDeny:  true,
+							Coverage: types.IsolationCoverage{
+								Pods:                           3,
+								IngressIsolatedPods:            2,
+								EgressIsolatedPods:             2,
+								IngressIsolatedPercentage:      200.0 / 3,
+								EgressIsolatedPercentage:       200.0 / 3,
+								PodsReachableFromAllNamespaces: []types.PodRef{{Name: "node-agent", Namespace: "ns"}},
+								PodsWithUnrestrictedEgress: []types.PodRef{{Name: "api", Namespace: "ns"},
+									{Name: "node-agent", Namespace: "ns"}},
+							},
+						},
+						{
+							Namespace: "other",
+							Coverage: types.IsolationCoverage{
+								Pods:                           1,
+								PodsReachableFromAllNamespaces: []types.PodRef{{Name: "client", Namespace: "other"}},
+								PodsWithUnrestrictedEgress:     []types.PodRef{{Name: "client", Namespace: "other"}},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := NewAnalyzer()
+			result := analyzer.Analyze(ClusterState{
+				Namespaces:      tt.args.namespaces,
+				NetworkPolicies: tt.args.networkPolicies,
+				PodIsolations: podIsolationsOf(tt.args.pods, tt.args.networkPolicies, tt.args.sourcePolicies,
+					tt.args.namespaces),
+			})
+			if diff := cmp.Diff(tt.expectedResult, result); diff != "" {
+				t.Errorf("Analyze() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func podIsolationsOf(pods []*corev1.Pod, networkPolicies []*networkingv1.NetworkPolicy,
+	sourcePolicies []*shared.SourcePolicy, namespaces []*corev1.Namespace) []*shared.PodIsolation {
+	result := make([]*shared.PodIsolation, 0, len(pods))
+	for _, pod := range pods {
+		podIsolation := podisolation.NewAnalyzer().Analyze(pod, networkPolicies)
+		for _, sourcePolicy := range sourcePolicies {
+			if !pod.Spec.HostNetwork && sourcePolicy.Selects(pod, shared.NamespaceLabels(pod.Namespace, namespaces)) {
+				podIsolation.AddSourcePolicy(sourcePolicy)
+			}
+		}
+		result = append(result, podIsolation)
+	}
+	return result
+}
@@ -0,0 +1,105 @@
+package configuration
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"karto/analyzer/configuration/podconfiguration"
+	"karto/analyzer/configuration/workloadconfiguration"
+	"karto/analyzer/shared"
+	"karto/commons"
+	"karto/types"
+)
+
+type ClusterState struct {
+	Namespaces   []*corev1.Namespace
+	Pods         []*corev1.Pod
+	Services     []*corev1.Service
+	ReplicaSets  []*appsv1.ReplicaSet
+	StatefulSets []*appsv1.StatefulSet
+	DaemonSets   []*appsv1.DaemonSet
+	Deployments  []*appsv1.Deployment
+}
+
+type AnalysisResult struct {
+	Pods      []*types.PodConfiguration
+	Workloads []*types.WorkloadConfiguration
+}
+
+type Analyzer interface {
+	Analyze(clusterState ClusterState) AnalysisResult
+}
+
+type analyzerImpl struct {
+	podConfigurationAnalyzer      podconfiguration.Analyzer
+	workloadConfigurationAnalyzer workloadconfiguration.Analyzer
+}
+
+func NewAnalyzer(
+	podConfigurationAnalyzer podconfiguration.Analyzer,
+	workloadConfigurationAnalyzer workloadconfiguration.Analyzer,
+) Analyzer {
+	return analyzerImpl{
+		podConfigurationAnalyzer:      podConfigurationAnalyzer,
+		workloadConfigurationAnalyzer: workloadConfigurationAnalyzer,
+	}
+}
+
+func (analyzer analyzerImpl) Analyze(clusterState ClusterState) AnalysisResult {
+	podConfigurations := commons.Map(clusterState.Pods, func(pod *corev1.Pod) *types.PodConfiguration {
+		return analyzer.podConfigurationAnalyzer.Analyze(pod, clusterState.Services, clusterState.Namespaces)
+	})
+	podConfigurationsByPod := map[*corev1.Pod]*types.PodConfiguration{}
+	for i, pod := range clusterState.Pods {
+		podConfigurationsByPod[pod] = podConfigurations[i]
+	}
+	workloadConfigurations := make([]*types.WorkloadConfiguration, 0)
+	for _, deployment := range clusterState.Deployments {
+		replicaSets := commons.Filter(clusterState.ReplicaSets, func(replicaSet *appsv1.ReplicaSet) bool {
+			return shared.IsOwnedBy(replicaSet, deployment)
+		})
+		ownedPods := commons.Filter(clusterState.Pods, func(pod *corev1.Pod) bool {
+			return commons.AnyMatch(replicaSets, func(replicaSet *appsv1.ReplicaSet) bool {
+				return shared.IsOwnedBy(pod, replicaSet)
+			})
+		})
+		workloadConfigurations = analyzer.appendWorkloadConfiguration(workloadConfigurations, "Deployment",
+			deployment, ownedPods, podConfigurationsByPod)
+	}
+	for _, statefulSet := range clusterState.StatefulSets {
+		workloadConfigurations = analyzer.appendWorkloadConfiguration(workloadConfigurations, "StatefulSet",
+			statefulSet, analyzer.podsOwnedBy(clusterState.Pods, statefulSet), podConfigurationsByPod)
+	}
+	for _, daemonSet := range clusterState.DaemonSets {
+		workloadConfigurations = analyzer.appendWorkloadConfiguration(workloadConfigurations, "DaemonSet",
+			daemonSet, analyzer.podsOwnedBy(clusterState.Pods, daemonSet), podConfigurationsByPod)
+	}
+	return AnalysisResult{
+		Pods:      podConfigurations,
+		Workloads: workloadConfigurations,
+	}
+}
+
+func (analyzer analyzerImpl) podsOwnedBy(pods []*corev1.Pod, owner metav1.Object) []*corev1.Pod {
+	return commons.Filter(pods, func(pod *corev1.Pod) bool {
+		return shared.IsOwnedBy(pod, owner)
+	})
+}
+
+func (analyzer analyzerImpl) appendWorkloadConfiguration(
+	workloadConfigurations []*types.WorkloadConfiguration,
+	kind string,
+	workload metav1.Object,
+	ownedPods []*corev1.Pod,
+	podConfigurationsByPod map[*corev1.Pod]*types.PodConfiguration,
+) []*types.WorkloadConfiguration {
+	workloadRef := types.ObjectRef{Kind: kind, Name: workload.GetName(), Namespace: workload.GetNamespace()}
+	workloadConfiguration := analyzer.workloadConfigurationAnalyzer.Analyze(workloadRef,
+		commons.Map(ownedPods, func(pod *corev1.Pod) *types.PodConfiguration {
+			return podConfigurationsByPod[pod]
+		}))
+	if workloadConfiguration == nil {
+		return workloadConfigurations
+	}
+	return append(workloadConfigurations, workloadConfiguration)
+}
@@ -0,0 +1,217 @@
+package configuration
+
+import (
+	"github.com/google/go-cmp/cmp"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"karto/analyzer/configuration/podconfiguration"
+	"karto/analyzer/configuration/workloadconfiguration"
+	"karto/testutils"
+	"karto/types"
+	"reflect"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	type args struct {
+		clusterState ClusterState
+	}
+	type mocks struct {
+		podConfiguration      []mockPodConfigurationAnalyzerCall
+		workloadConfiguration []mockWorkloadConfigurationAnalyzerCall
+	}
+	k8sNamespace := testutils.NewNamespaceBuilder().WithName("ns").Build()
+	k8sService := testutils.NewServiceBuilder().WithName("svc").WithNamespace("ns").Build()
+	k8sDeployment := testutils.NewDeploymentBuilder().WithName("deploy").WithNamespace("ns").
+		WithUID("deploy-uid").Build()
+	k8sReplicaSet := testutils.NewReplicaSetBuilder().WithName("rs").WithNamespace("ns").
+		WithUID("rs-uid").WithOwnerUID("deploy-uid").Build()
+	k8sStatefulSet := testutils.NewStatefulSetBuilder().WithName("ss").WithNamespace("ns").
+		WithUID("ss-uid").Build()
+	k8sDaemonSet := testutils.NewDaemonSetBuilder().WithName("ds").WithNamespace("ns").
+		WithUID("ds-uid").Build()
+	k8sPod1 := testutils.NewPodBuilder().WithName("pod1").WithNamespace("ns").WithOwnerUID("rs-uid").Build()
+	k8sPod2 := testutils.NewPodBuilder().WithName("pod2").WithNamespace("ns").WithOwnerUID("rs-uid").Build()
+	k8sPod3 := testutils.NewPodBuilder().WithName("pod3").WithNamespace("ns").WithOwnerUID("ss-uid").Build()
+	finding := types.ConfigurationFinding{Code: "NoMemoryLimit", Severity: types.FindingSeverityWarning,
+		Container: "app", Message: "no memory limit"}
+	podConfiguration1 := &types.PodConfiguration{Pod: types.PodRef{Name: "pod1", Namespace: "ns"},
+		QoSClass: "BestEffort", Findings: []types.ConfigurationFinding{finding}}
+	podConfiguration2 := &types.PodConfiguration{Pod: types.PodRef{Name: "pod2", Namespace: "ns"},
+		QoSClass: "BestEffort", Findings: []types.ConfigurationFinding{finding}}
+	podConfiguration3 := &types.PodConfiguration{Pod: types.PodRef{Name: "pod3", Namespace: "ns"},
+		QoSClass: "Guaranteed", Findings: []types.ConfigurationFinding{}}
+	deploymentRef := types.ObjectRef{Kind: "Deployment", Name: "deploy", Namespace: "ns"}
+	statefulSetRef := types.ObjectRef{Kind: "StatefulSet", Name: "ss", Namespace: "ns"}
+	daemonSetRef := types.ObjectRef{Kind: "DaemonSet", Name: "ds", Namespace: "ns"}
+	deploymentConfiguration := &types.WorkloadConfiguration{Workload: deploymentRef, Pods: 2,
+		Findings: []types.ConfigurationFinding{finding}}
+	statefulSetConfiguration := &types.WorkloadConfiguration{Workload: statefulSetRef, Pods: 1,
+		Findings: []types.ConfigurationFinding{}}
+	services := []*corev1.Service{k8sService}
+	namespaces := []*corev1.Namespace{k8sNamespace}
+	tests := []struct {
+		name                   string
+		mocks                  mocks
+		args                   args
+		expectedAnalysisResult AnalysisResult
+	}{
+		{
+			name: "delegates to sub-analyzers and groups pods by workload",
+			mocks: mocks{
+				podConfiguration: []mockPodConfigurationAnalyzerCall{
+					{
+						args: mockPodConfigurationAnalyzerCallArgs{
+							pod: k8sPod1, services: services, namespaces: namespaces,
+						},
+						returnValue: podConfiguration1,
+					},
+					{
+						args: mockPodConfigurationAnalyzerCallArgs{
+							pod: k8sPod2, services: services, namespaces: namespaces,
+						},
+						returnValue: podConfiguration2,
+					},
+					{
+						args: mockPodConfigurationAnalyzerCallArgs{
+							pod: k8sPod3, services: services, namespaces: namespaces,
+						},
+						returnValue: podConfiguration3,
+					},
+				},
+				workloadConfiguration: []mockWorkloadConfigurationAnalyzerCall{
+					{
+						args: mockWorkloadConfigurationAnalyzerCallArgs{
+							workload:          deploymentRef,
+							podConfigurations: []*types.PodConfiguration{podConfiguration1, podConfiguration2},
+						},
+						returnValue: deploymentConfiguration,
+					},
+					{
+						args: mockWorkloadConfigurationAnalyzerCallArgs{
+							workload:          statefulSetRef,
+							podConfigurations: []*types.PodConfiguration{podConfiguration3},
+						},
+						returnValue: statefulSetConfiguration,
+					},
+					{
+						args: mockWorkloadConfigurationAnalyzerCallArgs{
+							workload:          daemonSetRef,
+							podConfigurations: []*types.PodConfiguration{},
+						},
+						returnValue: nil,
+					},
+				},
+			},
+			args: args{
+				clusterState: ClusterState{
+					Namespaces:   namespaces,
+					Pods:         []*corev1.Pod{k8sPod1, k8sPod2, k8sPod3},
+					Services:     services,
+					ReplicaSets:  []*appsv1.ReplicaSet{k8sReplicaSet},
+					StatefulSets: []*appsv1.StatefulSet{k8sStatefulSet},
+					DaemonSets:   []*appsv1.DaemonSet{k8sDaemonSet},
+					Deployments:  []*appsv1.Deployment{k8sDeployment},
+				},
+			},
+			expectedAnalysisResult: AnalysisResult{
+				Pods:      []*types.PodConfiguration{podConfiguration1, podConfiguration2, podConfiguration3},
+				Workloads: []*types.WorkloadConfiguration{deploymentConfiguration, statefulSetConfiguration},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			podConfigurationAnalyzer := createMockPodConfigurationAnalyzer(t, tt.mocks.podConfiguration)
+			workloadConfigurationAnalyzer := createMockWorkloadConfigurationAnalyzer(t,
+				tt.mocks.workloadConfiguration)
+			analyzer := NewAnalyzer(podConfigurationAnalyzer, workloadConfigurationAnalyzer)
+			analysisResult := analyzer.Analyze(tt.args.clusterState)
+			if diff := cmp.Diff(tt.expectedAnalysisResult, analysisResult); diff != "" {
+				t.Errorf("Analyze() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+type mockPodConfigurationAnalyzerCallArgs struct {
+	pod        *corev1.Pod
+	services   []*corev1.Service
+	namespaces []*corev1.Namespace
+}
+
+type mockPodConfigurationAnalyzerCall struct {
+	args        mockPodConfigurationAnalyzerCallArgs
+	returnValue *types.PodConfiguration
+}
+
+type mockPodConfigurationAnalyzer struct {
+	t     *testing.T
+	calls []mockPodConfigurationAnalyzerCall
+}
+
+func (mock mockPodConfigurationAnalyzer) Analyze(
+	pod *corev1.Pod,
+	services []*corev1.Service,
+	namespaces []*corev1.Namespace,
+) *types.PodConfiguration {
+	for _, call := range mock.calls {
+		if reflect.DeepEqual(call.args.pod, pod) && reflect.DeepEqual(call.args.services, services) &&
+			reflect.DeepEqual(call.args.namespaces, namespaces) {
+			return call.returnValue
+		}
+	}
+	mock.t.Fatalf("mockPodConfigurationAnalyzer was called with unexpected arguments:\n\tpod: %s\n"+
+		"\tservices: %s\n\tnamespaces: %s\n", pod, services, namespaces)
+	return nil
+}
+
+func createMockPodConfigurationAnalyzer(
+	t *testing.T,
+	calls []mockPodConfigurationAnalyzerCall,
+) podconfiguration.Analyzer {
+	return mockPodConfigurationAnalyzer{
+		t:     t,
+		calls: calls,
+	}
+}
+
+type mockWorkloadConfigurationAnalyzerCallArgs struct {
+	workload          types.ObjectRef
+	podConfigurations []*types.PodConfiguration
+}
+
+type mockWorkloadConfigurationAnalyzerCall struct {
+	args        mockWorkloadConfigurationAnalyzerCallArgs
+	returnValue *types.WorkloadConfiguration
+}
+
+type mockWorkloadConfigurationAnalyzer struct {
+	t     *testing.T
+	calls []mockWorkloadConfigurationAnalyzerCall
+}
+
+func (mock mockWorkloadConfigurationAnalyzer) Analyze(
+	workload types.ObjectRef,
+	podConfigurations []*types.PodConfiguration,
+) *types.WorkloadConfiguration {
+	for _, call := range mock.calls {
+		if reflect.DeepEqual(call.args.workload, workload) &&
+			reflect.DeepEqual(call.args.podConfigurations, podConfigurations) {
+			return call.returnValue
+		}
+	}
+	mock.t.Fatalf("mockWorkloadConfigurationAnalyzer was called with unexpected arguments:\n\tworkload: %v\n"+
+		"\tpodConfigurations: %v\n", workload, podConfigurations)
+	return nil
+}
+
+func createMockWorkloadConfigurationAnalyzer(
+	t *testing.T,
+	calls []mockWorkloadConfigurationAnalyzerCall,
+) workloadconfiguration.Analyzer {
+	return mockWorkloadConfigurationAnalyzer{
+		t:     t,
+		calls: calls,
+	}
+}
@@ -0,0 +1,176 @@
+package podconfiguration
+
+import (
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"karto/analyzer/shared"
+	"karto/commons"
+	"karto/types"
+	"strings"
+)
+
+const (
+	NoLivenessProbe        = "NoLivenessProbe"
+	NoReadinessProbe       = "NoReadinessProbe"
+	NoStartupProbe         = "NoStartupProbe"
+	NoCPURequest           = "NoCPURequest"
+	NoMemoryRequest        = "NoMemoryRequest"
+	NoMemoryLimit          = "NoMemoryLimit"
+	MutableImageTag        = "MutableImageTag"
+	NeverPullPolicy        = "NeverPullPolicy"
+	BestEffortInProduction = "BestEffortInProduction"
+)
+
+const slowStartLivenessDelaySeconds = 30
+
+var productionEnvironmentLabels = []string{"environment", "env"}
+var productionEnvironmentValues = []string{"production", "prod"}
+
+type Analyzer interface {
+	Analyze(pod *corev1.Pod, services []*corev1.Service, namespaces []*corev1.Namespace) *types.PodConfiguration
+}
+
+type analyzerImpl struct{}
+
+func NewAnalyzer() Analyzer {
+	return analyzerImpl{}
+}
+
+func (analyzer analyzerImpl) Analyze(
+	pod *corev1.Pod,
+	services []*corev1.Service,
+	namespaces []*corev1.Namespace,
+) *types.PodConfiguration {
+	isBehindService := analyzer.isBehindService(pod, services)
+	findings := make([]types.ConfigurationFinding, 0)
+	for _, container := range pod.Spec.Containers {
+		findings = append(findings, analyzer.containerFindings(container, isBehindService)...)
+	}
+	qosClass := analyzer.qosClass(pod)
+	if qosClass == corev1.PodQOSBestEffort && analyzer.isProductionNamespace(pod.Namespace, namespaces) {
+		findings = append(findings, types.ConfigurationFinding{
+			Code:     BestEffortInProduction,
+			Severity: types.FindingSeverityError,
+			Message:  "pod has BestEffort QoS in a production namespace",
+		})
+	}
+	return &types.PodConfiguration{
+		Pod:      shared.ToPodRef(pod),
+		QoSClass: string(qosClass),
+		Findings: findings,
+	}
+}
+
+func (analyzer analyzerImpl) containerFindings(
+	container corev1.Container,
+	isBehindService bool,
+) []types.ConfigurationFinding {
+	findings := make([]types.ConfigurationFinding, 0)
+	addFinding := func(code string, severity types.FindingSeverity, message string) {
+		findings = append(findings, types.ConfigurationFinding{
+			Code:      code,
+			Severity:  severity,
+			Container: container.Name,
+			Message:   message,
+		})
+	}
+	if container.LivenessProbe == nil {
+		addFinding(NoLivenessProbe, types.FindingSeverityInfo, "no liveness probe")
+	}
+	if container.LivenessProbe != nil && container.StartupProbe == nil &&
+		container.LivenessProbe.InitialDelaySeconds >= slowStartLivenessDelaySeconds {
+		addFinding(NoStartupProbe, types.FindingSeverityWarning,
+			fmt.Sprintf("liveness probe delayed by %ds instead of a startup probe",
+				container.LivenessProbe.InitialDelaySeconds))
+	}
+	if container.ReadinessProbe == nil && isBehindService {
+		addFinding(NoReadinessProbe, types.FindingSeverityWarning, "no readiness probe behind a Service")
+	}
+	if _, ok := container.Resources.Requests[corev1.ResourceCPU]; !ok {
+		addFinding(NoCPURequest, types.FindingSeverityWarning, "no CPU request")
+	}
+	if _, ok := container.Resources.Requests[corev1.ResourceMemory]; !ok {
+		if _, ok := container.Resources.Limits[corev1.ResourceMemory]; !ok {
+			addFinding(NoMemoryRequest, types.FindingSeverityWarning, "no memory request")
+		}
+	}
+	if _, ok := container.Resources.Limits[corev1.ResourceMemory]; !ok {
+		addFinding(NoMemoryLimit, types.FindingSeverityWarning, "no memory limit")
+	}
+	if analyzer.hasMutableTag(container.Image) && container.ImagePullPolicy != corev1.PullAlways {
+		addFinding(MutableImageTag, types.FindingSeverityWarning,
+			"image uses the latest tag without an Always pull policy")
+	}
+	if container.ImagePullPolicy == corev1.PullNever {
+		addFinding(NeverPullPolicy, types.FindingSeverityInfo,
+			"image is never pulled and must be preloaded on every node")
+	}
+	return findings
+}
+
+func (analyzer analyzerImpl) isBehindService(pod *corev1.Pod, services []*corev1.Service) bool {
+	return commons.AnyMatch(services, func(service *corev1.Service) bool {
+		return service.Namespace == pod.Namespace && service.Spec.Selector != nil &&
+			shared.SelectorMatches(pod.Labels, *metav1.SetAsLabelSelector(service.Spec.Selector))
+	})
+}
+
+func (analyzer analyzerImpl) hasMutableTag(image string) bool {
+	if strings.Contains(image, "@") {
+		return false
+	}
+	lastSlash := strings.LastIndex(image, "/")
+	lastColon := strings.LastIndex(image, ":")
+	if lastColon <= lastSlash {
+		return true
+	}
+	return image[lastColon+1:] == "latest"
+}
+
+func (analyzer analyzerImpl) qosClass(pod *corev1.Pod) corev1.PodQOSClass {
+	if pod.Status.QOSClass != "" {
+		return pod.Status.QOSClass
+	}
+	hasResources := false
+	isGuaranteed := len(pod.Spec.Containers) > 0
+	containers := append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+	for _, container := range containers {
+		requests := container.Resources.Requests
+		limits := container.Resources.Limits
+		if len(requests) != 0 || len(limits) != 0 {
+			hasResources = true
+		}
+		for _, resourceName := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			limit, hasLimit := limits[resourceName]
+			request, hasRequest := requests[resourceName]
+			if !hasLimit || (hasRequest && request.Cmp(limit) != 0) {
+				isGuaranteed = false
+			}
+		}
+	}
+	if !hasResources {
+		return corev1.PodQOSBestEffort
+	}
+	if isGuaranteed {
+		return corev1.PodQOSGuaranteed
+	}
+	return corev1.PodQOSBurstable
+}
+
+func (analyzer analyzerImpl) isProductionNamespace(namespaceName string, namespaces []*corev1.Namespace) bool {
+	for _, namespace := range namespaces {
+		if namespace.Name != namespaceName {
+			continue
+		}
+		for _, labelKey := range productionEnvironmentLabels {
+			value := strings.ToLower(namespace.Labels[labelKey])
+			if commons.AnyMatch(productionEnvironmentValues, func(productionValue string) bool {
+				return value == productionValue
+			}) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,234 @@
+package podconfiguration
+
+import (
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"karto/testutils"
+	"karto/types"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	type args struct {
+		pod        *corev1.Pod
+		services   []*corev1.Service
+		namespaces []*corev1.Namespace
+	}
+	podRef := types.PodRef{Name: "pod", Namespace: "ns"}
+	wellConfiguredContainer := testutils.NewContainerBuilder().WithName("app").
+		WithLivenessProbe().WithReadinessProbe().
+		WithRequest(corev1.ResourceCPU, "100m").WithRequest(corev1.ResourceMemory, "64Mi").
+		WithLimit(corev1.ResourceMemory, "64Mi").Build()
+	tests := []struct {
+		name                     string
+		args                     args
+		expectedPodConfiguration *types.PodConfiguration
+	}{
+		{
+			name: "well configured pod has no finding",
+			args: args{
+				pod: testutils.NewPodBuilder().WithName("pod").WithNamespace("ns").
+					WithContainer(wellConfiguredContainer).Build(),
+			},
+			expectedPodConfiguration: &types.PodConfiguration{
+				Pod:      podRef,
+				QoSClass: "Burstable",
+				Findings: []types.ConfigurationFinding{},
+			},
+		},
+		{
+			name: "missing probes and resources are reported per container",
+			args: args{
+				pod: testutils.NewPodBuilder().WithName("pod").WithNamespace("ns").
+					WithContainer(wellConfiguredContainer).
+					WithContainer(testutils.NewContainerBuilder().WithName("sidecar").
+						WithRequest(corev1.ResourceCPU, "10m").Build()).Build(),
+			},
+			expectedPodConfiguration: &types.PodConfiguration{
+				Pod:      podRef,
+				QoSClass: "Burstable",
+				Findings: []types.ConfigurationFinding{
+					{Code: NoLivenessProbe, Severity: types.FindingSeverityInfo, Container: "sidecar",
+						Message: "no liveness probe"},
+					{Code: NoMemoryRequest, Severity: types.FindingSeverityWarning, Container: "sidecar",
+						Message: "no memory request"},
+					{Code: NoMemoryLimit, Severity: types.FindingSeverityWarning, Container: "sidecar",
+						Message: "no memory limit"},
+				},
+			},
+		},
+		{
+			name: "missing readiness probe is only reported for pods behind a service",
+			args: args{
+				pod: testutils.NewPodBuilder().WithName("pod").WithNamespace("ns").WithLabel("app", "foo").
+					WithContainer(testutils.NewContainerBuilder().WithName("app").WithLivenessProbe().
+						WithRequest(corev1.ResourceCPU, "100m").WithLimit(corev1.ResourceMemory, "64Mi").
+						Build()).Build(),
+				services: []*corev1.Service{
+					testutils.NewServiceBuilder().WithNamespace("ns").WithSelectorLabel("app", "foo").Build(),
+				},
+			},
+			expectedPodConfiguration: &types.PodConfiguration{
+				Pod:      podRef,
+				QoSClass: "Burstable",
+				Findings: []types.ConfigurationFinding{
+					{Code: NoReadinessProbe, Severity: types.FindingSeverityWarning, Container: "app",
+						Message: "no readiness probe behind a Service"},
+				},
+			},
+		},
+		{
+			name: "latest image tag without Always pull policy is reported",
+			args: args{
+				pod: testutils.NewPodBuilder().WithName("pod").WithNamespace("ns").
+					WithContainer(testutils.NewContainerBuilder().WithName("app").WithImage("registry:5000/app").
+						WithLivenessProbe().WithRequest(corev1.ResourceCPU, "100m").
+						WithLimit(corev1.ResourceMemory, "64Mi").Build()).
+					WithContainer(testutils.NewContainerBuilder().WithName("other").WithImage("other:latest").
+						WithImagePullPolicy(corev1.PullAlways).
+						WithLivenessProbe().WithRequest(corev1.ResourceCPU, "100m").
+						WithLimit(corev1.ResourceMemory, "64Mi").Build()).Build(),
+			},
+			expectedPodConfiguration: &types.PodConfiguration{
+				Pod:      podRef,
+				QoSClass: "Burstable",
+				Findings: []types.ConfigurationFinding{
+					{Code: MutableImageTag, Severity: types.FindingSeverityWarning, Container: "app",
+						Message: "image uses the latest tag without an Always pull policy"},
+				},
+			},
+		},
+		{
+			name: "never pulled image is reported",
+			args: args{
+				pod: testutils.NewPodBuilder().WithName("pod").WithNamespace("ns").
+					WithContainer(testutils.NewContainerBuilder().WithName("app").
+						WithImagePullPolicy(corev1.PullNever).
+						WithLivenessProbe().WithRequest(corev1.ResourceCPU, "100m").
+						WithLimit(corev1.ResourceMemory, "64Mi").Build()).Build(),
+			},
+			expectedPodConfiguration: &types.PodConfiguration{
+				Pod:      podRef,
+				QoSClass: "Burstable",
+				Findings: []types.ConfigurationFinding{
+					{Code: NeverPullPolicy, Severity: types.FindingSeverityInfo, Container: "app",
+						Message: "image is never pulled and must be preloaded on every node"},
+				},
+			},
+		},
+		{
+			name: "slow-starting liveness probe without startup probe is reported",
+			args: args{
+				pod: testutils.NewPodBuilder().WithName("pod").WithNamespace("ns").
+					WithContainer(testutils.NewContainerBuilder().WithName("app").
+						WithLivenessProbeInitialDelay(120).WithRequest(corev1.ResourceCPU, "100m").
+						WithLimit(corev1.ResourceMemory, "64Mi").Build()).
+					WithContainer(testutils.NewContainerBuilder().WithName("protected").
+						WithLivenessProbeInitialDelay(120).WithStartupProbe().
+						WithRequest(corev1.ResourceCPU, "100m").WithLimit(corev1.ResourceMemory, "64Mi").
+						Build()).
+					WithContainer(testutils.NewContainerBuilder().WithName("fast").
+						WithLivenessProbeInitialDelay(5).WithRequest(corev1.ResourceCPU, "100m").
+						WithLimit(corev1.ResourceMemory, "64Mi").Build()).Build(),
+			},
+			expectedPodConfiguration: &types.PodConfiguration{
+				Pod:      podRef,
+				QoSClass: "Burstable",
+				Findings: []types.ConfigurationFinding{
+					{Code: NoStartupProbe, Severity: types.FindingSeverityWarning, Container: "app",
+						Message: "liveness probe delayed by 120s instead of a startup probe"},
+				},
+			},
+		},
+		{
+			name: "pods with identical requests and limits are Guaranteed",
+			args: args{
+				pod: testutils.NewPodBuilder().WithName("pod").WithNamespace("ns").
+					WithContainer(testutils.NewContainerBuilder().WithName("app").WithLivenessProbe().
+						WithLimit(corev1.ResourceCPU, "1").WithLimit(corev1.ResourceMemory, "64Mi").
+						WithRequest(corev1.ResourceCPU, "1").Build()).Build(),
+			},
+			expectedPodConfiguration: &types.PodConfiguration{
+				Pod:      podRef,
+				QoSClass: "Guaranteed",
+				Findings: []types.ConfigurationFinding{},
+			},
+		},
+		{
+			name: "resources of init containers are part of the QoS class",
+			args: args{
+				pod: testutils.NewPodBuilder().WithName("pod").WithNamespace("ns").
+					WithInitContainer(testutils.NewContainerBuilder().WithName("init").
+						WithRequest(corev1.ResourceMemory, "64Mi").Build()).
+					WithContainer(testutils.NewContainerBuilder().WithName("app").WithLivenessProbe().
+						Build()).Build(),
+				namespaces: []*corev1.Namespace{
+					testutils.NewNamespaceBuilder().WithName("ns").WithLabel("environment", "production").Build(),
+				},
+			},
+			expectedPodConfiguration: &types.PodConfiguration{
+				Pod:      podRef,
+				QoSClass: "Burstable",
+				Findings: []types.ConfigurationFinding{
+					{Code: NoCPURequest, Severity: types.FindingSeverityWarning, Container: "app",
+						Message: "no CPU request"},
+					{Code: NoMemoryRequest, Severity: types.FindingSeverityWarning, Container: "app",
+						Message: "no memory request"},
+					{Code: NoMemoryLimit, Severity: types.FindingSeverityWarning, Container: "app",
+						Message: "no memory limit"},
+				},
+			},
+		},
+		{
+			name: "BestEffort pod in a production namespace is reported",
+			args: args{
+				pod: testutils.NewPodBuilder().WithName("pod").WithNamespace("ns").
+					WithContainer(testutils.NewContainerBuilder().WithName("app").WithLivenessProbe().
+						Build()).Build(),
+				namespaces: []*corev1.Namespace{
+					testutils.NewNamespaceBuilder().WithName("ns").WithLabel("environment", "production").Build(),
+				},
+			},
+			expectedPodConfiguration: &types.PodConfiguration{
+				Pod:      podRef,
+				QoSClass: "BestEffort",
+				Findings: []types.ConfigurationFinding{
+					{Code: NoCPURequest, Severity: types.FindingSeverityWarning, Container: "app",
+						Message: "no CPU request"},
+					{Code: NoMemoryRequest, Severity: types.FindingSeverityWarning, Container: "app",
+						Message: "no memory request"},
+					{Code: NoMemoryLimit, Severity: types.FindingSeverityWarning, Container: "app",
+						Message: "no memory limit"},
+					{Code: BestEffortInProduction, Severity: types.FindingSeverityError,
+						Message: "pod has BestEffort QoS in a production namespace"},
+				},
+			},
+		},
+		{
+			name: "BestEffort pod outside of a production namespace is not reported",
+			args: args{
+				pod: testutils.NewPodBuilder().WithName("pod").WithNamespace("ns").
+					WithContainer(testutils.NewContainerBuilder().WithName("app").WithLivenessProbe().
+						WithLimit(corev1.ResourceMemory, "64Mi").WithRequest(corev1.ResourceCPU, "100m").
+						Build()).Build(),
+				namespaces: []*corev1.Namespace{
+					testutils.NewNamespaceBuilder().WithName("ns").WithLabel("environment", "dev").Build(),
+				},
+			},
+			expectedPodConfiguration: &types.PodConfiguration{
+				Pod:      podRef,
+				QoSClass: "Burstable",
+				Findings: []types.ConfigurationFinding{},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := NewAnalyzer()
+			podConfiguration := analyzer.Analyze(tt.args.pod, tt.args.services, tt.args.namespaces)
+			if diff := cmp.Diff(tt.expectedPodConfiguration, podConfiguration); diff != "" {
+				t.Errorf("Analyze() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
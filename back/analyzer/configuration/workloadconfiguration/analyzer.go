@@ -0,0 +1,51 @@
+package workloadconfiguration
+
+import (
+	"karto/types"
+	"sort"
+)
+
+type Analyzer interface {
+	Analyze(workload types.ObjectRef, podConfigurations []*types.PodConfiguration) *types.WorkloadConfiguration
+}
+
+type analyzerImpl struct{}
+
+func NewAnalyzer() Analyzer {
+	return analyzerImpl{}
+}
+
+func (analyzer analyzerImpl) Analyze(
+	workload types.ObjectRef,
+	podConfigurations []*types.PodConfiguration,
+) *types.WorkloadConfiguration {
+	if len(podConfigurations) == 0 {
+		return nil
+	}
+	type findingKey struct {
+		code      string
+		container string
+	}
+	seenFindings := map[findingKey]bool{}
+	findings := make([]types.ConfigurationFinding, 0)
+	for _, podConfiguration := range podConfigurations {
+		for _, finding := range podConfiguration.Findings {
+			key := findingKey{code: finding.Code, container: finding.Container}
+			if !seenFindings[key] {
+				seenFindings[key] = true
+				findings = append(findings, finding)
+			}
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Container != findings[j].Container {
+			return findings[i].Container < findings[j].Container
+		}
+		return findings[i].Code < findings[j].Code
+	})
+	return &types.WorkloadConfiguration{
+		Workload: workload,
+		Pods:     int32(len(podConfigurations)),
+		Findings: findings,
+	}
+}
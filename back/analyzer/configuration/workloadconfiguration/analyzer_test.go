@@ -0,0 +1,61 @@
+package workloadconfiguration
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"karto/types"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	type args struct {
+		workload          types.ObjectRef
+		podConfigurations []*types.PodConfiguration
+	}
+	workloadRef := types.ObjectRef{Kind: "Deployment", Name: "deploy", Namespace: "ns"}
+	noLivenessApp := types.ConfigurationFinding{Code: "NoLivenessProbe", Severity: types.FindingSeverityInfo,
+		Container: "app", Message: "no liveness probe"}
+	noMemoryLimitApp := types.ConfigurationFinding{Code: "NoMemoryLimit", Severity: types.FindingSeverityWarning,
+		Container: "app", Message: "no memory limit"}
+	noMemoryLimitSidecar := types.ConfigurationFinding{Code: "NoMemoryLimit",
+		Severity: types.FindingSeverityWarning, Container: "sidecar", Message: "no memory limit"}
+	tests := []struct {
+		name                          string
+		args                          args
+		expectedWorkloadConfiguration *types.WorkloadConfiguration
+	}{
+		{
+			name: "findings of all pods are merged without duplicates",
+			args: args{
+				workload: workloadRef,
+				podConfigurations: []*types.PodConfiguration{
+					{Pod: types.PodRef{Name: "pod1", Namespace: "ns"},
+						Findings: []types.ConfigurationFinding{noMemoryLimitSidecar, noMemoryLimitApp}},
+					{Pod: types.PodRef{Name: "pod2", Namespace: "ns"},
+						Findings: []types.ConfigurationFinding{noMemoryLimitApp, noLivenessApp}},
+				},
+			},
+			expectedWorkloadConfiguration: &types.WorkloadConfiguration{
+				Workload: workloadRef,
+				Pods:     2,
+				Findings: []types.ConfigurationFinding{noLivenessApp, noMemoryLimitApp, noMemoryLimitSidecar},
+			},
+		},
+		{
+			name: "workload without pods is ignored",
+			args: args{
+				workload:          workloadRef,
+				podConfigurations: []*types.PodConfiguration{},
+			},
+			expectedWorkloadConfiguration: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := NewAnalyzer()
+			workloadConfiguration := analyzer.Analyze(tt.args.workload, tt.args.podConfigurations)
+			if diff := cmp.Diff(tt.expectedWorkloadConfiguration, workloadConfiguration); diff != "" {
+				t.Errorf("Analyze() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
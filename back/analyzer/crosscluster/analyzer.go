@@ -0,0 +1,237 @@
+package crosscluster
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"karto/analyzer/shared"
+	"karto/analyzer/traffic/allowedroute"
+	"karto/commons"
+	"karto/types"
+	"sort"
+)
+
+const (
+	globalServiceAnnotation       = "service.cilium.io/global"
+	legacyGlobalServiceAnnotation = "io.cilium/global-service"
+	sharedServiceAnnotation       = "service.cilium.io/shared"
+	clusterLabel                  = "io.cilium.k8s.policy.cluster"
+)
+
+type ClusterState struct {
+	Clusters      []*types.ClusterState
+	PodIsolations map[string][]*shared.PodIsolation
+}
+
+type AnalysisResult struct {
+	Routes []*types.CrossClusterRoute
+}
+
+type Analyzer interface {
+	Analyze(clusterState ClusterState) AnalysisResult
+}
+
+type analyzerImpl struct {
+	allowedRouteAnalyzer allowedroute.Analyzer
+}
+
+func NewAnalyzer(allowedRouteAnalyzer allowedroute.Analyzer) Analyzer {
+	return analyzerImpl{
+		allowedRouteAnalyzer: allowedRouteAnalyzer,
+	}
+}
+
+type globalServiceKey struct {
+	mechanism types.CrossClusterMechanism
+	service   types.ServiceRef
+}
+
+type exportedService struct {
+	cluster *types.ClusterState
+	service *corev1.Service
+}
+
+type globalService struct {
+	globalServiceKey
+	exporters []exportedService
+	importers []*types.ClusterState
+}
+
+func (analyzer analyzerImpl) Analyze(clusterState ClusterState) AnalysisResult {
+	podIsolationsOf := func(cluster *types.ClusterState) []*shared.PodIsolation {
+		return clusterState.PodIsolations[cluster.Cluster]
+	}
+	routes := make([]*types.CrossClusterRoute, 0)
+	for _, service := range analyzer.globalServices(clusterState.Clusters) {
+		for _, importer := range service.importers {
+			for _, exporter := range service.exporters {
+				if importer == exporter.cluster {
+					continue
+				}
+				routes = append(routes, analyzer.routes(service.mechanism, importer, podIsolationsOf(importer),
+					exporter, podIsolationsOf(exporter.cluster))...)
+			}
+		}
+	}
+	return AnalysisResult{Routes: routes}
+}
+
+func (analyzer analyzerImpl) globalServices(clusters []*types.ClusterState) []*globalService {
+	servicesByKey := make(map[globalServiceKey]*globalService)
+	serviceOf := func(mechanism types.CrossClusterMechanism, service types.ServiceRef) *globalService {
+		key := globalServiceKey{mechanism: mechanism, service: service}
+		result, ok := servicesByKey[key]
+		if !ok {
+			result = &globalService{globalServiceKey: key}
+			servicesByKey[key] = result
+		}
+		return result
+	}
+	for _, cluster := range clusters {
+		services := make(map[types.ServiceRef]*corev1.Service)
+		for _, service := range cluster.Services {
+			services[shared.ToServiceRef(service)] = service
+		}
+		for _, serviceExport := range cluster.ServiceExports {
+			ref := types.ServiceRef{Name: serviceExport.Name, Namespace: serviceExport.Namespace}
+			if service, ok := services[ref]; ok {
+				exported := serviceOf(types.CrossClusterServiceExport, ref)
+				exported.exporters = append(exported.exporters, exportedService{cluster: cluster, service: service})
+			}
+		}
+		for _, serviceImport := range cluster.ServiceImports {
+			ref := types.ServiceRef{Name: serviceImport.Name, Namespace: serviceImport.Namespace}
+			imported := serviceOf(types.CrossClusterServiceExport, ref)
+			imported.importers = append(imported.importers, cluster)
+		}
+		for _, service := range cluster.Services {
+			if !isGlobal(service) {
+				continue
+			}
+			global := serviceOf(types.CrossClusterGlobalService, shared.ToServiceRef(service))
+			global.importers = append(global.importers, cluster)
+			if service.Annotations[sharedServiceAnnotation] != "false" {
+				global.exporters = append(global.exporters, exportedService{cluster: cluster, service: service})
+			}
+		}
+	}
+	result := make([]*globalService, 0, len(servicesByKey))
+	for _, service := range servicesByKey {
+		result = append(result, service)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		left, right := result[i], result[j]
+		if left.mechanism != right.mechanism {
+			return left.mechanism < right.mechanism
+		}
+		if left.service.Namespace != right.service.Namespace {
+			return left.service.Namespace < right.service.Namespace
+		}
+		return left.service.Name < right.service.Name
+	})
+	return result
+}
+
+func TakesPart(cluster *types.ClusterState) bool {
+	return len(cluster.ServiceExports) != 0 || len(cluster.ServiceImports) != 0 ||
+		commons.AnyMatch(cluster.Services, isGlobal)
+}
+
+func isGlobal(service *corev1.Service) bool {
+	return service.Annotations[globalServiceAnnotation] == "true" ||
+		service.Annotations[legacyGlobalServiceAnnotation] == "true"
+}
+
+func (analyzer analyzerImpl) routes(
+	mechanism types.CrossClusterMechanism,
+	importer *types.ClusterState,
+	clientIsolations []*shared.PodIsolation,
+	exporter exportedService,
+	exporterIsolations []*shared.PodIsolation,
+) []*types.CrossClusterRoute {
+	service := exporter.service
+	if len(service.Spec.Selector) == 0 {
+		return nil
+	}
+	selector := *metav1.SetAsLabelSelector(service.Spec.Selector)
+	result := make([]*types.CrossClusterRoute, 0)
+	for _, backendIsolation := range exporterIsolations {
+		backend := backendIsolation.Pod
+		if backend.Namespace != service.Namespace || !shared.SelectorMatches(backend.Labels, selector) {
+			continue
+		}
+		ports := analyzer.targetPorts(service, backend)
+		if len(ports) == 0 {
+			continue
+		}
+		remoteBackend := analyzer.remotePodIsolation(mechanism, backend, exporter.cluster.Cluster)
+		for _, clientIsolation := range clientIsolations {
+			egressRoute := analyzer.allowedRouteAnalyzer.Analyze(clientIsolation, remoteBackend, importer.Namespaces)
+			if egressRoute == nil {
+				continue
+			}
+			remoteClient := analyzer.remotePodIsolation(mechanism, clientIsolation.Pod, importer.Cluster)
+			ingressRoute := analyzer.allowedRouteAnalyzer.Analyze(remoteClient, backendIsolation,
+				exporter.cluster.Namespaces)
+			if ingressRoute == nil {
+				continue
+			}
+			allowedPorts := commons.Filter(ports, func(port int32) bool {
+				return egressRoute.AllowsPort(port) && ingressRoute.AllowsPort(port)
+			})
+			if len(allowedPorts) == 0 {
+				continue
+			}
+			result = append(result, &types.CrossClusterRoute{
+				Mechanism:       mechanism,
+				Service:         shared.ToServiceRef(service),
+				SourceCluster:   importer.Cluster,
+				SourcePod:       shared.ToPodRef(clientIsolation.Pod),
+				EgressPolicies:  egressRoute.EgressPolicies,
+				TargetCluster:   exporter.cluster.Cluster,
+				TargetPod:       shared.ToPodRef(backend),
+				IngressPolicies: ingressRoute.IngressPolicies,
+				Ports:           allowedPorts,
+			})
+		}
+	}
+	return result
+}
+
+func (analyzer analyzerImpl) targetPorts(service *corev1.Service, backend *corev1.Pod) []int32 {
+	ports := commons.NewSet[int32]()
+	for _, servicePort := range service.Spec.Ports {
+		targetPort := servicePort.TargetPort
+		if targetPort.StrVal != "" {
+			for _, container := range backend.Spec.Containers {
+				for _, containerPort := range container.Ports {
+					if containerPort.Name == targetPort.StrVal {
+						ports.Add(containerPort.ContainerPort)
+					}
+				}
+			}
+		} else if targetPort.IntVal != 0 {
+			ports.Add(targetPort.IntVal)
+		} else {
+			ports.Add(servicePort.Port)
+		}
+	}
+	result := ports.ToSlice()
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
+
+func (analyzer analyzerImpl) remotePodIsolation(
+	mechanism types.CrossClusterMechanism,
+	pod *corev1.Pod,
+	cluster string,
+) *shared.PodIsolation {
+	if mechanism == types.CrossClusterGlobalService {
+		pod = pod.DeepCopy()
+		if pod.Labels == nil {
+			pod.Labels = make(map[string]string)
+		}
+		pod.Labels[clusterLabel] = cluster
+	}
+	podIsolation := shared.NewPodIsolation(pod)
+	return &podIsolation
+}
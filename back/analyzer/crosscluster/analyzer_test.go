@@ -0,0 +1,154 @@
+package crosscluster
+
+import (
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"karto/analyzer/shared"
+	"karto/analyzer/traffic/allowedroute"
+	"karto/analyzer/traffic/podisolation"
+	"karto/crds/mcs"
+	"karto/testutils"
+	"karto/types"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	type args struct {
+		clusterState ClusterState
+	}
+	newService := func(annotations map[string]string) *corev1.Service {
+		service := testutils.NewServiceBuilder().WithName("api").WithNamespace("shop").
+			WithSelectorLabel("app", "api").Build()
+		service.Annotations = annotations
+		service.Spec.Ports = []corev1.ServicePort{
+			{Port: 80, TargetPort: intstr.FromString("http")},
+			{Port: 9090},
+		}
+		return service
+	}
+	newPod := func(name string, app string) *corev1.Pod {
+		return testutils.NewPodBuilder().WithName(name).WithNamespace("shop").WithLabel("app", app).
+			WithContainer(corev1.Container{Ports: []corev1.ContainerPort{{Name: "http", ContainerPort: 8080}}}).
+			Build()
+	}
+	newIngressPolicy := func(from metav1.LabelSelector) *networkingv1.NetworkPolicy {
+		return testutils.NewNetworkPolicyBuilder().WithName("api").WithNamespace("shop").
+			WithTypes(networkingv1.PolicyTypeIngress).
+			WithPodSelector(testutils.NewLabelSelectorBuilder().WithMatchLabel("app", "api").Build()).
+			WithIngressRule(networkingv1.NetworkPolicyIngressRule{
+				From: []networkingv1.NetworkPolicyPeer{{PodSelector: &from}},
+			}).Build()
+	}
+	namespace := testutils.NewNamespaceBuilder().WithName("shop").Build()
+	apiRef := types.ServiceRef{Name: "api", Namespace: "shop"}
+	serviceExport := &mcs.ServiceExport{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "shop"}}
+	serviceImport := &mcs.ServiceImport{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "shop"}}
+	apiPolicy := types.NetworkPolicy{Name: "api", Namespace: "shop", Labels: map[string]string{}}
+	tests := []struct {
+		name                   string
+		args                   args
+		expectedAnalysisResult AnalysisResult
+	}{
+		{
+			name: "links clients of importing clusters to backends of exporting clusters allowed by both ends",
+			args: args{
+				clusterState: ClusterState{
+					Clusters: []*types.ClusterState{
+						{
+							Cluster:        "east",
+							Namespaces:     []*corev1.Namespace{namespace},
+							Pods:           []*corev1.Pod{newPod("api-east", "api")},
+							Services:       []*corev1.Service{newService(nil)},
+							ServiceExports: []*mcs.ServiceExport{serviceExport},
+							ServiceImports: []*mcs.ServiceImport{serviceImport},
+							NetworkPolicies: []*networkingv1.NetworkPolicy{
+								newIngressPolicy(*testutils.NewLabelSelectorBuilder().WithMatchLabel("app", "web").
+									Build()),
+							},
+						},
+						{
+							Cluster:        "west",
+							Namespaces:     []*corev1.Namespace{namespace},
+							Pods:           []*corev1.Pod{newPod("web", "web"), newPod("batch", "batch")},
+							ServiceImports: []*mcs.ServiceImport{serviceImport},
+						},
+					},
+				},
+			},
+			expectedAnalysisResult: AnalysisResult{
+				Routes: []*types.CrossClusterRoute{
+					{Mechanism: types.CrossClusterServiceExport, Service: apiRef,
+						SourceCluster: "west", SourcePod: types.PodRef{Name: "web", Namespace: "shop"},
+						EgressPolicies: []types.NetworkPolicy{},
+						TargetCluster:  "east", TargetPod: types.PodRef{Name: "api-east", Namespace: "shop"},
+						IngressPolicies: []types.NetworkPolicy{apiPolicy}, Ports: []int32{8080, 9090}},
+				},
+			},
+		},
+		{
+			name: "global services are reachable from every cluster sharing them and match remote cluster labels",
+			args: args{
+				clusterState: ClusterState{
+					Clusters: []*types.ClusterState{
+						{
+							Cluster:    "east",
+							Namespaces: []*corev1.Namespace{namespace},
+							Pods:       []*corev1.Pod{newPod("api-east", "api"), newPod("job", "job")},
+							Services: []*corev1.Service{
+								newService(map[string]string{globalServiceAnnotation: "true"}),
+							},
+							NetworkPolicies: []*networkingv1.NetworkPolicy{
+								newIngressPolicy(*testutils.NewLabelSelectorBuilder().WithMatchLabel(clusterLabel, "west").
+									Build()),
+							},
+						},
+						{
+							Cluster:    "west",
+							Namespaces: []*corev1.Namespace{namespace},
+							Pods:       []*corev1.Pod{newPod("api-west", "api")},
+							Services: []*corev1.Service{
+								newService(map[string]string{legacyGlobalServiceAnnotation: "true",
+									sharedServiceAnnotation: "false"}),
+							},
+						},
+					},
+				},
+			},
+			expectedAnalysisResult: AnalysisResult{
+				Routes: []*types.CrossClusterRoute{
+					{Mechanism: types.CrossClusterGlobalService, Service: apiRef,
+						SourceCluster: "west", SourcePod: types.PodRef{Name: "api-west", Namespace: "shop"},
+						EgressPolicies: []types.NetworkPolicy{},
+						TargetCluster:  "east", TargetPod: types.PodRef{Name: "api-east", Namespace: "shop"},
+						IngressPolicies: []types.NetworkPolicy{apiPolicy}, Ports: []int32{8080, 9090}},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusterState := tt.args.clusterState
+			clusterState.PodIsolations = podIsolationsOf(clusterState.Clusters)
+			analyzer := NewAnalyzer(allowedroute.NewAnalyzer())
+			analysisResult := analyzer.Analyze(clusterState)
+			if diff := cmp.Diff(tt.expectedAnalysisResult, analysisResult); diff != "" {
+				t.Errorf("Analyze() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func podIsolationsOf(clusters []*types.ClusterState) map[string][]*shared.PodIsolation {
+	podIsolationAnalyzer := podisolation.NewAnalyzer()
+	result := make(map[string][]*shared.PodIsolation)
+	for _, cluster := range clusters {
+		for _, pod := range cluster.Pods {
+			result[cluster.Cluster] = append(result[cluster.Cluster],
+				podIsolationAnalyzer.Analyze(pod, cluster.NetworkPolicies))
+		}
+	}
+	return result
+}
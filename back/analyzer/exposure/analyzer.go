@@ -0,0 +1,243 @@
+package exposure
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"karto/analyzer/shared"
+	"karto/commons"
+	"karto/types"
+	"sort"
+)
+
+const (
+	serviceKind = "Service"
+	ingressKind = "Ingress"
+)
+
+var riskOrder = map[types.ExposureRisk]int{
+	types.ExposureRiskCritical: 0,
+	types.ExposureRiskHigh:     1,
+	types.ExposureRiskMedium:   2,
+	types.ExposureRiskLow:      3,
+}
+
+type ClusterState struct {
+	Pods           []*corev1.Pod
+	Services       []*corev1.Service
+	ServiceTargets []*types.Service
+	IngressTargets []*types.Ingress
+	AllowedRoutes  []*types.AllowedRoute
+	PodSecurities  []*types.PodSecurity
+}
+
+type AnalysisResult struct {
+	Pods []*types.ExposedPod
+}
+
+type Analyzer interface {
+	Analyze(clusterState ClusterState) AnalysisResult
+}
+
+type analyzerImpl struct{}
+
+func NewAnalyzer() Analyzer {
+	return analyzerImpl{}
+}
+
+type reachedPod struct {
+	path        []types.PodRef
+	entryPoints []types.ObjectRef
+}
+
+func (analyzer analyzerImpl) Analyze(clusterState ClusterState) AnalysisResult {
+	reachedPods := analyzer.reachablePods(analyzer.entryPoints(clusterState), clusterState.AllowedRoutes)
+	privilegedPods := analyzer.privilegedPods(clusterState.PodSecurities)
+	pods := make([]*types.ExposedPod, 0, len(reachedPods))
+	for _, pod := range clusterState.Pods {
+		reached, ok := reachedPods[shared.ToPodRef(pod)]
+		if !ok {
+			continue
+		}
+		exposedPod := &types.ExposedPod{
+			Pod:         shared.ToPodRef(pod),
+			Hops:        len(reached.path) - 1,
+			EntryPoints: reached.entryPoints,
+			Path:        reached.path,
+			Secrets:     analyzer.secretsOf(pod),
+			Privileged:  privilegedPods.Contains(shared.ToPodRef(pod)),
+		}
+		exposedPod.Risk = analyzer.riskOf(exposedPod)
+		pods = append(pods, exposedPod)
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		if pods[i].Risk != pods[j].Risk {
+			return riskOrder[pods[i].Risk] < riskOrder[pods[j].Risk]
+		}
+		if pods[i].Hops != pods[j].Hops {
+			return pods[i].Hops < pods[j].Hops
+		}
+		if pods[i].Pod.Namespace != pods[j].Pod.Namespace {
+			return pods[i].Pod.Namespace < pods[j].Pod.Namespace
+		}
+		return pods[i].Pod.Name < pods[j].Pod.Name
+	})
+	return AnalysisResult{
+		Pods: pods,
+	}
+}
+
+func (analyzer analyzerImpl) entryPoints(clusterState ClusterState) map[types.PodRef][]types.ObjectRef {
+	targetsByService := make(map[types.ServiceRef][]types.PodRef, len(clusterState.ServiceTargets))
+	for _, service := range clusterState.ServiceTargets {
+		targetsByService[types.ServiceRef{Name: service.Name, Namespace: service.Namespace}] = service.TargetPods
+	}
+	result := make(map[types.PodRef][]types.ObjectRef)
+	for _, service := range clusterState.Services {
+		if service.Spec.Type != corev1.ServiceTypeLoadBalancer && service.Spec.Type != corev1.ServiceTypeNodePort {
+			continue
+		}
+		entryPoint := shared.ToObjectRef(serviceKind, service)
+		for _, pod := range targetsByService[shared.ToServiceRef(service)] {
+			result[pod] = append(result[pod], entryPoint)
+		}
+	}
+	for _, ingress := range clusterState.IngressTargets {
+		entryPoint := types.ObjectRef{Kind: ingressKind, Name: ingress.Name, Namespace: ingress.Namespace}
+		for _, service := range ingress.TargetServices {
+			for _, pod := range targetsByService[service] {
+				result[pod] = append(result[pod], entryPoint)
+			}
+		}
+	}
+	for pod, entryPoints := range result {
+		result[pod] = analyzer.distinctSorted(entryPoints)
+	}
+	return result
+}
+
+func (analyzer analyzerImpl) reachablePods(entryPoints map[types.PodRef][]types.ObjectRef,
+	allowedRoutes []*types.AllowedRoute) map[types.PodRef]*reachedPod {
+	targetsBySource := make(map[types.PodRef][]types.PodRef)
+	for _, allowedRoute := range allowedRoutes {
+		targetsBySource[allowedRoute.SourcePod] = append(targetsBySource[allowedRoute.SourcePod],
+			allowedRoute.TargetPod)
+	}
+	for _, targets := range targetsBySource {
+		sortPodRefs(targets)
+	}
+	queue := make([]types.PodRef, 0, len(entryPoints))
+	for pod := range entryPoints {
+		queue = append(queue, pod)
+	}
+	sortPodRefs(queue)
+	result := make(map[types.PodRef]*reachedPod, len(entryPoints))
+	for _, pod := range queue {
+		result[pod] = &reachedPod{path: []types.PodRef{pod}, entryPoints: entryPoints[pod]}
+	}
+	for len(queue) > 0 {
+		source := queue[0]
+		queue = queue[1:]
+		for _, target := range targetsBySource[source] {
+			if _, ok := result[target]; ok {
+				continue
+			}
+			path := make([]types.PodRef, len(result[source].path), len(result[source].path)+1)
+			copy(path, result[source].path)
+			result[target] = &reachedPod{path: append(path, target), entryPoints: result[source].entryPoints}
+			queue = append(queue, target)
+		}
+	}
+	return result
+}
+
+func (analyzer analyzerImpl) secretsOf(pod *corev1.Pod) []string {
+	secrets := commons.NewSet[string]()
+	for _, volume := range pod.Spec.Volumes {
+		if volume.Secret != nil {
+			secrets.Add(volume.Secret.SecretName)
+		}
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.Secret != nil {
+					secrets.Add(source.Secret.Name)
+				}
+			}
+		}
+	}
+	for _, container := range analyzer.containersOf(pod) {
+		for _, env := range container.Env {
+			if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
+				secrets.Add(env.ValueFrom.SecretKeyRef.Name)
+			}
+		}
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.SecretRef != nil {
+				secrets.Add(envFrom.SecretRef.Name)
+			}
+		}
+	}
+	result := secrets.ToSlice()
+	sort.Strings(result)
+	return result
+}
+
+func (analyzer analyzerImpl) privilegedPods(podSecurities []*types.PodSecurity) *commons.Set[types.PodRef] {
+	result := commons.NewSet[types.PodRef]()
+	for _, podSecurity := range podSecurities {
+		if podSecurity.Level == types.PodSecurityLevelPrivileged {
+			result.Add(podSecurity.Pod)
+		}
+	}
+	return result
+}
+
+func (analyzer analyzerImpl) containersOf(pod *corev1.Pod) []corev1.Container {
+	result := make([]corev1.Container, 0,
+		len(pod.Spec.InitContainers)+len(pod.Spec.Containers)+len(pod.Spec.EphemeralContainers))
+	result = append(result, pod.Spec.InitContainers...)
+	result = append(result, pod.Spec.Containers...)
+	for _, ephemeralContainer := range pod.Spec.EphemeralContainers {
+		result = append(result, corev1.Container(ephemeralContainer.EphemeralContainerCommon))
+	}
+	return result
+}
+
+func (analyzer analyzerImpl) riskOf(exposedPod *types.ExposedPod) types.ExposureRisk {
+	hasSecrets := len(exposedPod.Secrets) > 0
+	switch {
+	case exposedPod.Privileged && hasSecrets:
+		return types.ExposureRiskCritical
+	case exposedPod.Privileged:
+		return types.ExposureRiskHigh
+	case hasSecrets:
+		return types.ExposureRiskMedium
+	default:
+		return types.ExposureRiskLow
+	}
+}
+
+func (analyzer analyzerImpl) distinctSorted(objects []types.ObjectRef) []types.ObjectRef {
+	result := commons.NewSet[types.ObjectRef]()
+	for _, object := range objects {
+		result.Add(object)
+	}
+	sorted := result.ToSlice()
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Kind != sorted[j].Kind {
+			return sorted[i].Kind < sorted[j].Kind
+		}
+		if sorted[i].Namespace != sorted[j].Namespace {
+			return sorted[i].Namespace < sorted[j].Namespace
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+func sortPodRefs(pods []types.PodRef) {
+	sort.Slice(pods, func(i, j int) bool {
+		if pods[i].Namespace != pods[j].Namespace {
+			return pods[i].Namespace < pods[j].Namespace
+		}
+		return pods[i].Name < pods[j].Name
+	})
+}
@@ -0,0 +1,166 @@
+package exposure
+
+import (
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"karto/analyzer/podsecurity"
+	"karto/testutils"
+	"karto/types"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	type args struct {
+		clusterState ClusterState
+	}
+	privileged := true
+	privilegedContainer := corev1.Container{
+		Name:            "agent",
+		SecurityContext: &corev1.SecurityContext{Privileged: &privileged},
+	}
+	secretContainer := corev1.Container{
+		Name: "app",
+		Env: []corev1.EnvVar{
+			{Name: "PASSWORD", ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "db"}},
+			}},
+		},
+		EnvFrom: []corev1.EnvFromSource{
+			{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "api-keys"}}},
+		},
+	}
+	front := testutils.NewPodBuilder().WithName("front").Build()
+	api := testutils.NewPodBuilder().WithName("api").WithContainer(secretContainer).Build()
+	db := testutils.NewPodBuilder().WithName("db").WithContainer(privilegedContainer).Build()
+	db.Spec.Volumes = []corev1.Volume{
+		{Name: "data", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "db"}}},
+	}
+	agent := testutils.NewPodBuilder().WithName("agent").WithContainer(privilegedContainer).Build()
+	internal := testutils.NewPodBuilder().WithName("internal").Build()
+	debugged := testutils.NewPodBuilder().WithName("debugged").Build()
+	debugged.Spec.EphemeralContainers = []corev1.EphemeralContainer{
+		{EphemeralContainerCommon: corev1.EphemeralContainerCommon(privilegedContainer)},
+	}
+	hostNetwork := testutils.NewPodBuilder().WithName("host-network").WithHostNetwork().Build()
+	frontRef := types.PodRef{Name: "front", Namespace: "default"}
+	apiRef := types.PodRef{Name: "api", Namespace: "default"}
+	dbRef := types.PodRef{Name: "db", Namespace: "default"}
+	agentRef := types.PodRef{Name: "agent", Namespace: "default"}
+	internalRef := types.PodRef{Name: "internal", Namespace: "default"}
+	debuggedRef := types.PodRef{Name: "debugged", Namespace: "default"}
+	hostNetworkRef := types.PodRef{Name: "host-network", Namespace: "default"}
+	frontService := testutils.NewServiceBuilder().WithName("front").Build()
+	agentService := testutils.NewServiceBuilder().WithName("agent").WithType(corev1.ServiceTypeNodePort).Build()
+	apiService := testutils.NewServiceBuilder().WithName("api").WithType(corev1.ServiceTypeLoadBalancer).Build()
+	debugService := testutils.NewServiceBuilder().WithName("debug").WithType(corev1.ServiceTypeNodePort).Build()
+	serviceTargets := []*types.Service{
+		{Name: "front", Namespace: "default", TargetPods: []types.PodRef{frontRef}},
+		{Name: "agent", Namespace: "default", TargetPods: []types.PodRef{agentRef}},
+		{Name: "api", Namespace: "default", TargetPods: []types.PodRef{apiRef}},
+		{Name: "debug", Namespace: "default", TargetPods: []types.PodRef{debuggedRef, hostNetworkRef}},
+	}
+	ingress := &types.Ingress{Name: "web", Namespace: "default",
+		TargetServices: []types.ServiceRef{{Name: "front", Namespace: "default"}}}
+	ingressEntryPoint := types.ObjectRef{Kind: "Ingress", Name: "web", Namespace: "default"}
+	tests := []struct {
+		name                string
+		args                args
+		expectedExposedPods []*types.ExposedPod
+	}{
+		{
+			name: "pods behind cluster IP services only are not exposed",
+			args: args{
+				clusterState: ClusterState{
+					Pods:           []*corev1.Pod{front, api},
+					Services:       []*corev1.Service{frontService},
+					ServiceTargets: serviceTargets,
+					AllowedRoutes:  []*types.AllowedRoute{{SourcePod: frontRef, TargetPod: apiRef}},
+				},
+			},
+			expectedExposedPods: []*types.ExposedPod{},
+		},
+		{
+			name: "pods reachable from an ingress are exposed with the number of hops and ranked by risk",
+			args: args{
+				clusterState: ClusterState{
+					Pods:           []*corev1.Pod{front, api, db, internal},
+					Services:       []*corev1.Service{frontService},
+					ServiceTargets: serviceTargets,
+					IngressTargets: []*types.Ingress{ingress},
+					AllowedRoutes: []*types.AllowedRoute{
+						{SourcePod: frontRef, TargetPod: apiRef},
+						{SourcePod: apiRef, TargetPod: dbRef},
+						{SourcePod: internalRef, TargetPod: frontRef},
+					},
+				},
+			},
+			expectedExposedPods: []*types.ExposedPod{
+				{Pod: dbRef, Hops: 2, EntryPoints: []types.ObjectRef{ingressEntryPoint},
+					Path: []types.PodRef{frontRef, apiRef, dbRef}, Secrets: []string{"db"}, Privileged: true,
+					Risk: types.ExposureRiskCritical},
+				{Pod: apiRef, Hops: 1, EntryPoints: []types.ObjectRef{ingressEntryPoint},
+					Path: []types.PodRef{frontRef, apiRef}, Secrets: []string{"api-keys", "db"},
+					Risk: types.ExposureRiskMedium},
+				{Pod: frontRef, Hops: 0, EntryPoints: []types.ObjectRef{ingressEntryPoint},
+					Path: []types.PodRef{frontRef}, Secrets: []string{}, Risk: types.ExposureRiskLow},
+			},
+		},
+		{
+			name: "load balancer and node port services expose their target pods directly",
+			args: args{
+				clusterState: ClusterState{
+					Pods:           []*corev1.Pod{front, api, agent},
+					Services:       []*corev1.Service{frontService, agentService, apiService},
+					ServiceTargets: serviceTargets,
+					IngressTargets: []*types.Ingress{ingress},
+					AllowedRoutes:  []*types.AllowedRoute{{SourcePod: frontRef, TargetPod: apiRef}},
+				},
+			},
+			expectedExposedPods: []*types.ExposedPod{
+				{Pod: agentRef, Hops: 0,
+					EntryPoints: []types.ObjectRef{{Kind: "Service", Name: "agent", Namespace: "default"}},
+					Path:        []types.PodRef{agentRef}, Secrets: []string{}, Privileged: true,
+					Risk: types.ExposureRiskHigh},
+				{Pod: apiRef, Hops: 0,
+					EntryPoints: []types.ObjectRef{{Kind: "Service", Name: "api", Namespace: "default"}},
+					Path:        []types.PodRef{apiRef}, Secrets: []string{"api-keys", "db"},
+					Risk: types.ExposureRiskMedium},
+				{Pod: frontRef, Hops: 0, EntryPoints: []types.ObjectRef{ingressEntryPoint},
+					Path: []types.PodRef{frontRef}, Secrets: []string{}, Risk: types.ExposureRiskLow},
+			},
+		},
+		{
+			name: "pods breaking the baseline pod security level are privileged",
+			args: args{
+				clusterState: ClusterState{
+					Pods:           []*corev1.Pod{debugged, hostNetwork},
+					Services:       []*corev1.Service{debugService},
+					ServiceTargets: serviceTargets,
+				},
+			},
+			expectedExposedPods: []*types.ExposedPod{
+				{Pod: debuggedRef, Hops: 0,
+					EntryPoints: []types.ObjectRef{{Kind: "Service", Name: "debug", Namespace: "default"}},
+					Path:        []types.PodRef{debuggedRef}, Secrets: []string{}, Privileged: true,
+					Risk: types.ExposureRiskHigh},
+				{Pod: hostNetworkRef, Hops: 0,
+					EntryPoints: []types.ObjectRef{{Kind: "Service", Name: "debug", Namespace: "default"}},
+					Path:        []types.PodRef{hostNetworkRef}, Secrets: []string{}, Privileged: true,
+					Risk: types.ExposureRiskHigh},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusterState := tt.args.clusterState
+			clusterState.PodSecurities = podsecurity.NewAnalyzer().Analyze(podsecurity.ClusterState{
+				Pods: clusterState.Pods,
+			}).Pods
+			analyzer := NewAnalyzer()
+			analysisResult := analyzer.Analyze(clusterState)
+			if diff := cmp.Diff(tt.expectedExposedPods, analysisResult.Pods); diff != "" {
+				t.Errorf("Analyze() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -1,18 +1,34 @@
 package health
 
 import (
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"karto/analyzer/health/daemonsethealth"
+	"karto/analyzer/health/deploymenthealth"
 	"karto/analyzer/health/podhealth"
+	"karto/analyzer/health/servicehealth"
+	"karto/analyzer/health/statefulsethealth"
+	"karto/analyzer/health/warnings"
 	"karto/commons"
 	"karto/types"
 )
 
 type ClusterState struct {
-	Pods []*corev1.Pod
+	Pods         []*corev1.Pod
+	Services     []*corev1.Service
+	StatefulSets []*appsv1.StatefulSet
+	DaemonSets   []*appsv1.DaemonSet
+	Deployments  []*appsv1.Deployment
+	Events       []*corev1.Event
 }
 
 type AnalysisResult struct {
-	Pods []*types.PodHealth
+	Pods         []*types.PodHealth
+	Services     []*types.ServiceHealth
+	StatefulSets []*types.StatefulSetHealth
+	DaemonSets   []*types.DaemonSetHealth
+	Deployments  []*types.DeploymentHealth
+	Warnings     []*types.ObjectWarnings
 }
 
 type Analyzer interface {
@@ -20,18 +36,47 @@ type Analyzer interface {
 }
 
 type analyzerImpl struct {
-	podHealthAnalyzer podhealth.Analyzer
+	podHealthAnalyzer         podhealth.Analyzer
+	serviceHealthAnalyzer     servicehealth.Analyzer
+	statefulSetHealthAnalyzer statefulsethealth.Analyzer
+	daemonSetHealthAnalyzer   daemonsethealth.Analyzer
+	deploymentHealthAnalyzer  deploymenthealth.Analyzer
+	warningsAnalyzer          warnings.Analyzer
 }
 
-func NewAnalyzer(podHealthAnalyzer podhealth.Analyzer) Analyzer {
+func NewAnalyzer(
+	podHealthAnalyzer podhealth.Analyzer,
+	serviceHealthAnalyzer servicehealth.Analyzer,
+	statefulSetHealthAnalyzer statefulsethealth.Analyzer,
+	daemonSetHealthAnalyzer daemonsethealth.Analyzer,
+	deploymentHealthAnalyzer deploymenthealth.Analyzer,
+	warningsAnalyzer warnings.Analyzer,
+) Analyzer {
 	return analyzerImpl{
-		podHealthAnalyzer: podHealthAnalyzer,
+		podHealthAnalyzer:         podHealthAnalyzer,
+		serviceHealthAnalyzer:     serviceHealthAnalyzer,
+		statefulSetHealthAnalyzer: statefulSetHealthAnalyzer,
+		daemonSetHealthAnalyzer:   daemonSetHealthAnalyzer,
+		deploymentHealthAnalyzer:  deploymentHealthAnalyzer,
+		warningsAnalyzer:          warningsAnalyzer,
 	}
 }
 
 func (analyzer analyzerImpl) Analyze(clusterState ClusterState) AnalysisResult {
 	podsHealth := commons.Map(clusterState.Pods, analyzer.podHealthAnalyzer.Analyze)
+	servicesHealth := commons.Map(clusterState.Services, func(service *corev1.Service) *types.ServiceHealth {
+		return analyzer.serviceHealthAnalyzer.Analyze(service, clusterState.Pods)
+	})
+	statefulSetsHealth := commons.Map(clusterState.StatefulSets, analyzer.statefulSetHealthAnalyzer.Analyze)
+	daemonSetsHealth := commons.Map(clusterState.DaemonSets, analyzer.daemonSetHealthAnalyzer.Analyze)
+	deploymentsHealth := commons.Map(clusterState.Deployments, analyzer.deploymentHealthAnalyzer.Analyze)
+	objectsWarnings := analyzer.warningsAnalyzer.Analyze(clusterState.Events)
 	return AnalysisResult{
-		Pods: podsHealth,
+		Pods:         podsHealth,
+		Services:     servicesHealth,
+		StatefulSets: statefulSetsHealth,
+		DaemonSets:   daemonSetsHealth,
+		Deployments:  deploymentsHealth,
+		Warnings:     objectsWarnings,
 	}
 }
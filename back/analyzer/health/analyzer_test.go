@@ -2,8 +2,14 @@ package health
 
 import (
 	"github.com/google/go-cmp/cmp"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"karto/analyzer/health/daemonsethealth"
+	"karto/analyzer/health/deploymenthealth"
 	"karto/analyzer/health/podhealth"
+	"karto/analyzer/health/servicehealth"
+	"karto/analyzer/health/statefulsethealth"
+	"karto/analyzer/health/warnings"
 	"karto/testutils"
 	"karto/types"
 	"reflect"
@@ -15,17 +21,43 @@ func TestAnalyze(t *testing.T) {
 		clusterState ClusterState
 	}
 	type mocks struct {
-		podHealth []mockPodHealthAnalyzerCall
+		podHealth         []mockPodHealthAnalyzerCall
+		serviceHealth     []mockServiceHealthAnalyzerCall
+		statefulSetHealth []mockStatefulSetHealthAnalyzerCall
+		daemonSetHealth   []mockDaemonSetHealthAnalyzerCall
+		deploymentHealth  []mockDeploymentHealthAnalyzerCall
+		warnings          []mockWarningsAnalyzerCall
 	}
 	k8sPod1 := testutils.NewPodBuilder().WithContainerStatus(true, false, 0).Build()
 	k8sPod2 := testutils.NewPodBuilder().WithContainerStatus(true, false, 0).
 		WithContainerStatus(false, false, 0).Build()
+	k8sService := testutils.NewServiceBuilder().WithName("svc").Build()
+	k8sStatefulSet := testutils.NewStatefulSetBuilder().WithName("ss").Build()
+	k8sDaemonSet := testutils.NewDaemonSetBuilder().WithName("ds").Build()
+	k8sDeployment := testutils.NewDeploymentBuilder().WithName("deploy").Build()
+	k8sEvent := testutils.NewEventBuilder().WithInvolvedObject("Pod", k8sPod1.Namespace, k8sPod1.Name).
+		WithReason("BackOff").Build()
 	podRef1 := types.PodRef{Name: k8sPod1.Name, Namespace: k8sPod1.Namespace}
 	podRef2 := types.PodRef{Name: k8sPod2.Name, Namespace: k8sPod2.Namespace}
 	podHealth1 := &types.PodHealth{Pod: podRef1, Containers: 1, ContainersRunning: 1, ContainersReady: 0,
 		ContainersWithoutRestart: 1}
 	podHealth2 := &types.PodHealth{Pod: podRef2, Containers: 2, ContainersRunning: 1, ContainersReady: 0,
 		ContainersWithoutRestart: 2}
+	serviceHealth := &types.ServiceHealth{
+		Service:   types.ServiceRef{Name: k8sService.Name, Namespace: k8sService.Namespace},
+		Endpoints: 2, EndpointsAvailable: 0, Status: types.HealthStatusDown}
+	statefulSetHealth := &types.StatefulSetHealth{
+		StatefulSet:     types.StatefulSetRef{Name: k8sStatefulSet.Name, Namespace: k8sStatefulSet.Namespace},
+		DesiredReplicas: 1, AvailableReplicas: 1, Status: types.HealthStatusHealthy}
+	daemonSetHealth := &types.DaemonSetHealth{
+		DaemonSet:       types.DaemonSetRef{Name: k8sDaemonSet.Name, Namespace: k8sDaemonSet.Namespace},
+		DesiredReplicas: 3, AvailableReplicas: 2, RolloutInProgress: true, Status: types.HealthStatusDegraded}
+	deploymentHealth := &types.DeploymentHealth{
+		Deployment:      types.DeploymentRef{Name: k8sDeployment.Name, Namespace: k8sDeployment.Namespace},
+		DesiredReplicas: 2, AvailableReplicas: 2, Status: types.HealthStatusHealthy}
+	objectWarnings := &types.ObjectWarnings{
+		Object:   types.ObjectRef{Kind: "Pod", Name: k8sPod1.Name, Namespace: k8sPod1.Namespace},
+		Warnings: []types.Warning{{Reason: "BackOff", Count: 1}}}
 	tests := []struct {
 		name                   string
 		mocks                  mocks
@@ -49,21 +81,78 @@ func TestAnalyze(t *testing.T) {
 						returnValue: podHealth2,
 					},
 				},
+				serviceHealth: []mockServiceHealthAnalyzerCall{
+					{
+						args: mockServiceHealthAnalyzerCallArgs{
+							service: k8sService,
+							pods:    []*corev1.Pod{k8sPod1, k8sPod2},
+						},
+						returnValue: serviceHealth,
+					},
+				},
+				statefulSetHealth: []mockStatefulSetHealthAnalyzerCall{
+					{
+						args: mockStatefulSetHealthAnalyzerCallArgs{
+							statefulSet: k8sStatefulSet,
+						},
+						returnValue: statefulSetHealth,
+					},
+				},
+				daemonSetHealth: []mockDaemonSetHealthAnalyzerCall{
+					{
+						args: mockDaemonSetHealthAnalyzerCallArgs{
+							daemonSet: k8sDaemonSet,
+						},
+						returnValue: daemonSetHealth,
+					},
+				},
+				deploymentHealth: []mockDeploymentHealthAnalyzerCall{
+					{
+						args: mockDeploymentHealthAnalyzerCallArgs{
+							deployment: k8sDeployment,
+						},
+						returnValue: deploymentHealth,
+					},
+				},
+				warnings: []mockWarningsAnalyzerCall{
+					{
+						args: mockWarningsAnalyzerCallArgs{
+							events: []*corev1.Event{k8sEvent},
+						},
+						returnValue: []*types.ObjectWarnings{objectWarnings},
+					},
+				},
 			},
 			args: args{
 				clusterState: ClusterState{
-					Pods: []*corev1.Pod{k8sPod1, k8sPod2},
+					Pods:         []*corev1.Pod{k8sPod1, k8sPod2},
+					Services:     []*corev1.Service{k8sService},
+					StatefulSets: []*appsv1.StatefulSet{k8sStatefulSet},
+					DaemonSets:   []*appsv1.DaemonSet{k8sDaemonSet},
+					Deployments:  []*appsv1.Deployment{k8sDeployment},
+					Events:       []*corev1.Event{k8sEvent},
 				},
 			},
 			expectedAnalysisResult: AnalysisResult{
-				Pods: []*types.PodHealth{podHealth1, podHealth2},
+				Pods:         []*types.PodHealth{podHealth1, podHealth2},
+				Services:     []*types.ServiceHealth{serviceHealth},
+				StatefulSets: []*types.StatefulSetHealth{statefulSetHealth},
+				DaemonSets:   []*types.DaemonSetHealth{daemonSetHealth},
+				Deployments:  []*types.DeploymentHealth{deploymentHealth},
+				Warnings:     []*types.ObjectWarnings{objectWarnings},
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			podHealthAnalyzer := createMockPodHealthAnalyzer(t, tt.mocks.podHealth)
-			analyzer := NewAnalyzer(podHealthAnalyzer)
+			serviceHealthAnalyzer := createMockServiceHealthAnalyzer(t, tt.mocks.serviceHealth)
+			statefulSetHealthAnalyzer := createMockStatefulSetHealthAnalyzer(t, tt.mocks.statefulSetHealth)
+			daemonSetHealthAnalyzer := createMockDaemonSetHealthAnalyzer(t, tt.mocks.daemonSetHealth)
+			deploymentHealthAnalyzer := createMockDeploymentHealthAnalyzer(t, tt.mocks.deploymentHealth)
+			warningsAnalyzer := createMockWarningsAnalyzer(t, tt.mocks.warnings)
+			analyzer := NewAnalyzer(podHealthAnalyzer, serviceHealthAnalyzer, statefulSetHealthAnalyzer,
+				daemonSetHealthAnalyzer, deploymentHealthAnalyzer, warningsAnalyzer)
 			analysisResult := analyzer.Analyze(tt.args.clusterState)
 			if diff := cmp.Diff(tt.expectedAnalysisResult, analysisResult); diff != "" {
 				t.Errorf("Analyze() result mismatch (-want +got):\n%s", diff)
@@ -102,3 +191,172 @@ func createMockPodHealthAnalyzer(t *testing.T, calls []mockPodHealthAnalyzerCall
 		calls: calls,
 	}
 }
+
+type mockServiceHealthAnalyzerCallArgs struct {
+	service *corev1.Service
+	pods    []*corev1.Pod
+}
+
+type mockServiceHealthAnalyzerCall struct {
+	args        mockServiceHealthAnalyzerCallArgs
+	returnValue *types.ServiceHealth
+}
+
+type mockServiceHealthAnalyzer struct {
+	t     *testing.T
+	calls []mockServiceHealthAnalyzerCall
+}
+
+func (mock mockServiceHealthAnalyzer) Analyze(service *corev1.Service, pods []*corev1.Pod) *types.ServiceHealth {
+	for _, call := range mock.calls {
+		if reflect.DeepEqual(call.args.service, service) && reflect.DeepEqual(call.args.pods, pods) {
+			return call.returnValue
+		}
+	}
+	mock.t.Fatalf("mockServiceHealthAnalyzer was called with unexpected arguments:\n\tservice: %s\n\tpods: %s\n",
+		service, pods)
+	return nil
+}
+
+func createMockServiceHealthAnalyzer(t *testing.T, calls []mockServiceHealthAnalyzerCall) servicehealth.Analyzer {
+	return mockServiceHealthAnalyzer{
+		t:     t,
+		calls: calls,
+	}
+}
+
+type mockStatefulSetHealthAnalyzerCallArgs struct {
+	statefulSet *appsv1.StatefulSet
+}
+
+type mockStatefulSetHealthAnalyzerCall struct {
+	args        mockStatefulSetHealthAnalyzerCallArgs
+	returnValue *types.StatefulSetHealth
+}
+
+type mockStatefulSetHealthAnalyzer struct {
+	t     *testing.T
+	calls []mockStatefulSetHealthAnalyzerCall
+}
+
+func (mock mockStatefulSetHealthAnalyzer) Analyze(statefulSet *appsv1.StatefulSet) *types.StatefulSetHealth {
+	for _, call := range mock.calls {
+		if reflect.DeepEqual(call.args.statefulSet, statefulSet) {
+			return call.returnValue
+		}
+	}
+	mock.t.Fatalf("mockStatefulSetHealthAnalyzer was called with unexpected arguments:\n\tstatefulSet: %s\n",
+		statefulSet)
+	return nil
+}
+
+func createMockStatefulSetHealthAnalyzer(
+	t *testing.T,
+	calls []mockStatefulSetHealthAnalyzerCall,
+) statefulsethealth.Analyzer {
+	return mockStatefulSetHealthAnalyzer{
+		t:     t,
+		calls: calls,
+	}
+}
+
+type mockDaemonSetHealthAnalyzerCallArgs struct {
+	daemonSet *appsv1.DaemonSet
+}
+
+type mockDaemonSetHealthAnalyzerCall struct {
+	args        mockDaemonSetHealthAnalyzerCallArgs
+	returnValue *types.DaemonSetHealth
+}
+
+type mockDaemonSetHealthAnalyzer struct {
+	t     *testing.T
+	calls []mockDaemonSetHealthAnalyzerCall
+}
+
+func (mock mockDaemonSetHealthAnalyzer) Analyze(daemonSet *appsv1.DaemonSet) *types.DaemonSetHealth {
+	for _, call := range mock.calls {
+		if reflect.DeepEqual(call.args.daemonSet, daemonSet) {
+			return call.returnValue
+		}
+	}
+	mock.t.Fatalf("mockDaemonSetHealthAnalyzer was called with unexpected arguments:\n\tdaemonSet: %s\n",
+		daemonSet)
+	return nil
+}
+
+func createMockDaemonSetHealthAnalyzer(
+	t *testing.T,
+	calls []mockDaemonSetHealthAnalyzerCall,
+) daemonsethealth.Analyzer {
+	return mockDaemonSetHealthAnalyzer{
+		t:     t,
+		calls: calls,
+	}
+}
+
+type mockDeploymentHealthAnalyzerCallArgs struct {
+	deployment *appsv1.Deployment
+}
+
+type mockDeploymentHealthAnalyzerCall struct {
+	args        mockDeploymentHealthAnalyzerCallArgs
+	returnValue *types.DeploymentHealth
+}
+
+type mockDeploymentHealthAnalyzer struct {
+	t     *testing.T
+	calls []mockDeploymentHealthAnalyzerCall
+}
+
+func (mock mockDeploymentHealthAnalyzer) Analyze(deployment *appsv1.Deployment) *types.DeploymentHealth {
+	for _, call := range mock.calls {
+		if reflect.DeepEqual(call.args.deployment, deployment) {
+			return call.returnValue
+		}
+	}
+	mock.t.Fatalf("mockDeploymentHealthAnalyzer was called with unexpected arguments:\n\tdeployment: %s\n",
+		deployment)
+	return nil
+}
+
+func createMockDeploymentHealthAnalyzer(
+	t *testing.T,
+	calls []mockDeploymentHealthAnalyzerCall,
+) deploymenthealth.Analyzer {
+	return mockDeploymentHealthAnalyzer{
+		t:     t,
+		calls: calls,
+	}
+}
+
+type mockWarningsAnalyzerCallArgs struct {
+	events []*corev1.Event
+}
+
+type mockWarningsAnalyzerCall struct {
+	args        mockWarningsAnalyzerCallArgs
+	returnValue []*types.ObjectWarnings
+}
+
+type mockWarningsAnalyzer struct {
+	t     *testing.T
+	calls []mockWarningsAnalyzerCall
+}
+
+func (mock mockWarningsAnalyzer) Analyze(events []*corev1.Event) []*types.ObjectWarnings {
+	for _, call := range mock.calls {
+		if reflect.DeepEqual(call.args.events, events) {
+			return call.returnValue
+		}
+	}
+	mock.t.Fatalf("mockWarningsAnalyzer was called with unexpected arguments:\n\tevents: %s\n", events)
+	return nil
+}
+
+func createMockWarningsAnalyzer(t *testing.T, calls []mockWarningsAnalyzerCall) warnings.Analyzer {
+	return mockWarningsAnalyzer{
+		t:     t,
+		calls: calls,
+	}
+}
@@ -0,0 +1,30 @@
+package daemonsethealth
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	"karto/analyzer/shared"
+	"karto/types"
+)
+
+type Analyzer interface {
+	Analyze(daemonSet *appsv1.DaemonSet) *types.DaemonSetHealth
+}
+
+type analyzerImpl struct{}
+
+func NewAnalyzer() Analyzer {
+	return analyzerImpl{}
+}
+
+func (analyzer analyzerImpl) Analyze(daemonSet *appsv1.DaemonSet) *types.DaemonSetHealth {
+	status := daemonSet.Status
+	desired := status.DesiredNumberScheduled
+	available := status.NumberAvailable
+	return &types.DaemonSetHealth{
+		DaemonSet:         shared.ToDaemonSetRef(daemonSet),
+		DesiredReplicas:   desired,
+		AvailableReplicas: available,
+		RolloutInProgress: status.ObservedGeneration < daemonSet.Generation || status.UpdatedNumberScheduled < desired,
+		Status:            shared.ToHealthStatus(desired, available, false),
+	}
+}
@@ -0,0 +1,84 @@
+package daemonsethealth
+
+import (
+	"github.com/google/go-cmp/cmp"
+	appsv1 "k8s.io/api/apps/v1"
+	"karto/testutils"
+	"karto/types"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	type args struct {
+		daemonSet *appsv1.DaemonSet
+	}
+	daemonSetRef := types.DaemonSetRef{Name: "ds", Namespace: "ns"}
+	tests := []struct {
+		name                    string
+		args                    args
+		expectedDaemonSetHealth *types.DaemonSetHealth
+	}{
+		{
+			name: "daemonSet with all scheduled pods available is healthy",
+			args: args{
+				daemonSet: testutils.NewDaemonSetBuilder().WithName("ds").WithNamespace("ns").
+					WithScheduledStatus(3, 3, 3).Build(),
+			},
+			expectedDaemonSetHealth: &types.DaemonSetHealth{
+				DaemonSet:         daemonSetRef,
+				DesiredReplicas:   3,
+				AvailableReplicas: 3,
+				Status:            types.HealthStatusHealthy,
+			},
+		},
+		{
+			name: "daemonSet with some scheduled pods unavailable is degraded",
+			args: args{
+				daemonSet: testutils.NewDaemonSetBuilder().WithName("ds").WithNamespace("ns").
+					WithScheduledStatus(3, 3, 1).Build(),
+			},
+			expectedDaemonSetHealth: &types.DaemonSetHealth{
+				DaemonSet:         daemonSetRef,
+				DesiredReplicas:   3,
+				AvailableReplicas: 1,
+				Status:            types.HealthStatusDegraded,
+			},
+		},
+		{
+			name: "daemonSet without any available pod is down",
+			args: args{
+				daemonSet: testutils.NewDaemonSetBuilder().WithName("ds").WithNamespace("ns").
+					WithScheduledStatus(3, 3, 0).Build(),
+			},
+			expectedDaemonSetHealth: &types.DaemonSetHealth{
+				DaemonSet:         daemonSetRef,
+				DesiredReplicas:   3,
+				AvailableReplicas: 0,
+				Status:            types.HealthStatusDown,
+			},
+		},
+		{
+			name: "daemonSet with pods not yet updated has a rollout in progress",
+			args: args{
+				daemonSet: testutils.NewDaemonSetBuilder().WithName("ds").WithNamespace("ns").
+					WithScheduledStatus(3, 1, 3).Build(),
+			},
+			expectedDaemonSetHealth: &types.DaemonSetHealth{
+				DaemonSet:         daemonSetRef,
+				DesiredReplicas:   3,
+				AvailableReplicas: 3,
+				RolloutInProgress: true,
+				Status:            types.HealthStatusHealthy,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := NewAnalyzer()
+			daemonSetHealth := analyzer.Analyze(tt.args.daemonSet)
+			if diff := cmp.Diff(tt.expectedDaemonSetHealth, daemonSetHealth); diff != "" {
+				t.Errorf("Analyze() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package deploymenthealth
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"karto/analyzer/shared"
+	"karto/types"
+)
+
+const progressDeadlineExceededReason = "ProgressDeadlineExceeded"
+
+type Analyzer interface {
+	Analyze(deployment *appsv1.Deployment) *types.DeploymentHealth
+}
+
+type analyzerImpl struct{}
+
+func NewAnalyzer() Analyzer {
+	return analyzerImpl{}
+}
+
+func (analyzer analyzerImpl) Analyze(deployment *appsv1.Deployment) *types.DeploymentHealth {
+	desired := analyzer.desiredReplicas(deployment)
+	available := deployment.Status.AvailableReplicas
+	isStuck := analyzer.isRolloutStuck(deployment)
+	return &types.DeploymentHealth{
+		Deployment:        shared.ToDeploymentRef(deployment),
+		DesiredReplicas:   desired,
+		AvailableReplicas: available,
+		RolloutInProgress: analyzer.isRolloutInProgress(deployment, desired),
+		RolloutStuck:      isStuck,
+		Status:            shared.ToHealthStatus(desired, available, isStuck),
+	}
+}
+
+func (analyzer analyzerImpl) desiredReplicas(deployment *appsv1.Deployment) int32 {
+	if deployment.Spec.Replicas == nil {
+		return 1
+	}
+	return *deployment.Spec.Replicas
+}
+
+func (analyzer analyzerImpl) isRolloutInProgress(deployment *appsv1.Deployment, desired int32) bool {
+	status := deployment.Status
+	return status.ObservedGeneration < deployment.Generation ||
+		status.UpdatedReplicas < desired ||
+		status.Replicas > status.UpdatedReplicas ||
+		status.AvailableReplicas < status.UpdatedReplicas
+}
+
+func (analyzer analyzerImpl) isRolloutStuck(deployment *appsv1.Deployment) bool {
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.Status == corev1.ConditionFalse &&
+			condition.Reason == progressDeadlineExceededReason {
+			return true
+		}
+	}
+	return false
+}
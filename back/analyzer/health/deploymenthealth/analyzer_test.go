@@ -0,0 +1,112 @@
+package deploymenthealth
+
+import (
+	"github.com/google/go-cmp/cmp"
+	appsv1 "k8s.io/api/apps/v1"
+	"karto/testutils"
+	"karto/types"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	type args struct {
+		deployment *appsv1.Deployment
+	}
+	deploymentRef := types.DeploymentRef{Name: "deploy", Namespace: "ns"}
+	tests := []struct {
+		name                     string
+		args                     args
+		expectedDeploymentHealth *types.DeploymentHealth
+	}{
+		{
+			name: "deployment with all desired replicas available is healthy",
+			args: args{
+				deployment: testutils.NewDeploymentBuilder().WithName("deploy").WithNamespace("ns").
+					WithDesiredReplicas(3).WithReplicaStatus(3, 3, 3).Build(),
+			},
+			expectedDeploymentHealth: &types.DeploymentHealth{
+				Deployment:        deploymentRef,
+				DesiredReplicas:   3,
+				AvailableReplicas: 3,
+				Status:            types.HealthStatusHealthy,
+			},
+		},
+		{
+			name: "deployment with some desired replicas unavailable is degraded",
+			args: args{
+				deployment: testutils.NewDeploymentBuilder().WithName("deploy").WithNamespace("ns").
+					WithDesiredReplicas(3).WithReplicaStatus(3, 3, 1).Build(),
+			},
+			expectedDeploymentHealth: &types.DeploymentHealth{
+				Deployment:        deploymentRef,
+				DesiredReplicas:   3,
+				AvailableReplicas: 1,
+				RolloutInProgress: true,
+				Status:            types.HealthStatusDegraded,
+			},
+		},
+		{
+			name: "deployment without any available replica is down",
+			args: args{
+				deployment: testutils.NewDeploymentBuilder().WithName("deploy").WithNamespace("ns").
+					WithDesiredReplicas(2).WithReplicaStatus(2, 2, 0).Build(),
+			},
+			expectedDeploymentHealth: &types.DeploymentHealth{
+				Deployment:        deploymentRef,
+				DesiredReplicas:   2,
+				AvailableReplicas: 0,
+				RolloutInProgress: true,
+				Status:            types.HealthStatusDown,
+			},
+		},
+		{
+			name: "deployment scaled to zero is healthy",
+			args: args{
+				deployment: testutils.NewDeploymentBuilder().WithName("deploy").WithNamespace("ns").
+					WithDesiredReplicas(0).Build(),
+			},
+			expectedDeploymentHealth: &types.DeploymentHealth{
+				Deployment: deploymentRef,
+				Status:     types.HealthStatusHealthy,
+			},
+		},
+		{
+			name: "deployment with replicas not yet updated has a rollout in progress",
+			args: args{
+				deployment: testutils.NewDeploymentBuilder().WithName("deploy").WithNamespace("ns").
+					WithDesiredReplicas(2).WithReplicaStatus(3, 1, 2).Build(),
+			},
+			expectedDeploymentHealth: &types.DeploymentHealth{
+				Deployment:        deploymentRef,
+				DesiredReplicas:   2,
+				AvailableReplicas: 2,
+				RolloutInProgress: true,
+				Status:            types.HealthStatusHealthy,
+			},
+		},
+		{
+			name: "deployment which exceeded its progress deadline is stuck and degraded",
+			args: args{
+				deployment: testutils.NewDeploymentBuilder().WithName("deploy").WithNamespace("ns").
+					WithDesiredReplicas(2).WithReplicaStatus(3, 1, 2).WithProgressDeadlineExceeded().Build(),
+			},
+			expectedDeploymentHealth: &types.DeploymentHealth{
+				Deployment:        deploymentRef,
+				DesiredReplicas:   2,
+				AvailableReplicas: 2,
+				RolloutInProgress: true,
+				RolloutStuck:      true,
+				Status:            types.HealthStatusDegraded,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := NewAnalyzer()
+			deploymentHealth := analyzer.Analyze(tt.args.deployment)
+			if diff := cmp.Diff(tt.expectedDeploymentHealth, deploymentHealth); diff != "" {
+				t.Errorf("Analyze() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package servicehealth
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"karto/analyzer/shared"
+	"karto/commons"
+	"karto/types"
+)
+
+type Analyzer interface {
+	Analyze(service *corev1.Service, pods []*corev1.Pod) *types.ServiceHealth
+}
+
+type analyzerImpl struct{}
+
+func NewAnalyzer() Analyzer {
+	return analyzerImpl{}
+}
+
+func (analyzer analyzerImpl) Analyze(service *corev1.Service, pods []*corev1.Pod) *types.ServiceHealth {
+	if service.Spec.Selector == nil {
+		return &types.ServiceHealth{
+			Service: shared.ToServiceRef(service),
+			Status:  types.HealthStatusHealthy,
+		}
+	}
+	endpoints := commons.Filter(pods, func(pod *corev1.Pod) bool {
+		return pod.Namespace == service.Namespace &&
+			shared.SelectorMatches(pod.Labels, *metav1.SetAsLabelSelector(service.Spec.Selector))
+	})
+	availableEndpoints := commons.Filter(endpoints, shared.IsPodReady)
+	status := types.HealthStatusDown
+	if len(endpoints) != 0 {
+		status = shared.ToHealthStatus(int32(len(endpoints)), int32(len(availableEndpoints)), false)
+	}
+	return &types.ServiceHealth{
+		Service:            shared.ToServiceRef(service),
+		Endpoints:          int32(len(endpoints)),
+		EndpointsAvailable: int32(len(availableEndpoints)),
+		Status:             status,
+	}
+}
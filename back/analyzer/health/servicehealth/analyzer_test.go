@@ -0,0 +1,104 @@
+package servicehealth
+
+import (
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"karto/testutils"
+	"karto/types"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	type args struct {
+		service *corev1.Service
+		pods    []*corev1.Pod
+	}
+	serviceRef := types.ServiceRef{Name: "svc", Namespace: "ns"}
+	tests := []struct {
+		name                  string
+		args                  args
+		expectedServiceHealth *types.ServiceHealth
+	}{
+		{
+			name: "service with all endpoints ready is healthy",
+			args: args{
+				service: testutils.NewServiceBuilder().WithName("svc").WithNamespace("ns").
+					WithSelectorLabel("app", "foo").Build(),
+				pods: []*corev1.Pod{
+					testutils.NewPodBuilder().WithName("pod1").WithNamespace("ns").WithLabel("app", "foo").
+						WithContainerStatus(true, true, 0).Build(),
+					testutils.NewPodBuilder().WithName("pod2").WithNamespace("ns").WithLabel("app", "foo").
+						WithContainerStatus(true, true, 0).WithContainerStatus(true, true, 0).Build(),
+				},
+			},
+			expectedServiceHealth: &types.ServiceHealth{
+				Service:            serviceRef,
+				Endpoints:          2,
+				EndpointsAvailable: 2,
+				Status:             types.HealthStatusHealthy,
+			},
+		},
+		{
+			name: "service with some endpoints not ready is degraded",
+			args: args{
+				service: testutils.NewServiceBuilder().WithName("svc").WithNamespace("ns").
+					WithSelectorLabel("app", "foo").Build(),
+				pods: []*corev1.Pod{
+					testutils.NewPodBuilder().WithName("pod1").WithNamespace("ns").WithLabel("app", "foo").
+						WithContainerStatus(true, true, 0).Build(),
+					testutils.NewPodBuilder().WithName("pod2").WithNamespace("ns").WithLabel("app", "foo").
+						WithContainerStatus(true, true, 0).WithContainerStatus(true, false, 0).Build(),
+				},
+			},
+			expectedServiceHealth: &types.ServiceHealth{
+				Service:            serviceRef,
+				Endpoints:          2,
+				EndpointsAvailable: 1,
+				Status:             types.HealthStatusDegraded,
+			},
+		},
+		{
+			name: "service without any ready endpoint is down",
+			args: args{
+				service: testutils.NewServiceBuilder().WithName("svc").WithNamespace("ns").
+					WithSelectorLabel("app", "foo").Build(),
+				pods: []*corev1.Pod{
+					testutils.NewPodBuilder().WithName("pod1").WithNamespace("ns").WithLabel("app", "foo").
+						WithContainerStatus(false, false, 0).Build(),
+				},
+			},
+			expectedServiceHealth: &types.ServiceHealth{
+				Service:            serviceRef,
+				Endpoints:          1,
+				EndpointsAvailable: 0,
+				Status:             types.HealthStatusDown,
+			},
+		},
+		{
+			name: "service selecting no pod is down",
+			args: args{
+				service: testutils.NewServiceBuilder().WithName("svc").WithNamespace("ns").
+					WithSelectorLabel("app", "foo").Build(),
+				pods: []*corev1.Pod{
+					testutils.NewPodBuilder().WithName("pod1").WithNamespace("ns").WithLabel("app", "bar").
+						WithContainerStatus(true, true, 0).Build(),
+					testutils.NewPodBuilder().WithName("pod2").WithNamespace("other").WithLabel("app", "foo").
+						WithContainerStatus(true, true, 0).Build(),
+				},
+			},
+			expectedServiceHealth: &types.ServiceHealth{
+				Service: serviceRef,
+				Status:  types.HealthStatusDown,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := NewAnalyzer()
+			serviceHealth := analyzer.Analyze(tt.args.service, tt.args.pods)
+			if diff := cmp.Diff(tt.expectedServiceHealth, serviceHealth); diff != "" {
+				t.Errorf("Analyze() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
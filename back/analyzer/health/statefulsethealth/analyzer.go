@@ -0,0 +1,43 @@
+package statefulsethealth
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	"karto/analyzer/shared"
+	"karto/types"
+)
+
+type Analyzer interface {
+	Analyze(statefulSet *appsv1.StatefulSet) *types.StatefulSetHealth
+}
+
+type analyzerImpl struct{}
+
+func NewAnalyzer() Analyzer {
+	return analyzerImpl{}
+}
+
+func (analyzer analyzerImpl) Analyze(statefulSet *appsv1.StatefulSet) *types.StatefulSetHealth {
+	desired := analyzer.desiredReplicas(statefulSet)
+	available := statefulSet.Status.AvailableReplicas
+	return &types.StatefulSetHealth{
+		StatefulSet:       shared.ToStatefulSetRef(statefulSet),
+		DesiredReplicas:   desired,
+		AvailableReplicas: available,
+		RolloutInProgress: analyzer.isRolloutInProgress(statefulSet, desired),
+		Status:            shared.ToHealthStatus(desired, available, false),
+	}
+}
+
+func (analyzer analyzerImpl) desiredReplicas(statefulSet *appsv1.StatefulSet) int32 {
+	if statefulSet.Spec.Replicas == nil {
+		return 1
+	}
+	return *statefulSet.Spec.Replicas
+}
+
+func (analyzer analyzerImpl) isRolloutInProgress(statefulSet *appsv1.StatefulSet, desired int32) bool {
+	status := statefulSet.Status
+	return status.ObservedGeneration < statefulSet.Generation ||
+		status.UpdatedReplicas < desired ||
+		status.CurrentRevision != status.UpdateRevision
+}
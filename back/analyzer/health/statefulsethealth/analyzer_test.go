@@ -0,0 +1,84 @@
+package statefulsethealth
+
+import (
+	"github.com/google/go-cmp/cmp"
+	appsv1 "k8s.io/api/apps/v1"
+	"karto/testutils"
+	"karto/types"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	type args struct {
+		statefulSet *appsv1.StatefulSet
+	}
+	statefulSetRef := types.StatefulSetRef{Name: "ss", Namespace: "ns"}
+	tests := []struct {
+		name                      string
+		args                      args
+		expectedStatefulSetHealth *types.StatefulSetHealth
+	}{
+		{
+			name: "statefulSet with all desired replicas available is healthy",
+			args: args{
+				statefulSet: testutils.NewStatefulSetBuilder().WithName("ss").WithNamespace("ns").
+					WithDesiredReplicas(2).WithReplicaStatus(2, 2).Build(),
+			},
+			expectedStatefulSetHealth: &types.StatefulSetHealth{
+				StatefulSet:       statefulSetRef,
+				DesiredReplicas:   2,
+				AvailableReplicas: 2,
+				Status:            types.HealthStatusHealthy,
+			},
+		},
+		{
+			name: "statefulSet with some desired replicas unavailable is degraded",
+			args: args{
+				statefulSet: testutils.NewStatefulSetBuilder().WithName("ss").WithNamespace("ns").
+					WithDesiredReplicas(3).WithReplicaStatus(3, 2).Build(),
+			},
+			expectedStatefulSetHealth: &types.StatefulSetHealth{
+				StatefulSet:       statefulSetRef,
+				DesiredReplicas:   3,
+				AvailableReplicas: 2,
+				Status:            types.HealthStatusDegraded,
+			},
+		},
+		{
+			name: "statefulSet without any available replica is down",
+			args: args{
+				statefulSet: testutils.NewStatefulSetBuilder().WithName("ss").WithNamespace("ns").
+					WithDesiredReplicas(3).WithReplicaStatus(3, 0).Build(),
+			},
+			expectedStatefulSetHealth: &types.StatefulSetHealth{
+				StatefulSet:       statefulSetRef,
+				DesiredReplicas:   3,
+				AvailableReplicas: 0,
+				Status:            types.HealthStatusDown,
+			},
+		},
+		{
+			name: "statefulSet with different current and update revisions has a rollout in progress",
+			args: args{
+				statefulSet: testutils.NewStatefulSetBuilder().WithName("ss").WithNamespace("ns").
+					WithDesiredReplicas(2).WithReplicaStatus(2, 2).WithRevisions("rev1", "rev2").Build(),
+			},
+			expectedStatefulSetHealth: &types.StatefulSetHealth{
+				StatefulSet:       statefulSetRef,
+				DesiredReplicas:   2,
+				AvailableReplicas: 2,
+				RolloutInProgress: true,
+				Status:            types.HealthStatusHealthy,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := NewAnalyzer()
+			statefulSetHealth := analyzer.Analyze(tt.args.statefulSet)
+			if diff := cmp.Diff(tt.expectedStatefulSetHealth, statefulSetHealth); diff != "" {
+				t.Errorf("Analyze() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package warnings
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"karto/analyzer/shared"
+	"karto/types"
+	"sort"
+	"time"
+)
+
+const maxWarningsPerObject = 5
+
+type Analyzer interface {
+	Analyze(events []*corev1.Event) []*types.ObjectWarnings
+}
+
+type analyzerImpl struct{}
+
+func NewAnalyzer() Analyzer {
+	return analyzerImpl{}
+}
+
+func (analyzer analyzerImpl) Analyze(events []*corev1.Event) []*types.ObjectWarnings {
+	warningsByObject := map[types.ObjectRef]map[string]*types.Warning{}
+	for _, event := range events {
+		if event.Type != corev1.EventTypeWarning {
+			continue
+		}
+		objectRef := analyzer.toObjectRef(event.InvolvedObject)
+		warningsByReason := warningsByObject[objectRef]
+		if warningsByReason == nil {
+			warningsByReason = map[string]*types.Warning{}
+			warningsByObject[objectRef] = warningsByReason
+		}
+		analyzer.mergeWarning(warningsByReason, event)
+	}
+	result := make([]*types.ObjectWarnings, 0, len(warningsByObject))
+	for objectRef, warningsByReason := range warningsByObject {
+		result = append(result, &types.ObjectWarnings{
+			Object:   objectRef,
+			Warnings: analyzer.latestWarnings(warningsByReason),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return analyzer.objectRefLess(result[i].Object, result[j].Object)
+	})
+	return result
+}
+
+func (analyzer analyzerImpl) mergeWarning(warningsByReason map[string]*types.Warning, event *corev1.Event) {
+	firstTimestamp, lastTimestamp := analyzer.timestamps(event)
+	count := analyzer.count(event)
+	warning := warningsByReason[event.Reason]
+	if warning == nil {
+		warningsByReason[event.Reason] = &types.Warning{
+			Reason:         event.Reason,
+			Message:        event.Message,
+			Count:          count,
+			FirstTimestamp: firstTimestamp,
+			LastTimestamp:  lastTimestamp,
+		}
+		return
+	}
+	warning.Count += count
+	if firstTimestamp.Before(warning.FirstTimestamp) {
+		warning.FirstTimestamp = firstTimestamp
+	}
+	if lastTimestamp.After(warning.LastTimestamp) {
+		warning.LastTimestamp = lastTimestamp
+		warning.Message = event.Message
+	}
+}
+
+func (analyzer analyzerImpl) latestWarnings(warningsByReason map[string]*types.Warning) []types.Warning {
+	warnings := make([]types.Warning, 0, len(warningsByReason))
+	for _, warning := range warningsByReason {
+		warnings = append(warnings, *warning)
+	}
+	sort.Slice(warnings, func(i, j int) bool {
+		if warnings[i].LastTimestamp.Equal(warnings[j].LastTimestamp) {
+			return warnings[i].Reason < warnings[j].Reason
+		}
+		return warnings[i].LastTimestamp.After(warnings[j].LastTimestamp)
+	})
+	if len(warnings) > maxWarningsPerObject {
+		warnings = warnings[:maxWarningsPerObject]
+	}
+	return warnings
+}
+
+func (analyzer analyzerImpl) timestamps(event *corev1.Event) (time.Time, time.Time) {
+	lastTimestamp := shared.EventLastSeen(event)
+	firstTimestamp := event.FirstTimestamp.Time
+	if firstTimestamp.IsZero() {
+		firstTimestamp = event.EventTime.Time
+	}
+	if firstTimestamp.IsZero() {
+		firstTimestamp = lastTimestamp
+	}
+	return firstTimestamp.UTC(), lastTimestamp.UTC()
+}
+
+func (analyzer analyzerImpl) count(event *corev1.Event) int32 {
+	if event.Series != nil && event.Series.Count > 0 {
+		return event.Series.Count
+	}
+	if event.Count > 0 {
+		return event.Count
+	}
+	return 1
+}
+
+func (analyzer analyzerImpl) toObjectRef(involvedObject corev1.ObjectReference) types.ObjectRef {
+	return types.ObjectRef{
+		Kind:      involvedObject.Kind,
+		Name:      involvedObject.Name,
+		Namespace: involvedObject.Namespace,
+	}
+}
+
+func (analyzer analyzerImpl) objectRefLess(objectRef1 types.ObjectRef, objectRef2 types.ObjectRef) bool {
+	if objectRef1.Kind != objectRef2.Kind {
+		return objectRef1.Kind < objectRef2.Kind
+	}
+	if objectRef1.Namespace != objectRef2.Namespace {
+		return objectRef1.Namespace < objectRef2.Namespace
+	}
+	return objectRef1.Name < objectRef2.Name
+}
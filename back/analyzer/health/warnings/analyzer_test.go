@@ -0,0 +1,127 @@
+package warnings
+
+import (
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"karto/testutils"
+	"karto/types"
+	"testing"
+	"time"
+)
+
+func TestAnalyze(t *testing.T) {
+	type args struct {
+		events []*corev1.Event
+	}
+	t1 := time.Date(2022, 5, 1, 10, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Minute)
+	t3 := t1.Add(2 * time.Minute)
+	podRef := types.ObjectRef{Kind: "Pod", Name: "pod1", Namespace: "ns"}
+	tests := []struct {
+		name                   string
+		args                   args
+		expectedObjectWarnings []*types.ObjectWarnings
+	}{
+		{
+			name: "warning events are attached to the object they involve",
+			args: args{
+				events: []*corev1.Event{
+					testutils.NewEventBuilder().WithInvolvedObject("Pod", "ns", "pod1").
+						WithReason("BackOff").WithMessage("Back-off restarting failed container").
+						WithCount(4).WithTimestamps(t1, t2).Build(),
+					testutils.NewEventBuilder().WithInvolvedObject("Node", "", "node1").
+						WithReason("NodeNotReady").WithMessage("Node is not ready").
+						WithTimestamps(t1, t1).Build(),
+				},
+			},
+			expectedObjectWarnings: []*types.ObjectWarnings{
+				{
+					Object: types.ObjectRef{Kind: "Node", Name: "node1"},
+					Warnings: []types.Warning{
+						{Reason: "NodeNotReady", Message: "Node is not ready", Count: 1,
+							FirstTimestamp: t1, LastTimestamp: t1},
+					},
+				},
+				{
+					Object: podRef,
+					Warnings: []types.Warning{
+						{Reason: "BackOff", Message: "Back-off restarting failed container", Count: 4,
+							FirstTimestamp: t1, LastTimestamp: t2},
+					},
+				},
+			},
+		},
+		{
+			name: "normal events are ignored",
+			args: args{
+				events: []*corev1.Event{
+					testutils.NewEventBuilder().WithInvolvedObject("Pod", "ns", "pod1").
+						WithType(corev1.EventTypeNormal).WithReason("Pulled").WithTimestamps(t1, t1).Build(),
+				},
+			},
+			expectedObjectWarnings: []*types.ObjectWarnings{},
+		},
+		{
+			name: "warnings with the same reason are merged, keeping the latest message",
+			args: args{
+				events: []*corev1.Event{
+					testutils.NewEventBuilder().WithInvolvedObject("Pod", "ns", "pod1").
+						WithReason("Unhealthy").WithMessage("Readiness probe failed: 500").
+						WithCount(2).WithTimestamps(t2, t3).Build(),
+					testutils.NewEventBuilder().WithInvolvedObject("Pod", "ns", "pod1").
+						WithReason("Unhealthy").WithMessage("Liveness probe failed: timeout").
+						WithCount(3).WithTimestamps(t1, t2).Build(),
+				},
+			},
+			expectedObjectWarnings: []*types.ObjectWarnings{
+				{
+					Object: podRef,
+					Warnings: []types.Warning{
+						{Reason: "Unhealthy", Message: "Readiness probe failed: 500", Count: 5,
+							FirstTimestamp: t1, LastTimestamp: t3},
+					},
+				},
+			},
+		},
+		{
+			name: "warnings are sorted from the most recent and limited per object",
+			args: args{
+				events: []*corev1.Event{
+					testutils.NewEventBuilder().WithInvolvedObject("Pod", "ns", "pod1").
+						WithReason("FailedMount").WithTimestamps(t1, t1).Build(),
+					testutils.NewEventBuilder().WithInvolvedObject("Pod", "ns", "pod1").
+						WithReason("FailedScheduling").WithTimestamps(t1, t3).Build(),
+					testutils.NewEventBuilder().WithInvolvedObject("Pod", "ns", "pod1").
+						WithReason("BackOff").WithTimestamps(t1, t2).Build(),
+					testutils.NewEventBuilder().WithInvolvedObject("Pod", "ns", "pod1").
+						WithReason("Failed").WithTimestamps(t1, t1).Build(),
+					testutils.NewEventBuilder().WithInvolvedObject("Pod", "ns", "pod1").
+						WithReason("Unhealthy").WithTimestamps(t1, t1).Build(),
+					testutils.NewEventBuilder().WithInvolvedObject("Pod", "ns", "pod1").
+						WithReason("Evicted").WithTimestamps(t1, t1).Build(),
+				},
+			},
+			expectedObjectWarnings: []*types.ObjectWarnings{
+				{
+					Object: podRef,
+					Warnings: []types.Warning{
+						{Reason: "FailedScheduling", Count: 1, FirstTimestamp: t1, LastTimestamp: t3},
+						{Reason: "BackOff", Count: 1, FirstTimestamp: t1, LastTimestamp: t2},
+						{Reason: "Evicted", Count: 1, FirstTimestamp: t1, LastTimestamp: t1},
+						{Reason: "Failed", Count: 1, FirstTimestamp: t1, LastTimestamp: t1},
+						{Reason: "FailedMount", Count: 1, FirstTimestamp: t1, LastTimestamp: t1},
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := NewAnalyzer()
+			objectWarnings := analyzer.Analyze(tt.args.events)
+			if diff := cmp.Diff(tt.expectedObjectWarnings, objectWarnings); diff != "" {
+				t.Errorf("Analyze() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
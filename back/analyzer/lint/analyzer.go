@@ -0,0 +1,368 @@
+package lint
+
+import (
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"karto/analyzer/shared"
+	"karto/commons"
+	"karto/types"
+	"sort"
+)
+
+const (
+	PolicySelectsNoPod           = "NP001"
+	PeerSelectsNothing           = "NP002"
+	ShadowedRule                 = "NP003"
+	DuplicatePolicy              = "NP004"
+	PortNotListened              = "NP005"
+	NoIngressDefaultDeny         = "NP006"
+	NoEgressDefaultDeny          = "NP007"
+	directionIngress             = "ingress"
+	directionEgress              = "egress"
+	networkPolicyKind            = "NetworkPolicy"
+	namespaceKind                = "Namespace"
+	defaultNetworkPolicyProtocol = corev1.ProtocolTCP
+)
+
+type ClusterState struct {
+	Namespaces      []*corev1.Namespace
+	Pods            []*corev1.Pod
+	NetworkPolicies []*networkingv1.NetworkPolicy
+}
+
+type AnalysisResult struct {
+	Findings []*types.Finding
+}
+
+type Analyzer interface {
+	Analyze(clusterState ClusterState) AnalysisResult
+}
+
+type analyzerImpl struct{}
+
+func NewAnalyzer() Analyzer {
+	return analyzerImpl{}
+}
+
+type policyRule struct {
+	policy    *networkingv1.NetworkPolicy
+	direction string
+	index     int
+	peers     []networkingv1.NetworkPolicyPeer
+	ports     []networkingv1.NetworkPolicyPort
+}
+
+func (rule policyRule) String() string {
+	return fmt.Sprintf("%s rule #%d of policy %s/%s", rule.direction, rule.index+1, rule.policy.Namespace,
+		rule.policy.Name)
+}
+
+func (analyzer analyzerImpl) Analyze(clusterState ClusterState) AnalysisResult {
+	policies := make([]*networkingv1.NetworkPolicy, len(clusterState.NetworkPolicies))
+	copy(policies, clusterState.NetworkPolicies)
+	sort.Slice(policies, func(i, j int) bool {
+		if policies[i].Namespace != policies[j].Namespace {
+			return policies[i].Namespace < policies[j].Namespace
+		}
+		return policies[i].Name < policies[j].Name
+	})
+	selectedPods := map[*networkingv1.NetworkPolicy][]*corev1.Pod{}
+	for _, policy := range policies {
+		selectedPods[policy] = commons.Filter(clusterState.Pods, func(pod *corev1.Pod) bool {
+			return shared.PolicySelectsPod(policy, pod)
+		})
+	}
+	duplicates := analyzer.duplicatePolicies(policies)
+	findings := make([]*types.Finding, 0)
+	findings = append(findings, analyzer.policiesSelectingNoPod(policies, selectedPods)...)
+	findings = append(findings, analyzer.peersSelectingNothing(policies, clusterState)...)
+	findings = append(findings, analyzer.shadowedRules(policies, selectedPods, duplicates, clusterState)...)
+	findings = append(findings, analyzer.duplicatePolicyFindings(policies, duplicates)...)
+	findings = append(findings, analyzer.portsNotListened(policies, selectedPods)...)
+	findings = append(findings, analyzer.namespacesWithoutDefaultDeny(clusterState.Namespaces, policies)...)
+	return AnalysisResult{
+		Findings: findings,
+	}
+}
+
+func (analyzer analyzerImpl) policiesSelectingNoPod(
+	policies []*networkingv1.NetworkPolicy,
+	selectedPods map[*networkingv1.NetworkPolicy][]*corev1.Pod,
+) []*types.Finding {
+	findings := make([]*types.Finding, 0)
+	for _, policy := range policies {
+		if len(selectedPods[policy]) == 0 {
+			findings = append(findings, &types.Finding{
+				ID:       PolicySelectsNoPod,
+				Severity: types.FindingSeverityWarning,
+				Objects:  []types.ObjectRef{shared.ToObjectRef(networkPolicyKind, policy)},
+				Message:  "network policy selects no pod",
+			})
+		}
+	}
+	return findings
+}
+
+func (analyzer analyzerImpl) peersSelectingNothing(
+	policies []*networkingv1.NetworkPolicy,
+	clusterState ClusterState,
+) []*types.Finding {
+	findings := make([]*types.Finding, 0)
+	for _, policy := range policies {
+		for _, rule := range analyzer.rulesOf(policy) {
+			for peerIndex, peer := range rule.peers {
+				if peer.IPBlock != nil {
+					continue
+				}
+				var message string
+				if peer.PodSelector == nil {
+					if !commons.AnyMatch(clusterState.Namespaces, func(namespace *corev1.Namespace) bool {
+						return shared.PeerSelectsNamespace(peer, namespace)
+					}) {
+						message = "selects no namespace"
+					}
+				} else if !commons.AnyMatch(clusterState.Pods, func(pod *corev1.Pod) bool {
+					return shared.PeerSelectsPod(policy.Namespace, peer, pod, clusterState.Namespaces)
+				}) {
+					message = "selects no pod"
+				}
+				if message != "" {
+					findings = append(findings, &types.Finding{
+						ID:       PeerSelectsNothing,
+						Severity: types.FindingSeverityWarning,
+						Objects:  []types.ObjectRef{shared.ToObjectRef(networkPolicyKind, policy)},
+						Message: fmt.Sprintf("peer #%d of %s rule #%d %s", peerIndex+1, rule.direction,
+							rule.index+1, message),
+					})
+				}
+			}
+		}
+	}
+	return findings
+}
+
+func (analyzer analyzerImpl) shadowedRules(
+	policies []*networkingv1.NetworkPolicy,
+	selectedPods map[*networkingv1.NetworkPolicy][]*corev1.Pod,
+	duplicates map[*networkingv1.NetworkPolicy]*networkingv1.NetworkPolicy,
+	clusterState ClusterState,
+) []*types.Finding {
+	rules := make([]policyRule, 0)
+	for _, policy := range policies {
+		rules = append(rules, analyzer.rulesOf(policy)...)
+	}
+	peerPods := make([]map[*corev1.Pod]bool, len(rules))
+	for i, rule := range rules {
+		peerPods[i] = analyzer.podsSelectedByPeers(rule, clusterState)
+	}
+	findings := make([]*types.Finding, 0)
+	for i, rule := range rules {
+		if len(selectedPods[rule.policy]) == 0 {
+			continue
+		}
+		for j, broaderRule := range rules {
+			if i == j || rule.direction != broaderRule.direction ||
+				rule.policy.Namespace != broaderRule.policy.Namespace ||
+				(rule.policy != broaderRule.policy && duplicates[rule.policy] == broaderRule.policy) ||
+				!analyzer.podsInclude(selectedPods[broaderRule.policy], selectedPods[rule.policy]) ||
+				!analyzer.ruleCovers(broaderRule, peerPods[j], rule, peerPods[i]) {
+				continue
+			}
+			isEquivalent := analyzer.podsInclude(selectedPods[rule.policy], selectedPods[broaderRule.policy]) &&
+				analyzer.ruleCovers(rule, peerPods[i], broaderRule, peerPods[j])
+			if isEquivalent && j > i {
+				continue
+			}
+			findings = append(findings, &types.Finding{
+				ID:       ShadowedRule,
+				Severity: types.FindingSeverityInfo,
+				Objects: []types.ObjectRef{
+					shared.ToObjectRef(networkPolicyKind, rule.policy),
+					shared.ToObjectRef(networkPolicyKind, broaderRule.policy),
+				},
+				Message: fmt.Sprintf("%s is shadowed by %s", rule, broaderRule),
+			})
+			break
+		}
+	}
+	return findings
+}
+
+func (analyzer analyzerImpl) ruleCovers(
+	broaderRule policyRule,
+	broaderPeerPods map[*corev1.Pod]bool,
+	rule policyRule,
+	rulePeerPods map[*corev1.Pod]bool,
+) bool {
+	if !analyzer.portsCover(broaderRule.ports, rule.ports) {
+		return false
+	}
+	if len(broaderRule.peers) == 0 {
+		return true
+	}
+	if len(rule.peers) == 0 || len(rulePeerPods) == 0 {
+		return false
+	}
+	for _, peer := range rule.peers {
+		if peer.IPBlock != nil {
+			return false
+		}
+	}
+	for pod := range rulePeerPods {
+		if !broaderPeerPods[pod] {
+			return false
+		}
+	}
+	return true
+}
+
+func (analyzer analyzerImpl) podsSelectedByPeers(rule policyRule, clusterState ClusterState) map[*corev1.Pod]bool {
+	result := map[*corev1.Pod]bool{}
+	for _, pod := range clusterState.Pods {
+		for _, peer := range rule.peers {
+			if shared.PeerSelectsPod(rule.policy.Namespace, peer, pod, clusterState.Namespaces) {
+				result[pod] = true
+				break
+			}
+		}
+	}
+	return result
+}
+
+func (analyzer analyzerImpl) podsInclude(pods []*corev1.Pod, includedPods []*corev1.Pod) bool {
+	for _, includedPod := range includedPods {
+		if !commons.AnyMatch(pods, func(pod *corev1.Pod) bool { return pod == includedPod }) {
+			return false
+		}
+	}
+	return true
+}
+
+func (analyzer analyzerImpl) duplicatePolicies(
+	policies []*networkingv1.NetworkPolicy,
+) map[*networkingv1.NetworkPolicy]*networkingv1.NetworkPolicy {
+	duplicates := map[*networkingv1.NetworkPolicy]*networkingv1.NetworkPolicy{}
+	for i, policy := range policies {
+		for _, original := range policies[:i] {
+			if original.Namespace == policy.Namespace && equality.Semantic.DeepEqual(original.Spec, policy.Spec) {
+				duplicates[policy] = original
+				break
+			}
+		}
+	}
+	return duplicates
+}
+
+func (analyzer analyzerImpl) duplicatePolicyFindings(
+	policies []*networkingv1.NetworkPolicy,
+	duplicates map[*networkingv1.NetworkPolicy]*networkingv1.NetworkPolicy,
+) []*types.Finding {
+	findings := make([]*types.Finding, 0)
+	for _, policy := range policies {
+		original, isDuplicate := duplicates[policy]
+		if !isDuplicate {
+			continue
+		}
+		findings = append(findings, &types.Finding{
+			ID:       DuplicatePolicy,
+			Severity: types.FindingSeverityWarning,
+			Objects: []types.ObjectRef{
+				shared.ToObjectRef(networkPolicyKind, policy),
+				shared.ToObjectRef(networkPolicyKind, original),
+			},
+			Message: fmt.Sprintf("network policy is a duplicate of %s/%s", original.Namespace, original.Name),
+		})
+	}
+	return findings
+}
+
+func (analyzer analyzerImpl) portsNotListened(
+	policies []*networkingv1.NetworkPolicy,
+	selectedPods map[*networkingv1.NetworkPolicy][]*corev1.Pod,
+) []*types.Finding {
+	findings := make([]*types.Finding, 0)
+	for _, policy := range policies {
+		containerPorts := make([]corev1.ContainerPort, 0)
+		for _, pod := range selectedPods[policy] {
+			for _, container := range pod.Spec.Containers {
+				containerPorts = append(containerPorts, container.Ports...)
+			}
+		}
+		if len(containerPorts) == 0 {
+			continue
+		}
+		for ruleIndex, ingressRule := range policy.Spec.Ingress {
+			for _, port := range ingressRule.Ports {
+				if port.Port == nil || commons.AnyMatch(containerPorts, func(containerPort corev1.ContainerPort) bool {
+					return analyzer.portMatchesContainerPort(port, containerPort)
+				}) {
+					continue
+				}
+				findings = append(findings, &types.Finding{
+					ID:       PortNotListened,
+					Severity: types.FindingSeverityInfo,
+					Objects:  []types.ObjectRef{shared.ToObjectRef(networkPolicyKind, policy)},
+					Message: fmt.Sprintf("%s rule #%d allows port %s/%s on which no selected container listens",
+						directionIngress, ruleIndex+1, port.Port.String(), analyzer.protocol(port)),
+				})
+			}
+		}
+	}
+	return findings
+}
+
+func (analyzer analyzerImpl) namespacesWithoutDefaultDeny(
+	namespaces []*corev1.Namespace,
+	policies []*networkingv1.NetworkPolicy,
+) []*types.Finding {
+	findings := make([]*types.Finding, 0)
+	for _, namespace := range namespaces {
+		var hasIngressDefaultDeny, hasEgressDefaultDeny bool
+		for _, policy := range policies {
+			if policy.Namespace != namespace.Name {
+				continue
+			}
+			isIngressDefaultDeny, isEgressDefaultDeny := shared.DefaultDenyTypes(policy)
+			hasIngressDefaultDeny = hasIngressDefaultDeny || isIngressDefaultDeny
+			hasEgressDefaultDeny = hasEgressDefaultDeny || isEgressDefaultDeny
+		}
+		namespaceRef := types.ObjectRef{Kind: namespaceKind, Name: namespace.Name}
+		if !hasIngressDefaultDeny {
+			findings = append(findings, &types.Finding{
+				ID:       NoIngressDefaultDeny,
+				Severity: types.FindingSeverityWarning,
+				Objects:  []types.ObjectRef{namespaceRef},
+				Message:  "namespace has no default-deny ingress policy",
+			})
+		}
+		if !hasEgressDefaultDeny {
+			findings = append(findings, &types.Finding{
+				ID:       NoEgressDefaultDeny,
+				Severity: types.FindingSeverityInfo,
+				Objects:  []types.ObjectRef{namespaceRef},
+				Message:  "namespace has no default-deny egress policy",
+			})
+		}
+	}
+	return findings
+}
+
+func (analyzer analyzerImpl) rulesOf(policy *networkingv1.NetworkPolicy) []policyRule {
+	isIngress, isEgress := shared.PolicyTypes(policy)
+	rules := make([]policyRule, 0)
+	if isIngress {
+		for i, ingressRule := range policy.Spec.Ingress {
+			rules = append(rules, policyRule{policy: policy, direction: directionIngress, index: i,
+				peers: ingressRule.From, ports: ingressRule.Ports})
+		}
+	}
+	if isEgress {
+		for i, egressRule := range policy.Spec.Egress {
+			rules = append(rules, policyRule{policy: policy, direction: directionEgress, index: i,
+				peers: egressRule.To, ports: egressRule.Ports})
+		}
+	}
+	return rules
+}
@@ -0,0 +1,278 @@
+package lint
+
+import (
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"karto/testutils"
+	"karto/types"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	type args struct {
+		clusterState ClusterState
+	}
+	port80 := intstr.FromInt(80)
+	portHttp := intstr.FromString("http")
+	tests := []struct {
+		name           string
+		args           args
+		expectedResult AnalysisResult
+	}{
+		{
+			name: "reports a network policy selecting no pod",
+			args: args{
+				clusterState: ClusterState{
+					Namespaces: []*corev1.Namespace{},
+					Pods: []*corev1.Pod{
+						testutils.NewPodBuilder().WithName("pod").WithLabel("app", "bar").Build(),
+					},
+					NetworkPolicies: []*networkingv1.NetworkPolicy{
+						testutils.NewNetworkPolicyBuilder().WithName("pol").WithTypes("Ingress").
+							WithPodSelector(testutils.NewLabelSelectorBuilder().WithMatchLabel("app", "foo").
+								Build()).Build(),
+					},
+				},
+			},
+			expectedResult: AnalysisResult{
+				Findings: []*types.Finding{
+					{
+						ID:       PolicySelectsNoPod,
+						Severity: types.FindingSeverityWarning,
+						Objects:  []types.ObjectRef{{Kind: "NetworkPolicy", Name: "pol", Namespace: "default"}},
+						Message:  "network policy selects no pod",
+					},
+				},
+			},
+		},
+		{
+			name: "reports peers selecting no pod or no namespace",
+			args: args{
+				clusterState: ClusterState{
+					Namespaces: []*corev1.Namespace{},
+					Pods: []*corev1.Pod{
+						testutils.NewPodBuilder().WithName("pod").WithLabel("app", "foo").Build(),
+					},
+					NetworkPolicies: []*networkingv1.NetworkPolicy{
+						testutils.NewNetworkPolicyBuilder().WithName("pol").WithTypes("Ingress").
+							WithPodSelector(testutils.NewLabelSelectorBuilder().WithMatchLabel("app", "foo").
+								Build()).
+							WithIngressRule(networkingv1.NetworkPolicyIngressRule{
+								From: []networkingv1.NetworkPolicyPeer{
+									{
+										PodSelector: testutils.NewLabelSelectorBuilder().
+											WithMatchLabel("app", "missing").Build(),
+									},
+									{
+										NamespaceSelector: testutils.NewLabelSelectorBuilder().
+											WithMatchLabel("team", "missing").Build(),
+									},
+								},
+							}).Build(),
+					},
+				},
+			},
+			expectedResult: AnalysisResult{
+				Findings: []*types.Finding{
+					{
+						ID:       PeerSelectsNothing,
+						Severity: types.FindingSeverityWarning,
+						Objects:  []types.ObjectRef{{Kind: "NetworkPolicy", Name: "pol", Namespace: "default"}},
+						Message:  "peer #1 of ingress rule #1 selects no pod",
+					},
+					{
+						ID:       PeerSelectsNothing,
+						Severity: types.FindingSeverityWarning,
+						Objects:  []types.ObjectRef{{Kind: "NetworkPolicy", Name: "pol", Namespace: "default"}},
+						Message:  "peer #2 of ingress rule #1 selects no namespace",
+					},
+				},
+			},
+		},
+		{
+			name: "reports a rule shadowed by a broader rule of another policy",
+			args: args{
+				clusterState: ClusterState{
+					Namespaces: []*corev1.Namespace{},
+					Pods: []*corev1.Pod{
+						testutils.NewPodBuilder().WithName("foo").WithLabel("app", "foo").Build(),
+						testutils.NewPodBuilder().WithName("bar").WithLabel("app", "bar").Build(),
+					},
+					NetworkPolicies: []*networkingv1.NetworkPolicy{
+						testutils.NewNetworkPolicyBuilder().WithName("narrow").WithTypes("Ingress").
+							WithPodSelector(testutils.NewLabelSelectorBuilder().WithMatchLabel("app", "foo").
+								Build()).
+							WithIngressRule(networkingv1.NetworkPolicyIngressRule{
+								Ports: []networkingv1.NetworkPolicyPort{{Port: &port80}},
+								From: []networkingv1.NetworkPolicyPeer{
+									{
+										PodSelector: testutils.NewLabelSelectorBuilder().
+											WithMatchLabel("app", "bar").Build(),
+									},
+								},
+							}).Build(),
+						testutils.NewNetworkPolicyBuilder().WithName("broad").WithTypes("Ingress").
+							WithPodSelector(testutils.NewLabelSelectorBuilder().Build()).
+							WithIngressRule(networkingv1.NetworkPolicyIngressRule{
+								From: []networkingv1.NetworkPolicyPeer{
+									{PodSelector: testutils.NewLabelSelectorBuilder().Build()},
+								},
+							}).Build(),
+					},
+				},
+			},
+			expectedResult: AnalysisResult{
+				Findings: []*types.Finding{
+					{
+						ID:       ShadowedRule,
+						Severity: types.FindingSeverityInfo,
+						Objects: []types.ObjectRef{
+							{Kind: "NetworkPolicy", Name: "narrow", Namespace: "default"},
+							{Kind: "NetworkPolicy", Name: "broad", Namespace: "default"},
+						},
+						Message: "ingress rule #1 of policy default/narrow is shadowed by ingress rule #1 of " +
+							"policy default/broad",
+					},
+				},
+			},
+		},
+		{
+			name: "reports duplicate policies without reporting their rules as shadowed",
+			args: args{
+				clusterState: ClusterState{
+					Namespaces: []*corev1.Namespace{},
+					Pods: []*corev1.Pod{
+						testutils.NewPodBuilder().WithName("foo").WithLabel("app", "foo").Build(),
+						testutils.NewPodBuilder().WithName("bar").WithLabel("app", "bar").Build(),
+					},
+					NetworkPolicies: []*networkingv1.NetworkPolicy{
+						testutils.NewNetworkPolicyBuilder().WithName("pol-b").WithTypes("Ingress").
+							WithPodSelector(testutils.NewLabelSelectorBuilder().WithMatchLabel("app", "foo").
+								Build()).
+							WithIngressRule(networkingv1.NetworkPolicyIngressRule{
+								From: []networkingv1.NetworkPolicyPeer{
+									{
+										PodSelector: testutils.NewLabelSelectorBuilder().
+											WithMatchLabel("app", "bar").Build(),
+									},
+								},
+							}).Build(),
+						testutils.NewNetworkPolicyBuilder().WithName("pol-a").WithTypes("Ingress").
+							WithPodSelector(testutils.NewLabelSelectorBuilder().WithMatchLabel("app", "foo").
+								Build()).
+							WithIngressRule(networkingv1.NetworkPolicyIngressRule{
+								From: []networkingv1.NetworkPolicyPeer{
+									{
+										PodSelector: testutils.NewLabelSelectorBuilder().
+											WithMatchLabel("app", "bar").Build(),
+									},
+								},
+							}).Build(),
+					},
+				},
+			},
+			expectedResult: AnalysisResult{
+				Findings: []*types.Finding{
+					{
+						ID:       DuplicatePolicy,
+						Severity: types.FindingSeverityWarning,
+						Objects: []types.ObjectRef{
+							{Kind: "NetworkPolicy", Name: "pol-b", Namespace: "default"},
+							{Kind: "NetworkPolicy", Name: "pol-a", Namespace: "default"},
+						},
+						Message: "network policy is a duplicate of default/pol-a",
+					},
+				},
+			},
+		},
+		{
+			name: "reports allowed ports on which no selected container listens",
+			args: args{
+				clusterState: ClusterState{
+					Namespaces: []*corev1.Namespace{},
+					Pods: []*corev1.Pod{
+						testutils.NewPodBuilder().WithName("foo").WithLabel("app", "foo").
+							WithContainer(testutils.NewContainerBuilder().WithName("app").
+								WithPort("http", 8080).Build()).Build(),
+					},
+					NetworkPolicies: []*networkingv1.NetworkPolicy{
+						testutils.NewNetworkPolicyBuilder().WithName("pol").WithTypes("Ingress").
+							WithPodSelector(testutils.NewLabelSelectorBuilder().WithMatchLabel("app", "foo").
+								Build()).
+							WithIngressRule(networkingv1.NetworkPolicyIngressRule{
+								Ports: []networkingv1.NetworkPolicyPort{{Port: &port80}, {Port: &portHttp}},
+							}).Build(),
+					},
+				},
+			},
+			expectedResult: AnalysisResult{
+				Findings: []*types.Finding{
+					{
+						ID:       PortNotListened,
+						Severity: types.FindingSeverityInfo,
+						Objects:  []types.ObjectRef{{Kind: "NetworkPolicy", Name: "pol", Namespace: "default"}},
+						Message:  "ingress rule #1 allows port 80/TCP on which no selected container listens",
+					},
+				},
+			},
+		},
+		{
+			name: "reports namespaces without default-deny policies",
+			args: args{
+				clusterState: ClusterState{
+					Namespaces: []*corev1.Namespace{
+						testutils.NewNamespaceBuilder().WithName("ns1").Build(),
+						testutils.NewNamespaceBuilder().WithName("ns2").Build(),
+						testutils.NewNamespaceBuilder().WithName("ns3").Build(),
+					},
+					Pods: []*corev1.Pod{
+						testutils.NewPodBuilder().WithName("pod1").WithNamespace("ns1").Build(),
+						testutils.NewPodBuilder().WithName("pod2").WithNamespace("ns2").Build(),
+						testutils.NewPodBuilder().WithName("pod3").WithNamespace("ns3").Build(),
+					},
+					NetworkPolicies: []*networkingv1.NetworkPolicy{
+						testutils.NewNetworkPolicyBuilder().WithName("deny-all").WithNamespace("ns1").
+							WithTypes("Ingress", "Egress").
+							WithPodSelector(testutils.NewLabelSelectorBuilder().Build()).Build(),
+						testutils.NewNetworkPolicyBuilder().WithName("deny-ingress").WithNamespace("ns2").
+							WithTypes("Ingress").
+							WithPodSelector(testutils.NewLabelSelectorBuilder().Build()).Build(),
+					},
+				},
+			},
+			expectedResult: AnalysisResult{
+				Findings: []*types.Finding{
+					{
+						ID:       NoEgressDefaultDeny,
+						Severity: types.FindingSeverityInfo,
+						Objects:  []types.ObjectRef{{Kind: "Namespace", Name: "ns2"}},
+						Message:  "namespace has no default-deny egress policy",
+					},
+					{
+						ID:       NoIngressDefaultDeny,
+						Severity: types.FindingSeverityWarning,
+						Objects:  []types.ObjectRef{{Kind: "Namespace", Name: "ns3"}},
+						Message:  "namespace has no default-deny ingress policy",
+					},
+					{
+						ID:       NoEgressDefaultDeny,
+						Severity: types.FindingSeverityInfo,
+						Objects:  []types.ObjectRef{{Kind: "Namespace", Name: "ns3"}},
+						Message:  "namespace has no default-deny egress policy",
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := NewAnalyzer()
+			result := analyzer.Analyze(tt.args.clusterState)
+			if diff := cmp.Diff(tt.expectedResult, result); diff != "" {
+				t.Errorf("Analyze() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
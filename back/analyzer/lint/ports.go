@@ -0,0 +1,80 @@
+package lint
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func (analyzer analyzerImpl) portsCover(broaderPorts []networkingv1.NetworkPolicyPort,
+	ports []networkingv1.NetworkPolicyPort) bool {
+	if len(broaderPorts) == 0 {
+		return true
+	}
+	if len(ports) == 0 {
+		return false
+	}
+	for _, port := range ports {
+		isCovered := false
+		for _, broaderPort := range broaderPorts {
+			if analyzer.portCovers(broaderPort, port) {
+				isCovered = true
+				break
+			}
+		}
+		if !isCovered {
+			return false
+		}
+	}
+	return true
+}
+
+func (analyzer analyzerImpl) portCovers(broaderPort networkingv1.NetworkPolicyPort,
+	port networkingv1.NetworkPolicyPort) bool {
+	if analyzer.protocol(broaderPort) != analyzer.protocol(port) {
+		return false
+	}
+	if broaderPort.Port == nil {
+		return true
+	}
+	if port.Port == nil || broaderPort.Port.Type != port.Port.Type {
+		return false
+	}
+	if port.Port.Type == intstr.String {
+		return broaderPort.Port.StrVal == port.Port.StrVal
+	}
+	broaderStart, broaderEnd := analyzer.portRange(broaderPort)
+	start, end := analyzer.portRange(port)
+	return broaderStart <= start && end <= broaderEnd
+}
+
+func (analyzer analyzerImpl) portMatchesContainerPort(port networkingv1.NetworkPolicyPort,
+	containerPort corev1.ContainerPort) bool {
+	containerProtocol := containerPort.Protocol
+	if containerProtocol == "" {
+		containerProtocol = corev1.ProtocolTCP
+	}
+	if analyzer.protocol(port) != containerProtocol {
+		return false
+	}
+	if port.Port.Type == intstr.String {
+		return port.Port.StrVal == containerPort.Name
+	}
+	start, end := analyzer.portRange(port)
+	return start <= containerPort.ContainerPort && containerPort.ContainerPort <= end
+}
+
+func (analyzer analyzerImpl) portRange(port networkingv1.NetworkPolicyPort) (int32, int32) {
+	start := port.Port.IntVal
+	if port.EndPort != nil {
+		return start, *port.EndPort
+	}
+	return start, start
+}
+
+func (analyzer analyzerImpl) protocol(port networkingv1.NetworkPolicyPort) corev1.Protocol {
+	if port.Protocol == nil {
+		return defaultNetworkPolicyProtocol
+	}
+	return *port.Protocol
+}
@@ -0,0 +1,442 @@
+package mesh
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"karto/analyzer/shared"
+	"karto/commons"
+	"karto/crds/istio"
+	"karto/types"
+	"sort"
+	"strconv"
+)
+
+const (
+	rootNamespace           = "istio-system"
+	proxyContainerName      = "istio-proxy"
+	sidecarStatusAnnotation = "sidecar.istio.io/status"
+	injectLabel             = "sidecar.istio.io/inject"
+	injectionLabel          = "istio-injection"
+	revisionLabel           = "istio.io/rev"
+	injectionEnabled        = "enabled"
+	trustDomain             = "cluster.local"
+	defaultServiceAccount   = "default"
+)
+
+var authorizationPolicyKind = istio.AuthorizationPolicyKind + "." + istio.GroupVersion.Group
+
+type ClusterState struct {
+	Namespaces            []*corev1.Namespace
+	Pods                  []*corev1.Pod
+	AllowedRoutes         []*types.AllowedRoute
+	AuthorizationPolicies []*istio.AuthorizationPolicy
+	PeerAuthentications   []*istio.PeerAuthentication
+}
+
+type AnalysisResult struct {
+	Routes []*types.MeshRoute
+}
+
+type Analyzer interface {
+	Analyze(clusterState ClusterState) AnalysisResult
+}
+
+type analyzerImpl struct{}
+
+func NewAnalyzer() Analyzer {
+	return analyzerImpl{}
+}
+
+type request struct {
+	source      *corev1.Pod
+	hasIdentity bool
+	port        int32
+}
+
+type portDecision struct {
+	decision   types.MeshDecision
+	policies   []*istio.AuthorizationPolicy
+	conditions []string
+	reason     string
+}
+
+func (analyzer analyzerImpl) Analyze(clusterState ClusterState) AnalysisResult {
+	podsByRef := make(map[types.PodRef]*corev1.Pod, len(clusterState.Pods))
+	for _, pod := range clusterState.Pods {
+		podsByRef[types.PodRef{Name: pod.Name, Namespace: pod.Namespace}] = pod
+	}
+	authorizationPolicies := make([]*istio.AuthorizationPolicy, len(clusterState.AuthorizationPolicies))
+	copy(authorizationPolicies, clusterState.AuthorizationPolicies)
+	sort.Slice(authorizationPolicies, func(i, j int) bool {
+		if authorizationPolicies[i].Namespace != authorizationPolicies[j].Namespace {
+			return authorizationPolicies[i].Namespace < authorizationPolicies[j].Namespace
+		}
+		return authorizationPolicies[i].Name < authorizationPolicies[j].Name
+	})
+	routes := make([]*types.MeshRoute, 0)
+	for _, allowedRoute := range clusterState.AllowedRoutes {
+		source, target := podsByRef[allowedRoute.SourcePod], podsByRef[allowedRoute.TargetPod]
+		if source == nil || target == nil ||
+			!analyzer.isInMesh(target, shared.NamespaceLabels(target.Namespace, clusterState.Namespaces)) {
+			continue
+		}
+		sourceInMesh := analyzer.isInMesh(source, shared.NamespaceLabels(source.Namespace, clusterState.Namespaces))
+		routes = append(routes, analyzer.meshRouteOf(allowedRoute, source, sourceInMesh, target,
+			analyzer.policiesApplyingTo(target, authorizationPolicies), clusterState.PeerAuthentications))
+	}
+	return AnalysisResult{
+		Routes: routes,
+	}
+}
+
+func (analyzer analyzerImpl) isInMesh(pod *corev1.Pod, namespaceLabels map[string]string) bool {
+	if pod.Spec.HostNetwork {
+		return false
+	}
+	for _, containers := range [][]corev1.Container{pod.Spec.Containers, pod.Spec.InitContainers} {
+		for _, container := range containers {
+			if container.Name == proxyContainerName {
+				return true
+			}
+		}
+	}
+	if _, isInjected := pod.Annotations[sidecarStatusAnnotation]; isInjected {
+		return true
+	}
+	inject := pod.Labels[injectLabel]
+	if inject == "" {
+		inject = pod.Annotations[injectLabel]
+	}
+	if inject != "" {
+		return inject == "true"
+	}
+	return namespaceLabels[injectionLabel] == injectionEnabled || namespaceLabels[revisionLabel] != ""
+}
+
+func (analyzer analyzerImpl) policiesApplyingTo(
+	target *corev1.Pod,
+	authorizationPolicies []*istio.AuthorizationPolicy,
+) []*istio.AuthorizationPolicy {
+	return commons.Filter(authorizationPolicies, func(policy *istio.AuthorizationPolicy) bool {
+		if policy.Namespace != rootNamespace && policy.Namespace != target.Namespace {
+			return false
+		}
+		return policy.ActionName() != istio.ActionAudit && analyzer.selects(policy.Spec.Selector, target)
+	})
+}
+
+func (analyzer analyzerImpl) selects(selector *istio.WorkloadSelector, pod *corev1.Pod) bool {
+	if selector == nil {
+		return true
+	}
+	for key, value := range selector.MatchLabels {
+		if podValue, ok := pod.Labels[key]; !ok || podValue != value {
+			return false
+		}
+	}
+	return true
+}
+
+func (analyzer analyzerImpl) meshRouteOf(
+	allowedRoute *types.AllowedRoute,
+	source *corev1.Pod,
+	sourceInMesh bool,
+	target *corev1.Pod,
+	authorizationPolicies []*istio.AuthorizationPolicy,
+	peerAuthentications []*istio.PeerAuthentication,
+) *types.MeshRoute {
+	ports := analyzer.candidatePorts(allowedRoute, target, authorizationPolicies, peerAuthentications)
+	meshRoute := &types.MeshRoute{
+		SourcePod: allowedRoute.SourcePod,
+		TargetPod: allowedRoute.TargetPod,
+		Policies:  []types.NetworkPolicy{},
+		Ports:     []int32{},
+	}
+	decisions := make([]types.MeshDecision, 0, len(ports))
+	allowsOtherPorts := false
+	for i, portRange := range ports {
+		port := portRange.Start
+		mode := analyzer.mtlsMode(target, port, peerAuthentications)
+		mutualTLS := sourceInMesh && mode != istio.MTLSModeDisable
+		if i == 0 {
+			meshRoute.MTLSMode = mode
+			meshRoute.MutualTLS = mutualTLS
+		}
+		decision := analyzer.decide(request{source: source, hasIdentity: mutualTLS, port: port}, sourceInMesh, mode,
+			authorizationPolicies)
+		decisions = append(decisions, decision.decision)
+		analyzer.merge(meshRoute, decision)
+		if decision.decision == types.MeshDecisionDenied {
+			if port == shared.AnyOtherPort {
+				continue
+			} else if portRange.Start == portRange.End {
+				meshRoute.DeniedPorts = append(meshRoute.DeniedPorts, port)
+			} else {
+				meshRoute.DeniedPortRanges = append(meshRoute.DeniedPortRanges, portRange)
+			}
+		} else if port == shared.AnyOtherPort {
+			allowsOtherPorts = true
+		} else if portRange.Start == portRange.End {
+			meshRoute.Ports = append(meshRoute.Ports, port)
+		} else {
+			meshRoute.PortRanges = append(meshRoute.PortRanges, portRange)
+		}
+	}
+	if allowsOtherPorts {
+		meshRoute.Ports, meshRoute.PortRanges = nil, nil
+	}
+	meshRoute.Decision = analyzer.overallDecision(decisions)
+	return meshRoute
+}
+
+func (analyzer analyzerImpl) merge(meshRoute *types.MeshRoute, decision portDecision) {
+	for _, policy := range decision.policies {
+		ref := types.NetworkPolicy{Kind: authorizationPolicyKind, Name: policy.Name, Namespace: policy.Namespace,
+			Labels: policy.Labels}
+		if !commons.AnyMatch(meshRoute.Policies, func(existing types.NetworkPolicy) bool {
+			return existing.Name == ref.Name && existing.Namespace == ref.Namespace
+		}) {
+			meshRoute.Policies = append(meshRoute.Policies, ref)
+		}
+	}
+	for _, condition := range decision.conditions {
+		if !commons.AnyMatch(meshRoute.Conditions, func(existing string) bool { return existing == condition }) {
+			meshRoute.Conditions = append(meshRoute.Conditions, condition)
+		}
+	}
+	if meshRoute.Reason == "" {
+		meshRoute.Reason = decision.reason
+	}
+}
+
+func (analyzer analyzerImpl) overallDecision(decisions []types.MeshDecision) types.MeshDecision {
+	allAllowed, allDenied := true, true
+	for _, decision := range decisions {
+		allAllowed = allAllowed && decision == types.MeshDecisionAllowed
+		allDenied = allDenied && decision == types.MeshDecisionDenied
+	}
+	if allAllowed {
+		return types.MeshDecisionAllowed
+	} else if allDenied {
+		return types.MeshDecisionDenied
+	}
+	return types.MeshDecisionConditional
+}
+
+func (analyzer analyzerImpl) candidatePorts(
+	allowedRoute *types.AllowedRoute,
+	target *corev1.Pod,
+	authorizationPolicies []*istio.AuthorizationPolicy,
+	peerAuthentications []*istio.PeerAuthentication,
+) []types.PortRange {
+	candidates := commons.NewSet[int32]()
+	addPorts := func(values []string) {
+		for _, value := range values {
+			port, err := strconv.ParseInt(value, 10, 32)
+			if err == nil {
+				candidates.Add(int32(port))
+			}
+		}
+	}
+	for _, policy := range authorizationPolicies {
+		for _, rule := range policy.Spec.Rules {
+			for _, to := range rule.To {
+				addPorts(to.Operation.Ports)
+				addPorts(to.Operation.NotPorts)
+			}
+			for _, condition := range rule.When {
+				if condition.Key == destinationPortKey {
+					addPorts(condition.Values)
+					addPorts(condition.NotValues)
+				}
+			}
+		}
+	}
+	for _, peerAuthentication := range peerAuthentications {
+		if peerAuthentication.Namespace == target.Namespace &&
+			peerAuthentication.Spec.Selector != nil && analyzer.selects(peerAuthentication.Spec.Selector, target) {
+			for port := range peerAuthentication.Spec.PortLevelMTLS {
+				addPorts([]string{port})
+			}
+		}
+	}
+	var result []types.PortRange
+	if allowedRoute.Ports != nil {
+		result = commons.Map(allowedRoute.Ports, shared.SinglePort)
+		for _, portRange := range allowedRoute.PortRanges {
+			result = append(result, analyzer.splitPortRange(portRange, candidates)...)
+		}
+	} else {
+		candidates.Add(shared.AnyOtherPort)
+		ports := commons.Filter(candidates.ToSlice(), func(port int32) bool {
+			return port == shared.AnyOtherPort || allowedRoute.AllowsPort(port)
+		})
+		result = commons.Map(ports, shared.SinglePort)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Start < result[j].Start })
+	return result
+}
+
+func (analyzer analyzerImpl) splitPortRange(portRange types.PortRange, ports *commons.Set[int32]) []types.PortRange {
+	boundaries := commons.NewSet[int32]()
+	boundaries.Add(portRange.Start)
+	for _, port := range ports.ToSlice() {
+		if port > portRange.Start && port <= portRange.End {
+			boundaries.Add(port)
+		}
+		if port >= portRange.Start && port < portRange.End {
+			boundaries.Add(port + 1)
+		}
+	}
+	starts := boundaries.ToSlice()
+	sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+	result := make([]types.PortRange, 0, len(starts))
+	for i, start := range starts {
+		end := portRange.End
+		if i+1 < len(starts) {
+			end = starts[i+1] - 1
+		}
+		result = append(result, types.PortRange{Start: start, End: end})
+	}
+	return result
+}
+
+func (analyzer analyzerImpl) mtlsMode(
+	target *corev1.Pod,
+	port int32,
+	peerAuthentications []*istio.PeerAuthentication,
+) string {
+	var workloadPolicy, namespacePolicy, meshPolicy *istio.PeerAuthentication
+	for _, peerAuthentication := range peerAuthentications {
+		switch {
+		case peerAuthentication.Namespace == target.Namespace && peerAuthentication.Spec.Selector != nil:
+			if analyzer.selects(peerAuthentication.Spec.Selector, target) &&
+				analyzer.isOlder(peerAuthentication, workloadPolicy) {
+				workloadPolicy = peerAuthentication
+			}
+		case peerAuthentication.Namespace == target.Namespace:
+			if analyzer.isOlder(peerAuthentication, namespacePolicy) {
+				namespacePolicy = peerAuthentication
+			}
+		case peerAuthentication.Namespace == rootNamespace && peerAuthentication.Spec.Selector == nil:
+			if analyzer.isOlder(peerAuthentication, meshPolicy) {
+				meshPolicy = peerAuthentication
+			}
+		}
+	}
+	if workloadPolicy != nil {
+		if portMTLS, ok := workloadPolicy.Spec.PortLevelMTLS[strconv.Itoa(int(port))]; ok &&
+			analyzer.isSet(&portMTLS) {
+			return portMTLS.Mode
+		}
+	}
+	for _, policy := range []*istio.PeerAuthentication{workloadPolicy, namespacePolicy, meshPolicy} {
+		if policy != nil && analyzer.isSet(policy.Spec.MTLS) {
+			return policy.Spec.MTLS.Mode
+		}
+	}
+	return istio.MTLSModePermissive
+}
+
+func (analyzer analyzerImpl) isOlder(candidate *istio.PeerAuthentication, current *istio.PeerAuthentication) bool {
+	if current == nil {
+		return true
+	}
+	if !candidate.CreationTimestamp.Equal(&current.CreationTimestamp) {
+		return candidate.CreationTimestamp.Before(&current.CreationTimestamp)
+	}
+	return candidate.Name < current.Name
+}
+
+func (analyzer analyzerImpl) isSet(mtls *istio.MTLS) bool {
+	return mtls != nil && mtls.Mode != "" && mtls.Mode != istio.MTLSModeUnset
+}
+
+func (analyzer analyzerImpl) decide(
+	req request,
+	sourceInMesh bool,
+	mode string,
+	authorizationPolicies []*istio.AuthorizationPolicy,
+) portDecision {
+	if mode == istio.MTLSModeStrict && !sourceInMesh {
+		return portDecision{decision: types.MeshDecisionDenied,
+			reason: "mutual TLS is required by the target but the source is not in the mesh"}
+	}
+	result := portDecision{decision: types.MeshDecisionAllowed}
+	for _, policy := range authorizationPolicies {
+		if policy.ActionName() != istio.ActionDeny && policy.ActionName() != istio.ActionCustom {
+			continue
+		}
+		policyMatch := analyzer.policyMatch(policy, req)
+		if !policyMatch.matches {
+			continue
+		}
+		if policy.ActionName() == istio.ActionCustom {
+			result.policies = append(result.policies, policy)
+			result.conditions = append(result.conditions, withCondition("delegated to "+analyzer.provider(policy)+
+				" by "+analyzer.policyName(policy), policyMatch))
+			continue
+		}
+		if len(policyMatch.conditions) == 0 {
+			return portDecision{decision: types.MeshDecisionDenied, policies: []*istio.AuthorizationPolicy{policy},
+				reason: "denied by " + analyzer.policyName(policy)}
+		}
+		result.policies = append(result.policies, policy)
+		result.conditions = append(result.conditions,
+			withCondition("denied by "+analyzer.policyName(policy), policyMatch))
+	}
+	allowPolicies := commons.Filter(authorizationPolicies, func(policy *istio.AuthorizationPolicy) bool {
+		return policy.ActionName() == istio.ActionAllow
+	})
+	if len(allowPolicies) == 0 {
+		return analyzer.withConditions(result)
+	}
+	allowConditions := make([]string, 0)
+	var conditionalPolicies []*istio.AuthorizationPolicy
+	for _, policy := range allowPolicies {
+		policyMatch := analyzer.policyMatch(policy, req)
+		if !policyMatch.matches {
+			continue
+		}
+		if len(policyMatch.conditions) == 0 {
+			result.policies = append(result.policies, policy)
+			return analyzer.withConditions(result)
+		}
+		conditionalPolicies = append(conditionalPolicies, policy)
+		allowConditions = append(allowConditions, withCondition("allowed by "+analyzer.policyName(policy),
+			policyMatch))
+	}
+	if len(conditionalPolicies) == 0 {
+		return portDecision{decision: types.MeshDecisionDenied, policies: allowPolicies,
+			reason: "no ALLOW authorization policy matches the traffic"}
+	}
+	result.decision = types.MeshDecisionConditional
+	result.policies = append(result.policies, conditionalPolicies...)
+	result.conditions = append(result.conditions, allowConditions...)
+	return result
+}
+
+func (analyzer analyzerImpl) withConditions(decision portDecision) portDecision {
+	if len(decision.conditions) != 0 {
+		decision.decision = types.MeshDecisionConditional
+	}
+	return decision
+}
+
+func (analyzer analyzerImpl) policyName(policy *istio.AuthorizationPolicy) string {
+	return "AuthorizationPolicy " + policy.Namespace + "/" + policy.Name
+}
+
+func (analyzer analyzerImpl) provider(policy *istio.AuthorizationPolicy) string {
+	if policy.Spec.Provider == nil || policy.Spec.Provider.Name == "" {
+		return "an external authorizer"
+	}
+	return "external authorizer " + policy.Spec.Provider.Name
+}
+
+func (analyzer analyzerImpl) principal(pod *corev1.Pod) string {
+	serviceAccount := pod.Spec.ServiceAccountName
+	if serviceAccount == "" {
+		serviceAccount = defaultServiceAccount
+	}
+	return trustDomain + "/ns/" + pod.Namespace + "/sa/" + serviceAccount
+}
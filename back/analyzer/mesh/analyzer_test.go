@@ -0,0 +1,182 @@
+package mesh
+
+import (
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"karto/crds/istio"
+	"karto/testutils"
+	"karto/types"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	type args struct {
+		clusterState ClusterState
+	}
+	sidecar := corev1.Container{Name: "istio-proxy"}
+	meshNamespace := testutils.NewNamespaceBuilder().WithName("shop").WithLabel("istio-injection", "enabled").Build()
+	otherNamespace := testutils.NewNamespaceBuilder().WithName("jobs").Build()
+	web := testutils.NewPodBuilder().WithName("web").WithNamespace("shop").WithLabel("app", "web").
+		WithServiceAccount("web").Build()
+	api := testutils.NewPodBuilder().WithName("api").WithNamespace("shop").WithLabel("app", "api").Build()
+	batch := testutils.NewPodBuilder().WithName("batch").WithNamespace("jobs").WithIP("10.0.0.5").Build()
+	meshedBatch := testutils.NewPodBuilder().WithName("meshed").WithNamespace("jobs").WithContainer(sidecar).Build()
+	webRef := types.PodRef{Name: "web", Namespace: "shop"}
+	apiRef := types.PodRef{Name: "api", Namespace: "shop"}
+	batchRef := types.PodRef{Name: "batch", Namespace: "jobs"}
+	meshedBatchRef := types.PodRef{Name: "meshed", Namespace: "jobs"}
+	webToAPI := &types.AllowedRoute{SourcePod: webRef, TargetPod: apiRef, Ports: []int32{8080}}
+	batchToAPI := &types.AllowedRoute{SourcePod: batchRef, TargetPod: apiRef}
+	apiToBatch := &types.AllowedRoute{SourcePod: apiRef, TargetPod: batchRef}
+	meshedToAPI := &types.AllowedRoute{SourcePod: meshedBatchRef, TargetPod: apiRef}
+	apiPolicyRef := types.NetworkPolicy{Kind: "AuthorizationPolicy.security.istio.io", Name: "api",
+		Namespace: "shop"}
+	tests := []struct {
+		name               string
+		args               args
+		expectedMeshRoutes []*types.MeshRoute
+	}{
+		{
+			name: "routes to pods outside the mesh are ignored and others are allowed without authorization policy",
+			args: args{
+				clusterState: ClusterState{
+					Namespaces:    []*corev1.Namespace{meshNamespace, otherNamespace},
+					Pods:          []*corev1.Pod{web, api, batch, meshedBatch},
+					AllowedRoutes: []*types.AllowedRoute{webToAPI, apiToBatch, batchToAPI},
+				},
+			},
+			expectedMeshRoutes: []*types.MeshRoute{
+				{SourcePod: webRef, TargetPod: apiRef, Decision: types.MeshDecisionAllowed, MTLSMode: "PERMISSIVE",
+					MutualTLS: true, Policies: []types.NetworkPolicy{}, Ports: []int32{8080}},
+				{SourcePod: batchRef, TargetPod: apiRef, Decision: types.MeshDecisionAllowed, MTLSMode: "PERMISSIVE",
+					Policies: []types.NetworkPolicy{}},
+			},
+		},
+		{
+			name: "strict mutual TLS denies traffic from pods outside the mesh",
+			args: args{
+				clusterState: ClusterState{
+					Namespaces:    []*corev1.Namespace{meshNamespace, otherNamespace},
+					Pods:          []*corev1.Pod{api, batch, meshedBatch},
+					AllowedRoutes: []*types.AllowedRoute{batchToAPI, meshedToAPI},
+					PeerAuthentications: []*istio.PeerAuthentication{
+						{
+							ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "istio-system"},
+							Spec:       istio.PeerAuthenticationSpec{MTLS: &istio.MTLS{Mode: "STRICT"}},
+						},
+						{
+							ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "shop"},
+							Spec: istio.PeerAuthenticationSpec{
+								Selector:      &istio.WorkloadSelector{MatchLabels: map[string]string{"app": "api"}},
+								PortLevelMTLS: map[string]istio.MTLS{"9090": {Mode: "PERMISSIVE"}},
+							},
+						},
+					},
+				},
+			},
+			expectedMeshRoutes: []*types.MeshRoute{
+				{SourcePod: batchRef, TargetPod: apiRef, Decision: types.MeshDecisionConditional, MTLSMode: "STRICT",
+					Policies: []types.NetworkPolicy{}, Ports: []int32{9090},
+					Reason: "mutual TLS is required by the target but the source is not in the mesh"},
+				{SourcePod: meshedBatchRef, TargetPod: apiRef, Decision: types.MeshDecisionAllowed, MTLSMode: "STRICT",
+					MutualTLS: true, Policies: []types.NetworkPolicy{}},
+			},
+		},
+		{
+			name: "allow policies matching on request attributes make traffic conditional",
+			args: args{
+				clusterState: ClusterState{
+					Namespaces:    []*corev1.Namespace{meshNamespace, otherNamespace},
+					Pods:          []*corev1.Pod{web, api, batch},
+					AllowedRoutes: []*types.AllowedRoute{webToAPI, batchToAPI},
+					AuthorizationPolicies: []*istio.AuthorizationPolicy{
+						{
+							ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "shop"},
+							Spec: istio.AuthorizationPolicySpec{
+								Selector: &istio.WorkloadSelector{MatchLabels: map[string]string{"app": "api"}},
+								Rules: []istio.Rule{
+									{
+										From: []istio.From{
+											{Source: istio.Source{Principals: []string{"cluster.local/ns/shop/sa/web"}}},
+										},
+										To: []istio.To{
+											{Operation: istio.Operation{Methods: []string{"GET"}, Paths: []string{"/api/*"}}},
+										},
+									},
+									{
+										From: []istio.From{{Source: istio.Source{IPBlocks: []string{"10.1.0.0/16"}}}},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectedMeshRoutes: []*types.MeshRoute{
+				{SourcePod: webRef, TargetPod: apiRef, Decision: types.MeshDecisionConditional, MTLSMode: "PERMISSIVE",
+					MutualTLS: true, Policies: []types.NetworkPolicy{apiPolicyRef}, Ports: []int32{8080},
+					Conditions: []string{
+						"allowed by AuthorizationPolicy shop/api when (method in [GET] and path in [/api/*]) or " +
+							"(source IP in [10.1.0.0/16])",
+					}},
+				{SourcePod: batchRef, TargetPod: apiRef, Decision: types.MeshDecisionDenied, MTLSMode: "PERMISSIVE",
+					Policies: []types.NetworkPolicy{apiPolicyRef}, Ports: []int32{},
+					Reason: "no ALLOW authorization policy matches the traffic"},
+			},
+		},
+		{
+			name: "deny policies take precedence over allow policies",
+			args: args{
+				clusterState: ClusterState{
+					Namespaces:    []*corev1.Namespace{meshNamespace, otherNamespace},
+					Pods:          []*corev1.Pod{api, meshedBatch},
+					AllowedRoutes: []*types.AllowedRoute{meshedToAPI},
+					AuthorizationPolicies: []*istio.AuthorizationPolicy{
+						{
+							ObjectMeta: metav1.ObjectMeta{Name: "allow-all", Namespace: "shop"},
+							Spec:       istio.AuthorizationPolicySpec{Rules: []istio.Rule{{}}},
+						},
+						{
+							ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "istio-system"},
+							Spec: istio.AuthorizationPolicySpec{
+								Action: "DENY",
+								Rules: []istio.Rule{
+									{
+										From: []istio.From{{Source: istio.Source{NotNamespaces: []string{"shop"}}}},
+										To:   []istio.To{{Operation: istio.Operation{Ports: []string{"22"}}}},
+									},
+									{
+										When: []istio.Condition{{Key: "request.headers[x-debug]", Values: []string{"1"}}},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectedMeshRoutes: []*types.MeshRoute{
+				{SourcePod: meshedBatchRef, TargetPod: apiRef, Decision: types.MeshDecisionConditional,
+					MTLSMode: "PERMISSIVE", MutualTLS: true,
+					Policies: []types.NetworkPolicy{
+						{Kind: "AuthorizationPolicy.security.istio.io", Name: "api", Namespace: "istio-system"},
+						{Kind: "AuthorizationPolicy.security.istio.io", Name: "allow-all", Namespace: "shop"},
+					},
+					DeniedPorts: []int32{22},
+					Conditions: []string{
+						"denied by AuthorizationPolicy istio-system/api when request.headers[x-debug] in [1]",
+					},
+					Reason: "denied by AuthorizationPolicy istio-system/api"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := NewAnalyzer()
+			analysisResult := analyzer.Analyze(tt.args.clusterState)
+			if diff := cmp.Diff(tt.expectedMeshRoutes, analysisResult.Routes); diff != "" {
+				t.Errorf("Analyze() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package mesh
+
+import (
+	"karto/analyzer/shared"
+	"karto/crds/istio"
+	"net"
+	"strconv"
+	"strings"
+)
+
+const (
+	sourceNamespaceKey = "source.namespace"
+	sourcePrincipalKey = "source.principal"
+	sourceIPKey        = "source.ip"
+	remoteIPKey        = "remote.ip"
+	destinationPortKey = "destination.port"
+	principalPrefix    = "spiffe://"
+)
+
+type match struct {
+	matches    bool
+	conditions []string
+}
+
+var (
+	noMatch     = match{}
+	alwaysMatch = match{matches: true}
+)
+
+func conditionalMatch(condition string) match {
+	return match{matches: true, conditions: []string{condition}}
+}
+
+func allOf(matches ...match) match {
+	result := alwaysMatch
+	for _, candidate := range matches {
+		if !candidate.matches {
+			return noMatch
+		}
+		result.conditions = append(result.conditions, candidate.conditions...)
+	}
+	return result
+}
+
+func anyOf(matches []match) match {
+	alternatives := make([]string, 0)
+	for _, candidate := range matches {
+		if !candidate.matches {
+			continue
+		}
+		if len(candidate.conditions) == 0 {
+			return alwaysMatch
+		}
+		alternatives = append(alternatives, strings.Join(candidate.conditions, " and "))
+	}
+	if len(alternatives) == 0 {
+		return noMatch
+	}
+	if len(alternatives) == 1 {
+		return conditionalMatch(alternatives[0])
+	}
+	return conditionalMatch("(" + strings.Join(alternatives, ") or (") + ")")
+}
+
+func withCondition(prefix string, policyMatch match) string {
+	return prefix + " when " + strings.Join(policyMatch.conditions, " and ")
+}
+
+func (analyzer analyzerImpl) policyMatch(policy *istio.AuthorizationPolicy, req request) match {
+	ruleMatches := make([]match, 0, len(policy.Spec.Rules))
+	for _, rule := range policy.Spec.Rules {
+		ruleMatches = append(ruleMatches, analyzer.ruleMatch(rule, req))
+	}
+	return anyOf(ruleMatches)
+}
+
+func (analyzer analyzerImpl) ruleMatch(rule istio.Rule, req request) match {
+	fromMatch := alwaysMatch
+	if len(rule.From) != 0 {
+		fromMatches := make([]match, 0, len(rule.From))
+		for _, from := range rule.From {
+			fromMatches = append(fromMatches, analyzer.sourceMatch(from.Source, req))
+		}
+		fromMatch = anyOf(fromMatches)
+	}
+	toMatch := alwaysMatch
+	if len(rule.To) != 0 {
+		toMatches := make([]match, 0, len(rule.To))
+		for _, to := range rule.To {
+			toMatches = append(toMatches, analyzer.operationMatch(to.Operation, req))
+		}
+		toMatch = anyOf(toMatches)
+	}
+	whenMatches := make([]match, 0, len(rule.When))
+	for _, condition := range rule.When {
+		whenMatches = append(whenMatches, analyzer.conditionMatch(condition, req))
+	}
+	return allOf(fromMatch, toMatch, allOf(whenMatches...))
+}
+
+func (analyzer analyzerImpl) sourceMatch(source istio.Source, req request) match {
+	principal, namespace := "", ""
+	if req.hasIdentity {
+		principal, namespace = analyzer.principal(req.source), req.source.Namespace
+	}
+	return allOf(
+		knownValueMatch(source.Principals, source.NotPrincipals, principal, trimPrincipal),
+		knownValueMatch(source.Namespaces, source.NotNamespaces, namespace, nil),
+		analyzer.ipMatch("source IP", source.IPBlocks, source.NotIPBlocks, req),
+		analyzer.ipMatch("remote IP", source.RemoteIPBlocks, source.NotRemoteIPBlocks, req),
+		unknownValueMatch("request principal", source.RequestPrincipals, source.NotRequestPrincipals),
+	)
+}
+
+func (analyzer analyzerImpl) operationMatch(operation istio.Operation, req request) match {
+	return allOf(
+		portMatch(operation.Ports, operation.NotPorts, req.port),
+		unknownValueMatch("host", operation.Hosts, operation.NotHosts),
+		unknownValueMatch("method", operation.Methods, operation.NotMethods),
+		unknownValueMatch("path", operation.Paths, operation.NotPaths),
+	)
+}
+
+func (analyzer analyzerImpl) conditionMatch(condition istio.Condition, req request) match {
+	switch condition.Key {
+	case sourceNamespaceKey:
+		namespace := ""
+		if req.hasIdentity {
+			namespace = req.source.Namespace
+		}
+		return knownValueMatch(condition.Values, condition.NotValues, namespace, nil)
+	case sourcePrincipalKey:
+		principal := ""
+		if req.hasIdentity {
+			principal = analyzer.principal(req.source)
+		}
+		return knownValueMatch(condition.Values, condition.NotValues, principal, trimPrincipal)
+	case sourceIPKey, remoteIPKey:
+		return analyzer.ipMatch(condition.Key, condition.Values, condition.NotValues, req)
+	case destinationPortKey:
+		return portMatch(condition.Values, condition.NotValues, req.port)
+	}
+	return unknownValueMatch(condition.Key, condition.Values, condition.NotValues)
+}
+
+func (analyzer analyzerImpl) ipMatch(name string, blocks []string, notBlocks []string, req request) match {
+	if len(blocks) == 0 && len(notBlocks) == 0 {
+		return alwaysMatch
+	}
+	ip := net.ParseIP(req.source.Status.PodIP)
+	if ip == nil {
+		return unknownValueMatch(name, blocks, notBlocks)
+	}
+	if len(blocks) != 0 && !containsIP(blocks, ip) {
+		return noMatch
+	}
+	if containsIP(notBlocks, ip) {
+		return noMatch
+	}
+	return alwaysMatch
+}
+
+func containsIP(blocks []string, ip net.IP) bool {
+	for _, block := range blocks {
+		if !strings.Contains(block, "/") {
+			if net.ParseIP(block).Equal(ip) {
+				return true
+			}
+			continue
+		}
+		_, network, err := net.ParseCIDR(block)
+		if err == nil && network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func portMatch(ports []string, notPorts []string, port int32) match {
+	value := ""
+	if port != shared.AnyOtherPort {
+		value = strconv.Itoa(int(port))
+	}
+	if len(ports) != 0 && !containsValue(ports, value, nil) {
+		return noMatch
+	}
+	if containsValue(notPorts, value, nil) {
+		return noMatch
+	}
+	return alwaysMatch
+}
+
+func knownValueMatch(values []string, notValues []string, value string, normalize func(string) string) match {
+	if len(values) != 0 && !containsValue(values, value, normalize) {
+		return noMatch
+	}
+	if containsValue(notValues, value, normalize) {
+		return noMatch
+	}
+	return alwaysMatch
+}
+
+func unknownValueMatch(name string, values []string, notValues []string) match {
+	conditions := make([]string, 0, 2)
+	if len(values) != 0 {
+		conditions = append(conditions, name+" in ["+strings.Join(values, ", ")+"]")
+	}
+	if len(notValues) != 0 {
+		conditions = append(conditions, name+" not in ["+strings.Join(notValues, ", ")+"]")
+	}
+	return match{matches: true, conditions: conditions}
+}
+
+func containsValue(patterns []string, value string, normalize func(string) string) bool {
+	if value == "" {
+		return false
+	}
+	for _, pattern := range patterns {
+		if normalize != nil {
+			pattern = normalize(pattern)
+		}
+		if valueMatches(pattern, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func valueMatches(pattern string, value string) bool {
+	switch {
+	case pattern == "*":
+		return true
+	case strings.HasPrefix(pattern, "*"):
+		return strings.HasSuffix(value, strings.TrimPrefix(pattern, "*"))
+	case strings.HasSuffix(pattern, "*"):
+		return strings.HasPrefix(value, strings.TrimSuffix(pattern, "*"))
+	}
+	return pattern == value
+}
+
+func trimPrincipal(principal string) string {
+	return strings.TrimPrefix(principal, principalPrefix)
+}
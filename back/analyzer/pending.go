@@ -0,0 +1,64 @@
+package analyzer
+
+import (
+	"expvar"
+	"karto/types"
+	"sync"
+)
+
+var (
+	supersededClusterStates = expvar.NewInt("clusterStatesSuperseded")
+	completedAnalyses       = expvar.NewInt("analysesCompleted")
+	lastAnalysisDuration    = expvar.NewFloat("lastAnalysisSeconds")
+)
+
+type pendingClusterStates struct {
+	mutex    sync.Mutex
+	states   map[string]types.ClusterState
+	clusters []string
+	signal   chan struct{}
+}
+
+func newPendingClusterStates() *pendingClusterStates {
+	return &pendingClusterStates{
+		states: make(map[string]types.ClusterState),
+		signal: make(chan struct{}, 1),
+	}
+}
+
+func (pending *pendingClusterStates) receive(clusterStateChannel <-chan types.ClusterState) {
+	for clusterState := range clusterStateChannel {
+		pending.put(clusterState)
+	}
+}
+
+func (pending *pendingClusterStates) put(clusterState types.ClusterState) {
+	pending.mutex.Lock()
+	if _, ok := pending.states[clusterState.Cluster]; ok {
+		supersededClusterStates.Add(1)
+	} else {
+		pending.clusters = append(pending.clusters, clusterState.Cluster)
+	}
+	pending.states[clusterState.Cluster] = clusterState
+	pending.mutex.Unlock()
+	select {
+	case pending.signal <- struct{}{}:
+	default:
+	}
+}
+
+func (pending *pendingClusterStates) next() types.ClusterState {
+	for {
+		pending.mutex.Lock()
+		if len(pending.clusters) > 0 {
+			cluster := pending.clusters[0]
+			pending.clusters = pending.clusters[1:]
+			clusterState := pending.states[cluster]
+			delete(pending.states, cluster)
+			pending.mutex.Unlock()
+			return clusterState
+		}
+		pending.mutex.Unlock()
+		<-pending.signal
+	}
+}
@@ -0,0 +1,67 @@
+package analyzer
+
+import (
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"karto/testutils"
+	"karto/types"
+	"testing"
+)
+
+func TestPendingClusterStates(t *testing.T) {
+	type args struct {
+		clusterStates []types.ClusterState
+	}
+	oldPod := testutils.NewPodBuilder().WithName("old").Build()
+	newPod := testutils.NewPodBuilder().WithName("new").Build()
+	tests := []struct {
+		name                  string
+		args                  args
+		expectedClusterStates []types.ClusterState
+	}{
+		{
+			name: "keeps only the latest state of a cluster changed during an analysis",
+			args: args{
+				clusterStates: []types.ClusterState{
+					{Cluster: "east", Pods: []*corev1.Pod{oldPod}},
+					{Cluster: "east", Pods: []*corev1.Pod{newPod}},
+				},
+			},
+			expectedClusterStates: []types.ClusterState{
+				{Cluster: "east", Pods: []*corev1.Pod{newPod}},
+			},
+		},
+		{
+			name: "analyzes clusters in the order they first changed",
+			args: args{
+				clusterStates: []types.ClusterState{
+					{Cluster: "west", Pods: []*corev1.Pod{oldPod}},
+					{Cluster: "east", Pods: []*corev1.Pod{oldPod}},
+					{Cluster: "west", Pods: []*corev1.Pod{newPod}},
+				},
+			},
+			expectedClusterStates: []types.ClusterState{
+				{Cluster: "west", Pods: []*corev1.Pod{newPod}},
+				{Cluster: "east", Pods: []*corev1.Pod{oldPod}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pending := newPendingClusterStates()
+			for _, clusterState := range tt.args.clusterStates {
+				pending.put(clusterState)
+			}
+			clusterStates := make([]types.ClusterState, 0, len(tt.expectedClusterStates))
+			for range tt.expectedClusterStates {
+				clusterStates = append(clusterStates, pending.next())
+			}
+			if diff := cmp.Diff(tt.expectedClusterStates, clusterStates); diff != "" {
+				t.Errorf("next() result mismatch (-want +got):\n%s", diff)
+			}
+			if len(pending.clusters) != 0 {
+				t.Errorf("next() expected no pending cluster state left, got %v", pending.clusters)
+			}
+		})
+	}
+}
@@ -0,0 +1,323 @@
+package podsecurity
+
+import (
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	"karto/analyzer/shared"
+	"karto/commons"
+	"karto/types"
+	"sort"
+	"strings"
+)
+
+const (
+	HostNamespaces      = "HostNamespaces"
+	Privileged          = "Privileged"
+	Capabilities        = "Capabilities"
+	HostPathVolumes     = "HostPathVolumes"
+	HostPorts           = "HostPorts"
+	VolumeTypes         = "VolumeTypes"
+	PrivilegeEscalation = "PrivilegeEscalation"
+	RunAsNonRoot        = "RunAsNonRoot"
+	RunAsUser           = "RunAsUser"
+	SeccompProfile      = "SeccompProfile"
+	ProcMount           = "ProcMount"
+	Sysctls             = "Sysctls"
+	SELinux             = "SELinux"
+	AppArmor            = "AppArmor"
+)
+
+const (
+	namespaceLabelPrefix        = "pod-security.kubernetes.io/"
+	allCapabilities             = "ALL"
+	appArmorAnnotationPrefix    = "container.apparmor.security.beta.kubernetes.io/"
+	appArmorRuntimeDefault      = "runtime/default"
+	appArmorLocalhostPrefix     = "localhost/"
+	seccompPodAnnotation        = "seccomp.security.alpha.kubernetes.io/pod"
+	seccompAnnotationPrefix     = "container.seccomp.security.alpha.kubernetes.io/"
+	seccompUnconfinedAnnotation = "unconfined"
+)
+
+var namespaceModes = []string{"enforce", "audit", "warn"}
+
+var levelRanks = map[types.PodSecurityLevel]int{
+	types.PodSecurityLevelPrivileged: 0,
+	types.PodSecurityLevelBaseline:   1,
+	types.PodSecurityLevelRestricted: 2,
+}
+
+var baselineCapabilities = []corev1.Capability{"AUDIT_WRITE", "CHOWN", "DAC_OVERRIDE", "FOWNER", "FSETID", "KILL",
+	"MKNOD", "NET_BIND_SERVICE", "SETFCAP", "SETGID", "SETPCAP", "SETUID", "SYS_CHROOT"}
+
+var restrictedCapabilities = []corev1.Capability{"NET_BIND_SERVICE"}
+
+var safeSysctls = []string{"kernel.shm_rmid_forced", "net.ipv4.ip_local_port_range",
+	"net.ipv4.ip_unprivileged_port_start", "net.ipv4.tcp_syncookies", "net.ipv4.ping_group_range",
+	"net.ipv4.ip_local_reserved_ports", "net.ipv4.tcp_keepalive_time", "net.ipv4.tcp_fin_timeout",
+	"net.ipv4.tcp_keepalive_intvl", "net.ipv4.tcp_keepalive_probes"}
+
+var allowedSELinuxTypes = []string{"", "container_t", "container_init_t", "container_kvm_t"}
+
+type ClusterState struct {
+	Namespaces []*corev1.Namespace
+	Pods       []*corev1.Pod
+}
+
+type AnalysisResult struct {
+	Pods []*types.PodSecurity
+}
+
+type Analyzer interface {
+	Analyze(clusterState ClusterState) AnalysisResult
+}
+
+type analyzerImpl struct{}
+
+func NewAnalyzer() Analyzer {
+	return analyzerImpl{}
+}
+
+func (analyzer analyzerImpl) Analyze(clusterState ClusterState) AnalysisResult {
+	return AnalysisResult{
+		Pods: commons.Map(clusterState.Pods, func(pod *corev1.Pod) *types.PodSecurity {
+			return analyzer.podSecurity(pod, shared.NamespaceLabels(pod.Namespace, clusterState.Namespaces))
+		}),
+	}
+}
+
+func (analyzer analyzerImpl) podSecurity(pod *corev1.Pod, namespaceLabels map[string]string) *types.PodSecurity {
+	baselineViolations := analyzer.baselineViolations(pod)
+	restrictedViolations := analyzer.restrictedViolations(pod)
+	level := types.PodSecurityLevelRestricted
+	if len(baselineViolations) > 0 {
+		level = types.PodSecurityLevelPrivileged
+	} else if len(restrictedViolations) > 0 {
+		level = types.PodSecurityLevelBaseline
+	}
+	namespaceLevels := make(map[string]types.PodSecurityLevel)
+	violatedModes := make([]string, 0)
+	for _, mode := range namespaceModes {
+		namespaceLevel := types.PodSecurityLevel(namespaceLabels[namespaceLabelPrefix+mode])
+		if _, ok := levelRanks[namespaceLevel]; !ok {
+			continue
+		}
+		namespaceLevels[mode] = namespaceLevel
+		if levelRanks[level] < levelRanks[namespaceLevel] {
+			violatedModes = append(violatedModes, mode)
+		}
+	}
+	return &types.PodSecurity{
+		Pod:             shared.ToPodRef(pod),
+		Level:           level,
+		HostNetwork:     pod.Spec.HostNetwork,
+		Violations:      append(baselineViolations, restrictedViolations...),
+		NamespaceLevels: namespaceLevels,
+		ViolatedModes:   violatedModes,
+	}
+}
+
+func (analyzer analyzerImpl) baselineViolations(pod *corev1.Pod) []types.PodSecurityViolation {
+	violations := make([]types.PodSecurityViolation, 0)
+	addViolation := func(check string, container string, message string) {
+		violations = append(violations, types.PodSecurityViolation{
+			Check:     check,
+			Level:     types.PodSecurityLevelBaseline,
+			Container: container,
+			Message:   message,
+		})
+	}
+	if pod.Spec.HostNetwork {
+		addViolation(HostNamespaces, "", "pod shares the host network namespace")
+	}
+	if pod.Spec.HostPID {
+		addViolation(HostNamespaces, "", "pod shares the host PID namespace")
+	}
+	if pod.Spec.HostIPC {
+		addViolation(HostNamespaces, "", "pod shares the host IPC namespace")
+	}
+	for _, volume := range pod.Spec.Volumes {
+		if volume.HostPath != nil {
+			addViolation(HostPathVolumes, "", fmt.Sprintf("volume %s mounts host path %s", volume.Name,
+				volume.HostPath.Path))
+		}
+	}
+	podSecurityContext := pod.Spec.SecurityContext
+	if podSecurityContext == nil {
+		podSecurityContext = &corev1.PodSecurityContext{}
+	}
+	if analyzer.isUnconfinedSeccomp(podSecurityContext.SeccompProfile) ||
+		pod.Annotations[seccompPodAnnotation] == seccompUnconfinedAnnotation {
+		addViolation(SeccompProfile, "", "pod uses the Unconfined seccomp profile")
+	}
+	if message, ok := analyzer.seLinuxViolation(podSecurityContext.SELinuxOptions); ok {
+		addViolation(SELinux, "", "pod "+message)
+	}
+	for _, sysctl := range podSecurityContext.Sysctls {
+		if !commons.AnyMatch(safeSysctls, func(safeSysctl string) bool { return sysctl.Name == safeSysctl }) {
+			addViolation(Sysctls, "", fmt.Sprintf("pod sets unsafe sysctl %s", sysctl.Name))
+		}
+	}
+	for _, container := range analyzer.containersOf(pod) {
+		securityContext := container.SecurityContext
+		if securityContext == nil {
+			securityContext = &corev1.SecurityContext{}
+		}
+		if securityContext.Privileged != nil && *securityContext.Privileged {
+			addViolation(Privileged, container.Name, "container is privileged")
+		}
+		if analyzer.isUnconfinedSeccomp(securityContext.SeccompProfile) ||
+			pod.Annotations[seccompAnnotationPrefix+container.Name] == seccompUnconfinedAnnotation {
+			addViolation(SeccompProfile, container.Name, "container uses the Unconfined seccomp profile")
+		}
+		if securityContext.ProcMount != nil && *securityContext.ProcMount != corev1.DefaultProcMount {
+			addViolation(ProcMount, container.Name, fmt.Sprintf("container uses the %s proc mount",
+				*securityContext.ProcMount))
+		}
+		if message, ok := analyzer.seLinuxViolation(securityContext.SELinuxOptions); ok {
+			addViolation(SELinux, container.Name, "container "+message)
+		}
+		if appArmorProfile, ok := pod.Annotations[appArmorAnnotationPrefix+container.Name]; ok &&
+			appArmorProfile != appArmorRuntimeDefault && !strings.HasPrefix(appArmorProfile, appArmorLocalhostPrefix) {
+			addViolation(AppArmor, container.Name, fmt.Sprintf("container uses the %s AppArmor profile",
+				appArmorProfile))
+		}
+		for _, capability := range analyzer.addedCapabilities(container) {
+			if !analyzer.containsCapability(baselineCapabilities, capability) {
+				addViolation(Capabilities, container.Name, fmt.Sprintf("container adds capability %s", capability))
+			}
+		}
+		for _, port := range container.Ports {
+			if port.HostPort != 0 {
+				addViolation(HostPorts, container.Name, fmt.Sprintf("container binds host port %d", port.HostPort))
+			}
+		}
+	}
+	sortViolations(violations)
+	return violations
+}
+
+func (analyzer analyzerImpl) restrictedViolations(pod *corev1.Pod) []types.PodSecurityViolation {
+	violations := make([]types.PodSecurityViolation, 0)
+	addViolation := func(check string, container string, message string) {
+		violations = append(violations, types.PodSecurityViolation{
+			Check:     check,
+			Level:     types.PodSecurityLevelRestricted,
+			Container: container,
+			Message:   message,
+		})
+	}
+	for _, volume := range pod.Spec.Volumes {
+		if volume.HostPath == nil && !analyzer.hasRestrictedVolumeType(volume) {
+			addViolation(VolumeTypes, "", fmt.Sprintf("volume %s has a type not allowed by the restricted level",
+				volume.Name))
+		}
+	}
+	podSecurityContext := pod.Spec.SecurityContext
+	if podSecurityContext == nil {
+		podSecurityContext = &corev1.PodSecurityContext{}
+	}
+	if podSecurityContext.RunAsUser != nil && *podSecurityContext.RunAsUser == 0 {
+		addViolation(RunAsUser, "", "pod runs as user 0")
+	}
+	for _, container := range analyzer.containersOf(pod) {
+		securityContext := container.SecurityContext
+		if securityContext == nil {
+			securityContext = &corev1.SecurityContext{}
+		}
+		if securityContext.AllowPrivilegeEscalation == nil || *securityContext.AllowPrivilegeEscalation {
+			addViolation(PrivilegeEscalation, container.Name, "container allows privilege escalation")
+		}
+		runAsNonRoot := securityContext.RunAsNonRoot
+		if runAsNonRoot == nil {
+			runAsNonRoot = podSecurityContext.RunAsNonRoot
+		}
+		if runAsNonRoot == nil || !*runAsNonRoot {
+			addViolation(RunAsNonRoot, container.Name, "container may run as root")
+		}
+		if securityContext.RunAsUser != nil && *securityContext.RunAsUser == 0 {
+			addViolation(RunAsUser, container.Name, "container runs as user 0")
+		}
+		seccompProfile := securityContext.SeccompProfile
+		if seccompProfile == nil {
+			seccompProfile = podSecurityContext.SeccompProfile
+		}
+		if seccompProfile == nil || (seccompProfile.Type != corev1.SeccompProfileTypeRuntimeDefault &&
+			seccompProfile.Type != corev1.SeccompProfileTypeLocalhost &&
+			seccompProfile.Type != corev1.SeccompProfileTypeUnconfined) {
+			addViolation(SeccompProfile, container.Name, "container does not use the RuntimeDefault or Localhost "+
+				"seccomp profile")
+		}
+		if securityContext.Capabilities == nil ||
+			!analyzer.containsCapability(securityContext.Capabilities.Drop, allCapabilities) {
+			addViolation(Capabilities, container.Name, "container does not drop all capabilities")
+		}
+		for _, capability := range analyzer.addedCapabilities(container) {
+			if analyzer.containsCapability(baselineCapabilities, capability) &&
+				!analyzer.containsCapability(restrictedCapabilities, capability) {
+				addViolation(Capabilities, container.Name, fmt.Sprintf("container adds capability %s", capability))
+			}
+		}
+	}
+	sortViolations(violations)
+	return violations
+}
+
+func (analyzer analyzerImpl) containersOf(pod *corev1.Pod) []corev1.Container {
+	result := make([]corev1.Container, 0,
+		len(pod.Spec.InitContainers)+len(pod.Spec.Containers)+len(pod.Spec.EphemeralContainers))
+	result = append(result, pod.Spec.InitContainers...)
+	result = append(result, pod.Spec.Containers...)
+	for _, ephemeralContainer := range pod.Spec.EphemeralContainers {
+		result = append(result, corev1.Container(ephemeralContainer.EphemeralContainerCommon))
+	}
+	return result
+}
+
+func (analyzer analyzerImpl) addedCapabilities(container corev1.Container) []corev1.Capability {
+	if container.SecurityContext == nil || container.SecurityContext.Capabilities == nil {
+		return nil
+	}
+	return container.SecurityContext.Capabilities.Add
+}
+
+func (analyzer analyzerImpl) isUnconfinedSeccomp(seccompProfile *corev1.SeccompProfile) bool {
+	return seccompProfile != nil && seccompProfile.Type == corev1.SeccompProfileTypeUnconfined
+}
+
+func (analyzer analyzerImpl) seLinuxViolation(seLinuxOptions *corev1.SELinuxOptions) (string, bool) {
+	switch {
+	case seLinuxOptions == nil:
+		return "", false
+	case !commons.AnyMatch(allowedSELinuxTypes, func(seLinuxType string) bool {
+		return seLinuxOptions.Type == seLinuxType
+	}):
+		return fmt.Sprintf("sets SELinux type %s", seLinuxOptions.Type), true
+	case seLinuxOptions.User != "":
+		return fmt.Sprintf("sets SELinux user %s", seLinuxOptions.User), true
+	case seLinuxOptions.Role != "":
+		return fmt.Sprintf("sets SELinux role %s", seLinuxOptions.Role), true
+	default:
+		return "", false
+	}
+}
+
+func (analyzer analyzerImpl) containsCapability(capabilities []corev1.Capability, capability corev1.Capability) bool {
+	return commons.AnyMatch(capabilities, func(candidate corev1.Capability) bool {
+		return candidate == capability
+	})
+}
+
+func (analyzer analyzerImpl) hasRestrictedVolumeType(volume corev1.Volume) bool {
+	return volume.ConfigMap != nil || volume.CSI != nil || volume.DownwardAPI != nil || volume.EmptyDir != nil ||
+		volume.Ephemeral != nil || volume.PersistentVolumeClaim != nil || volume.Projected != nil ||
+		volume.Secret != nil
+}
+
+func sortViolations(violations []types.PodSecurityViolation) {
+	sort.SliceStable(violations, func(i, j int) bool {
+		if violations[i].Check != violations[j].Check {
+			return violations[i].Check < violations[j].Check
+		}
+		return violations[i].Container < violations[j].Container
+	})
+}
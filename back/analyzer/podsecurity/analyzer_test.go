@@ -0,0 +1,210 @@
+package podsecurity
+
+import (
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"karto/testutils"
+	"karto/types"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	type args struct {
+		clusterState ClusterState
+	}
+	yes, no := true, false
+	nonRoot := int64(1000)
+	restrictedContext := &corev1.SecurityContext{
+		AllowPrivilegeEscalation: &no,
+		RunAsNonRoot:             &yes,
+		RunAsUser:                &nonRoot,
+		SeccompProfile:           &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+		Capabilities: &corev1.Capabilities{
+			Add:  []corev1.Capability{"NET_BIND_SERVICE"},
+			Drop: []corev1.Capability{"ALL"},
+		},
+	}
+	restrictedNamespace := testutils.NewNamespaceBuilder().WithName("shop").
+		WithLabel("pod-security.kubernetes.io/enforce", "baseline").
+		WithLabel("pod-security.kubernetes.io/warn", "restricted").Build()
+	restrictedPod := testutils.NewPodBuilder().WithName("api").WithNamespace("shop").
+		WithContainer(corev1.Container{Name: "app", SecurityContext: restrictedContext}).Build()
+	restrictedPod.Spec.Volumes = []corev1.Volume{
+		{Name: "config", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{}}},
+	}
+	baselinePod := testutils.NewPodBuilder().WithName("web").WithNamespace("shop").
+		WithContainer(corev1.Container{Name: "app", SecurityContext: &corev1.SecurityContext{
+			Capabilities: &corev1.Capabilities{Add: []corev1.Capability{"CHOWN"}},
+		}}).Build()
+	privilegedPod := testutils.NewPodBuilder().WithName("agent").WithNamespace("shop").WithHostNetwork().
+		WithContainer(corev1.Container{
+			Name: "agent",
+			SecurityContext: &corev1.SecurityContext{
+				Privileged:               &yes,
+				AllowPrivilegeEscalation: &no,
+				RunAsNonRoot:             &yes,
+				SeccompProfile:           &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+				Capabilities: &corev1.Capabilities{
+					Add:  []corev1.Capability{"NET_ADMIN"},
+					Drop: []corev1.Capability{"ALL"},
+				},
+			},
+			Ports: []corev1.ContainerPort{{ContainerPort: 9100, HostPort: 9100}},
+		}).Build()
+	privilegedPod.Spec.Volumes = []corev1.Volume{
+		{Name: "logs", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/var/log"}}},
+	}
+	unmaskedProcMount := corev1.UnmaskedProcMount
+	unconfinedPod := testutils.NewPodBuilder().WithName("debug").WithNamespace("shop").
+		WithContainer(corev1.Container{Name: "app", SecurityContext: &corev1.SecurityContext{
+			AllowPrivilegeEscalation: &no,
+			RunAsNonRoot:             &yes,
+			SeccompProfile:           &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeUnconfined},
+			ProcMount:                &unmaskedProcMount,
+			SELinuxOptions:           &corev1.SELinuxOptions{Type: "spc_t"},
+			Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+		}}).
+		WithContainer(corev1.Container{Name: "sidecar", SecurityContext: restrictedContext}).Build()
+	unconfinedPod.Annotations = map[string]string{
+		"container.apparmor.security.beta.kubernetes.io/app":     "unconfined",
+		"container.apparmor.security.beta.kubernetes.io/sidecar": "localhost/sidecar",
+	}
+	unconfinedPod.Spec.SecurityContext = &corev1.PodSecurityContext{
+		SELinuxOptions: &corev1.SELinuxOptions{User: "system_u"},
+		Sysctls: []corev1.Sysctl{
+			{Name: "net.ipv4.tcp_syncookies", Value: "1"},
+			{Name: "kernel.msgmax", Value: "65536"},
+		},
+	}
+	tests := []struct {
+		name                  string
+		args                  args
+		expectedPodSecurities []*types.PodSecurity
+	}{
+		{
+			name: "a pod hardening all its containers satisfies the restricted level",
+			args: args{
+				clusterState: ClusterState{
+					Namespaces: []*corev1.Namespace{restrictedNamespace},
+					Pods:       []*corev1.Pod{restrictedPod},
+				},
+			},
+			expectedPodSecurities: []*types.PodSecurity{
+				{
+					Pod:        types.PodRef{Name: "api", Namespace: "shop"},
+					Level:      types.PodSecurityLevelRestricted,
+					Violations: []types.PodSecurityViolation{},
+					NamespaceLevels: map[string]types.PodSecurityLevel{
+						"enforce": types.PodSecurityLevelBaseline,
+						"warn":    types.PodSecurityLevelRestricted,
+					},
+					ViolatedModes: []string{},
+				},
+			},
+		},
+		{
+			name: "a pod without security context only satisfies the baseline level",
+			args: args{
+				clusterState: ClusterState{
+					Namespaces: []*corev1.Namespace{restrictedNamespace},
+					Pods:       []*corev1.Pod{baselinePod},
+				},
+			},
+			expectedPodSecurities: []*types.PodSecurity{
+				{
+					Pod:   types.PodRef{Name: "web", Namespace: "shop"},
+					Level: types.PodSecurityLevelBaseline,
+					Violations: []types.PodSecurityViolation{
+						{Check: Capabilities, Level: types.PodSecurityLevelRestricted, Container: "app",
+							Message: "container does not drop all capabilities"},
+						{Check: Capabilities, Level: types.PodSecurityLevelRestricted, Container: "app",
+							Message: "container adds capability CHOWN"},
+						{Check: PrivilegeEscalation, Level: types.PodSecurityLevelRestricted, Container: "app",
+							Message: "container allows privilege escalation"},
+						{Check: RunAsNonRoot, Level: types.PodSecurityLevelRestricted, Container: "app",
+							Message: "container may run as root"},
+						{Check: SeccompProfile, Level: types.PodSecurityLevelRestricted, Container: "app",
+							Message: "container does not use the RuntimeDefault or Localhost seccomp profile"},
+					},
+					NamespaceLevels: map[string]types.PodSecurityLevel{
+						"enforce": types.PodSecurityLevelBaseline,
+						"warn":    types.PodSecurityLevelRestricted,
+					},
+					ViolatedModes: []string{"warn"},
+				},
+			},
+		},
+		{
+			name: "a pod using host namespaces, host paths, host ports or extra capabilities is privileged",
+			args: args{
+				clusterState: ClusterState{
+					Namespaces: []*corev1.Namespace{restrictedNamespace},
+					Pods:       []*corev1.Pod{privilegedPod},
+				},
+			},
+			expectedPodSecurities: []*types.PodSecurity{
+				{
+					Pod:         types.PodRef{Name: "agent", Namespace: "shop"},
+					Level:       types.PodSecurityLevelPrivileged,
+					HostNetwork: true,
+					Violations: []types.PodSecurityViolation{
+						{Check: Capabilities, Level: types.PodSecurityLevelBaseline, Container: "agent",
+							Message: "container adds capability NET_ADMIN"},
+						{Check: HostNamespaces, Level: types.PodSecurityLevelBaseline,
+							Message: "pod shares the host network namespace"},
+						{Check: HostPathVolumes, Level: types.PodSecurityLevelBaseline,
+							Message: "volume logs mounts host path /var/log"},
+						{Check: HostPorts, Level: types.PodSecurityLevelBaseline, Container: "agent",
+							Message: "container binds host port 9100"},
+						{Check: Privileged, Level: types.PodSecurityLevelBaseline, Container: "agent",
+							Message: "container is privileged"},
+					},
+					NamespaceLevels: map[string]types.PodSecurityLevel{
+						"enforce": types.PodSecurityLevelBaseline,
+						"warn":    types.PodSecurityLevelRestricted,
+					},
+					ViolatedModes: []string{"enforce", "warn"},
+				},
+			},
+		},
+		{
+			name: "a pod lifting seccomp, AppArmor, SELinux, proc mount or sysctl confinement is privileged",
+			args: args{
+				clusterState: ClusterState{
+					Pods: []*corev1.Pod{unconfinedPod},
+				},
+			},
+			expectedPodSecurities: []*types.PodSecurity{
+				{
+					Pod:   types.PodRef{Name: "debug", Namespace: "shop"},
+					Level: types.PodSecurityLevelPrivileged,
+					Violations: []types.PodSecurityViolation{
+						{Check: AppArmor, Level: types.PodSecurityLevelBaseline, Container: "app",
+							Message: "container uses the unconfined AppArmor profile"},
+						{Check: ProcMount, Level: types.PodSecurityLevelBaseline, Container: "app",
+							Message: "container uses the Unmasked proc mount"},
+						{Check: SELinux, Level: types.PodSecurityLevelBaseline,
+							Message: "pod sets SELinux user system_u"},
+						{Check: SELinux, Level: types.PodSecurityLevelBaseline, Container: "app",
+							Message: "container sets SELinux type spc_t"},
+						{Check: SeccompProfile, Level: types.PodSecurityLevelBaseline, Container: "app",
+							Message: "container uses the Unconfined seccomp profile"},
+						{Check: Sysctls, Level: types.PodSecurityLevelBaseline,
+							Message: "pod sets unsafe sysctl kernel.msgmax"},
+					},
+					NamespaceLevels: map[string]types.PodSecurityLevel{},
+					ViolatedModes:   []string{},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := NewAnalyzer()
+			analysisResult := analyzer.Analyze(tt.args.clusterState)
+			if diff := cmp.Diff(tt.expectedPodSecurities, analysisResult.Pods); diff != "" {
+				t.Errorf("Analyze() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
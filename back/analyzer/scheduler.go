@@ -1,55 +1,159 @@
 package analyzer
 
 import (
+	"karto/analyzer/compliance"
+	"karto/analyzer/configuration"
+	"karto/analyzer/crosscluster"
+	"karto/analyzer/exposure"
 	"karto/analyzer/health"
+	"karto/analyzer/lint"
+	"karto/analyzer/mesh"
 	"karto/analyzer/pod"
+	"karto/analyzer/podsecurity"
+	"karto/analyzer/shared"
 	"karto/analyzer/traffic"
 	"karto/analyzer/workload"
+	"karto/commons"
 	"karto/types"
 	"log"
+	"reflect"
+	"sort"
 	"time"
 )
 
 type AnalysisScheduler interface {
 	AnalyzeOnClusterStateChange(clusterStateChannel <-chan types.ClusterState,
 		resultsChannel chan<- types.AnalysisResult)
+	Analyze(clusterState types.ClusterState) types.AnalysisResult
 }
 
 type analysisSchedulerImpl struct {
-	podAnalyzer      pod.Analyzer
-	trafficAnalyzer  traffic.Analyzer
-	workloadAnalyzer workload.Analyzer
-	healthAnalyzer   health.Analyzer
+	podAnalyzer           pod.Analyzer
+	trafficAnalyzer       traffic.Analyzer
+	workloadAnalyzer      workload.Analyzer
+	healthAnalyzer        health.Analyzer
+	configurationAnalyzer configuration.Analyzer
+	lintAnalyzer          lint.Analyzer
+	complianceAnalyzer    compliance.Analyzer
+	meshAnalyzer          mesh.Analyzer
+	crossClusterAnalyzer  crosscluster.Analyzer
+	exposureAnalyzer      exposure.Analyzer
+	podSecurityAnalyzer   podsecurity.Analyzer
 }
 
 func NewAnalysisScheduler(podAnalyzer pod.Analyzer, trafficAnalyzer traffic.Analyzer,
-	workloadAnalyzer workload.Analyzer, healthAnalyzer health.Analyzer) AnalysisScheduler {
+	workloadAnalyzer workload.Analyzer, healthAnalyzer health.Analyzer,
+	configurationAnalyzer configuration.Analyzer, lintAnalyzer lint.Analyzer,
+	complianceAnalyzer compliance.Analyzer, meshAnalyzer mesh.Analyzer,
+	crossClusterAnalyzer crosscluster.Analyzer, exposureAnalyzer exposure.Analyzer,
+	podSecurityAnalyzer podsecurity.Analyzer) AnalysisScheduler {
 	return analysisSchedulerImpl{
-		podAnalyzer:      podAnalyzer,
-		trafficAnalyzer:  trafficAnalyzer,
-		workloadAnalyzer: workloadAnalyzer,
-		healthAnalyzer:   healthAnalyzer,
+		podAnalyzer:           podAnalyzer,
+		trafficAnalyzer:       trafficAnalyzer,
+		workloadAnalyzer:      workloadAnalyzer,
+		healthAnalyzer:        healthAnalyzer,
+		configurationAnalyzer: configurationAnalyzer,
+		lintAnalyzer:          lintAnalyzer,
+		complianceAnalyzer:    complianceAnalyzer,
+		meshAnalyzer:          meshAnalyzer,
+		crossClusterAnalyzer:  crossClusterAnalyzer,
+		exposureAnalyzer:      exposureAnalyzer,
+		podSecurityAnalyzer:   podSecurityAnalyzer,
 	}
 }
 
 func (analysisScheduler analysisSchedulerImpl) AnalyzeOnClusterStateChange(
 	clusterStateChannel <-chan types.ClusterState, resultsChannel chan<- types.AnalysisResult) {
+	clusterStates := make(map[string]*types.ClusterState)
+	analysisResults := make(map[string]types.AnalysisResult)
+	podIsolations := make(map[string][]*shared.PodIsolation)
+	publishedResults := make(map[string]types.AnalysisResult)
+	pendingClusterStates := newPendingClusterStates()
+	go pendingClusterStates.receive(clusterStateChannel)
 	for {
-		clusterState := <-clusterStateChannel
-		analysisResult := analysisScheduler.analyze(clusterState)
-		resultsChannel <- analysisResult
+		clusterState := pendingClusterStates.next()
+		previousClusterState, wasWatched := clusterStates[clusterState.Cluster]
+		tookPart := wasWatched && crosscluster.TakesPart(previousClusterState)
+		clusterStates[clusterState.Cluster] = &clusterState
+		analysisResults[clusterState.Cluster], podIsolations[clusterState.Cluster] =
+			analysisScheduler.analyze(clusterState)
+		if len(clusterStates) == 1 || !(tookPart || crosscluster.TakesPart(&clusterState)) {
+			publishedResults[clusterState.Cluster] = analysisResults[clusterState.Cluster]
+			resultsChannel <- analysisResults[clusterState.Cluster]
+			continue
+		}
+		for _, analysisResult := range analysisScheduler.withCrossClusterRoutes(clusterStates, analysisResults,
+			podIsolations) {
+			published, ok := publishedResults[analysisResult.Cluster]
+			if analysisResult.Cluster != clusterState.Cluster && ok &&
+				sameCrossClusterRoutes(published.CrossClusterRoutes, analysisResult.CrossClusterRoutes) {
+				continue
+			}
+			publishedResults[analysisResult.Cluster] = analysisResult
+			resultsChannel <- analysisResult
+		}
 	}
 }
 
-func (analysisScheduler analysisSchedulerImpl) analyze(clusterState types.ClusterState) types.AnalysisResult {
+func sameCrossClusterRoutes(routes []*types.CrossClusterRoute, otherRoutes []*types.CrossClusterRoute) bool {
+	return len(routes) == 0 && len(otherRoutes) == 0 || reflect.DeepEqual(routes, otherRoutes)
+}
+
+func (analysisScheduler analysisSchedulerImpl) withCrossClusterRoutes(
+	clusterStates map[string]*types.ClusterState,
+	analysisResults map[string]types.AnalysisResult,
+	podIsolations map[string][]*shared.PodIsolation,
+) []types.AnalysisResult {
+	clusters := make([]string, 0, len(clusterStates))
+	for cluster := range clusterStates {
+		clusters = append(clusters, cluster)
+	}
+	sort.Strings(clusters)
+	crossClusterResult := analysisScheduler.crossClusterAnalyzer.Analyze(crosscluster.ClusterState{
+		Clusters:      commons.Map(clusters, func(cluster string) *types.ClusterState { return clusterStates[cluster] }),
+		PodIsolations: podIsolations,
+	})
+	result := make([]types.AnalysisResult, 0, len(clusters))
+	for _, cluster := range clusters {
+		analysisResult := analysisResults[cluster]
+		analysisResult.CrossClusterRoutes = commons.Filter(crossClusterResult.Routes,
+			func(route *types.CrossClusterRoute) bool {
+				return route.SourceCluster == cluster || route.TargetCluster == cluster
+			})
+		result = append(result, analysisResult)
+	}
+	return result
+}
+
+func (analysisScheduler analysisSchedulerImpl) Analyze(clusterState types.ClusterState) types.AnalysisResult {
+	analysisResult, _ := analysisScheduler.analyze(clusterState)
+	return analysisResult
+}
+
+func (analysisScheduler analysisSchedulerImpl) analyze(
+	clusterState types.ClusterState,
+) (types.AnalysisResult, []*shared.PodIsolation) {
 	start := time.Now()
 	podsResult := analysisScheduler.podAnalyzer.Analyze(pod.ClusterState{
 		Pods: clusterState.Pods,
 	})
 	trafficResult := analysisScheduler.trafficAnalyzer.Analyze(traffic.ClusterState{
-		Pods:            clusterState.Pods,
-		Namespaces:      clusterState.Namespaces,
-		NetworkPolicies: clusterState.NetworkPolicies,
+		Pods:                  clusterState.Pods,
+		Namespaces:            clusterState.Namespaces,
+		NetworkPolicies:       clusterState.NetworkPolicies,
+		CiliumNetworkPolicies: clusterState.CiliumNetworkPolicies,
+		CalicoNetworkPolicies: clusterState.CalicoNetworkPolicies,
+		CalicoTiers:           clusterState.CalicoTiers,
+		AdminNetworkPolicies:  clusterState.AdminNetworkPolicies,
+		Services:              clusterState.Services,
+		ServiceEntries:        clusterState.ServiceEntries,
+	})
+	meshResult := analysisScheduler.meshAnalyzer.Analyze(mesh.ClusterState{
+		Namespaces:            clusterState.Namespaces,
+		Pods:                  clusterState.Pods,
+		AllowedRoutes:         trafficResult.AllowedRoutes,
+		AuthorizationPolicies: clusterState.AuthorizationPolicies,
+		PeerAuthentications:   clusterState.PeerAuthentications,
 	})
 	workloadResult := analysisScheduler.workloadAnalyzer.Analyze(workload.ClusterState{
 		Pods:         clusterState.Pods,
@@ -60,12 +164,53 @@ func (analysisScheduler analysisSchedulerImpl) analyze(clusterState types.Cluste
 		DaemonSets:   clusterState.DaemonSets,
 		Deployments:  clusterState.Deployments,
 	})
+	podSecurityResult := analysisScheduler.podSecurityAnalyzer.Analyze(podsecurity.ClusterState{
+		Namespaces: clusterState.Namespaces,
+		Pods:       clusterState.Pods,
+	})
+	exposureResult := analysisScheduler.exposureAnalyzer.Analyze(exposure.ClusterState{
+		Pods:           clusterState.Pods,
+		Services:       clusterState.Services,
+		ServiceTargets: workloadResult.Services,
+		IngressTargets: workloadResult.Ingresses,
+		AllowedRoutes:  trafficResult.AllowedRoutes,
+		PodSecurities:  podSecurityResult.Pods,
+	})
 	healthResult := analysisScheduler.healthAnalyzer.Analyze(health.ClusterState{
-		Pods: clusterState.Pods,
+		Pods:         clusterState.Pods,
+		Services:     clusterState.Services,
+		StatefulSets: clusterState.StatefulSets,
+		DaemonSets:   clusterState.DaemonSets,
+		Deployments:  clusterState.Deployments,
+		Events:       clusterState.Events,
+	})
+	configurationResult := analysisScheduler.configurationAnalyzer.Analyze(configuration.ClusterState{
+		Namespaces:   clusterState.Namespaces,
+		Pods:         clusterState.Pods,
+		Services:     clusterState.Services,
+		ReplicaSets:  clusterState.ReplicaSets,
+		StatefulSets: clusterState.StatefulSets,
+		DaemonSets:   clusterState.DaemonSets,
+		Deployments:  clusterState.Deployments,
+	})
+	lintResult := analysisScheduler.lintAnalyzer.Analyze(lint.ClusterState{
+		Namespaces:      clusterState.Namespaces,
+		Pods:            clusterState.Pods,
+		NetworkPolicies: clusterState.NetworkPolicies,
+	})
+	complianceResult := analysisScheduler.complianceAnalyzer.Analyze(compliance.ClusterState{
+		Namespaces:      clusterState.Namespaces,
+		NetworkPolicies: clusterState.NetworkPolicies,
+		PodIsolations:   trafficResult.PodIsolations,
 	})
 	pods := podsResult.Pods
 	podIsolations := trafficResult.Pods
 	allowedRoutes := trafficResult.AllowedRoutes
+	externalRoutes := trafficResult.ExternalRoutes
+	externalNodes := trafficResult.ExternalNodes
+	externalAccesses := trafficResult.ExternalAccesses
+	meshRoutes := meshResult.Routes
+	exposedPods := exposureResult.Pods
 	services := workloadResult.Services
 	ingresses := workloadResult.Ingresses
 	replicaSets := workloadResult.ReplicaSets
@@ -73,20 +218,49 @@ func (analysisScheduler analysisSchedulerImpl) analyze(clusterState types.Cluste
 	daemonSets := workloadResult.DaemonSets
 	deployments := workloadResult.Deployments
 	podHealths := healthResult.Pods
+	serviceHealths := healthResult.Services
+	statefulSetHealths := healthResult.StatefulSets
+	daemonSetHealths := healthResult.DaemonSets
+	deploymentHealths := healthResult.Deployments
+	warnings := healthResult.Warnings
+	podConfigurations := configurationResult.Pods
+	workloadConfigurations := configurationResult.Workloads
+	podSecurities := podSecurityResult.Pods
+	findings := lintResult.Findings
+	complianceReport := complianceResult.Report
 	elapsed := time.Since(start)
+	completedAnalyses.Add(1)
+	lastAnalysisDuration.Set(elapsed.Seconds())
 	log.Printf("Finished analysis in %s, found: %d pods, %d allowed routes, %d services, %d ingresses, "+
 		"%d replicaSets, %d statefulSets, %d daemonSets and %d deployments\n", elapsed, len(pods), len(allowedRoutes),
 		len(services), len(ingresses), len(replicaSets), len(statefulSets), len(daemonSets), len(deployments))
 	return types.AnalysisResult{
-		Pods:          pods,
-		PodIsolations: podIsolations,
-		AllowedRoutes: allowedRoutes,
-		Services:      services,
-		Ingresses:     ingresses,
-		ReplicaSets:   replicaSets,
-		StatefulSets:  statefulSets,
-		DaemonSets:    daemonSets,
-		Deployments:   deployments,
-		PodHealths:    podHealths,
-	}
+		Cluster:                clusterState.Cluster,
+		Pods:                   pods,
+		PodIsolations:          podIsolations,
+		AllowedRoutes:          allowedRoutes,
+		ExternalRoutes:         externalRoutes,
+		ExternalNodes:          externalNodes,
+		ExternalAccesses:       externalAccesses,
+		MeshRoutes:             meshRoutes,
+		ExposedPods:            exposedPods,
+		Services:               services,
+		Ingresses:              ingresses,
+		ReplicaSets:            replicaSets,
+		StatefulSets:           statefulSets,
+		DaemonSets:             daemonSets,
+		Deployments:            deployments,
+		PodHealths:             podHealths,
+		DeploymentHealths:      deploymentHealths,
+		StatefulSetHealths:     statefulSetHealths,
+		DaemonSetHealths:       daemonSetHealths,
+		ServiceHealths:         serviceHealths,
+		Warnings:               warnings,
+		PodConfigurations:      podConfigurations,
+		WorkloadConfigurations: workloadConfigurations,
+		PodSecurities:          podSecurities,
+		Findings:               findings,
+		Compliance:             complianceReport,
+		Capabilities:           capabilities(clusterState.ForbiddenResources),
+	}, trafficResult.PodIsolations
 }
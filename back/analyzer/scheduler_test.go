@@ -5,10 +5,20 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"karto/analyzer/compliance"
+	"karto/analyzer/configuration"
+	"karto/analyzer/crosscluster"
+	"karto/analyzer/exposure"
 	"karto/analyzer/health"
+	"karto/analyzer/lint"
+	"karto/analyzer/mesh"
 	"karto/analyzer/pod"
+	"karto/analyzer/podsecurity"
+	"karto/analyzer/shared"
 	"karto/analyzer/traffic"
 	"karto/analyzer/workload"
+	"karto/crds/mcs"
 	"karto/testutils"
 	"karto/types"
 	"reflect"
@@ -21,10 +31,16 @@ func TestAnalyze(t *testing.T) {
 		clusterState types.ClusterState
 	}
 	type mocks struct {
-		pods     []mockPodAnalyzerCall
-		traffic  []mockTrafficAnalyzerCall
-		workload []mockWorkloadAnalyzerCall
-		health   []mockHealthAnalyzerCall
+		pods       []mockPodAnalyzerCall
+		traffic    []mockTrafficAnalyzerCall
+		workload   []mockWorkloadAnalyzerCall
+		health     []mockHealthAnalyzerCall
+		config     []mockConfigurationAnalyzerCall
+		lint       []mockLintAnalyzerCall
+		compliance []mockComplianceAnalyzerCall
+		mesh       []mockMeshAnalyzerCall
+		exposure   []mockExposureAnalyzerCall
+		security   []mockPodSecurityAnalyzerCall
 	}
 	k8sNamespace := testutils.NewNamespaceBuilder().WithName("ns").Build()
 	k8sPod1 := testutils.NewPodBuilder().WithName("pod1").WithNamespace("ns").
@@ -50,10 +66,13 @@ func TestAnalyze(t *testing.T) {
 	k8sDaemonSet2 := testutils.NewDaemonSetBuilder().WithName("rs2").WithNamespace("ns").Build()
 	k8sDeployment1 := testutils.NewDeploymentBuilder().WithName("deploy1").WithNamespace("ns").Build()
 	k8sDeployment2 := testutils.NewDeploymentBuilder().WithName("deploy2").WithNamespace("ns").Build()
+	k8sEvent := testutils.NewEventBuilder().WithInvolvedObject("Pod", "ns", "pod1").WithReason("BackOff").Build()
 	pod1 := &types.Pod{Name: k8sPod1.Name, Namespace: k8sPod1.Namespace, Labels: k8sPod1.Labels}
 	pod2 := &types.Pod{Name: k8sPod2.Name, Namespace: k8sPod2.Namespace, Labels: k8sPod2.Labels}
 	podRef1 := types.PodRef{Name: k8sPod1.Name, Namespace: k8sPod1.Namespace}
 	podRef2 := types.PodRef{Name: k8sPod2.Name, Namespace: k8sPod2.Namespace}
+	sharedPodIsolation1 := &shared.PodIsolation{Pod: k8sPod1}
+	sharedPodIsolation2 := &shared.PodIsolation{Pod: k8sPod2}
 	podIsolation1 := &types.PodIsolation{Pod: podRef1, IsIngressIsolated: false, IsEgressIsolated: false}
 	podIsolation2 := &types.PodIsolation{Pod: podRef2, IsIngressIsolated: false, IsEgressIsolated: false}
 	networkPolicy1 := types.NetworkPolicy{Name: k8sNetworkPolicy1.Name, Namespace: k8sNetworkPolicy1.Namespace,
@@ -62,6 +81,23 @@ func TestAnalyze(t *testing.T) {
 		Labels: k8sNetworkPolicy2.Labels}
 	allowedRoute := &types.AllowedRoute{SourcePod: podRef1, EgressPolicies: []types.NetworkPolicy{networkPolicy1},
 		TargetPod: podRef2, IngressPolicies: []types.NetworkPolicy{networkPolicy2}, Ports: []int32{80, 443}}
+	externalRoute := &types.ExternalRoute{Pod: podRef1, Direction: types.TrafficEgress,
+		Peer:     types.ExternalPeer{Kind: types.ExternalPeerFQDN, Name: "example.com"},
+		Policies: []types.NetworkPolicy{networkPolicy1}, Ports: []int32{443}}
+	externalNode := &types.ExternalNode{Kind: types.ExternalPeerFQDN, Name: "example.com",
+		Sources: []types.ObjectRef{{Kind: "NetworkPolicy", Name: networkPolicy1.Name,
+			Namespace: networkPolicy1.Namespace}}}
+	externalAccess := &types.ExternalAccess{Pod: podRef1,
+		Node:     types.ExternalPeer{Kind: types.ExternalPeerFQDN, Name: "example.com"},
+		Policies: []types.NetworkPolicy{networkPolicy1}, Ports: []int32{443}}
+	meshRoute := &types.MeshRoute{SourcePod: podRef1, TargetPod: podRef2, Decision: types.MeshDecisionAllowed,
+		MTLSMode: "PERMISSIVE", MutualTLS: true, Policies: []types.NetworkPolicy{}, Ports: []int32{80, 443}}
+	exposedPod := &types.ExposedPod{Pod: podRef1, EntryPoints: []types.ObjectRef{{Kind: "Ingress",
+		Name: k8sIngress1.Name, Namespace: k8sIngress1.Namespace}}, Path: []types.PodRef{podRef1},
+		Secrets: []string{}, Risk: types.ExposureRiskLow}
+	podSecurity := &types.PodSecurity{Pod: podRef1, Level: types.PodSecurityLevelRestricted,
+		Violations: []types.PodSecurityViolation{}, NamespaceLevels: map[string]types.PodSecurityLevel{},
+		ViolatedModes: []string{}}
 	service1 := &types.Service{Name: k8sService1.Name, Namespace: k8sService1.Namespace,
 		TargetPods: []types.PodRef{podRef1}}
 	service2 := &types.Service{Name: k8sService2.Name, Namespace: k8sService2.Namespace,
@@ -94,6 +130,34 @@ func TestAnalyze(t *testing.T) {
 		ContainersWithoutRestart: 1}
 	podHealth2 := &types.PodHealth{Pod: podRef2, Containers: 2, ContainersRunning: 1, ContainersReady: 0,
 		ContainersWithoutRestart: 2}
+	serviceHealth := &types.ServiceHealth{Service: serviceRef1, Endpoints: 1, EndpointsAvailable: 0,
+		Status: types.HealthStatusDown}
+	statefulSetHealth := &types.StatefulSetHealth{
+		StatefulSet:     types.StatefulSetRef{Name: k8sStatefulSet1.Name, Namespace: k8sStatefulSet1.Namespace},
+		DesiredReplicas: 1, AvailableReplicas: 1, Status: types.HealthStatusHealthy}
+	daemonSetHealth := &types.DaemonSetHealth{
+		DaemonSet:       types.DaemonSetRef{Name: k8sDaemonSet1.Name, Namespace: k8sDaemonSet1.Namespace},
+		DesiredReplicas: 2, AvailableReplicas: 1, Status: types.HealthStatusDegraded}
+	deploymentHealth := &types.DeploymentHealth{
+		Deployment:      types.DeploymentRef{Name: k8sDeployment1.Name, Namespace: k8sDeployment1.Namespace},
+		DesiredReplicas: 1, AvailableReplicas: 1, Status: types.HealthStatusHealthy}
+	objectWarnings := &types.ObjectWarnings{Object: types.ObjectRef{Kind: "Pod", Name: "pod1", Namespace: "ns"},
+		Warnings: []types.Warning{{Reason: "BackOff", Count: 1}}}
+	podConfiguration := &types.PodConfiguration{Pod: podRef1, QoSClass: "BestEffort",
+		Findings: []types.ConfigurationFinding{{Code: "NoMemoryLimit", Severity: types.FindingSeverityWarning,
+			Container: "app", Message: "no memory limit"}}}
+	workloadConfiguration := &types.WorkloadConfiguration{
+		Workload: types.ObjectRef{Kind: "Deployment", Name: "deploy1", Namespace: "ns"}, Pods: 1,
+		Findings: podConfiguration.Findings}
+	finding := &types.Finding{ID: "NP001", Severity: types.FindingSeverityWarning,
+		Objects: []types.ObjectRef{{Kind: "NetworkPolicy", Name: "netPol1", Namespace: "ns"}},
+		Message: "policy selects no pod"}
+	complianceReport := &types.ComplianceReport{
+		Coverage: types.IsolationCoverage{Pods: 2, PodsReachableFromAllNamespaces: []types.PodRef{podRef1, podRef2},
+			PodsWithUnrestrictedEgress: []types.PodRef{podRef1, podRef2}},
+		NamespacesWithoutIngressDefaultDeny: []string{"ns"},
+		NamespacesWithoutEgressDefaultDeny:  []string{"ns"},
+	}
 	tests := []struct {
 		name                   string
 		mocks                  mocks
@@ -119,10 +183,15 @@ func TestAnalyze(t *testing.T) {
 							Pods:            []*corev1.Pod{k8sPod1, k8sPod2},
 							Namespaces:      []*corev1.Namespace{k8sNamespace},
 							NetworkPolicies: []*networkingv1.NetworkPolicy{k8sNetworkPolicy1, k8sNetworkPolicy2},
+							Services:        []*corev1.Service{k8sService1, k8sService2},
 						},
 						returnValue: traffic.AnalysisResult{
-							Pods:          []*types.PodIsolation{podIsolation1, podIsolation2},
-							AllowedRoutes: []*types.AllowedRoute{allowedRoute},
+							Pods:             []*types.PodIsolation{podIsolation1, podIsolation2},
+							PodIsolations:    []*shared.PodIsolation{sharedPodIsolation1, sharedPodIsolation2},
+							AllowedRoutes:    []*types.AllowedRoute{allowedRoute},
+							ExternalRoutes:   []*types.ExternalRoute{externalRoute},
+							ExternalNodes:    []*types.ExternalNode{externalNode},
+							ExternalAccesses: []*types.ExternalAccess{externalAccess},
 						},
 					},
 				},
@@ -150,16 +219,106 @@ func TestAnalyze(t *testing.T) {
 				health: []mockHealthAnalyzerCall{
 					{
 						clusterState: health.ClusterState{
-							Pods: []*corev1.Pod{k8sPod1, k8sPod2},
+							Pods:         []*corev1.Pod{k8sPod1, k8sPod2},
+							Services:     []*corev1.Service{k8sService1, k8sService2},
+							StatefulSets: []*appsv1.StatefulSet{k8sStatefulSet1, k8sStatefulSet2},
+							DaemonSets:   []*appsv1.DaemonSet{k8sDaemonSet1, k8sDaemonSet2},
+							Deployments:  []*appsv1.Deployment{k8sDeployment1, k8sDeployment2},
+							Events:       []*corev1.Event{k8sEvent},
 						},
 						returnValue: health.AnalysisResult{
-							Pods: []*types.PodHealth{podHealth1, podHealth2},
+							Pods:         []*types.PodHealth{podHealth1, podHealth2},
+							Services:     []*types.ServiceHealth{serviceHealth},
+							StatefulSets: []*types.StatefulSetHealth{statefulSetHealth},
+							DaemonSets:   []*types.DaemonSetHealth{daemonSetHealth},
+							Deployments:  []*types.DeploymentHealth{deploymentHealth},
+							Warnings:     []*types.ObjectWarnings{objectWarnings},
+						},
+					},
+				},
+				config: []mockConfigurationAnalyzerCall{
+					{
+						clusterState: configuration.ClusterState{
+							Namespaces:   []*corev1.Namespace{k8sNamespace},
+							Pods:         []*corev1.Pod{k8sPod1, k8sPod2},
+							Services:     []*corev1.Service{k8sService1, k8sService2},
+							ReplicaSets:  []*appsv1.ReplicaSet{k8sReplicaSet1, k8sReplicaSet2},
+							StatefulSets: []*appsv1.StatefulSet{k8sStatefulSet1, k8sStatefulSet2},
+							DaemonSets:   []*appsv1.DaemonSet{k8sDaemonSet1, k8sDaemonSet2},
+							Deployments:  []*appsv1.Deployment{k8sDeployment1, k8sDeployment2},
+						},
+						returnValue: configuration.AnalysisResult{
+							Pods:      []*types.PodConfiguration{podConfiguration},
+							Workloads: []*types.WorkloadConfiguration{workloadConfiguration},
+						},
+					},
+				},
+				lint: []mockLintAnalyzerCall{
+					{
+						clusterState: lint.ClusterState{
+							Namespaces:      []*corev1.Namespace{k8sNamespace},
+							Pods:            []*corev1.Pod{k8sPod1, k8sPod2},
+							NetworkPolicies: []*networkingv1.NetworkPolicy{k8sNetworkPolicy1, k8sNetworkPolicy2},
+						},
+						returnValue: lint.AnalysisResult{
+							Findings: []*types.Finding{finding},
+						},
+					},
+				},
+				compliance: []mockComplianceAnalyzerCall{
+					{
+						clusterState: compliance.ClusterState{
+							Namespaces:      []*corev1.Namespace{k8sNamespace},
+							NetworkPolicies: []*networkingv1.NetworkPolicy{k8sNetworkPolicy1, k8sNetworkPolicy2},
+							PodIsolations:   []*shared.PodIsolation{sharedPodIsolation1, sharedPodIsolation2},
+						},
+						returnValue: compliance.AnalysisResult{
+							Report: complianceReport,
+						},
+					},
+				},
+				mesh: []mockMeshAnalyzerCall{
+					{
+						clusterState: mesh.ClusterState{
+							Namespaces:    []*corev1.Namespace{k8sNamespace},
+							Pods:          []*corev1.Pod{k8sPod1, k8sPod2},
+							AllowedRoutes: []*types.AllowedRoute{allowedRoute},
+						},
+						returnValue: mesh.AnalysisResult{
+							Routes: []*types.MeshRoute{meshRoute},
+						},
+					},
+				},
+				exposure: []mockExposureAnalyzerCall{
+					{
+						clusterState: exposure.ClusterState{
+							Pods:           []*corev1.Pod{k8sPod1, k8sPod2},
+							Services:       []*corev1.Service{k8sService1, k8sService2},
+							ServiceTargets: []*types.Service{service1, service2},
+							IngressTargets: []*types.Ingress{ingress1, ingress2},
+							AllowedRoutes:  []*types.AllowedRoute{allowedRoute},
+							PodSecurities:  []*types.PodSecurity{podSecurity},
+						},
+						returnValue: exposure.AnalysisResult{
+							Pods: []*types.ExposedPod{exposedPod},
+						},
+					},
+				},
+				security: []mockPodSecurityAnalyzerCall{
+					{
+						clusterState: podsecurity.ClusterState{
+							Namespaces: []*corev1.Namespace{k8sNamespace},
+							Pods:       []*corev1.Pod{k8sPod1, k8sPod2},
+						},
+						returnValue: podsecurity.AnalysisResult{
+							Pods: []*types.PodSecurity{podSecurity},
 						},
 					},
 				},
 			},
 			args: args{
 				clusterState: types.ClusterState{
+					Cluster:         "prod",
 					Namespaces:      []*corev1.Namespace{k8sNamespace},
 					Pods:            []*corev1.Pod{k8sPod1, k8sPod2},
 					Services:        []*corev1.Service{k8sService1, k8sService2},
@@ -169,19 +328,47 @@ func TestAnalyze(t *testing.T) {
 					DaemonSets:      []*appsv1.DaemonSet{k8sDaemonSet1, k8sDaemonSet2},
 					Deployments:     []*appsv1.Deployment{k8sDeployment1, k8sDeployment2},
 					NetworkPolicies: []*networkingv1.NetworkPolicy{k8sNetworkPolicy1, k8sNetworkPolicy2},
+					Events:          []*corev1.Event{k8sEvent},
+					ForbiddenResources: []types.ForbiddenResource{
+						{Resource: "ingresses.networking.k8s.io", Namespace: "ns"},
+						{Resource: "events"},
+					},
 				},
 			},
 			expectedAnalysisResult: types.AnalysisResult{
-				Pods:          []*types.Pod{pod1, pod2},
-				PodIsolations: []*types.PodIsolation{podIsolation1, podIsolation2},
-				AllowedRoutes: []*types.AllowedRoute{allowedRoute},
-				Services:      []*types.Service{service1, service2},
-				Ingresses:     []*types.Ingress{ingress1, ingress2},
-				ReplicaSets:   []*types.ReplicaSet{replicaSet1, replicaSet2},
-				StatefulSets:  []*types.StatefulSet{statefulSet1, statefulSet2},
-				DaemonSets:    []*types.DaemonSet{daemonSet1, daemonSet2},
-				Deployments:   []*types.Deployment{deployment1, deployment2},
-				PodHealths:    []*types.PodHealth{podHealth1, podHealth2},
+				Cluster:                "prod",
+				Pods:                   []*types.Pod{pod1, pod2},
+				PodIsolations:          []*types.PodIsolation{podIsolation1, podIsolation2},
+				AllowedRoutes:          []*types.AllowedRoute{allowedRoute},
+				ExternalRoutes:         []*types.ExternalRoute{externalRoute},
+				ExternalNodes:          []*types.ExternalNode{externalNode},
+				ExternalAccesses:       []*types.ExternalAccess{externalAccess},
+				MeshRoutes:             []*types.MeshRoute{meshRoute},
+				ExposedPods:            []*types.ExposedPod{exposedPod},
+				Services:               []*types.Service{service1, service2},
+				Ingresses:              []*types.Ingress{ingress1, ingress2},
+				ReplicaSets:            []*types.ReplicaSet{replicaSet1, replicaSet2},
+				StatefulSets:           []*types.StatefulSet{statefulSet1, statefulSet2},
+				DaemonSets:             []*types.DaemonSet{daemonSet1, daemonSet2},
+				Deployments:            []*types.Deployment{deployment1, deployment2},
+				PodHealths:             []*types.PodHealth{podHealth1, podHealth2},
+				DeploymentHealths:      []*types.DeploymentHealth{deploymentHealth},
+				StatefulSetHealths:     []*types.StatefulSetHealth{statefulSetHealth},
+				DaemonSetHealths:       []*types.DaemonSetHealth{daemonSetHealth},
+				ServiceHealths:         []*types.ServiceHealth{serviceHealth},
+				Warnings:               []*types.ObjectWarnings{objectWarnings},
+				PodConfigurations:      []*types.PodConfiguration{podConfiguration},
+				WorkloadConfigurations: []*types.WorkloadConfiguration{workloadConfiguration},
+				PodSecurities:          []*types.PodSecurity{podSecurity},
+				Findings:               []*types.Finding{finding},
+				Compliance:             complianceReport,
+				Capabilities: &types.Capabilities{
+					ForbiddenResources: []types.ForbiddenResource{
+						{Resource: "ingresses.networking.k8s.io", Namespace: "ns"},
+						{Resource: "events"},
+					},
+					PartialAnalyses: []string{"exposure", "health", "workloads"},
+				},
 			},
 		},
 	}
@@ -191,7 +378,16 @@ func TestAnalyze(t *testing.T) {
 			trafficAnalyzer := createMockTrafficAnalyzer(t, tt.mocks.traffic)
 			workloadAnalyzer := createMockWorkloadAnalyzer(t, tt.mocks.workload)
 			healthAnalyzer := createMockHealthAnalyzer(t, tt.mocks.health)
-			analyzer := NewAnalysisScheduler(podAnalyzer, trafficAnalyzer, workloadAnalyzer, healthAnalyzer)
+			configurationAnalyzer := createMockConfigurationAnalyzer(t, tt.mocks.config)
+			lintAnalyzer := createMockLintAnalyzer(t, tt.mocks.lint)
+			complianceAnalyzer := createMockComplianceAnalyzer(t, tt.mocks.compliance)
+			meshAnalyzer := createMockMeshAnalyzer(t, tt.mocks.mesh)
+			crossClusterAnalyzer := createMockCrossClusterAnalyzer(t, nil)
+			exposureAnalyzer := createMockExposureAnalyzer(t, tt.mocks.exposure)
+			podSecurityAnalyzer := createMockPodSecurityAnalyzer(t, tt.mocks.security)
+			analyzer := NewAnalysisScheduler(podAnalyzer, trafficAnalyzer, workloadAnalyzer, healthAnalyzer,
+				configurationAnalyzer, lintAnalyzer, complianceAnalyzer, meshAnalyzer, crossClusterAnalyzer,
+				exposureAnalyzer, podSecurityAnalyzer)
 			clusterStateChannel := make(chan types.ClusterState)
 			resultsChannel := make(chan types.AnalysisResult)
 			go analyzer.AnalyzeOnClusterStateChange(clusterStateChannel, resultsChannel)
@@ -208,6 +404,79 @@ func TestAnalyze(t *testing.T) {
 	}
 }
 
+func TestAnalyzeOnClusterStateChangeAcrossClusters(t *testing.T) {
+	east := types.ClusterState{Cluster: "east", ServiceExports: []*mcs.ServiceExport{
+		{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "shop"}},
+	}}
+	west := types.ClusterState{Cluster: "west", ServiceImports: []*mcs.ServiceImport{
+		{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "shop"}},
+	}}
+	north := types.ClusterState{Cluster: "north"}
+	crossClusterRoute := &types.CrossClusterRoute{Mechanism: types.CrossClusterServiceExport,
+		Service: types.ServiceRef{Name: "api", Namespace: "shop"}, SourceCluster: "west",
+		SourcePod: types.PodRef{Name: "web", Namespace: "shop"}, EgressPolicies: []types.NetworkPolicy{},
+		TargetCluster: "east", TargetPod: types.PodRef{Name: "api", Namespace: "shop"},
+		IngressPolicies: []types.NetworkPolicy{}, Ports: []int32{8080}}
+	analyzer := NewAnalysisScheduler(
+		createMockPodAnalyzer(t, []mockPodAnalyzerCall{{}}),
+		createMockTrafficAnalyzer(t, []mockTrafficAnalyzerCall{{}}),
+		createMockWorkloadAnalyzer(t, []mockWorkloadAnalyzerCall{{}}),
+		createMockHealthAnalyzer(t, []mockHealthAnalyzerCall{{}}),
+		createMockConfigurationAnalyzer(t, []mockConfigurationAnalyzerCall{{}}),
+		createMockLintAnalyzer(t, []mockLintAnalyzerCall{{}}),
+		createMockComplianceAnalyzer(t, []mockComplianceAnalyzerCall{{}}),
+		createMockMeshAnalyzer(t, []mockMeshAnalyzerCall{{}}),
+		createMockCrossClusterAnalyzer(t, []mockCrossClusterAnalyzerCall{
+			{
+				clusterState: crosscluster.ClusterState{
+					Clusters:      []*types.ClusterState{&east, &west},
+					PodIsolations: map[string][]*shared.PodIsolation{"east": nil, "west": nil},
+				},
+				returnValue: crosscluster.AnalysisResult{Routes: []*types.CrossClusterRoute{crossClusterRoute}},
+			},
+			{
+				clusterState: crosscluster.ClusterState{
+					Clusters:      []*types.ClusterState{&east, &north, &west},
+					PodIsolations: map[string][]*shared.PodIsolation{"east": nil, "north": nil, "west": nil},
+				},
+				returnValue: crosscluster.AnalysisResult{Routes: []*types.CrossClusterRoute{crossClusterRoute}},
+			},
+		}),
+		createMockExposureAnalyzer(t, []mockExposureAnalyzerCall{{}}),
+		createMockPodSecurityAnalyzer(t, []mockPodSecurityAnalyzerCall{{}}),
+	)
+	clusterStateChannel := make(chan types.ClusterState)
+	resultsChannel := make(chan types.AnalysisResult)
+	go analyzer.AnalyzeOnClusterStateChange(clusterStateChannel, resultsChannel)
+	expectedAnalysisResults := [][]types.AnalysisResult{
+		{{Cluster: "east"}},
+		{
+			{Cluster: "east", CrossClusterRoutes: []*types.CrossClusterRoute{crossClusterRoute}},
+			{Cluster: "west", CrossClusterRoutes: []*types.CrossClusterRoute{crossClusterRoute}},
+		},
+		{{Cluster: "north"}},
+		{{Cluster: "west", CrossClusterRoutes: []*types.CrossClusterRoute{crossClusterRoute}}},
+	}
+	for i, clusterState := range []types.ClusterState{east, west, north, west} {
+		clusterStateChannel <- clusterState
+		for _, expectedAnalysisResult := range expectedAnalysisResults[i] {
+			select {
+			case analysisResult := <-resultsChannel:
+				if diff := cmp.Diff(expectedAnalysisResult, analysisResult); diff != "" {
+					t.Errorf("AnalyzeOnClusterStateChange() result mismatch (-want +got):\n%s", diff)
+				}
+			case <-time.After(3 * time.Second):
+				t.Errorf("Test timed out (nothing was received on the channel)")
+			}
+		}
+	}
+	select {
+	case analysisResult := <-resultsChannel:
+		t.Errorf("AnalyzeOnClusterStateChange() published an unchanged result: %v", analysisResult)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
 type mockPodAnalyzerCall struct {
 	clusterState pod.ClusterState
 	returnValue  pod.AnalysisResult
@@ -251,7 +520,7 @@ func (mock mockTrafficAnalyzer) Analyze(clusterState traffic.ClusterState) traff
 			return call.returnValue
 		}
 	}
-	mock.t.Fatalf("mockTrafficAnalyzer was called with unexpected arguments: \n\tclusterState: %s\n",
+	mock.t.Fatalf("mockTrafficAnalyzer was called with unexpected arguments: \n\tclusterState: %v\n",
 		clusterState)
 	return traffic.AnalysisResult{}
 }
@@ -318,3 +587,199 @@ func createMockHealthAnalyzer(t *testing.T, calls []mockHealthAnalyzerCall) heal
 		calls: calls,
 	}
 }
+
+type mockConfigurationAnalyzerCall struct {
+	clusterState configuration.ClusterState
+	returnValue  configuration.AnalysisResult
+}
+
+type mockConfigurationAnalyzer struct {
+	t     *testing.T
+	calls []mockConfigurationAnalyzerCall
+}
+
+func (mock mockConfigurationAnalyzer) Analyze(clusterState configuration.ClusterState) configuration.AnalysisResult {
+	for _, call := range mock.calls {
+		if reflect.DeepEqual(call.clusterState, clusterState) {
+			return call.returnValue
+		}
+	}
+	mock.t.Fatalf("mockConfigurationAnalyzer was called with unexpected arguments: \n\tclusterState: %s\n",
+		clusterState)
+	return configuration.AnalysisResult{}
+}
+
+func createMockConfigurationAnalyzer(t *testing.T, calls []mockConfigurationAnalyzerCall) configuration.Analyzer {
+	return mockConfigurationAnalyzer{
+		t:     t,
+		calls: calls,
+	}
+}
+
+type mockLintAnalyzerCall struct {
+	clusterState lint.ClusterState
+	returnValue  lint.AnalysisResult
+}
+
+type mockLintAnalyzer struct {
+	t     *testing.T
+	calls []mockLintAnalyzerCall
+}
+
+func (mock mockLintAnalyzer) Analyze(clusterState lint.ClusterState) lint.AnalysisResult {
+	for _, call := range mock.calls {
+		if reflect.DeepEqual(call.clusterState, clusterState) {
+			return call.returnValue
+		}
+	}
+	mock.t.Fatalf("mockLintAnalyzer was called with unexpected arguments: \n\tclusterState: %s\n",
+		clusterState)
+	return lint.AnalysisResult{}
+}
+
+func createMockLintAnalyzer(t *testing.T, calls []mockLintAnalyzerCall) lint.Analyzer {
+	return mockLintAnalyzer{
+		t:     t,
+		calls: calls,
+	}
+}
+
+type mockComplianceAnalyzerCall struct {
+	clusterState compliance.ClusterState
+	returnValue  compliance.AnalysisResult
+}
+
+type mockComplianceAnalyzer struct {
+	t     *testing.T
+	calls []mockComplianceAnalyzerCall
+}
+
+func (mock mockComplianceAnalyzer) Analyze(clusterState compliance.ClusterState) compliance.AnalysisResult {
+	for _, call := range mock.calls {
+		if reflect.DeepEqual(call.clusterState, clusterState) {
+			return call.returnValue
+		}
+	}
+	mock.t.Fatalf("mockComplianceAnalyzer was called with unexpected arguments: \n\tclusterState: %v\n",
+		clusterState)
+	return compliance.AnalysisResult{}
+}
+
+func createMockComplianceAnalyzer(t *testing.T, calls []mockComplianceAnalyzerCall) compliance.Analyzer {
+	return mockComplianceAnalyzer{
+		t:     t,
+		calls: calls,
+	}
+}
+
+type mockMeshAnalyzerCall struct {
+	clusterState mesh.ClusterState
+	returnValue  mesh.AnalysisResult
+}
+
+type mockMeshAnalyzer struct {
+	t     *testing.T
+	calls []mockMeshAnalyzerCall
+}
+
+func (mock mockMeshAnalyzer) Analyze(clusterState mesh.ClusterState) mesh.AnalysisResult {
+	for _, call := range mock.calls {
+		if reflect.DeepEqual(call.clusterState, clusterState) {
+			return call.returnValue
+		}
+	}
+	mock.t.Fatalf("mockMeshAnalyzer was called with unexpected arguments: \n\tclusterState: %v\n",
+		clusterState)
+	return mesh.AnalysisResult{}
+}
+
+func createMockMeshAnalyzer(t *testing.T, calls []mockMeshAnalyzerCall) mesh.Analyzer {
+	return mockMeshAnalyzer{
+		t:     t,
+		calls: calls,
+	}
+}
+
+type mockCrossClusterAnalyzerCall struct {
+	clusterState crosscluster.ClusterState
+	returnValue  crosscluster.AnalysisResult
+}
+
+type mockCrossClusterAnalyzer struct {
+	t     *testing.T
+	calls []mockCrossClusterAnalyzerCall
+}
+
+func (mock mockCrossClusterAnalyzer) Analyze(clusterState crosscluster.ClusterState) crosscluster.AnalysisResult {
+	for _, call := range mock.calls {
+		if reflect.DeepEqual(call.clusterState, clusterState) {
+			return call.returnValue
+		}
+	}
+	mock.t.Fatalf("mockCrossClusterAnalyzer was called with unexpected arguments: \n\tclusterState: %v\n",
+		clusterState)
+	return crosscluster.AnalysisResult{}
+}
+
+func createMockCrossClusterAnalyzer(t *testing.T, calls []mockCrossClusterAnalyzerCall) crosscluster.Analyzer {
+	return mockCrossClusterAnalyzer{
+		t:     t,
+		calls: calls,
+	}
+}
+
+type mockExposureAnalyzerCall struct {
+	clusterState exposure.ClusterState
+	returnValue  exposure.AnalysisResult
+}
+
+type mockExposureAnalyzer struct {
+	t     *testing.T
+	calls []mockExposureAnalyzerCall
+}
+
+func (mock mockExposureAnalyzer) Analyze(clusterState exposure.ClusterState) exposure.AnalysisResult {
+	for _, call := range mock.calls {
+		if reflect.DeepEqual(call.clusterState, clusterState) {
+			return call.returnValue
+		}
+	}
+	mock.t.Fatalf("mockExposureAnalyzer was called with unexpected arguments: \n\tclusterState: %v\n",
+		clusterState)
+	return exposure.AnalysisResult{}
+}
+
+func createMockExposureAnalyzer(t *testing.T, calls []mockExposureAnalyzerCall) exposure.Analyzer {
+	return mockExposureAnalyzer{
+		t:     t,
+		calls: calls,
+	}
+}
+
+type mockPodSecurityAnalyzerCall struct {
+	clusterState podsecurity.ClusterState
+	returnValue  podsecurity.AnalysisResult
+}
+
+type mockPodSecurityAnalyzer struct {
+	t     *testing.T
+	calls []mockPodSecurityAnalyzerCall
+}
+
+func (mock mockPodSecurityAnalyzer) Analyze(clusterState podsecurity.ClusterState) podsecurity.AnalysisResult {
+	for _, call := range mock.calls {
+		if reflect.DeepEqual(call.clusterState, clusterState) {
+			return call.returnValue
+		}
+	}
+	mock.t.Fatalf("mockPodSecurityAnalyzer was called with unexpected arguments: \n\tclusterState: %v\n",
+		clusterState)
+	return podsecurity.AnalysisResult{}
+}
+
+func createMockPodSecurityAnalyzer(t *testing.T, calls []mockPodSecurityAnalyzerCall) podsecurity.Analyzer {
+	return mockPodSecurityAnalyzer{
+		t:     t,
+		calls: calls,
+	}
+}
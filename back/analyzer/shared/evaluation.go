@@ -0,0 +1,236 @@
+package shared
+
+import (
+	"karto/commons"
+	"karto/types"
+	"math"
+	"sort"
+)
+
+const (
+	AnyOtherPort          int32 = -1
+	DefaultTierName             = "default"
+	DefaultTierOrder            = 1000000
+	kubernetesPolicyOrder       = 1000
+)
+
+type Decision struct {
+	Allowed  bool
+	Policies []*SourcePolicy
+	Tier     string
+}
+
+type RuleMatcher func(rule SourceRule) bool
+
+type RulesOf func(policy *SourcePolicy) []SourceRule
+
+func HasTieredPolicies(policies []*SourcePolicy) bool {
+	return commons.AnyMatch(policies, func(policy *SourcePolicy) bool { return policy.Tier != nil })
+}
+
+func IngressRules(policy *SourcePolicy) []SourceRule {
+	return orderedRules(policy.IngressDeny, policy.Ingress)
+}
+
+func EgressRules(policy *SourcePolicy) []SourceRule {
+	return orderedRules(policy.EgressDeny, policy.Egress)
+}
+
+func orderedRules(denyRules []SourceRule, rules []SourceRule) []SourceRule {
+	result := make([]SourceRule, 0, len(denyRules)+len(rules))
+	for _, rule := range denyRules {
+		rule.Action = RuleActionDeny
+		result = append(result, rule)
+	}
+	return append(result, rules...)
+}
+
+func CandidatePorts(policies []*SourcePolicy, rulesOf RulesOf) *commons.Set[int32] {
+	result := commons.NewSet[int32]()
+	result.Add(AnyOtherPort)
+	addBoundaries := func(portRanges []types.PortRange) {
+		for _, portRange := range portRanges {
+			result.Add(portRange.Start)
+			if portRange.End < MaxPort {
+				result.Add(portRange.End + 1)
+			}
+		}
+	}
+	for _, policy := range policies {
+		for _, rule := range rulesOf(policy) {
+			addBoundaries(rule.Ports)
+			addBoundaries(rule.ExceptPorts)
+		}
+	}
+	return result
+}
+
+func OrderedTiers(policies []*SourcePolicy) [][]*SourcePolicy {
+	defaultTierOrder := float64(DefaultTierOrder)
+	tierOrders := map[string]*float64{DefaultTierName: &defaultTierOrder}
+	policiesByTier := make(map[string][]*SourcePolicy)
+	for _, policy := range policies {
+		tierName := DefaultTierName
+		if policy.Tier != nil {
+			tierName = policy.Tier.Name
+			if policy.Tier.Order != nil {
+				tierOrders[tierName] = policy.Tier.Order
+			}
+		}
+		policiesByTier[tierName] = append(policiesByTier[tierName], policy)
+	}
+	tierNames := make([]string, 0, len(policiesByTier))
+	for tierName := range policiesByTier {
+		tierNames = append(tierNames, tierName)
+	}
+	sort.Slice(tierNames, func(i, j int) bool {
+		return orderLess(tierOrders[tierNames[i]], tierNames[i], tierOrders[tierNames[j]], tierNames[j])
+	})
+	result := make([][]*SourcePolicy, 0, len(tierNames))
+	for _, tierName := range tierNames {
+		tierPolicies := policiesByTier[tierName]
+		sort.SliceStable(tierPolicies, func(i, j int) bool {
+			return orderLess(tierPolicies[i].Order, tierPolicies[i].Ref.Name, tierPolicies[j].Order,
+				tierPolicies[j].Ref.Name)
+		})
+		result = append(result, tierPolicies)
+	}
+	return result
+}
+
+func orderLess(leftOrder *float64, leftName string, rightOrder *float64, rightName string) bool {
+	left, right := math.Inf(1), math.Inf(1)
+	if leftOrder != nil {
+		left = *leftOrder
+	}
+	if rightOrder != nil {
+		right = *rightOrder
+	}
+	if left != right {
+		return left < right
+	}
+	return leftName < rightName
+}
+
+func Evaluate(tiers [][]*SourcePolicy, rulesOf RulesOf, matches RuleMatcher, port int32) Decision {
+	for _, tierPolicies := range tiers {
+		tier := tierOf(tierPolicies[0])
+		action, policy := firstMatchingAction(tierPolicies, rulesOf, matches, port)
+		if policy == nil && tier.DefaultAction != "" {
+			action = tier.DefaultAction
+		}
+		if action != RuleActionPass {
+			decision := Decision{Allowed: action == RuleActionAllow, Tier: tier.Name}
+			if policy != nil {
+				decision.Policies = []*SourcePolicy{policy}
+			}
+			return decision
+		}
+	}
+	return Decision{Allowed: true}
+}
+
+func tierOf(policy *SourcePolicy) PolicyTier {
+	if policy.Tier == nil {
+		return PolicyTier{Name: DefaultTierName}
+	}
+	return *policy.Tier
+}
+
+func firstMatchingAction(
+	tierPolicies []*SourcePolicy,
+	rulesOf RulesOf,
+	matches RuleMatcher,
+	port int32,
+) (RuleAction, *SourcePolicy) {
+	for _, policy := range tierPolicies {
+		for _, rule := range rulesOf(policy) {
+			if rule.action() == RuleActionLog || !rule.MatchesPort(port) || !matches(rule) {
+				continue
+			}
+			return rule.action(), policy
+		}
+	}
+	return RuleActionDeny, nil
+}
+
+type PortsDecision struct {
+	Ports            []int32
+	PortRanges       []types.PortRange
+	DeniedPorts      []int32
+	DeniedPortRanges []types.PortRange
+	Policies         [][]*SourcePolicy
+	Tiers            [][]string
+}
+
+func EvaluatePorts(intervals []types.PortRange, evaluators ...func(port int32) Decision) *PortsDecision {
+	allowsOtherPorts := false
+	allowedRanges := make([]types.PortRange, 0)
+	deniedRanges := make([]types.PortRange, 0)
+	policies := make([][]*SourcePolicy, len(evaluators))
+	tiers := make([][]string, len(evaluators))
+	for _, interval := range intervals {
+		decisions := commons.Map(evaluators, func(evaluator func(port int32) Decision) Decision {
+			return evaluator(interval.Start)
+		})
+		if commons.AnyMatch(decisions, func(decision Decision) bool { return !decision.Allowed }) {
+			if interval.Start != AnyOtherPort {
+				deniedRanges = append(deniedRanges, interval)
+			}
+			continue
+		}
+		for i, decision := range decisions {
+			policies[i] = withDecidingPolicies(policies[i], decision)
+			tiers[i] = withDecidingTier(tiers[i], decision)
+		}
+		if interval.Start == AnyOtherPort {
+			allowsOtherPorts = true
+		} else {
+			allowedRanges = append(allowedRanges, interval)
+		}
+	}
+	if allowsOtherPorts {
+		result := &PortsDecision{Policies: policies, Tiers: tiers}
+		if len(deniedRanges) != 0 {
+			result.DeniedPorts, result.DeniedPortRanges = ExpandPortRanges(deniedRanges)
+		}
+		if len(result.DeniedPorts) == 0 {
+			result.DeniedPorts = nil
+		}
+		return result
+	} else if len(allowedRanges) != 0 {
+		result := &PortsDecision{Policies: policies, Tiers: tiers}
+		result.Ports, result.PortRanges = ExpandPortRanges(allowedRanges)
+		return result
+	}
+	return nil
+}
+
+func withDecidingPolicies(policies []*SourcePolicy, decision Decision) []*SourcePolicy {
+	for _, decidingPolicy := range decision.Policies {
+		if !commons.AnyMatch(policies, func(policy *SourcePolicy) bool { return policy == decidingPolicy }) {
+			policies = append(policies, decidingPolicy)
+		}
+	}
+	return policies
+}
+
+func withDecidingTier(tiers []string, decision Decision) []string {
+	if decision.Tier == "" {
+		return tiers
+	}
+	for _, tier := range tiers {
+		if tier == decision.Tier {
+			return tiers
+		}
+	}
+	return append(tiers, decision.Tier)
+}
+
+func PolicyRefs(policies []*SourcePolicy) []types.NetworkPolicy {
+	result := make([]types.NetworkPolicy, 0, len(policies))
+	for _, policy := range policies {
+		result = append(result, policy.Ref)
+	}
+	return result
+}
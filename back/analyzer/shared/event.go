@@ -0,0 +1,19 @@
+package shared
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"time"
+)
+
+func EventLastSeen(event *corev1.Event) time.Time {
+	if event.Series != nil && !event.Series.LastObservedTime.IsZero() {
+		return event.Series.LastObservedTime.Time
+	}
+	if !event.LastTimestamp.IsZero() {
+		return event.LastTimestamp.Time
+	}
+	if !event.EventTime.IsZero() {
+		return event.EventTime.Time
+	}
+	return event.CreationTimestamp.Time
+}
@@ -0,0 +1,125 @@
+package shared
+
+import (
+	"karto/types"
+	"net"
+	"regexp"
+	"strings"
+)
+
+const cidrExceptSeparator = " except "
+
+type ExternalDestination struct {
+	Peer      types.ExternalPeer
+	Addresses []string
+}
+
+func (rule SourceRule) MatchesDestination(destination ExternalDestination) bool {
+	for _, peer := range rule.Peers {
+		if peer.External != nil && PeerCovers(*peer.External, destination) {
+			return true
+		}
+	}
+	return false
+}
+
+func PeerCovers(peer types.ExternalPeer, destination ExternalDestination) bool {
+	if peer == destination.Peer {
+		return true
+	}
+	switch peer.Kind {
+	case types.ExternalPeerEntity:
+		return peer.Name == worldEntity && destination.Peer.Kind != types.ExternalPeerEntity
+	case types.ExternalPeerFQDN:
+		return destination.Peer.Kind == types.ExternalPeerFQDN && fqdnMatches(peer.Name, destination.Peer.Name)
+	case types.ExternalPeerCIDR:
+		return cidrCovers(peer.Name, destination)
+	}
+	return false
+}
+
+func fqdnMatches(pattern string, name string) bool {
+	pattern = strings.ToLower(strings.TrimSuffix(pattern, "."))
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	if pattern == "*" || pattern == name {
+		return true
+	}
+	if !strings.Contains(pattern, "*") {
+		return false
+	}
+	expression := strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, "[-a-z0-9_]*")
+	matched, err := regexp.MatchString("^"+expression+"$", name)
+	return err == nil && matched
+}
+
+func cidrCovers(peerName string, destination ExternalDestination) bool {
+	network, exceptions := parseCIDRPeer(peerName)
+	if network == nil {
+		return false
+	}
+	if destination.Peer.Kind == types.ExternalPeerCIDR {
+		destinationNetwork, _ := parseCIDRPeer(destination.Peer.Name)
+		return destinationNetwork != nil && networkCovers(network, exceptions, destinationNetwork)
+	}
+	if destination.Peer.Kind != types.ExternalPeerFQDN {
+		return false
+	}
+	if len(destination.Addresses) == 0 {
+		ones, _ := network.Mask.Size()
+		return ones == 0 && len(exceptions) == 0
+	}
+	for _, address := range destination.Addresses {
+		addressNetwork := parseNetwork(address)
+		if addressNetwork == nil || !networkCovers(network, exceptions, addressNetwork) {
+			return false
+		}
+	}
+	return true
+}
+
+func parseCIDRPeer(name string) (*net.IPNet, []*net.IPNet) {
+	parts := strings.SplitN(name, cidrExceptSeparator, 2)
+	network := parseNetwork(parts[0])
+	exceptions := make([]*net.IPNet, 0)
+	if len(parts) == 2 {
+		for _, exception := range strings.Split(parts[1], ",") {
+			if exceptionNetwork := parseNetwork(strings.TrimSpace(exception)); exceptionNetwork != nil {
+				exceptions = append(exceptions, exceptionNetwork)
+			}
+		}
+	}
+	return network, exceptions
+}
+
+func parseNetwork(value string) *net.IPNet {
+	if !strings.Contains(value, "/") {
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return nil
+		}
+		bits := 8 * net.IPv6len
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 8*net.IPv4len
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+	}
+	_, network, err := net.ParseCIDR(value)
+	if err != nil {
+		return nil
+	}
+	return network
+}
+
+func networkCovers(network *net.IPNet, exceptions []*net.IPNet, candidate *net.IPNet) bool {
+	networkOnes, networkBits := network.Mask.Size()
+	candidateOnes, candidateBits := candidate.Mask.Size()
+	if networkBits != candidateBits || candidateOnes < networkOnes || !network.Contains(candidate.IP) {
+		return false
+	}
+	for _, exception := range exceptions {
+		if exception.Contains(candidate.IP) || candidate.Contains(exception.IP) {
+			return false
+		}
+	}
+	return true
+}
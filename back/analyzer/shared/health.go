@@ -0,0 +1,31 @@
+package shared
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"karto/types"
+)
+
+func ToHealthStatus(desired int32, available int32, isStuck bool) types.HealthStatus {
+	if desired == 0 {
+		return types.HealthStatusHealthy
+	}
+	if available == 0 {
+		return types.HealthStatusDown
+	}
+	if available < desired || isStuck {
+		return types.HealthStatusDegraded
+	}
+	return types.HealthStatusHealthy
+}
+
+func IsPodReady(pod *corev1.Pod) bool {
+	if len(pod.Status.ContainerStatuses) == 0 {
+		return false
+	}
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		if !containerStatus.Ready {
+			return false
+		}
+	}
+	return true
+}
@@ -7,17 +7,19 @@ import (
 )
 
 type PodIsolation struct {
-	Pod             *corev1.Pod
-	IngressPolicies []*networkingv1.NetworkPolicy
-	EgressPolicies  []*networkingv1.NetworkPolicy
+	Pod                   *corev1.Pod
+	IngressPolicies       []*networkingv1.NetworkPolicy
+	EgressPolicies        []*networkingv1.NetworkPolicy
+	IngressSourcePolicies []*SourcePolicy
+	EgressSourcePolicies  []*SourcePolicy
 }
 
 func (podIsolation *PodIsolation) IsIngressIsolated() bool {
-	return len(podIsolation.IngressPolicies) != 0
+	return len(podIsolation.IngressPolicies) != 0 || len(podIsolation.IngressSourcePolicies) != 0
 }
 
 func (podIsolation *PodIsolation) IsEgressIsolated() bool {
-	return len(podIsolation.EgressPolicies) != 0
+	return len(podIsolation.EgressPolicies) != 0 || len(podIsolation.EgressSourcePolicies) != 0
 }
 
 func (podIsolation *PodIsolation) AddIngressPolicy(ingressPolicy *networkingv1.NetworkPolicy) {
@@ -28,11 +30,37 @@ func (podIsolation *PodIsolation) AddEgressPolicy(egressPolicy *networkingv1.Net
 	podIsolation.EgressPolicies = append(podIsolation.EgressPolicies, egressPolicy)
 }
 
+func (podIsolation *PodIsolation) AddSourcePolicy(policy *SourcePolicy) {
+	if policy.IsIngress {
+		podIsolation.IngressSourcePolicies = append(podIsolation.IngressSourcePolicies, policy)
+	}
+	if policy.IsEgress {
+		podIsolation.EgressSourcePolicies = append(podIsolation.EgressSourcePolicies, policy)
+	}
+}
+
+func (podIsolation *PodIsolation) IngressOrderedPolicies() []*SourcePolicy {
+	result := make([]*SourcePolicy, 0, len(podIsolation.IngressPolicies)+len(podIsolation.IngressSourcePolicies))
+	for _, ingressPolicy := range podIsolation.IngressPolicies {
+		result = append(result, ToSourcePolicy(ingressPolicy))
+	}
+	return append(result, podIsolation.IngressSourcePolicies...)
+}
+
+func (podIsolation *PodIsolation) EgressOrderedPolicies() []*SourcePolicy {
+	result := make([]*SourcePolicy, 0, len(podIsolation.EgressPolicies)+len(podIsolation.EgressSourcePolicies))
+	for _, egressPolicy := range podIsolation.EgressPolicies {
+		result = append(result, ToSourcePolicy(egressPolicy))
+	}
+	return append(result, podIsolation.EgressSourcePolicies...)
+}
+
 func (podIsolation *PodIsolation) ToPodIsolation() *types.PodIsolation {
 	return &types.PodIsolation{
 		Pod:               ToPodRef(podIsolation.Pod),
 		IsIngressIsolated: podIsolation.IsIngressIsolated(),
 		IsEgressIsolated:  podIsolation.IsEgressIsolated(),
+		HostNetwork:       podIsolation.Pod.Spec.HostNetwork,
 	}
 }
 
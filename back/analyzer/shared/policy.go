@@ -0,0 +1,149 @@
+package shared
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"karto/types"
+	"strings"
+)
+
+func PolicySelectsPod(policy *networkingv1.NetworkPolicy, pod *corev1.Pod) bool {
+	return policy.Namespace == pod.Namespace && SelectorMatches(pod.Labels, policy.Spec.PodSelector)
+}
+
+func PolicyTypes(policy *networkingv1.NetworkPolicy) (bool, bool) {
+	var isIngress, isEgress bool
+	for _, policyType := range policy.Spec.PolicyTypes {
+		if policyType == networkingv1.PolicyTypeIngress {
+			isIngress = true
+		} else if policyType == networkingv1.PolicyTypeEgress {
+			isEgress = true
+		}
+	}
+	return isIngress, isEgress
+}
+
+func DefaultDenyTypes(policy *networkingv1.NetworkPolicy) (bool, bool) {
+	if len(policy.Spec.PodSelector.MatchLabels) != 0 || len(policy.Spec.PodSelector.MatchExpressions) != 0 {
+		return false, false
+	}
+	isIngress, isEgress := PolicyTypes(policy)
+	return isIngress && len(policy.Spec.Ingress) == 0, isEgress && len(policy.Spec.Egress) == 0
+}
+
+func PeerSelectsPod(
+	policyNamespace string,
+	peer networkingv1.NetworkPolicyPeer,
+	pod *corev1.Pod,
+	namespaces []*corev1.Namespace,
+) bool {
+	if peer.IPBlock != nil {
+		return false
+	}
+	if peer.NamespaceSelector == nil {
+		if pod.Namespace != policyNamespace {
+			return false
+		}
+	} else if !SelectorMatches(NamespaceLabels(pod.Namespace, namespaces), *peer.NamespaceSelector) {
+		return false
+	}
+	return peer.PodSelector == nil || SelectorMatches(pod.Labels, *peer.PodSelector)
+}
+
+func PeerSelectsNamespace(peer networkingv1.NetworkPolicyPeer, namespace *corev1.Namespace) bool {
+	return peer.NamespaceSelector != nil && SelectorMatches(namespace.Labels, *peer.NamespaceSelector)
+}
+
+func NamespaceLabels(namespaceName string, namespaces []*corev1.Namespace) map[string]string {
+	for _, namespace := range namespaces {
+		if namespace.Name == namespaceName {
+			return namespace.Labels
+		}
+	}
+	return nil
+}
+
+func ToSourcePolicy(policy *networkingv1.NetworkPolicy) *SourcePolicy {
+	isIngress, isEgress := PolicyTypes(policy)
+	order := float64(kubernetesPolicyOrder)
+	result := &SourcePolicy{
+		Ref: types.NetworkPolicy{
+			Name:      policy.Name,
+			Namespace: policy.Namespace,
+			Labels:    policy.Labels,
+		},
+		Tier:      &PolicyTier{Name: DefaultTierName},
+		Order:     &order,
+		Selects:   func(pod *corev1.Pod, _ map[string]string) bool { return PolicySelectsPod(policy, pod) },
+		IsIngress: isIngress,
+		IsEgress:  isEgress,
+	}
+	for _, ingressRule := range policy.Spec.Ingress {
+		result.Ingress = append(result.Ingress, toSourceRule(policy.Namespace, ingressRule.From, ingressRule.Ports))
+	}
+	for _, egressRule := range policy.Spec.Egress {
+		result.Egress = append(result.Egress, toSourceRule(policy.Namespace, egressRule.To, egressRule.Ports))
+	}
+	return result
+}
+
+func toSourceRule(
+	policyNamespace string,
+	policyPeers []networkingv1.NetworkPolicyPeer,
+	policyPorts []networkingv1.NetworkPolicyPort,
+) SourceRule {
+	rule := SourceRule{Action: RuleActionAllow, Peers: make([]SourcePeer, 0, len(policyPeers))}
+	if len(policyPeers) == 0 {
+		rule.Peers = append(rule.Peers, SourcePeer{
+			Pods:     func(*corev1.Pod, map[string]string) bool { return true },
+			External: &types.ExternalPeer{Kind: types.ExternalPeerEntity, Name: worldEntity},
+		})
+	}
+	for _, policyPeer := range policyPeers {
+		rule.Peers = append(rule.Peers, toSourcePeer(policyNamespace, policyPeer))
+	}
+	for _, policyPort := range policyPorts {
+		if policyPort.Port == nil {
+			rule.Ports, rule.NamedPorts = nil, nil
+			break
+		}
+		if rule.Ports == nil {
+			rule.Ports = []types.PortRange{}
+		}
+		if policyPort.Port.Type != intstr.Int {
+			rule.NamedPorts = append(rule.NamedPorts, policyPort.Port.StrVal)
+			continue
+		}
+		endPort := policyPort.Port.IntVal
+		if policyPort.EndPort != nil {
+			endPort = *policyPort.EndPort
+		}
+		if portRange, ok := NewPortRange(int64(policyPort.Port.IntVal), int64(endPort)); ok {
+			rule.Ports = append(rule.Ports, portRange)
+		}
+	}
+	return rule
+}
+
+func toSourcePeer(policyNamespace string, policyPeer networkingv1.NetworkPolicyPeer) SourcePeer {
+	if policyPeer.IPBlock != nil {
+		name := policyPeer.IPBlock.CIDR
+		if len(policyPeer.IPBlock.Except) != 0 {
+			name += " except " + strings.Join(policyPeer.IPBlock.Except, ", ")
+		}
+		return SourcePeer{External: &types.ExternalPeer{Kind: types.ExternalPeerCIDR, Name: name}}
+	}
+	return SourcePeer{
+		Pods: func(pod *corev1.Pod, namespaceLabels map[string]string) bool {
+			if policyPeer.NamespaceSelector == nil {
+				if pod.Namespace != policyNamespace {
+					return false
+				}
+			} else if !SelectorMatches(namespaceLabels, *policyPeer.NamespaceSelector) {
+				return false
+			}
+			return policyPeer.PodSelector == nil || SelectorMatches(pod.Labels, *policyPeer.PodSelector)
+		},
+	}
+}
@@ -0,0 +1,115 @@
+package shared
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"karto/commons"
+	"karto/types"
+	"sort"
+)
+
+const (
+	MinPort              int32 = 1
+	MaxPort              int32 = 65535
+	maxExpandedPortRange       = 1024
+)
+
+func NewPortRange(start int64, end int64) (types.PortRange, bool) {
+	if start < int64(MinPort) {
+		start = int64(MinPort)
+	}
+	if end > int64(MaxPort) {
+		end = int64(MaxPort)
+	}
+	if start > end {
+		return types.PortRange{}, false
+	}
+	return types.PortRange{Start: int32(start), End: int32(end)}, true
+}
+
+func SinglePort(port int32) types.PortRange {
+	return types.PortRange{Start: port, End: port}
+}
+
+func ResolvedRules(rulesOf RulesOf, targetPod *corev1.Pod) RulesOf {
+	return func(policy *SourcePolicy) []SourceRule {
+		rules := rulesOf(policy)
+		result := make([]SourceRule, 0, len(rules))
+		for _, rule := range rules {
+			result = append(result, rule.WithNamedPortsOf(targetPod))
+		}
+		return result
+	}
+}
+
+func PortIntervals(candidatePorts *commons.Set[int32]) []types.PortRange {
+	boundaries := candidatePorts.ToSlice()
+	sort.Slice(boundaries, func(i, j int) bool { return boundaries[i] < boundaries[j] })
+	result := make([]types.PortRange, 0, len(boundaries))
+	for i, boundary := range boundaries {
+		if boundary == AnyOtherPort {
+			result = append(result, SinglePort(AnyOtherPort))
+			continue
+		}
+		end := MaxPort
+		if i+1 < len(boundaries) {
+			end = boundaries[i+1] - 1
+		}
+		result = append(result, types.PortRange{Start: boundary, End: end})
+	}
+	return result
+}
+
+func ExpandPortRanges(portRanges []types.PortRange) ([]int32, []types.PortRange) {
+	ports := make([]int32, 0)
+	var wideRanges []types.PortRange
+	for _, portRange := range mergedPortRanges(portRanges) {
+		if portRange.End-portRange.Start >= maxExpandedPortRange {
+			wideRanges = append(wideRanges, portRange)
+			continue
+		}
+		for port := portRange.Start; port <= portRange.End; port++ {
+			ports = append(ports, port)
+		}
+	}
+	return ports, wideRanges
+}
+
+func mergedPortRanges(portRanges []types.PortRange) []types.PortRange {
+	sorted := append([]types.PortRange{}, portRanges...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+	result := make([]types.PortRange, 0, len(sorted))
+	for _, portRange := range sorted {
+		last := len(result) - 1
+		if last >= 0 && portRange.Start <= result[last].End+1 {
+			if portRange.End > result[last].End {
+				result[last].End = portRange.End
+			}
+			continue
+		}
+		result = append(result, portRange)
+	}
+	return result
+}
+
+func rangesContain(portRanges []types.PortRange, port int32) bool {
+	for _, portRange := range portRanges {
+		if portRange.Contains(port) {
+			return true
+		}
+	}
+	return false
+}
+
+func namedPortRanges(names []string, pod *corev1.Pod) []types.PortRange {
+	result := make([]types.PortRange, 0, len(names))
+	for _, container := range pod.Spec.Containers {
+		for _, containerPort := range container.Ports {
+			for _, name := range names {
+				if containerPort.Name != "" && containerPort.Name == name {
+					result = append(result, SinglePort(containerPort.ContainerPort))
+				}
+			}
+		}
+	}
+	return result
+}
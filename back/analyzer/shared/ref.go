@@ -3,6 +3,7 @@ package shared
 import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"karto/types"
 )
 
@@ -26,3 +27,32 @@ func ToReplicaSetRef(replicaSet *appsv1.ReplicaSet) types.ReplicaSetRef {
 		Namespace: replicaSet.Namespace,
 	}
 }
+
+func ToDeploymentRef(deployment *appsv1.Deployment) types.DeploymentRef {
+	return types.DeploymentRef{
+		Name:      deployment.Name,
+		Namespace: deployment.Namespace,
+	}
+}
+
+func ToStatefulSetRef(statefulSet *appsv1.StatefulSet) types.StatefulSetRef {
+	return types.StatefulSetRef{
+		Name:      statefulSet.Name,
+		Namespace: statefulSet.Namespace,
+	}
+}
+
+func ToDaemonSetRef(daemonSet *appsv1.DaemonSet) types.DaemonSetRef {
+	return types.DaemonSetRef{
+		Name:      daemonSet.Name,
+		Namespace: daemonSet.Namespace,
+	}
+}
+
+func ToObjectRef(kind string, object metav1.Object) types.ObjectRef {
+	return types.ObjectRef{
+		Kind:      kind,
+		Name:      object.GetName(),
+		Namespace: object.GetNamespace(),
+	}
+}
@@ -0,0 +1,120 @@
+package shared
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"karto/types"
+)
+
+const worldEntity = "world"
+
+type PodMatcher func(pod *corev1.Pod, namespaceLabels map[string]string) bool
+
+type RuleAction string
+
+const (
+	RuleActionAllow RuleAction = "Allow"
+	RuleActionDeny  RuleAction = "Deny"
+	RuleActionPass  RuleAction = "Pass"
+	RuleActionLog   RuleAction = "Log"
+)
+
+type SourcePeer struct {
+	Pods     PodMatcher
+	External *types.ExternalPeer
+}
+
+type SourceRule struct {
+	Action           RuleAction
+	Peers            []SourcePeer
+	Ports            []types.PortRange
+	NamedPorts       []string
+	ExceptPorts      []types.PortRange
+	ExceptNamedPorts []string
+}
+
+type PolicyTier struct {
+	Name          string
+	Order         *float64
+	DefaultAction RuleAction
+}
+
+type SourcePolicy struct {
+	Ref         types.NetworkPolicy
+	Tier        *PolicyTier
+	Order       *float64
+	Selects     PodMatcher
+	IsIngress   bool
+	IsEgress    bool
+	Ingress     []SourceRule
+	IngressDeny []SourceRule
+	Egress      []SourceRule
+	EgressDeny  []SourceRule
+}
+
+func (rule SourceRule) AllowsPod(pod *corev1.Pod, namespaceLabels map[string]string) bool {
+	if pod.Spec.HostNetwork {
+		return false
+	}
+	for _, peer := range rule.Peers {
+		if peer.Pods != nil && peer.Pods(pod, namespaceLabels) {
+			return true
+		}
+	}
+	return false
+}
+
+func (rule SourceRule) Externals() []types.ExternalPeer {
+	result := make([]types.ExternalPeer, 0)
+	for _, peer := range rule.Peers {
+		if peer.External != nil {
+			result = append(result, *peer.External)
+		}
+	}
+	return result
+}
+
+func (rule SourceRule) MatchesExternal(external types.ExternalPeer) bool {
+	for _, peer := range rule.Peers {
+		if peer.External == nil {
+			continue
+		}
+		if *peer.External == external {
+			return true
+		}
+		isWorld := peer.External.Kind == types.ExternalPeerEntity && peer.External.Name == worldEntity
+		if isWorld && external.Kind != types.ExternalPeerEntity {
+			return true
+		}
+	}
+	return false
+}
+
+func (rule SourceRule) RestrictsPorts() bool {
+	return rule.Ports != nil || rule.NamedPorts != nil
+}
+
+func (rule SourceRule) MatchesPort(port int32) bool {
+	if rangesContain(rule.ExceptPorts, port) {
+		return false
+	}
+	return !rule.RestrictsPorts() || rangesContain(rule.Ports, port)
+}
+
+func (rule SourceRule) WithNamedPortsOf(targetPod *corev1.Pod) SourceRule {
+	if len(rule.NamedPorts) != 0 {
+		rule.Ports = append(namedPortRanges(rule.NamedPorts, targetPod), rule.Ports...)
+		rule.NamedPorts = nil
+	}
+	if len(rule.ExceptNamedPorts) != 0 {
+		rule.ExceptPorts = append(namedPortRanges(rule.ExceptNamedPorts, targetPod), rule.ExceptPorts...)
+		rule.ExceptNamedPorts = nil
+	}
+	return rule
+}
+
+func (rule SourceRule) action() RuleAction {
+	if rule.Action == "" {
+		return RuleActionAllow
+	}
+	return rule.Action
+}
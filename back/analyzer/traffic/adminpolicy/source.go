@@ -0,0 +1,168 @@
+package adminpolicy
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"karto/analyzer/shared"
+	"karto/analyzer/traffic/policysource"
+	"karto/crds/policyapi"
+	"karto/types"
+)
+
+const (
+	adminTierName     = "adminnetworkpolicy"
+	adminTierOrder    = 1000
+	baselineTierName  = "baselineadminnetworkpolicy"
+	baselineTierOrder = 10000000
+	nodesEntity       = "nodes"
+)
+
+var (
+	adminNetworkPolicyKind         = policyapi.AdminNetworkPolicyKind + "." + policyapi.GroupVersion.Group
+	baselineAdminNetworkPolicyKind = policyapi.BaselineAdminNetworkPolicyKind + "." + policyapi.GroupVersion.Group
+	actions                        = map[string]shared.RuleAction{
+		policyapi.ActionAllow: shared.RuleActionAllow,
+		policyapi.ActionDeny:  shared.RuleActionDeny,
+		policyapi.ActionPass:  shared.RuleActionPass,
+	}
+)
+
+type sourceImpl struct{}
+
+func NewSource() policysource.Source {
+	return sourceImpl{}
+}
+
+func (source sourceImpl) Policies(clusterState policysource.ClusterState) []*shared.SourcePolicy {
+	result := make([]*shared.SourcePolicy, 0, len(clusterState.AdminNetworkPolicies))
+	for _, adminPolicy := range clusterState.AdminNetworkPolicies {
+		result = append(result, source.toSourcePolicy(adminPolicy))
+	}
+	return result
+}
+
+func (source sourceImpl) toSourcePolicy(adminPolicy *policyapi.AdminNetworkPolicy) *shared.SourcePolicy {
+	ingress := make([]shared.SourceRule, 0, len(adminPolicy.Spec.Ingress))
+	for _, ingressRule := range adminPolicy.Spec.Ingress {
+		peers := make([]shared.SourcePeer, 0, len(ingressRule.From))
+		for _, ingressPeer := range ingressRule.From {
+			peers = append(peers, shared.SourcePeer{Pods: source.podMatcher(ingressPeer.Namespaces, ingressPeer.Pods)})
+		}
+		ingress = append(ingress, source.toSourceRule(ingressRule.Action, peers, ingressRule.Ports))
+	}
+	egress := make([]shared.SourceRule, 0, len(adminPolicy.Spec.Egress))
+	for _, egressRule := range adminPolicy.Spec.Egress {
+		peers := make([]shared.SourcePeer, 0, len(egressRule.To))
+		for _, egressPeer := range egressRule.To {
+			peers = append(peers, source.egressPeers(egressPeer)...)
+		}
+		egress = append(egress, source.toSourceRule(egressRule.Action, peers, egressRule.Ports))
+	}
+	return &shared.SourcePolicy{
+		Ref:       source.toNetworkPolicy(adminPolicy),
+		Tier:      source.tier(adminPolicy),
+		Order:     source.order(adminPolicy),
+		Selects:   source.podMatcher(adminPolicy.Spec.Subject.Namespaces, adminPolicy.Spec.Subject.Pods),
+		IsIngress: len(ingress) != 0,
+		IsEgress:  len(egress) != 0,
+		Ingress:   ingress,
+		Egress:    egress,
+	}
+}
+
+func (source sourceImpl) toNetworkPolicy(adminPolicy *policyapi.AdminNetworkPolicy) types.NetworkPolicy {
+	kind := adminNetworkPolicyKind
+	if adminPolicy.IsBaseline() {
+		kind = baselineAdminNetworkPolicyKind
+	}
+	return types.NetworkPolicy{
+		Kind:   kind,
+		Name:   adminPolicy.Name,
+		Labels: adminPolicy.Labels,
+	}
+}
+
+func (source sourceImpl) tier(adminPolicy *policyapi.AdminNetworkPolicy) *shared.PolicyTier {
+	name, order := adminTierName, float64(adminTierOrder)
+	if adminPolicy.IsBaseline() {
+		name, order = baselineTierName, float64(baselineTierOrder)
+	}
+	return &shared.PolicyTier{Name: name, Order: &order, DefaultAction: shared.RuleActionPass}
+}
+
+func (source sourceImpl) order(adminPolicy *policyapi.AdminNetworkPolicy) *float64 {
+	if adminPolicy.IsBaseline() {
+		return nil
+	}
+	priority := float64(adminPolicy.Spec.Priority)
+	return &priority
+}
+
+func (source sourceImpl) toSourceRule(action string, peers []shared.SourcePeer, ports []policyapi.Port) shared.SourceRule {
+	ruleAction, ok := actions[action]
+	if !ok {
+		ruleAction = shared.RuleActionDeny
+	}
+	portRanges, namedPorts := source.ports(ports)
+	return shared.SourceRule{Action: ruleAction, Peers: peers, Ports: portRanges, NamedPorts: namedPorts}
+}
+
+func (source sourceImpl) egressPeers(egressPeer policyapi.EgressPeer) []shared.SourcePeer {
+	result := make([]shared.SourcePeer, 0)
+	if egressPeer.Namespaces != nil || egressPeer.Pods != nil {
+		result = append(result, shared.SourcePeer{Pods: source.podMatcher(egressPeer.Namespaces, egressPeer.Pods)})
+	}
+	if egressPeer.Nodes != nil {
+		result = append(result, shared.SourcePeer{
+			External: &types.ExternalPeer{Kind: types.ExternalPeerEntity, Name: nodesEntity},
+		})
+	}
+	for _, network := range egressPeer.Networks {
+		result = append(result, shared.SourcePeer{
+			External: &types.ExternalPeer{Kind: types.ExternalPeerCIDR, Name: network},
+		})
+	}
+	for _, domainName := range egressPeer.DomainNames {
+		result = append(result, shared.SourcePeer{
+			External: &types.ExternalPeer{Kind: types.ExternalPeerFQDN, Name: domainName},
+		})
+	}
+	return result
+}
+
+func (source sourceImpl) podMatcher(
+	namespaces *metav1.LabelSelector,
+	pods *policyapi.NamespacedPod,
+) shared.PodMatcher {
+	return func(pod *corev1.Pod, namespaceLabels map[string]string) bool {
+		if namespaces != nil {
+			return shared.SelectorMatches(namespaceLabels, *namespaces)
+		}
+		if pods != nil {
+			return shared.SelectorMatches(namespaceLabels, pods.NamespaceSelector) &&
+				shared.SelectorMatches(pod.Labels, pods.PodSelector)
+		}
+		return false
+	}
+}
+
+func (source sourceImpl) ports(adminPorts []policyapi.Port) ([]types.PortRange, []string) {
+	if len(adminPorts) == 0 {
+		return nil, nil
+	}
+	portRanges := make([]types.PortRange, 0, len(adminPorts))
+	var namedPorts []string
+	for _, adminPort := range adminPorts {
+		if adminPort.PortNumber != nil {
+			portRanges = append(portRanges, shared.SinglePort(adminPort.PortNumber.Port))
+		} else if adminPort.PortRange != nil {
+			portRange, ok := shared.NewPortRange(int64(adminPort.PortRange.Start), int64(adminPort.PortRange.End))
+			if ok {
+				portRanges = append(portRanges, portRange)
+			}
+		} else if adminPort.NamedPort != nil {
+			namedPorts = append(namedPorts, *adminPort.NamedPort)
+		}
+	}
+	return portRanges, namedPorts
+}
@@ -0,0 +1,209 @@
+package adminpolicy
+
+import (
+	"fmt"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"karto/analyzer/shared"
+	"karto/analyzer/traffic/policysource"
+	"karto/crds/policyapi"
+	"karto/testutils"
+	"karto/types"
+	"testing"
+)
+
+type describedRule struct {
+	Action     shared.RuleAction
+	Pods       []string
+	Externals  []string
+	Ports      []types.PortRange
+	NamedPorts []string
+}
+
+type describedPolicy struct {
+	Policy    string
+	Tier      string
+	TierOrder float64
+	Order     *float64
+	Selects   []string
+	IsIngress bool
+	IsEgress  bool
+	Ingress   []describedRule
+	Egress    []describedRule
+}
+
+func TestPolicies(t *testing.T) {
+	type args struct {
+		policies []*policyapi.AdminNetworkPolicy
+	}
+	namespaces := []*corev1.Namespace{
+		testutils.NewNamespaceBuilder().WithName("front").WithLabel("team", "web").Build(),
+		testutils.NewNamespaceBuilder().WithName("back").Build(),
+	}
+	pods := []*corev1.Pod{
+		testutils.NewPodBuilder().WithName("web").WithNamespace("front").WithLabel("app", "web").Build(),
+		testutils.NewPodBuilder().WithName("api").WithNamespace("back").WithLabel("app", "api").Build(),
+		testutils.NewPodBuilder().WithName("db").WithNamespace("back").WithLabel("app", "db").Build(),
+	}
+	port := "http"
+	priority := 10.0
+	tests := []struct {
+		name             string
+		args             args
+		expectedPolicies []describedPolicy
+	}{
+		{
+			name: "translates subjects, peers, actions and ports of admin network policies",
+			args: args{
+				policies: []*policyapi.AdminNetworkPolicy{
+					{
+						TypeMeta:   metav1.TypeMeta{Kind: policyapi.AdminNetworkPolicyKind},
+						ObjectMeta: metav1.ObjectMeta{Name: "protect-db"},
+						Spec: policyapi.Spec{
+							Priority: 10,
+							Subject: policyapi.Subject{
+								Pods: &policyapi.NamespacedPod{
+									PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+								},
+							},
+							Ingress: []policyapi.IngressRule{
+								{
+									Action: policyapi.ActionDeny,
+									From: []policyapi.IngressPeer{
+										{Namespaces: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "web"}}},
+									},
+								},
+								{
+									Action: policyapi.ActionAllow,
+									From: []policyapi.IngressPeer{
+										{Pods: &policyapi.NamespacedPod{
+											PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "api"}},
+										}},
+									},
+									Ports: []policyapi.Port{
+										{PortNumber: &policyapi.PortNumber{Port: 5432}},
+										{PortRange: &policyapi.PortRange{Start: 8000, End: 8002}},
+										{NamedPort: &port},
+									},
+								},
+								{
+									Action: policyapi.ActionPass,
+									From:   []policyapi.IngressPeer{{Namespaces: &metav1.LabelSelector{}}},
+									Ports:  []policyapi.Port{{PortRange: &policyapi.PortRange{Start: 1, End: 65535}}},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectedPolicies: []describedPolicy{
+				{
+					Policy:    "AdminNetworkPolicy.policy.networking.k8s.io protect-db",
+					Tier:      "adminnetworkpolicy",
+					TierOrder: 1000,
+					Order:     &priority,
+					Selects:   []string{"back/db"},
+					IsIngress: true,
+					Ingress: []describedRule{
+						{Action: shared.RuleActionDeny, Pods: []string{"front/web"}, Externals: []string{}},
+						{Action: shared.RuleActionAllow, Pods: []string{"back/api"}, Externals: []string{},
+							Ports:      []types.PortRange{{Start: 5432, End: 5432}, {Start: 8000, End: 8002}},
+							NamedPorts: []string{port}},
+						{Action: shared.RuleActionPass, Pods: []string{"front/web", "back/api", "back/db"},
+							Externals: []string{}, Ports: []types.PortRange{{Start: 1, End: 65535}}},
+					},
+					Egress: []describedRule{},
+				},
+			},
+		},
+		{
+			name: "places baseline admin network policies in the last tier with external egress peers",
+			args: args{
+				policies: []*policyapi.AdminNetworkPolicy{
+					{
+						TypeMeta:   metav1.TypeMeta{Kind: policyapi.BaselineAdminNetworkPolicyKind},
+						ObjectMeta: metav1.ObjectMeta{Name: "default"},
+						Spec: policyapi.Spec{
+							Subject: policyapi.Subject{Namespaces: &metav1.LabelSelector{}},
+							Egress: []policyapi.EgressRule{
+								{
+									Action: policyapi.ActionDeny,
+									To: []policyapi.EgressPeer{
+										{Nodes: &metav1.LabelSelector{}},
+										{Networks: []string{"0.0.0.0/0"}, DomainNames: []string{"*.example.com"}},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectedPolicies: []describedPolicy{
+				{
+					Policy:    "BaselineAdminNetworkPolicy.policy.networking.k8s.io default",
+					Tier:      "baselineadminnetworkpolicy",
+					TierOrder: 10000000,
+					Selects:   []string{"front/web", "back/api", "back/db"},
+					IsEgress:  true,
+					Ingress:   []describedRule{},
+					Egress: []describedRule{
+						{Action: shared.RuleActionDeny, Pods: []string{},
+							Externals: []string{"entity:nodes", "cidr:0.0.0.0/0", "fqdn:*.example.com"}},
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := NewSource()
+			policies := source.Policies(policysource.ClusterState{AdminNetworkPolicies: tt.args.policies})
+			described := make([]describedPolicy, 0)
+			for _, policy := range policies {
+				described = append(described, describePolicy(policy, pods, namespaces))
+			}
+			if diff := cmp.Diff(tt.expectedPolicies, described); diff != "" {
+				t.Errorf("Policies() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func describePolicy(policy *shared.SourcePolicy, pods []*corev1.Pod,
+	namespaces []*corev1.Namespace) describedPolicy {
+	describeRules := func(rules []shared.SourceRule) []describedRule {
+		result := make([]describedRule, 0)
+		for _, rule := range rules {
+			described := describedRule{Action: rule.Action, Pods: []string{}, Externals: []string{},
+				Ports: rule.Ports, NamedPorts: rule.NamedPorts}
+			for _, pod := range pods {
+				if rule.AllowsPod(pod, shared.NamespaceLabels(pod.Namespace, namespaces)) {
+					described.Pods = append(described.Pods, pod.Namespace+"/"+pod.Name)
+				}
+			}
+			for _, external := range rule.Externals() {
+				described.Externals = append(described.Externals, fmt.Sprintf("%s:%s", external.Kind, external.Name))
+			}
+			result = append(result, described)
+		}
+		return result
+	}
+	selected := make([]string, 0)
+	for _, pod := range pods {
+		if policy.Selects(pod, shared.NamespaceLabels(pod.Namespace, namespaces)) {
+			selected = append(selected, pod.Namespace+"/"+pod.Name)
+		}
+	}
+	return describedPolicy{
+		Policy:    policy.Ref.Kind + " " + policy.Ref.Name,
+		Tier:      policy.Tier.Name,
+		TierOrder: *policy.Tier.Order,
+		Order:     policy.Order,
+		Selects:   selected,
+		IsIngress: policy.IsIngress,
+		IsEgress:  policy.IsEgress,
+		Ingress:   describeRules(policy.Ingress),
+		Egress:    describeRules(policy.Egress),
+	}
+}
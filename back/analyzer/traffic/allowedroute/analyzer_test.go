@@ -17,6 +17,8 @@ func TestAnalyze(t *testing.T) {
 		targetPodIsolation *shared.PodIsolation
 		namespaces         []*corev1.Namespace
 	}
+	tierOrder := 100.0
+	adminTierOrder, baselineTierOrder, priority := 1000.0, 10000000.0, 10.0
 	tests := []struct {
 		name                 string
 		args                 args
@@ -119,6 +121,36 @@ func TestAnalyze(t *testing.T) {
 			},
 			expectedAllowedRoute: nil,
 		},
+		{
+			name: "a pod on the host network cannot send traffic to pod accepting its labels",
+			args: args{
+				sourcePodIsolation: &shared.PodIsolation{
+					Pod: testutils.NewPodBuilder().WithName("Pod1").WithLabel("app", "foo").
+						WithHostNetwork().Build(),
+					IngressPolicies: []*networkingv1.NetworkPolicy{},
+					EgressPolicies:  []*networkingv1.NetworkPolicy{},
+				},
+				targetPodIsolation: &shared.PodIsolation{
+					Pod: testutils.NewPodBuilder().WithName("Pod2").Build(),
+					IngressPolicies: []*networkingv1.NetworkPolicy{
+						testutils.NewNetworkPolicyBuilder().WithName("in1").WithTypes("Ingress").
+							WithIngressRule(networkingv1.NetworkPolicyIngressRule{
+								From: []networkingv1.NetworkPolicyPeer{
+									{
+										PodSelector: testutils.NewLabelSelectorBuilder().
+											WithMatchLabel("app", "foo").Build(),
+									},
+								},
+							}).Build(),
+					},
+					EgressPolicies: []*networkingv1.NetworkPolicy{},
+				},
+				namespaces: []*corev1.Namespace{
+					testutils.NewNamespaceBuilder().WithName("default").Build(),
+				},
+			},
+			expectedAllowedRoute: nil,
+		},
 		{
 			name: "a non isolated pod can send traffic to pod accepting its namespace",
 			args: args{
@@ -827,6 +859,513 @@ func TestAnalyze(t *testing.T) {
 				Ports: []int32{80},
 			},
 		},
+		{
+			name: "policies of policy sources allow traffic to the pods they isolate",
+			args: args{
+				sourcePodIsolation: &shared.PodIsolation{
+					Pod:             testutils.NewPodBuilder().WithName("Pod1").WithLabel("app", "foo").Build(),
+					IngressPolicies: []*networkingv1.NetworkPolicy{},
+					EgressPolicies:  []*networkingv1.NetworkPolicy{},
+				},
+				targetPodIsolation: &shared.PodIsolation{
+					Pod:             testutils.NewPodBuilder().WithName("Pod2").Build(),
+					IngressPolicies: []*networkingv1.NetworkPolicy{},
+					EgressPolicies:  []*networkingv1.NetworkPolicy{},
+					IngressSourcePolicies: []*shared.SourcePolicy{
+						{
+							Ref:       types.NetworkPolicy{Kind: "CiliumNetworkPolicy", Name: "cnp", Namespace: "default"},
+							IsIngress: true,
+							Ingress: []shared.SourceRule{
+								{
+									Peers: []shared.SourcePeer{{Pods: podWithLabel("app", "foo")}},
+									Ports: []types.PortRange{{Start: 8080, End: 8080}},
+								},
+								{Peers: []shared.SourcePeer{{Pods: podWithLabel("app", "bar")}}, Ports: nil},
+							},
+						},
+					},
+				},
+				namespaces: []*corev1.Namespace{
+					testutils.NewNamespaceBuilder().WithName("default").Build(),
+				},
+			},
+			expectedAllowedRoute: &types.AllowedRoute{
+				SourcePod:      types.PodRef{Name: "Pod1", Namespace: "default"},
+				EgressPolicies: []types.NetworkPolicy{},
+				TargetPod:      types.PodRef{Name: "Pod2", Namespace: "default"},
+				IngressPolicies: []types.NetworkPolicy{
+					{Kind: "CiliumNetworkPolicy", Name: "cnp", Namespace: "default"},
+				},
+				Ports: []int32{8080},
+			},
+		},
+		{
+			name: "deny rules of policy sources remove ports from allowed traffic",
+			args: args{
+				sourcePodIsolation: &shared.PodIsolation{
+					Pod:             testutils.NewPodBuilder().WithName("Pod1").WithLabel("app", "foo").Build(),
+					IngressPolicies: []*networkingv1.NetworkPolicy{},
+					EgressPolicies:  []*networkingv1.NetworkPolicy{},
+				},
+				targetPodIsolation: &shared.PodIsolation{
+					Pod:             testutils.NewPodBuilder().WithName("Pod2").Build(),
+					IngressPolicies: []*networkingv1.NetworkPolicy{},
+					EgressPolicies:  []*networkingv1.NetworkPolicy{},
+					IngressSourcePolicies: []*shared.SourcePolicy{
+						{
+							Ref:       types.NetworkPolicy{Kind: "CiliumNetworkPolicy", Name: "cnp", Namespace: "default"},
+							IsIngress: true,
+							Ingress:   []shared.SourceRule{{Peers: []shared.SourcePeer{{Pods: podWithLabel("app", "foo")}}}},
+							IngressDeny: []shared.SourceRule{
+								{
+									Peers: []shared.SourcePeer{{Pods: podWithLabel("app", "foo")}},
+									Ports: []types.PortRange{{Start: 22, End: 22}},
+								},
+							},
+						},
+					},
+				},
+				namespaces: []*corev1.Namespace{
+					testutils.NewNamespaceBuilder().WithName("default").Build(),
+				},
+			},
+			expectedAllowedRoute: &types.AllowedRoute{
+				SourcePod:      types.PodRef{Name: "Pod1", Namespace: "default"},
+				EgressPolicies: []types.NetworkPolicy{},
+				TargetPod:      types.PodRef{Name: "Pod2", Namespace: "default"},
+				IngressPolicies: []types.NetworkPolicy{
+					{Kind: "CiliumNetworkPolicy", Name: "cnp", Namespace: "default"},
+				},
+				Ports:       nil,
+				DeniedPorts: []int32{22},
+			},
+		},
+		{
+			name: "deny rules of policy sources without ports deny all traffic",
+			args: args{
+				sourcePodIsolation: &shared.PodIsolation{
+					Pod:             testutils.NewPodBuilder().WithName("Pod1").WithLabel("app", "foo").Build(),
+					IngressPolicies: []*networkingv1.NetworkPolicy{},
+					EgressPolicies:  []*networkingv1.NetworkPolicy{},
+					EgressSourcePolicies: []*shared.SourcePolicy{
+						{
+							Ref:        types.NetworkPolicy{Kind: "CiliumNetworkPolicy", Name: "cnp", Namespace: "default"},
+							IsEgress:   true,
+							Egress:     []shared.SourceRule{{Peers: []shared.SourcePeer{{Pods: podWithLabel("app", "bar")}}}},
+							EgressDeny: []shared.SourceRule{{Peers: []shared.SourcePeer{{Pods: podWithLabel("app", "bar")}}}},
+						},
+					},
+				},
+				targetPodIsolation: &shared.PodIsolation{
+					Pod:             testutils.NewPodBuilder().WithName("Pod2").WithLabel("app", "bar").Build(),
+					IngressPolicies: []*networkingv1.NetworkPolicy{},
+					EgressPolicies:  []*networkingv1.NetworkPolicy{},
+				},
+				namespaces: []*corev1.Namespace{
+					testutils.NewNamespaceBuilder().WithName("default").Build(),
+				},
+			},
+			expectedAllowedRoute: nil,
+		},
+		{
+			name: "a full port range of policy sources is kept as a range",
+			args: args{
+				sourcePodIsolation: &shared.PodIsolation{
+					Pod:             testutils.NewPodBuilder().WithName("Pod1").WithLabel("app", "foo").Build(),
+					IngressPolicies: []*networkingv1.NetworkPolicy{},
+					EgressPolicies:  []*networkingv1.NetworkPolicy{},
+				},
+				targetPodIsolation: &shared.PodIsolation{
+					Pod:             testutils.NewPodBuilder().WithName("Pod2").Build(),
+					IngressPolicies: []*networkingv1.NetworkPolicy{},
+					EgressPolicies:  []*networkingv1.NetworkPolicy{},
+					IngressSourcePolicies: []*shared.SourcePolicy{
+						{
+							Ref:       types.NetworkPolicy{Kind: "CiliumNetworkPolicy", Name: "cnp", Namespace: "default"},
+							IsIngress: true,
+							Ingress: []shared.SourceRule{
+								{
+									Peers: []shared.SourcePeer{{Pods: podWithLabel("app", "foo")}},
+									Ports: []types.PortRange{{Start: 1, End: 65535}},
+								},
+							},
+							IngressDeny: []shared.SourceRule{
+								{
+									Peers: []shared.SourcePeer{{Pods: podWithLabel("app", "foo")}},
+									Ports: []types.PortRange{{Start: 1, End: 1023}},
+								},
+							},
+						},
+					},
+				},
+				namespaces: []*corev1.Namespace{
+					testutils.NewNamespaceBuilder().WithName("default").Build(),
+				},
+			},
+			expectedAllowedRoute: &types.AllowedRoute{
+				SourcePod:      types.PodRef{Name: "Pod1", Namespace: "default"},
+				EgressPolicies: []types.NetworkPolicy{},
+				TargetPod:      types.PodRef{Name: "Pod2", Namespace: "default"},
+				IngressPolicies: []types.NetworkPolicy{
+					{Kind: "CiliumNetworkPolicy", Name: "cnp", Namespace: "default"},
+				},
+				Ports:      []int32{},
+				PortRanges: []types.PortRange{{Start: 1024, End: 65535}},
+			},
+		},
+		{
+			name: "named ports and port ranges of network policies are resolved against the target pod",
+			args: args{
+				sourcePodIsolation: &shared.PodIsolation{
+					Pod:             testutils.NewPodBuilder().WithName("Pod1").Build(),
+					IngressPolicies: []*networkingv1.NetworkPolicy{},
+					EgressPolicies:  []*networkingv1.NetworkPolicy{},
+				},
+				targetPodIsolation: &shared.PodIsolation{
+					Pod: testutils.NewPodBuilder().WithName("Pod2").
+						WithContainer(testutils.NewContainerBuilder().WithPort("metrics", 9100).Build()).Build(),
+					IngressPolicies: []*networkingv1.NetworkPolicy{
+						testutils.NewNetworkPolicyBuilder().WithName("in1").WithTypes("Ingress").
+							WithIngressRule(networkingv1.NetworkPolicyIngressRule{
+								Ports: []networkingv1.NetworkPolicyPort{
+									{Port: &intstr.IntOrString{Type: intstr.String, StrVal: "metrics"}},
+									{Port: &intstr.IntOrString{IntVal: 8000}, EndPort: int32Ptr(8002)},
+								},
+							}).Build(),
+					},
+					EgressPolicies: []*networkingv1.NetworkPolicy{},
+				},
+				namespaces: []*corev1.Namespace{
+					testutils.NewNamespaceBuilder().WithName("default").Build(),
+				},
+			},
+			expectedAllowedRoute: &types.AllowedRoute{
+				SourcePod:      types.PodRef{Name: "Pod1", Namespace: "default"},
+				EgressPolicies: []types.NetworkPolicy{},
+				TargetPod:      types.PodRef{Name: "Pod2", Namespace: "default"},
+				IngressPolicies: []types.NetworkPolicy{
+					{Name: "in1", Namespace: "default", Labels: map[string]string{}},
+				},
+				Ports: []int32{8000, 8001, 8002, 9100},
+			},
+		},
+		{
+			name: "tiered policies are evaluated in order and the first matching rule decides",
+			args: args{
+				sourcePodIsolation: &shared.PodIsolation{
+					Pod:             testutils.NewPodBuilder().WithName("Pod1").WithLabel("app", "foo").Build(),
+					IngressPolicies: []*networkingv1.NetworkPolicy{},
+					EgressPolicies:  []*networkingv1.NetworkPolicy{},
+				},
+				targetPodIsolation: &shared.PodIsolation{
+					Pod:             testutils.NewPodBuilder().WithName("Pod2").Build(),
+					IngressPolicies: []*networkingv1.NetworkPolicy{},
+					EgressPolicies:  []*networkingv1.NetworkPolicy{},
+					IngressSourcePolicies: []*shared.SourcePolicy{
+						{
+							Ref:       types.NetworkPolicy{Kind: "NetworkPolicy.projectcalico.org", Name: "allow"},
+							Tier:      &shared.PolicyTier{Name: "default"},
+							IsIngress: true,
+							Ingress: []shared.SourceRule{
+								{Action: shared.RuleActionAllow, Peers: []shared.SourcePeer{{Pods: podWithLabel("app", "foo")}}},
+							},
+						},
+						{
+							Ref:       types.NetworkPolicy{Kind: "GlobalNetworkPolicy.projectcalico.org", Name: "security"},
+							Tier:      &shared.PolicyTier{Name: "security", Order: &tierOrder},
+							IsIngress: true,
+							Ingress: []shared.SourceRule{
+								{Action: shared.RuleActionLog, Peers: []shared.SourcePeer{{Pods: podWithLabel("app", "foo")}}},
+								{
+									Action: shared.RuleActionDeny,
+									Peers:  []shared.SourcePeer{{Pods: podWithLabel("app", "foo")}},
+									Ports:  []types.PortRange{{Start: 22, End: 22}},
+								},
+								{Action: shared.RuleActionPass, Peers: []shared.SourcePeer{{Pods: podWithLabel("app", "foo")}}},
+							},
+						},
+					},
+				},
+				namespaces: []*corev1.Namespace{
+					testutils.NewNamespaceBuilder().WithName("default").Build(),
+				},
+			},
+			expectedAllowedRoute: &types.AllowedRoute{
+				SourcePod:      types.PodRef{Name: "Pod1", Namespace: "default"},
+				EgressPolicies: []types.NetworkPolicy{},
+				TargetPod:      types.PodRef{Name: "Pod2", Namespace: "default"},
+				IngressPolicies: []types.NetworkPolicy{
+					{Kind: "NetworkPolicy.projectcalico.org", Name: "allow"},
+				},
+				Ports:        nil,
+				DeniedPorts:  []int32{22},
+				IngressTiers: []string{"default"},
+			},
+		},
+		{
+			name: "traffic not matched by any rule of a tier is denied at the end of the tier",
+			args: args{
+				sourcePodIsolation: &shared.PodIsolation{
+					Pod:             testutils.NewPodBuilder().WithName("Pod1").WithLabel("app", "foo").Build(),
+					IngressPolicies: []*networkingv1.NetworkPolicy{},
+					EgressPolicies:  []*networkingv1.NetworkPolicy{},
+				},
+				targetPodIsolation: &shared.PodIsolation{
+					Pod:             testutils.NewPodBuilder().WithName("Pod2").Build(),
+					IngressPolicies: []*networkingv1.NetworkPolicy{},
+					EgressPolicies:  []*networkingv1.NetworkPolicy{},
+					IngressSourcePolicies: []*shared.SourcePolicy{
+						{
+							Ref:       types.NetworkPolicy{Kind: "GlobalNetworkPolicy.projectcalico.org", Name: "security"},
+							Tier:      &shared.PolicyTier{Name: "security", Order: &tierOrder},
+							IsIngress: true,
+							Ingress: []shared.SourceRule{
+								{Action: shared.RuleActionPass, Peers: []shared.SourcePeer{{Pods: podWithLabel("app", "bar")}}},
+							},
+						},
+					},
+				},
+				namespaces: []*corev1.Namespace{
+					testutils.NewNamespaceBuilder().WithName("default").Build(),
+				},
+			},
+			expectedAllowedRoute: nil,
+		},
+		{
+			name: "network policies are evaluated in the default tier after passing tiered policies",
+			args: args{
+				sourcePodIsolation: &shared.PodIsolation{
+					Pod:             testutils.NewPodBuilder().WithName("Pod1").WithLabel("app", "foo").Build(),
+					IngressPolicies: []*networkingv1.NetworkPolicy{},
+					EgressPolicies:  []*networkingv1.NetworkPolicy{},
+				},
+				targetPodIsolation: &shared.PodIsolation{
+					Pod: testutils.NewPodBuilder().WithName("Pod2").Build(),
+					IngressPolicies: []*networkingv1.NetworkPolicy{
+						testutils.NewNetworkPolicyBuilder().WithName("in1").WithTypes("Ingress").
+							WithIngressRule(networkingv1.NetworkPolicyIngressRule{
+								Ports: []networkingv1.NetworkPolicyPort{{Port: &intstr.IntOrString{IntVal: 80}}},
+								From: []networkingv1.NetworkPolicyPeer{
+									{
+										PodSelector: testutils.NewLabelSelectorBuilder().
+											WithMatchLabel("app", "foo").Build(),
+									},
+								},
+							}).Build(),
+					},
+					EgressPolicies: []*networkingv1.NetworkPolicy{},
+					IngressSourcePolicies: []*shared.SourcePolicy{
+						{
+							Ref:       types.NetworkPolicy{Kind: "GlobalNetworkPolicy.projectcalico.org", Name: "platform"},
+							Tier:      &shared.PolicyTier{Name: "platform", Order: &tierOrder},
+							IsIngress: true,
+							Ingress: []shared.SourceRule{
+								{Action: shared.RuleActionPass, Peers: []shared.SourcePeer{{Pods: podWithLabel("app", "foo")}}},
+							},
+						},
+					},
+				},
+				namespaces: []*corev1.Namespace{
+					testutils.NewNamespaceBuilder().WithName("default").Build(),
+				},
+			},
+			expectedAllowedRoute: &types.AllowedRoute{
+				SourcePod:      types.PodRef{Name: "Pod1", Namespace: "default"},
+				EgressPolicies: []types.NetworkPolicy{},
+				TargetPod:      types.PodRef{Name: "Pod2", Namespace: "default"},
+				IngressPolicies: []types.NetworkPolicy{
+					{Name: "in1", Namespace: "default", Labels: map[string]string{}},
+				},
+				Ports:        []int32{80},
+				IngressTiers: []string{"default"},
+			},
+		},
+		{
+			name: "admin network policies decide before baseline admin network policies, to which they can pass",
+			args: args{
+				sourcePodIsolation: &shared.PodIsolation{
+					Pod:             testutils.NewPodBuilder().WithName("Pod1").WithLabel("app", "foo").Build(),
+					IngressPolicies: []*networkingv1.NetworkPolicy{},
+					EgressPolicies:  []*networkingv1.NetworkPolicy{},
+				},
+				targetPodIsolation: &shared.PodIsolation{
+					Pod:             testutils.NewPodBuilder().WithName("Pod2").Build(),
+					IngressPolicies: []*networkingv1.NetworkPolicy{},
+					EgressPolicies:  []*networkingv1.NetworkPolicy{},
+					IngressSourcePolicies: []*shared.SourcePolicy{
+						{
+							Ref: types.NetworkPolicy{Kind: "BaselineAdminNetworkPolicy.policy.networking.k8s.io",
+								Name: "default"},
+							Tier: &shared.PolicyTier{Name: "baselineadminnetworkpolicy", Order: &baselineTierOrder,
+								DefaultAction: shared.RuleActionPass},
+							IsIngress: true,
+							Ingress: []shared.SourceRule{
+								{Action: shared.RuleActionDeny, Peers: []shared.SourcePeer{{Pods: podWithLabel("app", "foo")}}},
+							},
+						},
+						{
+							Ref:       types.NetworkPolicy{Kind: "AdminNetworkPolicy.policy.networking.k8s.io", Name: "https"},
+							Tier:      &shared.PolicyTier{Name: "adminnetworkpolicy", Order: &adminTierOrder, DefaultAction: shared.RuleActionPass},
+							Order:     &priority,
+							IsIngress: true,
+							Ingress: []shared.SourceRule{
+								{
+									Action: shared.RuleActionAllow,
+									Peers:  []shared.SourcePeer{{Pods: podWithLabel("app", "foo")}},
+									Ports:  []types.PortRange{{Start: 443, End: 443}},
+								},
+								{
+									Action: shared.RuleActionPass,
+									Peers:  []shared.SourcePeer{{Pods: podWithLabel("app", "foo")}},
+									Ports:  []types.PortRange{{Start: 80, End: 80}},
+								},
+							},
+						},
+					},
+				},
+				namespaces: []*corev1.Namespace{
+					testutils.NewNamespaceBuilder().WithName("default").Build(),
+				},
+			},
+			expectedAllowedRoute: &types.AllowedRoute{
+				SourcePod:      types.PodRef{Name: "Pod1", Namespace: "default"},
+				EgressPolicies: []types.NetworkPolicy{},
+				TargetPod:      types.PodRef{Name: "Pod2", Namespace: "default"},
+				IngressPolicies: []types.NetworkPolicy{
+					{Kind: "AdminNetworkPolicy.policy.networking.k8s.io", Name: "https"},
+				},
+				Ports:        []int32{443},
+				IngressTiers: []string{"adminnetworkpolicy"},
+			},
+		},
+		{
+			name: "named ports and port ranges of network policies are resolved against the target pod with tiered policies",
+			args: args{
+				sourcePodIsolation: &shared.PodIsolation{
+					Pod:             testutils.NewPodBuilder().WithName("Pod1").WithLabel("app", "foo").Build(),
+					IngressPolicies: []*networkingv1.NetworkPolicy{},
+					EgressPolicies:  []*networkingv1.NetworkPolicy{},
+				},
+				targetPodIsolation: &shared.PodIsolation{
+					Pod: testutils.NewPodBuilder().WithName("Pod2").
+						WithContainer(testutils.NewContainerBuilder().WithPort("metrics", 9100).Build()).Build(),
+					IngressPolicies: []*networkingv1.NetworkPolicy{
+						testutils.NewNetworkPolicyBuilder().WithName("in1").WithTypes("Ingress").
+							WithIngressRule(networkingv1.NetworkPolicyIngressRule{
+								Ports: []networkingv1.NetworkPolicyPort{
+									{Port: &intstr.IntOrString{Type: intstr.String, StrVal: "metrics"}},
+									{Port: &intstr.IntOrString{IntVal: 20000}, EndPort: int32Ptr(2147483647)},
+								},
+							}).Build(),
+					},
+					EgressPolicies: []*networkingv1.NetworkPolicy{},
+					IngressSourcePolicies: []*shared.SourcePolicy{
+						{
+							Ref:       types.NetworkPolicy{Kind: "GlobalNetworkPolicy.projectcalico.org", Name: "platform"},
+							Tier:      &shared.PolicyTier{Name: "platform", Order: &tierOrder},
+							IsIngress: true,
+							Ingress: []shared.SourceRule{
+								{Action: shared.RuleActionPass, Peers: []shared.SourcePeer{{Pods: podWithLabel("app", "foo")}}},
+							},
+						},
+					},
+				},
+				namespaces: []*corev1.Namespace{
+					testutils.NewNamespaceBuilder().WithName("default").Build(),
+				},
+			},
+			expectedAllowedRoute: &types.AllowedRoute{
+				SourcePod:      types.PodRef{Name: "Pod1", Namespace: "default"},
+				EgressPolicies: []types.NetworkPolicy{},
+				TargetPod:      types.PodRef{Name: "Pod2", Namespace: "default"},
+				IngressPolicies: []types.NetworkPolicy{
+					{Name: "in1", Namespace: "default", Labels: map[string]string{}},
+				},
+				Ports:        []int32{9100},
+				PortRanges:   []types.PortRange{{Start: 20000, End: 65535}},
+				IngressTiers: []string{"default"},
+			},
+		},
+		{
+			name: "a network policy rule with only named ports unknown to the target pod allows no port",
+			args: args{
+				sourcePodIsolation: &shared.PodIsolation{
+					Pod:             testutils.NewPodBuilder().WithName("Pod1").WithLabel("app", "foo").Build(),
+					IngressPolicies: []*networkingv1.NetworkPolicy{},
+					EgressPolicies:  []*networkingv1.NetworkPolicy{},
+				},
+				targetPodIsolation: &shared.PodIsolation{
+					Pod: testutils.NewPodBuilder().WithName("Pod2").Build(),
+					IngressPolicies: []*networkingv1.NetworkPolicy{
+						testutils.NewNetworkPolicyBuilder().WithName("in1").WithTypes("Ingress").
+							WithIngressRule(networkingv1.NetworkPolicyIngressRule{
+								Ports: []networkingv1.NetworkPolicyPort{
+									{Port: &intstr.IntOrString{Type: intstr.String, StrVal: "metrics"}},
+								},
+							}).Build(),
+					},
+					EgressPolicies: []*networkingv1.NetworkPolicy{},
+					IngressSourcePolicies: []*shared.SourcePolicy{
+						{
+							Ref:       types.NetworkPolicy{Kind: "GlobalNetworkPolicy.projectcalico.org", Name: "platform"},
+							Tier:      &shared.PolicyTier{Name: "platform", Order: &tierOrder},
+							IsIngress: true,
+							Ingress: []shared.SourceRule{
+								{Action: shared.RuleActionPass, Peers: []shared.SourcePeer{{Pods: podWithLabel("app", "foo")}}},
+							},
+						},
+					},
+				},
+				namespaces: []*corev1.Namespace{
+					testutils.NewNamespaceBuilder().WithName("default").Build(),
+				},
+			},
+			expectedAllowedRoute: nil,
+		},
+		{
+			name: "a wide denied port range is reported as a range without denying other ports",
+			args: args{
+				sourcePodIsolation: &shared.PodIsolation{
+					Pod:             testutils.NewPodBuilder().WithName("Pod1").WithLabel("app", "foo").Build(),
+					IngressPolicies: []*networkingv1.NetworkPolicy{},
+					EgressPolicies:  []*networkingv1.NetworkPolicy{},
+				},
+				targetPodIsolation: &shared.PodIsolation{
+					Pod:             testutils.NewPodBuilder().WithName("Pod2").Build(),
+					IngressPolicies: []*networkingv1.NetworkPolicy{},
+					EgressPolicies:  []*networkingv1.NetworkPolicy{},
+					IngressSourcePolicies: []*shared.SourcePolicy{
+						{
+							Ref:       types.NetworkPolicy{Kind: "GlobalNetworkPolicy.projectcalico.org", Name: "high-ports"},
+							Tier:      &shared.PolicyTier{Name: "platform", Order: &tierOrder},
+							IsIngress: true,
+							Ingress: []shared.SourceRule{
+								{
+									Action: shared.RuleActionDeny,
+									Peers:  []shared.SourcePeer{{Pods: podWithLabel("app", "foo")}},
+									Ports:  []types.PortRange{{Start: 22, End: 22}, {Start: 1024, End: 65535}},
+								},
+								{Action: shared.RuleActionAllow, Peers: []shared.SourcePeer{{Pods: podWithLabel("app", "foo")}}},
+							},
+						},
+					},
+				},
+				namespaces: []*corev1.Namespace{
+					testutils.NewNamespaceBuilder().WithName("default").Build(),
+				},
+			},
+			expectedAllowedRoute: &types.AllowedRoute{
+				SourcePod:      types.PodRef{Name: "Pod1", Namespace: "default"},
+				EgressPolicies: []types.NetworkPolicy{},
+				TargetPod:      types.PodRef{Name: "Pod2", Namespace: "default"},
+				IngressPolicies: []types.NetworkPolicy{
+					{Kind: "GlobalNetworkPolicy.projectcalico.org", Name: "high-ports"},
+				},
+				DeniedPorts:      []int32{22},
+				DeniedPortRanges: []types.PortRange{{Start: 1024, End: 65535}},
+				IngressTiers:     []string{"platform"},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -838,3 +1377,13 @@ func TestAnalyze(t *testing.T) {
 		})
 	}
 }
+
+func podWithLabel(key string, value string) shared.PodMatcher {
+	return func(pod *corev1.Pod, namespaceLabels map[string]string) bool {
+		return pod.Labels[key] == value
+	}
+}
+
+func int32Ptr(value int32) *int32 {
+	return &value
+}
@@ -5,20 +5,37 @@ import (
 	networkingv1 "k8s.io/api/networking/v1"
 	"karto/analyzer/shared"
 	"karto/analyzer/traffic/allowedroute"
+	"karto/analyzer/traffic/externaldestination"
+	"karto/analyzer/traffic/externalroute"
 	"karto/analyzer/traffic/podisolation"
+	"karto/analyzer/traffic/policysource"
 	"karto/commons"
+	"karto/crds/calico"
+	"karto/crds/cilium"
+	"karto/crds/istio"
+	"karto/crds/policyapi"
 	"karto/types"
 )
 
 type ClusterState struct {
-	Pods            []*corev1.Pod
-	Namespaces      []*corev1.Namespace
-	NetworkPolicies []*networkingv1.NetworkPolicy
+	Pods                  []*corev1.Pod
+	Namespaces            []*corev1.Namespace
+	NetworkPolicies       []*networkingv1.NetworkPolicy
+	CiliumNetworkPolicies []*cilium.NetworkPolicy
+	CalicoNetworkPolicies []*calico.NetworkPolicy
+	CalicoTiers           []*calico.Tier
+	AdminNetworkPolicies  []*policyapi.AdminNetworkPolicy
+	Services              []*corev1.Service
+	ServiceEntries        []*istio.ServiceEntry
 }
 
 type AnalysisResult struct {
-	Pods          []*types.PodIsolation
-	AllowedRoutes []*types.AllowedRoute
+	Pods             []*types.PodIsolation
+	PodIsolations    []*shared.PodIsolation
+	AllowedRoutes    []*types.AllowedRoute
+	ExternalRoutes   []*types.ExternalRoute
+	ExternalNodes    []*types.ExternalNode
+	ExternalAccesses []*types.ExternalAccess
 }
 
 type Analyzer interface {
@@ -26,34 +43,83 @@ type Analyzer interface {
 }
 
 type analyzerImpl struct {
-	podIsolationAnalyzer podisolation.Analyzer
-	allowedRouteAnalyzer allowedroute.Analyzer
+	podIsolationAnalyzer        podisolation.Analyzer
+	allowedRouteAnalyzer        allowedroute.Analyzer
+	externalRouteAnalyzer       externalroute.Analyzer
+	externalDestinationAnalyzer externaldestination.Analyzer
+	policySources               []policysource.Source
 }
 
-func NewAnalyzer(podIsolationAnalyzer podisolation.Analyzer, allowedRouteAnalyzer allowedroute.Analyzer) Analyzer {
+func NewAnalyzer(podIsolationAnalyzer podisolation.Analyzer, allowedRouteAnalyzer allowedroute.Analyzer,
+	externalRouteAnalyzer externalroute.Analyzer, externalDestinationAnalyzer externaldestination.Analyzer,
+	policySources ...policysource.Source) Analyzer {
 	return analyzerImpl{
-		podIsolationAnalyzer: podIsolationAnalyzer,
-		allowedRouteAnalyzer: allowedRouteAnalyzer,
+		podIsolationAnalyzer:        podIsolationAnalyzer,
+		allowedRouteAnalyzer:        allowedRouteAnalyzer,
+		externalRouteAnalyzer:       externalRouteAnalyzer,
+		externalDestinationAnalyzer: externalDestinationAnalyzer,
+		policySources:               policySources,
 	}
 }
 
 func (analyzer analyzerImpl) Analyze(clusterState ClusterState) AnalysisResult {
-	podIsolations := analyzer.podIsolationsOfAllPods(clusterState.Pods, clusterState.NetworkPolicies)
+	sourcePolicies := analyzer.sourcePolicies(clusterState)
+	podIsolations := analyzer.podIsolationsOfAllPods(clusterState.Pods, clusterState.NetworkPolicies,
+		sourcePolicies, clusterState.Namespaces)
 	allowedRoutes := analyzer.allowedRoutesBetweenPods(podIsolations, clusterState.Namespaces)
+	externalRoutes := make([]*types.ExternalRoute, 0)
+	for _, podIsolation := range podIsolations {
+		externalRoutes = append(externalRoutes, analyzer.externalRouteAnalyzer.Analyze(podIsolation)...)
+	}
+	externalDestinations := analyzer.externalDestinationAnalyzer.Analyze(externaldestination.ClusterState{
+		PodIsolations:  podIsolations,
+		Services:       clusterState.Services,
+		ServiceEntries: clusterState.ServiceEntries,
+	})
 	return AnalysisResult{
 		Pods: commons.Map(podIsolations, func(podIsolation *shared.PodIsolation) *types.PodIsolation {
 			return podIsolation.ToPodIsolation()
 		}),
-		AllowedRoutes: allowedRoutes,
+		PodIsolations:    podIsolations,
+		AllowedRoutes:    allowedRoutes,
+		ExternalRoutes:   externalRoutes,
+		ExternalNodes:    externalDestinations.Nodes,
+		ExternalAccesses: externalDestinations.Accesses,
 	}
 }
 
+func (analyzer analyzerImpl) sourcePolicies(clusterState ClusterState) []*shared.SourcePolicy {
+	sourceClusterState := policysource.ClusterState{
+		CiliumNetworkPolicies: clusterState.CiliumNetworkPolicies,
+		CalicoNetworkPolicies: clusterState.CalicoNetworkPolicies,
+		CalicoTiers:           clusterState.CalicoTiers,
+		AdminNetworkPolicies:  clusterState.AdminNetworkPolicies,
+	}
+	result := make([]*shared.SourcePolicy, 0)
+	for _, policySource := range analyzer.policySources {
+		result = append(result, policySource.Policies(sourceClusterState)...)
+	}
+	return result
+}
+
 func (analyzer analyzerImpl) podIsolationsOfAllPods(
 	pods []*corev1.Pod,
 	policies []*networkingv1.NetworkPolicy,
+	sourcePolicies []*shared.SourcePolicy,
+	namespaces []*corev1.Namespace,
 ) []*shared.PodIsolation {
 	return commons.Map(pods, func(pod *corev1.Pod) *shared.PodIsolation {
-		return analyzer.podIsolationAnalyzer.Analyze(pod, policies)
+		podIsolation := analyzer.podIsolationAnalyzer.Analyze(pod, policies)
+		if pod.Spec.HostNetwork {
+			return podIsolation
+		}
+		namespaceLabels := shared.NamespaceLabels(pod.Namespace, namespaces)
+		for _, sourcePolicy := range sourcePolicies {
+			if sourcePolicy.Selects(pod, namespaceLabels) {
+				podIsolation.AddSourcePolicy(sourcePolicy)
+			}
+		}
+		return podIsolation
 	})
 }
 
@@ -6,7 +6,11 @@ import (
 	networkingv1 "k8s.io/api/networking/v1"
 	"karto/analyzer/shared"
 	"karto/analyzer/traffic/allowedroute"
+	"karto/analyzer/traffic/externaldestination"
+	"karto/analyzer/traffic/externalroute"
 	"karto/analyzer/traffic/podisolation"
+	"karto/analyzer/traffic/policysource"
+	"karto/crds/cilium"
 	"karto/testutils"
 	"karto/types"
 	"reflect"
@@ -18,8 +22,11 @@ func TestAnalyze(t *testing.T) {
 		clusterState ClusterState
 	}
 	type mocks struct {
-		podIsolation []mockPodIsolationAnalyzerCall
-		allowedRoute []mockAllowedRouteAnalyzerCall
+		podIsolation        []mockPodIsolationAnalyzerCall
+		allowedRoute        []mockAllowedRouteAnalyzerCall
+		externalRoute       []mockExternalRouteAnalyzerCall
+		externalDestination []mockExternalDestinationAnalyzerCall
+		policySource        []mockPolicySourceCall
 	}
 	k8sNamespace := testutils.NewNamespaceBuilder().WithName("ns").Build()
 	k8sPod1 := testutils.NewPodBuilder().WithName("pod1").WithNamespace("ns").Build()
@@ -51,6 +58,45 @@ func TestAnalyze(t *testing.T) {
 		},
 		Ports: []int32{80, 443},
 	}
+	ciliumPolicy := &cilium.NetworkPolicy{}
+	ciliumPolicy.Name = "ciliumPol"
+	ciliumPolicy.Namespace = "ns"
+	sourcePolicy := &shared.SourcePolicy{
+		Ref: types.NetworkPolicy{Kind: cilium.NetworkPolicyKind, Name: "ciliumPol", Namespace: "ns"},
+		Selects: func(pod *corev1.Pod, namespaceLabels map[string]string) bool {
+			return pod.Name == "pod2"
+		},
+		IsIngress: true,
+	}
+	sourcePodIsolation1 := &shared.PodIsolation{
+		Pod:             k8sPod1,
+		IngressPolicies: []*networkingv1.NetworkPolicy{},
+		EgressPolicies:  []*networkingv1.NetworkPolicy{},
+	}
+	sourcePodIsolation2 := &shared.PodIsolation{
+		Pod:             k8sPod2,
+		IngressPolicies: []*networkingv1.NetworkPolicy{},
+		EgressPolicies:  []*networkingv1.NetworkPolicy{},
+	}
+	externalRoute := &types.ExternalRoute{
+		Pod:       podRef2,
+		Direction: types.TrafficIngress,
+		Peer:      types.ExternalPeer{Kind: types.ExternalPeerEntity, Name: "world"},
+		Policies:  []types.NetworkPolicy{sourcePolicy.Ref},
+		Ports:     []int32{443},
+	}
+	externalNameService := testutils.NewServiceBuilder().WithName("api").WithNamespace("ns").
+		WithExternalName("api.example.com").Build()
+	externalNode := &types.ExternalNode{
+		Kind:    types.ExternalPeerFQDN,
+		Name:    "api.example.com",
+		Sources: []types.ObjectRef{{Kind: "Service", Name: "api", Namespace: "ns"}},
+	}
+	externalAccess := &types.ExternalAccess{
+		Pod:      podRef1,
+		Node:     types.ExternalPeer{Kind: types.ExternalPeerFQDN, Name: "api.example.com"},
+		Policies: []types.NetworkPolicy{},
+	}
 	tests := []struct {
 		name                   string
 		mocks                  mocks
@@ -90,6 +136,24 @@ func TestAnalyze(t *testing.T) {
 						returnValue: nil,
 					},
 				},
+				externalRoute: []mockExternalRouteAnalyzerCall{
+					{podIsolation: podIsolation1, returnValue: []*types.ExternalRoute{}},
+					{podIsolation: podIsolation2, returnValue: []*types.ExternalRoute{}},
+				},
+				externalDestination: []mockExternalDestinationAnalyzerCall{
+					{
+						clusterState: externaldestination.ClusterState{
+							PodIsolations: []*shared.PodIsolation{podIsolation1, podIsolation2},
+						},
+						returnValue: externaldestination.AnalysisResult{
+							Nodes:    []*types.ExternalNode{},
+							Accesses: []*types.ExternalAccess{},
+						},
+					},
+				},
+				policySource: []mockPolicySourceCall{
+					{clusterState: policysource.ClusterState{}, returnValue: []*shared.SourcePolicy{}},
+				},
 			},
 			args: args{
 				clusterState: ClusterState{
@@ -103,7 +167,87 @@ func TestAnalyze(t *testing.T) {
 					{Pod: podRef1, IsIngressIsolated: false, IsEgressIsolated: false},
 					{Pod: podRef2, IsIngressIsolated: false, IsEgressIsolated: false},
 				},
-				AllowedRoutes: []*types.AllowedRoute{allowedRoute},
+				PodIsolations:    []*shared.PodIsolation{podIsolation1, podIsolation2},
+				AllowedRoutes:    []*types.AllowedRoute{allowedRoute},
+				ExternalRoutes:   []*types.ExternalRoute{},
+				ExternalNodes:    []*types.ExternalNode{},
+				ExternalAccesses: []*types.ExternalAccess{},
+			},
+		},
+		{
+			name: "attaches policies of policy sources to the pods they select",
+			mocks: mocks{
+				podIsolation: []mockPodIsolationAnalyzerCall{
+					{
+						args:        mockPodIsolationAnalyzerCallArgs{pod: k8sPod1},
+						returnValue: sourcePodIsolation1,
+					},
+					{
+						args:        mockPodIsolationAnalyzerCallArgs{pod: k8sPod2},
+						returnValue: sourcePodIsolation2,
+					},
+				},
+				allowedRoute: []mockAllowedRouteAnalyzerCall{
+					{
+						args: mockAllowedRouteAnalyzerCallArgs{
+							sourcePodIsolation: sourcePodIsolation1,
+							targetPodIsolation: sourcePodIsolation2,
+							namespaces:         []*corev1.Namespace{k8sNamespace},
+						},
+						returnValue: nil,
+					},
+					{
+						args: mockAllowedRouteAnalyzerCallArgs{
+							sourcePodIsolation: sourcePodIsolation2,
+							targetPodIsolation: sourcePodIsolation1,
+							namespaces:         []*corev1.Namespace{k8sNamespace},
+						},
+						returnValue: nil,
+					},
+				},
+				externalRoute: []mockExternalRouteAnalyzerCall{
+					{podIsolation: sourcePodIsolation1, returnValue: []*types.ExternalRoute{}},
+					{podIsolation: sourcePodIsolation2, returnValue: []*types.ExternalRoute{externalRoute}},
+				},
+				externalDestination: []mockExternalDestinationAnalyzerCall{
+					{
+						clusterState: externaldestination.ClusterState{
+							PodIsolations: []*shared.PodIsolation{sourcePodIsolation1, sourcePodIsolation2},
+							Services:      []*corev1.Service{externalNameService},
+						},
+						returnValue: externaldestination.AnalysisResult{
+							Nodes:    []*types.ExternalNode{externalNode},
+							Accesses: []*types.ExternalAccess{externalAccess},
+						},
+					},
+				},
+				policySource: []mockPolicySourceCall{
+					{
+						clusterState: policysource.ClusterState{
+							CiliumNetworkPolicies: []*cilium.NetworkPolicy{ciliumPolicy},
+						},
+						returnValue: []*shared.SourcePolicy{sourcePolicy},
+					},
+				},
+			},
+			args: args{
+				clusterState: ClusterState{
+					Pods:                  []*corev1.Pod{k8sPod1, k8sPod2},
+					Namespaces:            []*corev1.Namespace{k8sNamespace},
+					CiliumNetworkPolicies: []*cilium.NetworkPolicy{ciliumPolicy},
+					Services:              []*corev1.Service{externalNameService},
+				},
+			},
+			expectedAnalysisResult: AnalysisResult{
+				Pods: []*types.PodIsolation{
+					{Pod: podRef1, IsIngressIsolated: false, IsEgressIsolated: false},
+					{Pod: podRef2, IsIngressIsolated: true, IsEgressIsolated: false},
+				},
+				PodIsolations:    []*shared.PodIsolation{sourcePodIsolation1, sourcePodIsolation2},
+				AllowedRoutes:    []*types.AllowedRoute{},
+				ExternalRoutes:   []*types.ExternalRoute{externalRoute},
+				ExternalNodes:    []*types.ExternalNode{externalNode},
+				ExternalAccesses: []*types.ExternalAccess{externalAccess},
 			},
 		},
 	}
@@ -111,9 +255,16 @@ func TestAnalyze(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			podIsolationAnalyzer := createMockPodIsolationAnalyzer(t, tt.mocks.podIsolation)
 			allowedRouteAnalyzer := createMockAllowedRouteAnalyzer(t, tt.mocks.allowedRoute)
-			analyzer := NewAnalyzer(podIsolationAnalyzer, allowedRouteAnalyzer)
+			externalRouteAnalyzer := createMockExternalRouteAnalyzer(t, tt.mocks.externalRoute)
+			externalDestinationAnalyzer := createMockExternalDestinationAnalyzer(t, tt.mocks.externalDestination)
+			policySource := createMockPolicySource(t, tt.mocks.policySource)
+			analyzer := NewAnalyzer(podIsolationAnalyzer, allowedRouteAnalyzer, externalRouteAnalyzer,
+				externalDestinationAnalyzer, policySource)
 			analysisResult := analyzer.Analyze(tt.args.clusterState)
-			if diff := cmp.Diff(tt.expectedAnalysisResult, analysisResult); diff != "" {
+			samePodIsolation := cmp.Comparer(func(left *shared.PodIsolation, right *shared.PodIsolation) bool {
+				return left == right
+			})
+			if diff := cmp.Diff(tt.expectedAnalysisResult, analysisResult, samePodIsolation); diff != "" {
 				t.Errorf("Analyze() result mismatch (-want +got):\n%s", diff)
 			}
 		})
@@ -181,7 +332,7 @@ func (mock mockAllowedRouteAnalyzer) Analyze(sourcePodIsolation *shared.PodIsola
 		}
 	}
 	mock.t.Fatalf("mockAllowedRouteAnalyzer was called with unexpected arguments: \n"+
-		"\tsourcePodIsolation: %s\n\ttargetPodIsolation: %s\n\tnamespaces: %s\n", sourcePodIsolation,
+		"\tsourcePodIsolation: %v\n\ttargetPodIsolation: %v\n\tnamespaces: %s\n", sourcePodIsolation,
 		targetPodIsolation, namespaces)
 	return nil
 }
@@ -192,3 +343,88 @@ func createMockAllowedRouteAnalyzer(t *testing.T, calls []mockAllowedRouteAnalyz
 		calls: calls,
 	}
 }
+
+type mockExternalRouteAnalyzerCall struct {
+	podIsolation *shared.PodIsolation
+	returnValue  []*types.ExternalRoute
+}
+
+type mockExternalRouteAnalyzer struct {
+	t     *testing.T
+	calls []mockExternalRouteAnalyzerCall
+}
+
+func (mock mockExternalRouteAnalyzer) Analyze(podIsolation *shared.PodIsolation) []*types.ExternalRoute {
+	for _, call := range mock.calls {
+		if call.podIsolation == podIsolation {
+			return call.returnValue
+		}
+	}
+	mock.t.Fatalf("mockExternalRouteAnalyzer was called with unexpected arguments: \n\tpodIsolation: %v\n",
+		podIsolation)
+	return nil
+}
+
+func createMockExternalRouteAnalyzer(t *testing.T, calls []mockExternalRouteAnalyzerCall) externalroute.Analyzer {
+	return mockExternalRouteAnalyzer{
+		t:     t,
+		calls: calls,
+	}
+}
+
+type mockExternalDestinationAnalyzerCall struct {
+	clusterState externaldestination.ClusterState
+	returnValue  externaldestination.AnalysisResult
+}
+
+type mockExternalDestinationAnalyzer struct {
+	t     *testing.T
+	calls []mockExternalDestinationAnalyzerCall
+}
+
+func (mock mockExternalDestinationAnalyzer) Analyze(
+	clusterState externaldestination.ClusterState) externaldestination.AnalysisResult {
+	for _, call := range mock.calls {
+		if reflect.DeepEqual(call.clusterState, clusterState) {
+			return call.returnValue
+		}
+	}
+	mock.t.Fatalf("mockExternalDestinationAnalyzer was called with unexpected arguments: \n\tclusterState: %v\n",
+		clusterState)
+	return externaldestination.AnalysisResult{}
+}
+
+func createMockExternalDestinationAnalyzer(t *testing.T,
+	calls []mockExternalDestinationAnalyzerCall) externaldestination.Analyzer {
+	return mockExternalDestinationAnalyzer{
+		t:     t,
+		calls: calls,
+	}
+}
+
+type mockPolicySourceCall struct {
+	clusterState policysource.ClusterState
+	returnValue  []*shared.SourcePolicy
+}
+
+type mockPolicySource struct {
+	t     *testing.T
+	calls []mockPolicySourceCall
+}
+
+func (mock mockPolicySource) Policies(clusterState policysource.ClusterState) []*shared.SourcePolicy {
+	for _, call := range mock.calls {
+		if reflect.DeepEqual(call.clusterState, clusterState) {
+			return call.returnValue
+		}
+	}
+	mock.t.Fatalf("mockPolicySource was called with unexpected arguments: \n\tclusterState: %v\n", clusterState)
+	return nil
+}
+
+func createMockPolicySource(t *testing.T, calls []mockPolicySourceCall) policysource.Source {
+	return mockPolicySource{
+		t:     t,
+		calls: calls,
+	}
+}
@@ -0,0 +1,268 @@
+package calicopolicy
+
+import (
+	"fmt"
+	"strings"
+)
+
+type selector func(labels map[string]string) bool
+
+type selectorParser struct {
+	input    string
+	position int
+}
+
+func parseSelector(expression string) (selector, error) {
+	if strings.TrimSpace(expression) == "" {
+		return matchAll, nil
+	}
+	parser := &selectorParser{input: expression}
+	result, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	parser.skipSpaces()
+	if parser.position != len(parser.input) {
+		return nil, parser.errorf("unexpected %q", parser.input[parser.position:])
+	}
+	return result, nil
+}
+
+func matchAll(map[string]string) bool {
+	return true
+}
+
+func matchNone(map[string]string) bool {
+	return false
+}
+
+func (parser *selectorParser) parseOr() (selector, error) {
+	left, err := parser.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for parser.consume("||") {
+		right, err := parser.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		previous := left
+		left = func(labels map[string]string) bool { return previous(labels) || right(labels) }
+	}
+	return left, nil
+}
+
+func (parser *selectorParser) parseAnd() (selector, error) {
+	left, err := parser.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for parser.consume("&&") {
+		right, err := parser.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		previous := left
+		left = func(labels map[string]string) bool { return previous(labels) && right(labels) }
+	}
+	return left, nil
+}
+
+func (parser *selectorParser) parseUnary() (selector, error) {
+	if parser.consume("!") {
+		operand, err := parser.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(labels map[string]string) bool { return !operand(labels) }, nil
+	}
+	if parser.consume("(") {
+		inner, err := parser.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !parser.consume(")") {
+			return nil, parser.errorf("expected ')'")
+		}
+		return inner, nil
+	}
+	return parser.parseTerm()
+}
+
+func (parser *selectorParser) parseTerm() (selector, error) {
+	if parser.consumeFunction("all") {
+		return matchAll, parser.expect(")")
+	}
+	if parser.consumeFunction("global") {
+		return matchNone, parser.expect(")")
+	}
+	if parser.consumeFunction("has") {
+		key := parser.parseLabel()
+		if key == "" || !parser.consume(")") {
+			return nil, parser.errorf("invalid has() expression")
+		}
+		return func(labels map[string]string) bool {
+			_, ok := labels[key]
+			return ok
+		}, nil
+	}
+	key := parser.parseLabel()
+	if key == "" {
+		return nil, parser.errorf("expected label")
+	}
+	switch {
+	case parser.consume("=="):
+		value, err := parser.parseString()
+		return func(labels map[string]string) bool {
+			actual, ok := labels[key]
+			return ok && actual == value
+		}, err
+	case parser.consume("!="):
+		value, err := parser.parseString()
+		return func(labels map[string]string) bool {
+			actual, ok := labels[key]
+			return !ok || actual != value
+		}, err
+	case parser.consumeWord("in"):
+		values, err := parser.parseSet()
+		return func(labels map[string]string) bool {
+			actual, ok := labels[key]
+			return ok && values[actual]
+		}, err
+	case parser.consumeWord("not"):
+		if !parser.consumeWord("in") {
+			return nil, parser.errorf("expected 'in'")
+		}
+		values, err := parser.parseSet()
+		return func(labels map[string]string) bool {
+			actual, ok := labels[key]
+			return !ok || !values[actual]
+		}, err
+	case parser.consumeWord("starts"):
+		return parser.parseStringOperator("with", key, strings.HasPrefix)
+	case parser.consumeWord("ends"):
+		return parser.parseStringOperator("with", key, strings.HasSuffix)
+	case parser.consumeWord("contains"):
+		return parser.parseStringOperator("", key, strings.Contains)
+	}
+	return nil, parser.errorf("expected operator after %q", key)
+}
+
+func (parser *selectorParser) parseStringOperator(
+	keyword string,
+	key string,
+	operator func(string, string) bool,
+) (selector, error) {
+	if keyword != "" && !parser.consumeWord(keyword) {
+		return nil, parser.errorf("expected %q", keyword)
+	}
+	value, err := parser.parseString()
+	return func(labels map[string]string) bool {
+		actual, ok := labels[key]
+		return ok && operator(actual, value)
+	}, err
+}
+
+func (parser *selectorParser) parseSet() (map[string]bool, error) {
+	if !parser.consume("{") {
+		return nil, parser.errorf("expected '{'")
+	}
+	values := make(map[string]bool)
+	if parser.consume("}") {
+		return values, nil
+	}
+	for {
+		value, err := parser.parseString()
+		if err != nil {
+			return nil, err
+		}
+		values[value] = true
+		if parser.consume("}") {
+			return values, nil
+		}
+		if !parser.consume(",") {
+			return nil, parser.errorf("expected ',' or '}'")
+		}
+	}
+}
+
+func (parser *selectorParser) parseString() (string, error) {
+	parser.skipSpaces()
+	if parser.position >= len(parser.input) {
+		return "", parser.errorf("expected string")
+	}
+	quote := parser.input[parser.position]
+	if quote != '\'' && quote != '"' {
+		return "", parser.errorf("expected quoted string")
+	}
+	end := strings.IndexByte(parser.input[parser.position+1:], quote)
+	if end < 0 {
+		return "", parser.errorf("unterminated string")
+	}
+	value := parser.input[parser.position+1 : parser.position+1+end]
+	parser.position += end + 2
+	return value, nil
+}
+
+func (parser *selectorParser) parseLabel() string {
+	parser.skipSpaces()
+	start := parser.position
+	for parser.position < len(parser.input) && isLabelCharacter(parser.input[parser.position]) {
+		parser.position++
+	}
+	return parser.input[start:parser.position]
+}
+
+func isLabelCharacter(character byte) bool {
+	return character >= 'a' && character <= 'z' || character >= 'A' && character <= 'Z' ||
+		character >= '0' && character <= '9' || strings.IndexByte("_./-", character) >= 0
+}
+
+func (parser *selectorParser) consumeFunction(name string) bool {
+	start := parser.position
+	if parser.consumeWord(name) && parser.consume("(") {
+		return true
+	}
+	parser.position = start
+	return false
+}
+
+func (parser *selectorParser) consumeWord(word string) bool {
+	parser.skipSpaces()
+	end := parser.position + len(word)
+	if !strings.HasPrefix(parser.input[parser.position:], word) {
+		return false
+	}
+	if end < len(parser.input) && isLabelCharacter(parser.input[end]) {
+		return false
+	}
+	parser.position = end
+	return true
+}
+
+func (parser *selectorParser) consume(token string) bool {
+	parser.skipSpaces()
+	if strings.HasPrefix(parser.input[parser.position:], token) {
+		parser.position += len(token)
+		return true
+	}
+	return false
+}
+
+func (parser *selectorParser) expect(token string) error {
+	if !parser.consume(token) {
+		return parser.errorf("expected %q", token)
+	}
+	return nil
+}
+
+func (parser *selectorParser) skipSpaces() {
+	for parser.position < len(parser.input) && strings.IndexByte(" \t\n", parser.input[parser.position]) >= 0 {
+		parser.position++
+	}
+}
+
+func (parser *selectorParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid selector %q at position %d: %s", parser.input, parser.position,
+		fmt.Sprintf(format, args...))
+}
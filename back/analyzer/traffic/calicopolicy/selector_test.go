@@ -0,0 +1,52 @@
+package calicopolicy
+
+import (
+	"testing"
+)
+
+func TestParseSelector(t *testing.T) {
+	labels := map[string]string{"app": "web", "tier": "front", "projectcalico.org/namespace": "shop"}
+	tests := []struct {
+		name            string
+		expression      string
+		expectedMatches bool
+		expectedError   bool
+	}{
+		{name: "empty selector matches everything", expression: "", expectedMatches: true},
+		{name: "all() matches everything", expression: "all()", expectedMatches: true},
+		{name: "global() matches no pod", expression: "global()", expectedMatches: false},
+		{name: "equality matches label value", expression: "app == 'web'", expectedMatches: true},
+		{name: "inequality matches absent labels", expression: "team != \"core\"", expectedMatches: true},
+		{name: "has() checks label presence", expression: "has(tier) && !has(team)", expectedMatches: true},
+		{name: "in matches one of the values", expression: "app in {'api', 'web'}", expectedMatches: true},
+		{name: "not in rejects listed values", expression: "app not in {'web'}", expectedMatches: false},
+		{
+			name:            "string operators match prefixes, suffixes and substrings",
+			expression:      "app starts with 'w' && tier ends with 'nt' && projectcalico.org/namespace contains 'ho'",
+			expectedMatches: true,
+		},
+		{
+			name:            "parentheses group boolean expressions",
+			expression:      "!(app == 'api' || tier == 'back') && (app == 'web')",
+			expectedMatches: true,
+		},
+		{name: "label named like a function is a label", expression: "all == 'x'", expectedMatches: false},
+		{name: "unterminated string is rejected", expression: "app == 'web", expectedError: true},
+		{name: "missing operator is rejected", expression: "app", expectedError: true},
+		{name: "trailing tokens are rejected", expression: "all() all()", expectedError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selector, err := parseSelector(tt.expression)
+			if (err != nil) != tt.expectedError {
+				t.Fatalf("parseSelector() error = %v, expectedError %v", err, tt.expectedError)
+			}
+			if err != nil {
+				return
+			}
+			if matches := selector(labels); matches != tt.expectedMatches {
+				t.Errorf("parseSelector() matches = %v, expected %v", matches, tt.expectedMatches)
+			}
+		})
+	}
+}
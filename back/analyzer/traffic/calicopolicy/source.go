@@ -0,0 +1,363 @@
+package calicopolicy
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"karto/analyzer/shared"
+	"karto/analyzer/traffic/policysource"
+	"karto/crds/calico"
+	"karto/types"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+)
+
+const (
+	namespaceLabel         = "projectcalico.org/namespace"
+	orchestratorLabel      = "projectcalico.org/orchestrator"
+	serviceAccountLabel    = "projectcalico.org/serviceaccount"
+	nameLabel              = "projectcalico.org/name"
+	kubernetesOrchestrator = "k8s"
+	defaultServiceAccount  = "default"
+	kubernetesPolicyPrefix = "knp.default."
+	worldEntity            = "world"
+	anyNetwork             = "0.0.0.0/0"
+	cidrExceptSeparator    = " except "
+)
+
+var (
+	networkPolicyKind       = calico.NetworkPolicyKind + "." + calico.GroupVersion.Group
+	globalNetworkPolicyKind = calico.GlobalNetworkPolicyKind + "." + calico.GroupVersion.Group
+	actions                 = map[string]shared.RuleAction{
+		calico.ActionAllow: shared.RuleActionAllow,
+		calico.ActionDeny:  shared.RuleActionDeny,
+		calico.ActionPass:  shared.RuleActionPass,
+		calico.ActionLog:   shared.RuleActionLog,
+	}
+)
+
+type sourceImpl struct{}
+
+func NewSource() policysource.Source {
+	return sourceImpl{}
+}
+
+func (source sourceImpl) Policies(clusterState policysource.ClusterState) []*shared.SourcePolicy {
+	tiers := make(map[string]calico.TierSpec, len(clusterState.CalicoTiers))
+	for _, tier := range clusterState.CalicoTiers {
+		tiers[tier.Name] = tier.Spec
+	}
+	result := make([]*shared.SourcePolicy, 0)
+	for _, calicoPolicy := range clusterState.CalicoNetworkPolicies {
+		if calicoPolicy.Spec.DoNotTrack || calicoPolicy.Spec.PreDNAT ||
+			strings.HasPrefix(calicoPolicy.Name, kubernetesPolicyPrefix) {
+			continue
+		}
+		policy, err := source.toSourcePolicy(calicoPolicy, tiers)
+		if err != nil {
+			log.Printf("Calico policy %s is ignored, the traffic it denies is reported as allowed: %s\n",
+				source.qualifiedName(calicoPolicy), err)
+			continue
+		}
+		result = append(result, policy)
+	}
+	return result
+}
+
+func (source sourceImpl) qualifiedName(calicoPolicy *calico.NetworkPolicy) string {
+	if calicoPolicy.Namespace == "" {
+		return calicoPolicy.Name
+	}
+	return calicoPolicy.Namespace + "/" + calicoPolicy.Name
+}
+
+func (source sourceImpl) toSourcePolicy(
+	calicoPolicy *calico.NetworkPolicy,
+	tiers map[string]calico.TierSpec,
+) (*shared.SourcePolicy, error) {
+	selects, err := source.subjectMatcher(calicoPolicy)
+	if err != nil {
+		return nil, err
+	}
+	isIngress, isEgress := source.policyTypes(calicoPolicy)
+	ingress, err := source.rules(calicoPolicy.Spec.Ingress, calicoPolicy.Namespace, true)
+	if err != nil {
+		return nil, err
+	}
+	egress, err := source.rules(calicoPolicy.Spec.Egress, calicoPolicy.Namespace, false)
+	if err != nil {
+		return nil, err
+	}
+	tierName := calicoPolicy.TierName()
+	return &shared.SourcePolicy{
+		Ref:       source.toNetworkPolicy(calicoPolicy),
+		Tier:      source.tier(tierName, tiers[tierName]),
+		Order:     calicoPolicy.Spec.Order,
+		Selects:   selects,
+		IsIngress: isIngress,
+		IsEgress:  isEgress,
+		Ingress:   ingress,
+		Egress:    egress,
+	}, nil
+}
+
+func (source sourceImpl) tier(tierName string, tier calico.TierSpec) *shared.PolicyTier {
+	defaultAction := shared.RuleActionDeny
+	if tier.DefaultAction == calico.ActionPass {
+		defaultAction = shared.RuleActionPass
+	}
+	return &shared.PolicyTier{Name: tierName, Order: tier.Order, DefaultAction: defaultAction}
+}
+
+func (source sourceImpl) toNetworkPolicy(calicoPolicy *calico.NetworkPolicy) types.NetworkPolicy {
+	kind := networkPolicyKind
+	if calicoPolicy.Namespace == "" {
+		kind = globalNetworkPolicyKind
+	}
+	return types.NetworkPolicy{
+		Kind:      kind,
+		Name:      calicoPolicy.Name,
+		Namespace: calicoPolicy.Namespace,
+		Labels:    calicoPolicy.Labels,
+	}
+}
+
+func (source sourceImpl) policyTypes(calicoPolicy *calico.NetworkPolicy) (bool, bool) {
+	if len(calicoPolicy.Spec.Types) == 0 {
+		return true, len(calicoPolicy.Spec.Egress) != 0
+	}
+	var isIngress, isEgress bool
+	for _, policyType := range calicoPolicy.Spec.Types {
+		if policyType == calico.PolicyTypeIngress {
+			isIngress = true
+		} else if policyType == calico.PolicyTypeEgress {
+			isEgress = true
+		}
+	}
+	return isIngress, isEgress
+}
+
+func (source sourceImpl) subjectMatcher(calicoPolicy *calico.NetworkPolicy) (shared.PodMatcher, error) {
+	podSelector, err := parseSelector(calicoPolicy.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	namespaceSelector, err := parseSelector(calicoPolicy.Spec.NamespaceSelector)
+	if err != nil {
+		return nil, err
+	}
+	serviceAccountSelector, err := parseSelector(calicoPolicy.Spec.ServiceAccountSelector)
+	if err != nil {
+		return nil, err
+	}
+	policyNamespace := calicoPolicy.Namespace
+	return func(pod *corev1.Pod, namespaceLabels map[string]string) bool {
+		if policyNamespace != "" && pod.Namespace != policyNamespace {
+			return false
+		}
+		if policyNamespace == "" && !namespaceSelector(source.namespaceLabels(pod.Namespace, namespaceLabels)) {
+			return false
+		}
+		return podSelector(source.endpointLabels(pod)) &&
+			serviceAccountSelector(map[string]string{nameLabel: source.serviceAccount(pod)})
+	}, nil
+}
+
+func (source sourceImpl) rules(calicoRules []calico.Rule, policyNamespace string, isIngress bool) (
+	[]shared.SourceRule,
+	error,
+) {
+	result := make([]shared.SourceRule, 0, len(calicoRules))
+	for _, calicoRule := range calicoRules {
+		peer := calicoRule.Destination
+		if isIngress {
+			peer = calicoRule.Source
+		}
+		peers, err := source.peers(peer, policyNamespace, !isIngress)
+		if err != nil {
+			return nil, err
+		}
+		action, ok := actions[calicoRule.Action]
+		if !ok {
+			action = shared.RuleActionDeny
+		}
+		ports, namedPorts := source.ports(calicoRule.Destination.Ports)
+		exceptPorts, exceptNamedPorts := source.ports(calicoRule.Destination.NotPorts)
+		result = append(result, shared.SourceRule{
+			Action:           action,
+			Peers:            peers,
+			Ports:            ports,
+			NamedPorts:       namedPorts,
+			ExceptPorts:      exceptPorts,
+			ExceptNamedPorts: exceptNamedPorts,
+		})
+	}
+	return result, nil
+}
+
+func (source sourceImpl) peers(entityRule calico.EntityRule, policyNamespace string, isEgress bool) (
+	[]shared.SourcePeer,
+	error,
+) {
+	result := make([]shared.SourcePeer, 0)
+	exceptNets := ""
+	if len(entityRule.NotNets) != 0 {
+		exceptNets = cidrExceptSeparator + strings.Join(entityRule.NotNets, ", ")
+	}
+	for _, network := range entityRule.Nets {
+		result = append(result, shared.SourcePeer{
+			External: &types.ExternalPeer{Kind: types.ExternalPeerCIDR, Name: network + exceptNets},
+		})
+	}
+	if isEgress {
+		for _, domain := range entityRule.Domains {
+			result = append(result, shared.SourcePeer{
+				External: &types.ExternalPeer{Kind: types.ExternalPeerFQDN, Name: domain},
+			})
+		}
+	}
+	selectsPods := entityRule.Selector != "" || entityRule.NotSelector != "" ||
+		entityRule.NamespaceSelector != "" || entityRule.ServiceAccounts != nil
+	if !selectsPods && len(result) != 0 {
+		return result, nil
+	}
+	podMatcher, err := source.peerMatcher(entityRule, policyNamespace)
+	if err != nil {
+		return nil, err
+	}
+	if !selectsPods {
+		external := &types.ExternalPeer{Kind: types.ExternalPeerEntity, Name: worldEntity}
+		if len(entityRule.NotNets) != 0 {
+			external = &types.ExternalPeer{Kind: types.ExternalPeerCIDR, Name: anyNetwork + exceptNets}
+		}
+		return append(result, shared.SourcePeer{Pods: podMatcher, External: external}), nil
+	}
+	return append(result, shared.SourcePeer{Pods: podMatcher}), nil
+}
+
+func (source sourceImpl) peerMatcher(entityRule calico.EntityRule, policyNamespace string) (
+	shared.PodMatcher,
+	error,
+) {
+	podSelector, err := parseSelector(entityRule.Selector)
+	if err != nil {
+		return nil, err
+	}
+	notPodSelector := matchNone
+	if entityRule.NotSelector != "" {
+		notPodSelector, err = parseSelector(entityRule.NotSelector)
+		if err != nil {
+			return nil, err
+		}
+	}
+	namespaceSelector, err := parseSelector(entityRule.NamespaceSelector)
+	if err != nil {
+		return nil, err
+	}
+	restrictToPolicyNamespace := policyNamespace != "" && entityRule.NamespaceSelector == "" &&
+		(entityRule.Selector != "" || entityRule.NotSelector != "" || entityRule.ServiceAccounts != nil)
+	serviceAccounts := entityRule.ServiceAccounts
+	notNets := entityRule.NotNets
+	return func(pod *corev1.Pod, namespaceLabels map[string]string) bool {
+		if restrictToPolicyNamespace && pod.Namespace != policyNamespace {
+			return false
+		}
+		if source.inNetworks(pod.Status.PodIP, notNets) {
+			return false
+		}
+		if !namespaceSelector(source.namespaceLabels(pod.Namespace, namespaceLabels)) {
+			return false
+		}
+		endpointLabels := source.endpointLabels(pod)
+		if !podSelector(endpointLabels) || notPodSelector(endpointLabels) {
+			return false
+		}
+		return serviceAccounts == nil || len(serviceAccounts.Names) == 0 ||
+			source.containsString(serviceAccounts.Names, source.serviceAccount(pod))
+	}, nil
+}
+
+func (source sourceImpl) ports(calicoPorts []intstr.IntOrString) ([]types.PortRange, []string) {
+	if len(calicoPorts) == 0 {
+		return nil, nil
+	}
+	portRanges := make([]types.PortRange, 0, len(calicoPorts))
+	var namedPorts []string
+	for _, calicoPort := range calicoPorts {
+		if calicoPort.Type == intstr.Int {
+			portRanges = append(portRanges, shared.SinglePort(calicoPort.IntVal))
+			continue
+		}
+		bounds := strings.SplitN(calicoPort.StrVal, ":", 2)
+		first, err := strconv.ParseInt(bounds[0], 10, 32)
+		if err != nil {
+			namedPorts = append(namedPorts, calicoPort.StrVal)
+			continue
+		}
+		last := first
+		if len(bounds) == 2 {
+			last, err = strconv.ParseInt(bounds[1], 10, 32)
+			if err != nil {
+				continue
+			}
+		}
+		if portRange, ok := shared.NewPortRange(first, last); ok {
+			portRanges = append(portRanges, portRange)
+		}
+	}
+	return portRanges, namedPorts
+}
+
+func (source sourceImpl) endpointLabels(pod *corev1.Pod) map[string]string {
+	result := make(map[string]string, len(pod.Labels)+3)
+	for key, value := range pod.Labels {
+		result[key] = value
+	}
+	result[namespaceLabel] = pod.Namespace
+	result[orchestratorLabel] = kubernetesOrchestrator
+	result[serviceAccountLabel] = source.serviceAccount(pod)
+	return result
+}
+
+func (source sourceImpl) namespaceLabels(namespace string, namespaceLabels map[string]string) map[string]string {
+	result := make(map[string]string, len(namespaceLabels)+1)
+	for key, value := range namespaceLabels {
+		result[key] = value
+	}
+	result[nameLabel] = namespace
+	return result
+}
+
+func (source sourceImpl) serviceAccount(pod *corev1.Pod) string {
+	if pod.Spec.ServiceAccountName == "" {
+		return defaultServiceAccount
+	}
+	return pod.Spec.ServiceAccountName
+}
+
+func (source sourceImpl) inNetworks(address string, networks []string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	for _, network := range networks {
+		_, ipNet, err := net.ParseCIDR(network)
+		if err == nil && ipNet.Contains(ip) {
+			return true
+		}
+		if err != nil && net.ParseIP(network).Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (source sourceImpl) containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
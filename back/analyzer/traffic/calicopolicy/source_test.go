@@ -0,0 +1,271 @@
+package calicopolicy
+
+import (
+	"fmt"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"karto/analyzer/shared"
+	"karto/analyzer/traffic/policysource"
+	"karto/crds/calico"
+	"karto/testutils"
+	"karto/types"
+	"testing"
+)
+
+type describedRule struct {
+	Action           shared.RuleAction
+	Pods             []string
+	Externals        []string
+	Ports            []types.PortRange
+	NamedPorts       []string
+	ExceptPorts      []types.PortRange
+	ExceptNamedPorts []string
+}
+
+type describedPolicy struct {
+	Policy      string
+	Tier        string
+	TierOrder   *float64
+	TierDefault shared.RuleAction
+	Order       *float64
+	Selects     []string
+	IsIngress   bool
+	IsEgress    bool
+	Ingress     []describedRule
+	Egress      []describedRule
+}
+
+func TestPolicies(t *testing.T) {
+	type args struct {
+		policies []*calico.NetworkPolicy
+		tiers    []*calico.Tier
+	}
+	namespaces := []*corev1.Namespace{
+		testutils.NewNamespaceBuilder().WithName("front").WithLabel("team", "web").Build(),
+		testutils.NewNamespaceBuilder().WithName("back").Build(),
+	}
+	pods := []*corev1.Pod{
+		testutils.NewPodBuilder().WithName("web").WithNamespace("front").WithLabel("app", "web").
+			WithIP("10.1.0.1").Build(),
+		testutils.NewPodBuilder().WithName("api").WithNamespace("back").WithLabel("app", "api").
+			WithIP("10.2.0.1").Build(),
+		testutils.NewPodBuilder().WithName("db").WithNamespace("back").WithLabel("app", "db").
+			WithIP("10.2.0.2").Build(),
+	}
+	order := 10.0
+	tierOrder := 100.0
+	tests := []struct {
+		name             string
+		args             args
+		expectedPolicies []describedPolicy
+	}{
+		{
+			name: "translates selectors, actions and ports of namespaced policies",
+			args: args{
+				policies: []*calico.NetworkPolicy{
+					{
+						ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "back"},
+						Spec: calico.PolicySpec{
+							Order:    &order,
+							Selector: "app == 'api'",
+							Ingress: []calico.Rule{
+								{
+									Action: calico.ActionDeny,
+									Source: calico.EntityRule{NamespaceSelector: "team == 'web'"},
+									Destination: calico.EntityRule{
+										Ports: []intstr.IntOrString{intstr.FromInt(22), intstr.FromString("8000:8001"),
+											intstr.FromString("metrics")},
+									},
+								},
+								{
+									Action: calico.ActionAllow,
+									Source: calico.EntityRule{Selector: "has(app)", NotSelector: "app == 'api'"},
+									Destination: calico.EntityRule{
+										NotPorts: []intstr.IntOrString{intstr.FromInt(9090), intstr.FromString("30000:99999")},
+									},
+								},
+								{Action: calico.ActionLog},
+							},
+							Egress: []calico.Rule{
+								{
+									Action:      calico.ActionAllow,
+									Destination: calico.EntityRule{Nets: []string{"10.0.0.0/8"}, Domains: []string{"example.com"}},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectedPolicies: []describedPolicy{
+				{
+					Policy:      "NetworkPolicy.projectcalico.org back/api",
+					Tier:        "default",
+					TierDefault: shared.RuleActionDeny,
+					Order:       &order,
+					Selects:     []string{"back/api"},
+					IsIngress:   true,
+					IsEgress:    true,
+					Ingress: []describedRule{
+						{Action: shared.RuleActionDeny, Pods: []string{"front/web"}, Externals: []string{},
+							Ports:      []types.PortRange{{Start: 22, End: 22}, {Start: 8000, End: 8001}},
+							NamedPorts: []string{"metrics"}},
+						{Action: shared.RuleActionAllow, Pods: []string{"back/db"}, Externals: []string{},
+							ExceptPorts: []types.PortRange{{Start: 9090, End: 9090}, {Start: 30000, End: 65535}}},
+						{Action: shared.RuleActionLog, Pods: []string{"front/web", "back/api", "back/db"},
+							Externals: []string{"entity:world"}},
+					},
+					Egress: []describedRule{
+						{Action: shared.RuleActionAllow, Pods: []string{},
+							Externals: []string{"cidr:10.0.0.0/8", "fqdn:example.com"}},
+					},
+				},
+			},
+		},
+		{
+			name: "selects pods of matching namespaces with global policies ordered in their tier",
+			args: args{
+				policies: []*calico.NetworkPolicy{
+					{
+						ObjectMeta: metav1.ObjectMeta{Name: "security.web"},
+						Spec: calico.PolicySpec{
+							Tier:              "security",
+							NamespaceSelector: "projectcalico.org/name == 'front'",
+							Types:             []string{calico.PolicyTypeEgress},
+							Egress: []calico.Rule{
+								{Action: calico.ActionPass, Destination: calico.EntityRule{Selector: "app == 'db'"}},
+							},
+						},
+					},
+					{
+						ObjectMeta: metav1.ObjectMeta{Name: "knp.default.web"},
+						Spec:       calico.PolicySpec{Selector: "all()"},
+					},
+					{
+						ObjectMeta: metav1.ObjectMeta{Name: "host", Namespace: "back"},
+						Spec:       calico.PolicySpec{Selector: "all()", PreDNAT: true},
+					},
+					{
+						ObjectMeta: metav1.ObjectMeta{Name: "invalid", Namespace: "back"},
+						Spec:       calico.PolicySpec{Selector: "app =="},
+					},
+				},
+				tiers: []*calico.Tier{
+					{ObjectMeta: metav1.ObjectMeta{Name: "security"}, Spec: calico.TierSpec{Order: &tierOrder}},
+				},
+			},
+			expectedPolicies: []describedPolicy{
+				{
+					Policy:      "GlobalNetworkPolicy.projectcalico.org /security.web",
+					Tier:        "security",
+					TierOrder:   &tierOrder,
+					TierDefault: shared.RuleActionDeny,
+					Selects:     []string{"front/web"},
+					IsEgress:    true,
+					Ingress:     []describedRule{},
+					Egress: []describedRule{
+						{Action: shared.RuleActionPass, Pods: []string{"back/db"}, Externals: []string{}},
+					},
+				},
+			},
+		},
+		{
+			name: "excludes networks of notNets and passes at the end of tiers with a pass default action",
+			args: args{
+				policies: []*calico.NetworkPolicy{
+					{
+						ObjectMeta: metav1.ObjectMeta{Name: "platform.api", Namespace: "back"},
+						Spec: calico.PolicySpec{
+							Tier:     "platform",
+							Selector: "app == 'api'",
+							Types:    []string{calico.PolicyTypeIngress},
+							Ingress: []calico.Rule{
+								{Action: calico.ActionAllow, Source: calico.EntityRule{NotNets: []string{"10.2.0.0/16"}}},
+								{Action: calico.ActionDeny, Source: calico.EntityRule{Nets: []string{"192.168.0.0/16"},
+									NotNets: []string{"192.168.1.0/24"}}},
+							},
+						},
+					},
+				},
+				tiers: []*calico.Tier{
+					{ObjectMeta: metav1.ObjectMeta{Name: "platform"},
+						Spec: calico.TierSpec{Order: &tierOrder, DefaultAction: calico.ActionPass}},
+				},
+			},
+			expectedPolicies: []describedPolicy{
+				{
+					Policy:      "NetworkPolicy.projectcalico.org back/platform.api",
+					Tier:        "platform",
+					TierOrder:   &tierOrder,
+					TierDefault: shared.RuleActionPass,
+					Selects:     []string{"back/api"},
+					IsIngress:   true,
+					Ingress: []describedRule{
+						{Action: shared.RuleActionAllow, Pods: []string{"front/web"},
+							Externals: []string{"cidr:0.0.0.0/0 except 10.2.0.0/16"}},
+						{Action: shared.RuleActionDeny, Pods: []string{},
+							Externals: []string{"cidr:192.168.0.0/16 except 192.168.1.0/24"}},
+					},
+					Egress: []describedRule{},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := NewSource()
+			policies := source.Policies(policysource.ClusterState{
+				CalicoNetworkPolicies: tt.args.policies,
+				CalicoTiers:           tt.args.tiers,
+			})
+			described := make([]describedPolicy, 0)
+			for _, policy := range policies {
+				described = append(described, describePolicy(policy, pods, namespaces))
+			}
+			if diff := cmp.Diff(tt.expectedPolicies, described); diff != "" {
+				t.Errorf("Policies() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func describePolicy(policy *shared.SourcePolicy, pods []*corev1.Pod,
+	namespaces []*corev1.Namespace) describedPolicy {
+	describeRules := func(rules []shared.SourceRule) []describedRule {
+		result := make([]describedRule, 0)
+		for _, rule := range rules {
+			described := describedRule{Action: rule.Action, Pods: []string{}, Externals: []string{},
+				Ports: rule.Ports, NamedPorts: rule.NamedPorts, ExceptPorts: rule.ExceptPorts,
+				ExceptNamedPorts: rule.ExceptNamedPorts}
+			for _, pod := range pods {
+				if rule.AllowsPod(pod, shared.NamespaceLabels(pod.Namespace, namespaces)) {
+					described.Pods = append(described.Pods, pod.Namespace+"/"+pod.Name)
+				}
+			}
+			for _, external := range rule.Externals() {
+				described.Externals = append(described.Externals, fmt.Sprintf("%s:%s", external.Kind, external.Name))
+			}
+			result = append(result, described)
+		}
+		return result
+	}
+	selected := make([]string, 0)
+	for _, pod := range pods {
+		if policy.Selects(pod, shared.NamespaceLabels(pod.Namespace, namespaces)) {
+			selected = append(selected, pod.Namespace+"/"+pod.Name)
+		}
+	}
+	return describedPolicy{
+		Policy:      policy.Ref.Kind + " " + policy.Ref.Namespace + "/" + policy.Ref.Name,
+		Tier:        policy.Tier.Name,
+		TierOrder:   policy.Tier.Order,
+		TierDefault: policy.Tier.DefaultAction,
+		Order:       policy.Order,
+		Selects:     selected,
+		IsIngress:   policy.IsIngress,
+		IsEgress:    policy.IsEgress,
+		Ingress:     describeRules(policy.Ingress),
+		Egress:      describeRules(policy.Egress),
+	}
+}
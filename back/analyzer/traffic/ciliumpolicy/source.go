@@ -0,0 +1,252 @@
+package ciliumpolicy
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"karto/analyzer/shared"
+	"karto/analyzer/traffic/policysource"
+	"karto/crds/cilium"
+	"karto/types"
+	"strconv"
+	"strings"
+)
+
+const (
+	podNamespaceLabel     = "io.kubernetes.pod.namespace"
+	namespaceLabelPrefix  = "io.cilium.k8s.namespace.labels."
+	serviceAccountLabel   = "io.cilium.k8s.policy.serviceaccount"
+	defaultServiceAccount = "default"
+	entityAll             = "all"
+	entityCluster         = "cluster"
+	entityWorld           = "world"
+)
+
+var labelSourcePrefixes = []string{"k8s:", "any:"}
+
+type sourceImpl struct{}
+
+func NewSource() policysource.Source {
+	return sourceImpl{}
+}
+
+func (source sourceImpl) Policies(clusterState policysource.ClusterState) []*shared.SourcePolicy {
+	result := make([]*shared.SourcePolicy, 0)
+	for _, ciliumPolicy := range clusterState.CiliumNetworkPolicies {
+		ref := source.toNetworkPolicy(ciliumPolicy)
+		for _, rule := range ciliumPolicy.Rules() {
+			if rule.EndpointSelector == nil || rule.NodeSelector != nil {
+				continue
+			}
+			result = append(result, &shared.SourcePolicy{
+				Ref:         ref,
+				Selects:     source.endpointMatcher(*rule.EndpointSelector, ciliumPolicy.Namespace, true),
+				IsIngress:   len(rule.Ingress) != 0 || len(rule.IngressDeny) != 0,
+				IsEgress:    len(rule.Egress) != 0 || len(rule.EgressDeny) != 0,
+				Ingress:     source.ingressRules(rule.Ingress, ciliumPolicy.Namespace),
+				IngressDeny: source.ingressRules(rule.IngressDeny, ciliumPolicy.Namespace),
+				Egress:      source.egressRules(rule.Egress, ciliumPolicy.Namespace),
+				EgressDeny:  source.egressRules(rule.EgressDeny, ciliumPolicy.Namespace),
+			})
+		}
+	}
+	return result
+}
+
+func (source sourceImpl) toNetworkPolicy(ciliumPolicy *cilium.NetworkPolicy) types.NetworkPolicy {
+	kind := cilium.NetworkPolicyKind
+	if ciliumPolicy.Namespace == "" {
+		kind = cilium.ClusterwideNetworkPolicyKind
+	}
+	return types.NetworkPolicy{
+		Kind:      kind,
+		Name:      ciliumPolicy.Name,
+		Namespace: ciliumPolicy.Namespace,
+		Labels:    ciliumPolicy.Labels,
+	}
+}
+
+func (source sourceImpl) ingressRules(rules []cilium.IngressRule, policyNamespace string) []shared.SourceRule {
+	result := make([]shared.SourceRule, 0, len(rules))
+	for _, rule := range rules {
+		peers := source.endpointPeers(rule.FromEndpoints, policyNamespace)
+		peers = append(peers, source.cidrPeers(rule.FromCIDR, rule.FromCIDRSet)...)
+		peers = append(peers, source.entityPeers(rule.FromEntities)...)
+		result = append(result, source.toSourceRule(peers, rule.ToPorts))
+	}
+	return result
+}
+
+func (source sourceImpl) egressRules(rules []cilium.EgressRule, policyNamespace string) []shared.SourceRule {
+	result := make([]shared.SourceRule, 0, len(rules))
+	for _, rule := range rules {
+		peers := source.endpointPeers(rule.ToEndpoints, policyNamespace)
+		peers = append(peers, source.cidrPeers(rule.ToCIDR, rule.ToCIDRSet)...)
+		peers = append(peers, source.entityPeers(rule.ToEntities)...)
+		for _, fqdn := range rule.ToFQDNs {
+			name := fqdn.MatchName
+			if name == "" {
+				name = fqdn.MatchPattern
+			}
+			peers = append(peers, shared.SourcePeer{
+				External: &types.ExternalPeer{Kind: types.ExternalPeerFQDN, Name: name},
+			})
+		}
+		result = append(result, source.toSourceRule(peers, rule.ToPorts))
+	}
+	return result
+}
+
+func (source sourceImpl) toSourceRule(peers []shared.SourcePeer, portRules []cilium.PortRule) shared.SourceRule {
+	rule := shared.SourceRule{Peers: peers}
+	source.addPorts(&rule, portRules)
+	if len(peers) == 0 && rule.RestrictsPorts() {
+		rule.Peers = source.entityPeers([]string{entityAll})
+	}
+	return rule
+}
+
+func (source sourceImpl) addPorts(rule *shared.SourceRule, portRules []cilium.PortRule) {
+	if len(portRules) == 0 {
+		return
+	}
+	rule.Ports = []types.PortRange{}
+	for _, portRule := range portRules {
+		for _, portProtocol := range portRule.Ports {
+			if portProtocol.Port == "" || portProtocol.Port == "0" {
+				rule.Ports, rule.NamedPorts = nil, nil
+				return
+			}
+			port, err := strconv.ParseInt(portProtocol.Port, 10, 32)
+			if err != nil {
+				rule.NamedPorts = append(rule.NamedPorts, portProtocol.Port)
+				continue
+			}
+			endPort := port
+			if int64(portProtocol.EndPort) > port {
+				endPort = int64(portProtocol.EndPort)
+			}
+			if portRange, ok := shared.NewPortRange(port, endPort); ok {
+				rule.Ports = append(rule.Ports, portRange)
+			}
+		}
+	}
+}
+
+func (source sourceImpl) endpointPeers(selectors []metav1.LabelSelector, policyNamespace string) []shared.SourcePeer {
+	result := make([]shared.SourcePeer, 0, len(selectors))
+	for _, selector := range selectors {
+		result = append(result, shared.SourcePeer{Pods: source.endpointMatcher(selector, policyNamespace, false)})
+	}
+	return result
+}
+
+func (source sourceImpl) cidrPeers(cidrs []string, cidrRules []cilium.CIDRRule) []shared.SourcePeer {
+	result := make([]shared.SourcePeer, 0, len(cidrs)+len(cidrRules))
+	for _, cidr := range cidrs {
+		result = append(result, shared.SourcePeer{
+			External: &types.ExternalPeer{Kind: types.ExternalPeerCIDR, Name: cidr},
+		})
+	}
+	for _, cidrRule := range cidrRules {
+		name := cidrRule.Cidr
+		if len(cidrRule.Except) != 0 {
+			name += " except " + strings.Join(cidrRule.Except, ", ")
+		}
+		result = append(result, shared.SourcePeer{
+			External: &types.ExternalPeer{Kind: types.ExternalPeerCIDR, Name: name},
+		})
+	}
+	return result
+}
+
+func (source sourceImpl) entityPeers(entities []string) []shared.SourcePeer {
+	result := make([]shared.SourcePeer, 0, len(entities))
+	for _, entity := range entities {
+		switch entity {
+		case entityAll:
+			result = append(result, shared.SourcePeer{
+				Pods:     allPods,
+				External: &types.ExternalPeer{Kind: types.ExternalPeerEntity, Name: entityWorld},
+			})
+		case entityCluster:
+			result = append(result, shared.SourcePeer{Pods: allPods})
+		default:
+			result = append(result, shared.SourcePeer{
+				External: &types.ExternalPeer{Kind: types.ExternalPeerEntity, Name: entity},
+			})
+		}
+	}
+	return result
+}
+
+func allPods(*corev1.Pod, map[string]string) bool {
+	return true
+}
+
+func (source sourceImpl) endpointMatcher(
+	selector metav1.LabelSelector,
+	policyNamespace string,
+	isSubject bool,
+) shared.PodMatcher {
+	selector = source.withoutLabelSources(selector)
+	restrictToPolicyNamespace := policyNamespace != "" && (isSubject || !source.selectsNamespace(selector))
+	return func(pod *corev1.Pod, namespaceLabels map[string]string) bool {
+		if restrictToPolicyNamespace && pod.Namespace != policyNamespace {
+			return false
+		}
+		return shared.SelectorMatches(source.endpointLabels(pod, namespaceLabels), selector)
+	}
+}
+
+func (source sourceImpl) withoutLabelSources(selector metav1.LabelSelector) metav1.LabelSelector {
+	result := metav1.LabelSelector{}
+	if selector.MatchLabels != nil {
+		result.MatchLabels = make(map[string]string, len(selector.MatchLabels))
+		for key, value := range selector.MatchLabels {
+			result.MatchLabels[source.withoutLabelSource(key)] = value
+		}
+	}
+	for _, expression := range selector.MatchExpressions {
+		expression.Key = source.withoutLabelSource(expression.Key)
+		result.MatchExpressions = append(result.MatchExpressions, expression)
+	}
+	return result
+}
+
+func (source sourceImpl) withoutLabelSource(key string) string {
+	for _, prefix := range labelSourcePrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return strings.TrimPrefix(key, prefix)
+		}
+	}
+	return key
+}
+
+func (source sourceImpl) selectsNamespace(selector metav1.LabelSelector) bool {
+	if _, ok := selector.MatchLabels[podNamespaceLabel]; ok {
+		return true
+	}
+	for _, expression := range selector.MatchExpressions {
+		if expression.Key == podNamespaceLabel {
+			return true
+		}
+	}
+	return false
+}
+
+func (source sourceImpl) endpointLabels(pod *corev1.Pod, namespaceLabels map[string]string) map[string]string {
+	result := make(map[string]string, len(pod.Labels)+len(namespaceLabels)+2)
+	for key, value := range pod.Labels {
+		result[key] = value
+	}
+	for key, value := range namespaceLabels {
+		result[namespaceLabelPrefix+key] = value
+	}
+	result[podNamespaceLabel] = pod.Namespace
+	serviceAccount := pod.Spec.ServiceAccountName
+	if serviceAccount == "" {
+		serviceAccount = defaultServiceAccount
+	}
+	result[serviceAccountLabel] = serviceAccount
+	return result
+}
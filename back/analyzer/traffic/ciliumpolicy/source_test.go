@@ -0,0 +1,237 @@
+package ciliumpolicy
+
+import (
+	"fmt"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"karto/analyzer/shared"
+	"karto/analyzer/traffic/policysource"
+	"karto/crds/cilium"
+	"karto/testutils"
+	"karto/types"
+	"testing"
+)
+
+type describedRule struct {
+	Pods      []string
+	Externals []string
+	Ports     []types.PortRange
+}
+
+type describedPolicy struct {
+	Policy      string
+	Selects     []string
+	IsIngress   bool
+	IsEgress    bool
+	Ingress     []describedRule
+	IngressDeny []describedRule
+	Egress      []describedRule
+	EgressDeny  []describedRule
+}
+
+func TestPolicies(t *testing.T) {
+	type args struct {
+		policies []*cilium.NetworkPolicy
+	}
+	namespaces := []*corev1.Namespace{
+		testutils.NewNamespaceBuilder().WithName("front").WithLabel("team", "web").Build(),
+		testutils.NewNamespaceBuilder().WithName("back").Build(),
+	}
+	pods := []*corev1.Pod{
+		testutils.NewPodBuilder().WithName("web").WithNamespace("front").WithLabel("app", "web").Build(),
+		testutils.NewPodBuilder().WithName("api").WithNamespace("back").WithLabel("app", "api").Build(),
+		testutils.NewPodBuilder().WithName("db").WithNamespace("back").WithLabel("app", "db").Build(),
+	}
+	tests := []struct {
+		name             string
+		args             args
+		expectedPolicies []describedPolicy
+	}{
+		{
+			name: "translates endpoint selectors, ports, entities and FQDNs of namespaced policies",
+			args: args{
+				policies: []*cilium.NetworkPolicy{
+					{
+						ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "back"},
+						Spec: &cilium.Rule{
+							EndpointSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "api"}},
+							Ingress: []cilium.IngressRule{
+								{
+									FromEndpoints: []metav1.LabelSelector{
+										{MatchLabels: map[string]string{"k8s:io.kubernetes.pod.namespace": "front"}},
+										{},
+									},
+									ToPorts: []cilium.PortRule{{Ports: []cilium.PortProtocol{{Port: "8080"}}}},
+								},
+								{FromEntities: []string{"world", "cluster"}},
+							},
+							Egress: []cilium.EgressRule{
+								{
+									ToFQDNs: []cilium.FQDNSelector{{MatchName: "api.example.com"}},
+									ToCIDRSet: []cilium.CIDRRule{
+										{Cidr: "10.0.0.0/8", Except: []string{"10.1.0.0/16"}},
+									},
+								},
+								{ToPorts: []cilium.PortRule{{Ports: []cilium.PortProtocol{{Port: "53"}}}}},
+							},
+						},
+					},
+				},
+			},
+			expectedPolicies: []describedPolicy{
+				{
+					Policy:    "CiliumNetworkPolicy back/api",
+					Selects:   []string{"back/api"},
+					IsIngress: true,
+					IsEgress:  true,
+					Ingress: []describedRule{
+						{Pods: []string{"front/web", "back/api", "back/db"}, Externals: []string{},
+							Ports: []types.PortRange{{Start: 8080, End: 8080}}},
+						{Pods: []string{"front/web", "back/api", "back/db"}, Externals: []string{"entity:world"}},
+					},
+					IngressDeny: []describedRule{},
+					Egress: []describedRule{
+						{Pods: []string{}, Externals: []string{"cidr:10.0.0.0/8 except 10.1.0.0/16",
+							"fqdn:api.example.com"}},
+						{Pods: []string{"front/web", "back/api", "back/db"}, Externals: []string{"entity:world"},
+							Ports: []types.PortRange{{Start: 53, End: 53}}},
+					},
+					EgressDeny: []describedRule{},
+				},
+			},
+		},
+		{
+			name: "selects pods of all namespaces with clusterwide policies and translates deny rules",
+			args: args{
+				policies: []*cilium.NetworkPolicy{
+					{
+						ObjectMeta: metav1.ObjectMeta{Name: "deny-web"},
+						Spec: &cilium.Rule{
+							EndpointSelector: &metav1.LabelSelector{MatchLabels: map[string]string{
+								"io.cilium.k8s.namespace.labels.team": "web",
+							}},
+							EgressDeny: []cilium.EgressRule{
+								{ToEndpoints: []metav1.LabelSelector{{MatchLabels: map[string]string{"app": "db"}}}},
+							},
+						},
+					},
+					{
+						ObjectMeta: metav1.ObjectMeta{Name: "node", Namespace: "back"},
+						Spec: &cilium.Rule{
+							NodeSelector: &metav1.LabelSelector{},
+							Ingress:      []cilium.IngressRule{{}},
+						},
+					},
+				},
+			},
+			expectedPolicies: []describedPolicy{
+				{
+					Policy:      "CiliumClusterwideNetworkPolicy /deny-web",
+					Selects:     []string{"front/web"},
+					IsEgress:    true,
+					Ingress:     []describedRule{},
+					IngressDeny: []describedRule{},
+					Egress:      []describedRule{},
+					EgressDeny:  []describedRule{{Pods: []string{"back/db"}, Externals: []string{}}},
+				},
+			},
+		},
+		{
+			name: "translates port ranges of allow and deny rules",
+			args: args{
+				policies: []*cilium.NetworkPolicy{
+					{
+						ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "back"},
+						Spec: &cilium.Rule{
+							EndpointSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+							Ingress: []cilium.IngressRule{
+								{
+									FromEndpoints: []metav1.LabelSelector{{MatchLabels: map[string]string{"app": "api"}}},
+									ToPorts: []cilium.PortRule{{Ports: []cilium.PortProtocol{
+										{Port: "5432", EndPort: 5433},
+									}}},
+								},
+							},
+							IngressDeny: []cilium.IngressRule{
+								{
+									FromEndpoints: []metav1.LabelSelector{{}},
+									ToPorts: []cilium.PortRule{{Ports: []cilium.PortProtocol{
+										{Port: "1", EndPort: 1023},
+										{Port: "8000", EndPort: 80},
+									}}},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectedPolicies: []describedPolicy{
+				{
+					Policy:    "CiliumNetworkPolicy back/db",
+					Selects:   []string{"back/db"},
+					IsIngress: true,
+					Ingress: []describedRule{
+						{Pods: []string{"back/api"}, Externals: []string{},
+							Ports: []types.PortRange{{Start: 5432, End: 5433}}},
+					},
+					IngressDeny: []describedRule{
+						{Pods: []string{"back/api", "back/db"}, Externals: []string{},
+							Ports: []types.PortRange{{Start: 1, End: 1023}, {Start: 8000, End: 8000}}},
+					},
+					Egress:     []describedRule{},
+					EgressDeny: []describedRule{},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := NewSource()
+			policies := source.Policies(policysource.ClusterState{CiliumNetworkPolicies: tt.args.policies})
+			described := make([]describedPolicy, 0)
+			for _, policy := range policies {
+				described = append(described, describePolicy(policy, pods, namespaces))
+			}
+			if diff := cmp.Diff(tt.expectedPolicies, described); diff != "" {
+				t.Errorf("Policies() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func describePolicy(policy *shared.SourcePolicy, pods []*corev1.Pod,
+	namespaces []*corev1.Namespace) describedPolicy {
+	describeRules := func(rules []shared.SourceRule) []describedRule {
+		result := make([]describedRule, 0)
+		for _, rule := range rules {
+			described := describedRule{Pods: []string{}, Externals: []string{}, Ports: rule.Ports}
+			for _, pod := range pods {
+				if rule.AllowsPod(pod, shared.NamespaceLabels(pod.Namespace, namespaces)) {
+					described.Pods = append(described.Pods, pod.Namespace+"/"+pod.Name)
+				}
+			}
+			for _, external := range rule.Externals() {
+				described.Externals = append(described.Externals, fmt.Sprintf("%s:%s", external.Kind, external.Name))
+			}
+			result = append(result, described)
+		}
+		return result
+	}
+	selected := make([]string, 0)
+	for _, pod := range pods {
+		if policy.Selects(pod, shared.NamespaceLabels(pod.Namespace, namespaces)) {
+			selected = append(selected, pod.Namespace+"/"+pod.Name)
+		}
+	}
+	return describedPolicy{
+		Policy:      policy.Ref.Kind + " " + policy.Ref.Namespace + "/" + policy.Ref.Name,
+		Selects:     selected,
+		IsIngress:   policy.IsIngress,
+		IsEgress:    policy.IsEgress,
+		Ingress:     describeRules(policy.Ingress),
+		IngressDeny: describeRules(policy.IngressDeny),
+		Egress:      describeRules(policy.Egress),
+		EgressDeny:  describeRules(policy.EgressDeny),
+	}
+}
@@ -0,0 +1,252 @@
+package externaldestination
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"karto/analyzer/shared"
+	"karto/commons"
+	"karto/crds/istio"
+	"karto/types"
+	"sort"
+	"strings"
+)
+
+const (
+	networkPolicyKind = "NetworkPolicy"
+	serviceKind       = "Service"
+	serviceEntryKind  = istio.ServiceEntryKind + ".networking.istio.io"
+)
+
+type ClusterState struct {
+	PodIsolations  []*shared.PodIsolation
+	Services       []*corev1.Service
+	ServiceEntries []*istio.ServiceEntry
+}
+
+type AnalysisResult struct {
+	Nodes    []*types.ExternalNode
+	Accesses []*types.ExternalAccess
+}
+
+type Analyzer interface {
+	Analyze(clusterState ClusterState) AnalysisResult
+}
+
+type analyzerImpl struct{}
+
+func NewAnalyzer() Analyzer {
+	return analyzerImpl{}
+}
+
+type nodeBuilder struct {
+	node      *types.ExternalNode
+	addresses *commons.Set[string]
+	ports     *commons.Set[int32]
+	sources   *commons.Set[types.ObjectRef]
+}
+
+func (analyzer analyzerImpl) Analyze(clusterState ClusterState) AnalysisResult {
+	egressPolicies := commons.Map(clusterState.PodIsolations, func(podIsolation *shared.PodIsolation) []*shared.SourcePolicy {
+		return podIsolation.EgressOrderedPolicies()
+	})
+	nodes := analyzer.externalNodes(egressPolicies, clusterState.Services, clusterState.ServiceEntries)
+	accesses := make([]*types.ExternalAccess, 0)
+	for i, podIsolation := range clusterState.PodIsolations {
+		podRef := shared.ToPodRef(podIsolation.Pod)
+		tiered := shared.HasTieredPolicies(podIsolation.EgressSourcePolicies)
+		for _, node := range nodes {
+			access := analyzer.externalAccess(podRef, egressPolicies[i], tiered, node)
+			if access != nil {
+				accesses = append(accesses, access)
+			}
+		}
+	}
+	return AnalysisResult{Nodes: nodes, Accesses: accesses}
+}
+
+func (analyzer analyzerImpl) externalNodes(
+	egressPolicies [][]*shared.SourcePolicy,
+	services []*corev1.Service,
+	serviceEntries []*istio.ServiceEntry,
+) []*types.ExternalNode {
+	builders := make(map[types.ExternalPeer]*nodeBuilder)
+	nodeOf := func(peer types.ExternalPeer) *nodeBuilder {
+		builder, ok := builders[peer]
+		if !ok {
+			builder = &nodeBuilder{
+				node:      &types.ExternalNode{Kind: peer.Kind, Name: peer.Name},
+				addresses: commons.NewSet[string](),
+				ports:     commons.NewSet[int32](),
+				sources:   commons.NewSet[types.ObjectRef](),
+			}
+			builders[peer] = builder
+		}
+		return builder
+	}
+	for _, policies := range egressPolicies {
+		for _, policy := range policies {
+			for _, rule := range shared.EgressRules(policy) {
+				for _, peer := range rule.Externals() {
+					if peer.Kind == types.ExternalPeerEntity {
+						continue
+					}
+					nodeOf(peer).addSource(policyObjectRef(policy.Ref))
+				}
+			}
+		}
+	}
+	for _, service := range services {
+		if service.Spec.Type != corev1.ServiceTypeExternalName || service.Spec.ExternalName == "" {
+			continue
+		}
+		builder := nodeOf(types.ExternalPeer{Kind: types.ExternalPeerFQDN, Name: hostName(service.Spec.ExternalName)})
+		builder.addSource(types.ObjectRef{Kind: serviceKind, Name: service.Name, Namespace: service.Namespace})
+		for _, port := range service.Spec.Ports {
+			builder.ports.Add(port.Port)
+		}
+	}
+	for _, serviceEntry := range serviceEntries {
+		if !serviceEntry.IsExternal() {
+			continue
+		}
+		for _, host := range serviceEntry.Spec.Hosts {
+			builder := nodeOf(types.ExternalPeer{Kind: types.ExternalPeerFQDN, Name: hostName(host)})
+			builder.addSource(types.ObjectRef{Kind: serviceEntryKind, Name: serviceEntry.Name,
+				Namespace: serviceEntry.Namespace})
+			for _, address := range serviceEntry.Spec.Addresses {
+				builder.addresses.Add(address)
+			}
+			for _, endpoint := range serviceEntry.Spec.Endpoints {
+				if endpoint.Address != "" && !strings.HasPrefix(endpoint.Address, "unix://") {
+					builder.addresses.Add(endpoint.Address)
+				}
+			}
+			for _, port := range serviceEntry.Spec.Ports {
+				builder.ports.Add(port.Number)
+			}
+		}
+	}
+	result := make([]*types.ExternalNode, 0, len(builders))
+	for _, builder := range builders {
+		result = append(result, builder.build())
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Kind != result[j].Kind {
+			return result[i].Kind < result[j].Kind
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+func (builder *nodeBuilder) addSource(source types.ObjectRef) {
+	if !builder.sources.Contains(source) {
+		builder.sources.Add(source)
+		builder.node.Sources = append(builder.node.Sources, source)
+	}
+}
+
+func (builder *nodeBuilder) build() *types.ExternalNode {
+	if builder.addresses.Size() != 0 {
+		builder.node.Addresses = builder.addresses.ToSlice()
+		sort.Strings(builder.node.Addresses)
+	}
+	if builder.ports.Size() != 0 {
+		builder.node.Ports = builder.ports.ToSlice()
+		sort.Slice(builder.node.Ports, func(i, j int) bool { return builder.node.Ports[i] < builder.node.Ports[j] })
+	}
+	sort.Slice(builder.node.Sources, func(i, j int) bool {
+		left, right := builder.node.Sources[i], builder.node.Sources[j]
+		if left.Kind != right.Kind {
+			return left.Kind < right.Kind
+		}
+		if left.Namespace != right.Namespace {
+			return left.Namespace < right.Namespace
+		}
+		return left.Name < right.Name
+	})
+	return builder.node
+}
+
+func (analyzer analyzerImpl) externalAccess(
+	podRef types.PodRef,
+	policies []*shared.SourcePolicy,
+	tiered bool,
+	node *types.ExternalNode,
+) *types.ExternalAccess {
+	peer := types.ExternalPeer{Kind: node.Kind, Name: node.Name}
+	if len(policies) == 0 {
+		return &types.ExternalAccess{Pod: podRef, Node: peer, Policies: []types.NetworkPolicy{}, Ports: node.Ports}
+	}
+	destination := shared.ExternalDestination{Peer: peer, Addresses: node.Addresses}
+	intervals := shared.PortIntervals(shared.CandidatePorts(policies, shared.EgressRules))
+	if len(node.Ports) != 0 {
+		intervals = commons.Map(node.Ports, shared.SinglePort)
+	}
+	var evaluate func(port int32) shared.Decision
+	if tiered {
+		tiers := shared.OrderedTiers(policies)
+		evaluate = func(port int32) shared.Decision {
+			return shared.Evaluate(tiers, shared.EgressRules, func(rule shared.SourceRule) bool {
+				return rule.MatchesDestination(destination)
+			}, port)
+		}
+	} else {
+		evaluate = func(port int32) shared.Decision {
+			return analyzer.unorderedDecision(policies, destination, port)
+		}
+	}
+	decision := shared.EvaluatePorts(intervals, evaluate)
+	if decision == nil {
+		return nil
+	}
+	return &types.ExternalAccess{
+		Pod:              podRef,
+		Node:             peer,
+		Policies:         shared.PolicyRefs(decision.Policies[0]),
+		Ports:            decision.Ports,
+		PortRanges:       decision.PortRanges,
+		DeniedPorts:      decision.DeniedPorts,
+		DeniedPortRanges: decision.DeniedPortRanges,
+		Tiers:            decision.Tiers[0],
+	}
+}
+
+func (analyzer analyzerImpl) unorderedDecision(
+	policies []*shared.SourcePolicy,
+	destination shared.ExternalDestination,
+	port int32,
+) shared.Decision {
+	var allowingPolicy *shared.SourcePolicy
+	for _, policy := range policies {
+		for _, rule := range policy.EgressDeny {
+			if rule.MatchesPort(port) && rule.MatchesDestination(destination) {
+				return shared.Decision{Allowed: false, Policies: []*shared.SourcePolicy{policy}}
+			}
+		}
+		if allowingPolicy != nil {
+			continue
+		}
+		for _, rule := range policy.Egress {
+			if rule.MatchesPort(port) && rule.MatchesDestination(destination) {
+				allowingPolicy = policy
+				break
+			}
+		}
+	}
+	if allowingPolicy == nil {
+		return shared.Decision{Allowed: false}
+	}
+	return shared.Decision{Allowed: true, Policies: []*shared.SourcePolicy{allowingPolicy}}
+}
+
+func policyObjectRef(policy types.NetworkPolicy) types.ObjectRef {
+	kind := policy.Kind
+	if kind == "" {
+		kind = networkPolicyKind
+	}
+	return types.ObjectRef{Kind: kind, Name: policy.Name, Namespace: policy.Namespace}
+}
+
+func hostName(host string) string {
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
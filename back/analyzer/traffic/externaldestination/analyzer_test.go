@@ -0,0 +1,201 @@
+package externaldestination
+
+import (
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"karto/analyzer/shared"
+	"karto/crds/istio"
+	"karto/testutils"
+	"karto/types"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	type args struct {
+		clusterState ClusterState
+	}
+	payments := testutils.NewServiceBuilder().WithName("payments").WithNamespace("shop").
+		WithExternalName("Payments.Example.com.").Build()
+	payments.Spec.Ports = []corev1.ServicePort{{Port: 443}}
+	storage := &istio.ServiceEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "storage", Namespace: "shop"},
+		Spec: istio.ServiceEntrySpec{
+			Hosts:     []string{"storage.example.com"},
+			Addresses: []string{"203.0.113.0/28"},
+			Ports:     []istio.ServicePort{{Number: 443, Protocol: "HTTPS"}, {Number: 80, Protocol: "HTTP"}},
+			Endpoints: []istio.WorkloadEntry{{Address: "203.0.113.5"}},
+		},
+	}
+	internal := &istio.ServiceEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "internal", Namespace: "shop"},
+		Spec:       istio.ServiceEntrySpec{Hosts: []string{"db.internal"}, Location: istio.LocationMeshInternal},
+	}
+	world := types.ExternalPeer{Kind: types.ExternalPeerEntity, Name: "world"}
+	cidr := types.ExternalPeer{Kind: types.ExternalPeerCIDR, Name: "203.0.113.0/24 except 203.0.113.128/25"}
+	pattern := types.ExternalPeer{Kind: types.ExternalPeerFQDN, Name: "*.example.com"}
+	paymentsPeer := types.ExternalPeer{Kind: types.ExternalPeerFQDN, Name: "payments.example.com"}
+	storagePeer := types.ExternalPeer{Kind: types.ExternalPeerFQDN, Name: "storage.example.com"}
+	egressPolicy := types.NetworkPolicy{Kind: "CiliumNetworkPolicy", Name: "egress", Namespace: "shop"}
+	denyPolicy := types.NetworkPolicy{Kind: "CiliumNetworkPolicy", Name: "deny", Namespace: "shop"}
+	tier := &shared.PolicyTier{Name: "security", DefaultAction: shared.RuleActionPass}
+	tierOrder := 100.0
+	tier.Order = &tierOrder
+	tieredPolicy := types.NetworkPolicy{Kind: "GlobalNetworkPolicy.projectcalico.org", Name: "security.block"}
+	webRef := types.PodRef{Name: "web", Namespace: "shop"}
+	jobRef := types.PodRef{Name: "job", Namespace: "shop"}
+	newPodIsolation := func(name string, policies ...*shared.SourcePolicy) *shared.PodIsolation {
+		podIsolation := shared.NewPodIsolation(testutils.NewPodBuilder().WithName(name).WithNamespace("shop").Build())
+		for _, policy := range policies {
+			podIsolation.AddSourcePolicy(policy)
+		}
+		return &podIsolation
+	}
+	tests := []struct {
+		name                   string
+		args                   args
+		expectedAnalysisResult AnalysisResult
+	}{
+		{
+			name: "aggregates external destinations into nodes reachable by pods without egress policies",
+			args: args{
+				clusterState: ClusterState{
+					PodIsolations: []*shared.PodIsolation{
+						newPodIsolation("job"),
+						newPodIsolation("web", &shared.SourcePolicy{
+							Ref:      egressPolicy,
+							IsEgress: true,
+							Egress: []shared.SourceRule{
+								{
+									Peers: []shared.SourcePeer{{External: &paymentsPeer}},
+									Ports: []types.PortRange{{Start: 443, End: 443}},
+								},
+							},
+						}),
+					},
+					Services:       []*corev1.Service{payments},
+					ServiceEntries: []*istio.ServiceEntry{storage, internal},
+				},
+			},
+			expectedAnalysisResult: AnalysisResult{
+				Nodes: []*types.ExternalNode{
+					{Kind: types.ExternalPeerFQDN, Name: "payments.example.com", Ports: []int32{443},
+						Sources: []types.ObjectRef{
+							{Kind: "CiliumNetworkPolicy", Name: "egress", Namespace: "shop"},
+							{Kind: "Service", Name: "payments", Namespace: "shop"},
+						}},
+					{Kind: types.ExternalPeerFQDN, Name: "storage.example.com",
+						Addresses: []string{"203.0.113.0/28", "203.0.113.5"}, Ports: []int32{80, 443},
+						Sources: []types.ObjectRef{
+							{Kind: "ServiceEntry.networking.istio.io", Name: "storage", Namespace: "shop"},
+						}},
+				},
+				Accesses: []*types.ExternalAccess{
+					{Pod: jobRef, Node: paymentsPeer, Policies: []types.NetworkPolicy{}, Ports: []int32{443}},
+					{Pod: jobRef, Node: storagePeer, Policies: []types.NetworkPolicy{}, Ports: []int32{80, 443}},
+					{Pod: webRef, Node: paymentsPeer, Policies: []types.NetworkPolicy{egressPolicy},
+						Ports: []int32{443}},
+				},
+			},
+		},
+		{
+			name: "egress rules reach nodes covered by their CIDRs and FQDN patterns unless denied",
+			args: args{
+				clusterState: ClusterState{
+					PodIsolations: []*shared.PodIsolation{
+						newPodIsolation("web",
+							&shared.SourcePolicy{
+								Ref:      egressPolicy,
+								IsEgress: true,
+								Egress: []shared.SourceRule{
+									{Peers: []shared.SourcePeer{{External: &cidr}}},
+									{
+										Peers: []shared.SourcePeer{{External: &pattern}},
+										Ports: []types.PortRange{{Start: 443, End: 443}},
+									},
+								},
+							},
+							&shared.SourcePolicy{
+								Ref:      denyPolicy,
+								IsEgress: true,
+								EgressDeny: []shared.SourceRule{
+									{Peers: []shared.SourcePeer{{External: &world}}, Ports: []types.PortRange{{Start: 80, End: 80}}},
+								},
+							},
+						),
+					},
+					Services:       []*corev1.Service{payments},
+					ServiceEntries: []*istio.ServiceEntry{storage},
+				},
+			},
+			expectedAnalysisResult: AnalysisResult{
+				Nodes: []*types.ExternalNode{
+					{Kind: types.ExternalPeerCIDR, Name: "203.0.113.0/24 except 203.0.113.128/25",
+						Sources: []types.ObjectRef{{Kind: "CiliumNetworkPolicy", Name: "egress", Namespace: "shop"}}},
+					{Kind: types.ExternalPeerFQDN, Name: "*.example.com",
+						Sources: []types.ObjectRef{{Kind: "CiliumNetworkPolicy", Name: "egress", Namespace: "shop"}}},
+					{Kind: types.ExternalPeerFQDN, Name: "payments.example.com", Ports: []int32{443},
+						Sources: []types.ObjectRef{{Kind: "Service", Name: "payments", Namespace: "shop"}}},
+					{Kind: types.ExternalPeerFQDN, Name: "storage.example.com",
+						Addresses: []string{"203.0.113.0/28", "203.0.113.5"}, Ports: []int32{80, 443},
+						Sources: []types.ObjectRef{
+							{Kind: "ServiceEntry.networking.istio.io", Name: "storage", Namespace: "shop"},
+						}},
+				},
+				Accesses: []*types.ExternalAccess{
+					{Pod: webRef, Node: cidr, Policies: []types.NetworkPolicy{egressPolicy}, DeniedPorts: []int32{80}},
+					{Pod: webRef, Node: pattern, Policies: []types.NetworkPolicy{egressPolicy}, Ports: []int32{443}},
+					{Pod: webRef, Node: paymentsPeer, Policies: []types.NetworkPolicy{egressPolicy},
+						Ports: []int32{443}},
+					{Pod: webRef, Node: storagePeer, Policies: []types.NetworkPolicy{egressPolicy},
+						Ports: []int32{443}},
+				},
+			},
+		},
+		{
+			name: "tiered policies are evaluated in order for every external node",
+			args: args{
+				clusterState: ClusterState{
+					PodIsolations: []*shared.PodIsolation{
+						newPodIsolation("web", &shared.SourcePolicy{
+							Ref:      tieredPolicy,
+							Tier:     tier,
+							IsEgress: true,
+							Egress: []shared.SourceRule{
+								{Action: shared.RuleActionDeny, Peers: []shared.SourcePeer{{External: &pattern}},
+									Ports: []types.PortRange{{Start: 80, End: 80}}},
+							},
+						}),
+					},
+					ServiceEntries: []*istio.ServiceEntry{storage},
+				},
+			},
+			expectedAnalysisResult: AnalysisResult{
+				Nodes: []*types.ExternalNode{
+					{Kind: types.ExternalPeerFQDN, Name: "*.example.com",
+						Sources: []types.ObjectRef{
+							{Kind: "GlobalNetworkPolicy.projectcalico.org", Name: "security.block"},
+						}},
+					{Kind: types.ExternalPeerFQDN, Name: "storage.example.com",
+						Addresses: []string{"203.0.113.0/28", "203.0.113.5"}, Ports: []int32{80, 443},
+						Sources: []types.ObjectRef{
+							{Kind: "ServiceEntry.networking.istio.io", Name: "storage", Namespace: "shop"},
+						}},
+				},
+				Accesses: []*types.ExternalAccess{
+					{Pod: webRef, Node: pattern, Policies: []types.NetworkPolicy{}, DeniedPorts: []int32{80}},
+					{Pod: webRef, Node: storagePeer, Policies: []types.NetworkPolicy{}, Ports: []int32{443}},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := NewAnalyzer()
+			analysisResult := analyzer.Analyze(tt.args.clusterState)
+			if diff := cmp.Diff(tt.expectedAnalysisResult, analysisResult); diff != "" {
+				t.Errorf("Analyze() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
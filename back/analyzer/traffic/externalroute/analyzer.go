@@ -0,0 +1,211 @@
+package externalroute
+
+import (
+	"karto/analyzer/shared"
+	"karto/types"
+	"sort"
+)
+
+type Analyzer interface {
+	Analyze(podIsolation *shared.PodIsolation) []*types.ExternalRoute
+}
+
+type analyzerImpl struct{}
+
+func NewAnalyzer() Analyzer {
+	return analyzerImpl{}
+}
+
+type externalAccess struct {
+	route       *types.ExternalRoute
+	allPorts    bool
+	ports       map[int32]bool
+	policyNames map[string]bool
+}
+
+func (analyzer analyzerImpl) Analyze(podIsolation *shared.PodIsolation) []*types.ExternalRoute {
+	result := make([]*types.ExternalRoute, 0)
+	podRef := shared.ToPodRef(podIsolation.Pod)
+	if shared.HasTieredPolicies(podIsolation.IngressSourcePolicies) {
+		result = append(result, analyzer.orderedExternalRoutes(podRef, types.TrafficIngress,
+			podIsolation.IngressOrderedPolicies(), shared.ResolvedRules(shared.IngressRules, podIsolation.Pod))...)
+	} else {
+		result = append(result, analyzer.externalRoutes(podRef, types.TrafficIngress,
+			podIsolation.IngressSourcePolicies, func(policy *shared.SourcePolicy) ([]shared.SourceRule, []shared.SourceRule) {
+				return resolvedRules(policy.Ingress, podIsolation), resolvedRules(policy.IngressDeny, podIsolation)
+			})...)
+	}
+	if shared.HasTieredPolicies(podIsolation.EgressSourcePolicies) {
+		result = append(result, analyzer.orderedExternalRoutes(podRef, types.TrafficEgress,
+			podIsolation.EgressOrderedPolicies(), shared.EgressRules)...)
+	} else {
+		result = append(result, analyzer.externalRoutes(podRef, types.TrafficEgress,
+			podIsolation.EgressSourcePolicies, func(policy *shared.SourcePolicy) ([]shared.SourceRule, []shared.SourceRule) {
+				return policy.Egress, policy.EgressDeny
+			})...)
+	}
+	return result
+}
+
+func (analyzer analyzerImpl) orderedExternalRoutes(
+	podRef types.PodRef,
+	direction types.TrafficDirection,
+	policies []*shared.SourcePolicy,
+	rulesOf shared.RulesOf,
+) []*types.ExternalRoute {
+	tiers := shared.OrderedTiers(policies)
+	candidatePorts := shared.CandidatePorts(policies, rulesOf)
+	result := make([]*types.ExternalRoute, 0)
+	for _, peer := range analyzer.allowedExternals(policies, rulesOf) {
+		peer := peer
+		decision := shared.EvaluatePorts(shared.PortIntervals(candidatePorts), func(port int32) shared.Decision {
+			return shared.Evaluate(tiers, rulesOf, func(rule shared.SourceRule) bool {
+				return rule.MatchesExternal(peer)
+			}, port)
+		})
+		if decision == nil {
+			continue
+		}
+		result = append(result, &types.ExternalRoute{
+			Pod:              podRef,
+			Direction:        direction,
+			Peer:             peer,
+			Policies:         shared.PolicyRefs(decision.Policies[0]),
+			Ports:            decision.Ports,
+			PortRanges:       decision.PortRanges,
+			DeniedPorts:      decision.DeniedPorts,
+			DeniedPortRanges: decision.DeniedPortRanges,
+			Tiers:            decision.Tiers[0],
+		})
+	}
+	sortByPeer(result)
+	return result
+}
+
+func (analyzer analyzerImpl) allowedExternals(
+	policies []*shared.SourcePolicy,
+	rulesOf shared.RulesOf,
+) []types.ExternalPeer {
+	result := make([]types.ExternalPeer, 0)
+	seen := make(map[types.ExternalPeer]bool)
+	for _, policy := range policies {
+		for _, rule := range rulesOf(policy) {
+			if rule.Action != "" && rule.Action != shared.RuleActionAllow {
+				continue
+			}
+			for _, peer := range rule.Externals() {
+				if !seen[peer] {
+					seen[peer] = true
+					result = append(result, peer)
+				}
+			}
+		}
+	}
+	return result
+}
+
+func (analyzer analyzerImpl) externalRoutes(
+	podRef types.PodRef,
+	direction types.TrafficDirection,
+	policies []*shared.SourcePolicy,
+	rulesOf func(policy *shared.SourcePolicy) ([]shared.SourceRule, []shared.SourceRule),
+) []*types.ExternalRoute {
+	accesses := make(map[types.ExternalPeer]*externalAccess)
+	denied := make(map[types.ExternalPeer]map[int32]bool)
+	fullyDenied := make(map[types.ExternalPeer]bool)
+	for _, policy := range policies {
+		allowRules, denyRules := rulesOf(policy)
+		for _, rule := range allowRules {
+			for _, peer := range rule.Externals() {
+				access, ok := accesses[peer]
+				if !ok {
+					access = &externalAccess{
+						route: &types.ExternalRoute{
+							Pod:       podRef,
+							Direction: direction,
+							Peer:      peer,
+							Policies:  []types.NetworkPolicy{},
+						},
+						ports:       map[int32]bool{},
+						policyNames: map[string]bool{},
+					}
+					accesses[peer] = access
+				}
+				policyName := policy.Ref.Kind + "/" + policy.Ref.Namespace + "/" + policy.Ref.Name
+				if !access.policyNames[policyName] {
+					access.policyNames[policyName] = true
+					access.route.Policies = append(access.route.Policies, policy.Ref)
+				}
+				if !rule.RestrictsPorts() {
+					access.allPorts = true
+				}
+				addPorts(access.ports, rule.Ports)
+			}
+		}
+		for _, rule := range denyRules {
+			for _, peer := range rule.Externals() {
+				if !rule.RestrictsPorts() {
+					fullyDenied[peer] = true
+				}
+				if denied[peer] == nil {
+					denied[peer] = map[int32]bool{}
+				}
+				addPorts(denied[peer], rule.Ports)
+			}
+		}
+	}
+	result := make([]*types.ExternalRoute, 0, len(accesses))
+	for peer, access := range accesses {
+		if fullyDenied[peer] {
+			continue
+		}
+		if access.allPorts && len(denied[peer]) != 0 {
+			access.route.DeniedPorts = sortedPorts(denied[peer], nil)
+		} else if !access.allPorts {
+			ports := sortedPorts(access.ports, denied[peer])
+			if len(ports) == 0 {
+				continue
+			}
+			access.route.Ports = ports
+		}
+		result = append(result, access.route)
+	}
+	sortByPeer(result)
+	return result
+}
+
+func resolvedRules(rules []shared.SourceRule, podIsolation *shared.PodIsolation) []shared.SourceRule {
+	result := make([]shared.SourceRule, 0, len(rules))
+	for _, rule := range rules {
+		result = append(result, rule.WithNamedPortsOf(podIsolation.Pod))
+	}
+	return result
+}
+
+func addPorts(ports map[int32]bool, portRanges []types.PortRange) {
+	for _, portRange := range portRanges {
+		for port := portRange.Start; port <= portRange.End; port++ {
+			ports[port] = true
+		}
+	}
+}
+
+func sortByPeer(routes []*types.ExternalRoute) {
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Peer.Kind != routes[j].Peer.Kind {
+			return routes[i].Peer.Kind < routes[j].Peer.Kind
+		}
+		return routes[i].Peer.Name < routes[j].Peer.Name
+	})
+}
+
+func sortedPorts(ports map[int32]bool, excludedPorts map[int32]bool) []int32 {
+	result := make([]int32, 0, len(ports))
+	for port := range ports {
+		if !excludedPorts[port] {
+			result = append(result, port)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
@@ -0,0 +1,125 @@
+package externalroute
+
+import (
+	"github.com/google/go-cmp/cmp"
+	networkingv1 "k8s.io/api/networking/v1"
+	"karto/analyzer/shared"
+	"karto/testutils"
+	"karto/types"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	type args struct {
+		podIsolation *shared.PodIsolation
+	}
+	world := types.ExternalPeer{Kind: types.ExternalPeerEntity, Name: "world"}
+	fqdn := types.ExternalPeer{Kind: types.ExternalPeerFQDN, Name: "api.example.com"}
+	cidr := types.ExternalPeer{Kind: types.ExternalPeerCIDR, Name: "10.0.0.0/8"}
+	policy1 := types.NetworkPolicy{Kind: "CiliumNetworkPolicy", Name: "pol1", Namespace: "default"}
+	policy2 := types.NetworkPolicy{Kind: "CiliumNetworkPolicy", Name: "pol2", Namespace: "default"}
+	podRef := types.PodRef{Name: "pod", Namespace: "default"}
+	tests := []struct {
+		name           string
+		args           args
+		expectedResult []*types.ExternalRoute
+	}{
+		{
+			name: "reports external peers allowed by the policies isolating the pod",
+			args: args{
+				podIsolation: &shared.PodIsolation{
+					Pod:             testutils.NewPodBuilder().WithName("pod").Build(),
+					IngressPolicies: []*networkingv1.NetworkPolicy{},
+					EgressPolicies:  []*networkingv1.NetworkPolicy{},
+					IngressSourcePolicies: []*shared.SourcePolicy{
+						{
+							Ref:       policy1,
+							IsIngress: true,
+							Ingress:   []shared.SourceRule{{Peers: []shared.SourcePeer{{External: &world}}}},
+						},
+					},
+					EgressSourcePolicies: []*shared.SourcePolicy{
+						{
+							Ref:      policy1,
+							IsEgress: true,
+							Egress: []shared.SourceRule{
+								{
+									Peers: []shared.SourcePeer{{External: &fqdn}},
+									Ports: []types.PortRange{{Start: 443, End: 443}},
+								},
+							},
+						},
+						{
+							Ref:      policy2,
+							IsEgress: true,
+							Egress: []shared.SourceRule{
+								{
+									Peers: []shared.SourcePeer{{External: &fqdn}, {External: &cidr}},
+									Ports: []types.PortRange{{Start: 80, End: 80}},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectedResult: []*types.ExternalRoute{
+				{Pod: podRef, Direction: types.TrafficIngress, Peer: world,
+					Policies: []types.NetworkPolicy{policy1}, Ports: nil},
+				{Pod: podRef, Direction: types.TrafficEgress, Peer: cidr,
+					Policies: []types.NetworkPolicy{policy2}, Ports: []int32{80}},
+				{Pod: podRef, Direction: types.TrafficEgress, Peer: fqdn,
+					Policies: []types.NetworkPolicy{policy1, policy2}, Ports: []int32{80, 443}},
+			},
+		},
+		{
+			name: "deny rules remove denied external peers and ports",
+			args: args{
+				podIsolation: &shared.PodIsolation{
+					Pod:             testutils.NewPodBuilder().WithName("pod").Build(),
+					IngressPolicies: []*networkingv1.NetworkPolicy{},
+					EgressPolicies:  []*networkingv1.NetworkPolicy{},
+					EgressSourcePolicies: []*shared.SourcePolicy{
+						{
+							Ref:      policy1,
+							IsEgress: true,
+							Egress: []shared.SourceRule{
+								{Peers: []shared.SourcePeer{{External: &world}}},
+								{
+									Peers: []shared.SourcePeer{{External: &fqdn}},
+									Ports: []types.PortRange{{Start: 80, End: 80}, {Start: 443, End: 443}},
+								},
+								{Peers: []shared.SourcePeer{{External: &cidr}}},
+							},
+							EgressDeny: []shared.SourceRule{
+								{
+									Peers: []shared.SourcePeer{{External: &world}},
+									Ports: []types.PortRange{{Start: 25, End: 25}},
+								},
+								{
+									Peers: []shared.SourcePeer{{External: &fqdn}},
+									Ports: []types.PortRange{{Start: 80, End: 80}},
+								},
+								{Peers: []shared.SourcePeer{{External: &cidr}}},
+							},
+						},
+					},
+				},
+			},
+			expectedResult: []*types.ExternalRoute{
+				{Pod: podRef, Direction: types.TrafficEgress, Peer: world,
+					Policies: []types.NetworkPolicy{policy1}, Ports: nil, DeniedPorts: []int32{25}},
+				{Pod: podRef, Direction: types.TrafficEgress, Peer: fqdn,
+					Policies: []types.NetworkPolicy{policy1}, Ports: []int32{443}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := NewAnalyzer()
+			result := analyzer.Analyze(tt.args.podIsolation)
+			if diff := cmp.Diff(tt.expectedResult, result); diff != "" {
+				t.Errorf("Analyze() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
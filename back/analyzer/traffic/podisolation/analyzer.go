@@ -18,6 +18,9 @@ func NewAnalyzer() Analyzer {
 
 func (analyzer analyzerImpl) Analyze(pod *corev1.Pod, policies []*networkingv1.NetworkPolicy) *shared.PodIsolation {
 	podIsolation := shared.NewPodIsolation(pod)
+	if pod.Spec.HostNetwork {
+		return &podIsolation
+	}
 	for _, policy := range policies {
 		namespaceMatches := analyzer.networkPolicyNamespaceMatches(pod, policy)
 		selectorMatches := shared.SelectorMatches(pod.Labels, policy.Spec.PodSelector)
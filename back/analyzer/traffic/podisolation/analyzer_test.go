@@ -139,6 +139,22 @@ func TestAnalyze(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "a pod on the host network is never isolated",
+			args: args{
+				pod: testutils.NewPodBuilder().WithName("Pod1").WithLabel("app", "foo").WithHostNetwork().Build(),
+				networkPolicies: []*networkingv1.NetworkPolicy{
+					testutils.NewNetworkPolicyBuilder().WithTypes("Ingress", "Egress").WithPodSelector(
+						testutils.NewLabelSelectorBuilder().WithMatchLabel("app", "foo").Build()).Build(),
+				},
+			},
+			expectedPodIsolation: &shared.PodIsolation{
+				Pod: testutils.NewPodBuilder().WithName("Pod1").WithLabel("app", "foo").
+					WithHostNetwork().Build(),
+				IngressPolicies: []*networkingv1.NetworkPolicy{},
+				EgressPolicies:  []*networkingv1.NetworkPolicy{},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,19 @@
+package policysource
+
+import (
+	"karto/analyzer/shared"
+	"karto/crds/calico"
+	"karto/crds/cilium"
+	"karto/crds/policyapi"
+)
+
+type ClusterState struct {
+	CiliumNetworkPolicies []*cilium.NetworkPolicy
+	CalicoNetworkPolicies []*calico.NetworkPolicy
+	CalicoTiers           []*calico.Tier
+	AdminNetworkPolicies  []*policyapi.AdminNetworkPolicy
+}
+
+type Source interface {
+	Policies(clusterState ClusterState) []*shared.SourcePolicy
+}
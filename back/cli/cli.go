@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"karto/clusterlistener"
+	"karto/manifests"
+	"karto/types"
+	"strings"
+	"time"
+)
+
+const (
+	exitOk          = 0
+	exitCheckFailed = 1
+	exitError       = 2
+	outputText      = "text"
+	outputJson      = "json"
+	failOnNone      = "none"
+)
+
+type AnalyzeFunc func(clusterState types.ClusterState) types.AnalysisResult
+
+type command func(args []string, analyze AnalyzeFunc, stdout io.Writer, stderr io.Writer) int
+
+var commands = map[string]command{
+	"lint":       runLint,
+	"compliance": runCompliance,
+	"snapshot":   runSnapshot,
+	"verify":     runVerify,
+	"synthesize": runSynthesize,
+	"flows":      runFlows,
+}
+
+func IsCommand(name string) bool {
+	_, ok := commands[name]
+	return ok
+}
+
+func Run(args []string, analyze AnalyzeFunc, stdout io.Writer, stderr io.Writer) int {
+	return commands[args[0]](args[1:], analyze, stdout, stderr)
+}
+
+type stringList []string
+
+func (list *stringList) String() string {
+	return strings.Join(*list, ",")
+}
+
+func (list *stringList) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item != "" {
+			*list = append(*list, item)
+		}
+	}
+	return nil
+}
+
+type sourceFlags struct {
+	k8sConfigPath      string
+	manifestPaths      stringList
+	snapshotPath       string
+	namespaces         stringList
+	excludedNamespaces stringList
+	labelSelector      string
+	eventsRetention    time.Duration
+}
+
+func (source *sourceFlags) register(flagSet *flag.FlagSet) {
+	flagSet.StringVar(&source.k8sConfigPath, "kubeconfig", clusterlistener.DefaultK8sConfigPath(),
+		"(optional) absolute path to the kubeconfig file of the cluster to analyze")
+	flagSet.Var(&source.manifestPaths, "manifests",
+		"(optional) manifest files or directories to analyze instead of a live cluster (repeatable)")
+	flagSet.StringVar(&source.snapshotPath, "snapshot", "",
+		"(optional) snapshot file created by the snapshot command to analyze instead of a live cluster")
+	flagSet.Var(&source.namespaces, "namespaces",
+		"(optional) comma-separated namespaces to read, only requiring namespace-level permissions")
+	flagSet.Var(&source.excludedNamespaces, "exclude-namespaces", "(optional) comma-separated namespaces to ignore")
+	flagSet.StringVar(&source.labelSelector, "label-selector", "",
+		"(optional) label selector restricting the pods, services, ingresses and workloads read")
+	flagSet.DurationVar(&source.eventsRetention, "events-retention", time.Hour,
+		"(optional) how long warning events are kept and reported by the health analysis")
+}
+
+func (source *sourceFlags) load() (types.ClusterState, error) {
+	if len(source.manifestPaths) != 0 && source.snapshotPath != "" {
+		return types.ClusterState{}, fmt.Errorf("--manifests and --snapshot cannot be used together")
+	}
+	if len(source.manifestPaths) != 0 {
+		return manifests.Load(source.manifestPaths)
+	}
+	if source.snapshotPath != "" {
+		return readSnapshot(source.snapshotPath)
+	}
+	return clusterlistener.Snapshot(clusterlistener.Config{
+		K8sConfigPath:      source.k8sConfigPath,
+		Namespaces:         source.namespaces,
+		ExcludedNamespaces: source.excludedNamespaces,
+		LabelSelector:      source.labelSelector,
+		EventsRetention:    source.eventsRetention,
+	})
+}
+
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	flagSet := flag.NewFlagSet("karto "+name, flag.ContinueOnError)
+	flagSet.SetOutput(stderr)
+	return flagSet
+}
+
+func checkOutputFormat(output string) error {
+	if output != outputText && output != outputJson {
+		return fmt.Errorf("unsupported output format: %s", output)
+	}
+	return nil
+}
+
+func printError(stderr io.Writer, err error) int {
+	_, _ = fmt.Fprintf(stderr, "Error: %s\n", err)
+	return exitError
+}
@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"karto/types"
+	"strings"
+	"text/tabwriter"
+)
+
+const unchecked = -1
+
+type complianceThresholds struct {
+	minIngressIsolation           float64
+	minEgressIsolation            float64
+	requireIngressDefaultDeny     bool
+	requireEgressDefaultDeny      bool
+	maxReachableFromAllNamespaces int
+	maxUnrestrictedEgress         int
+}
+
+type complianceOutput struct {
+	Report     *types.ComplianceReport `json:"report"`
+	Violations []string                `json:"violations"`
+}
+
+func runCompliance(args []string, analyze AnalyzeFunc, stdout io.Writer, stderr io.Writer) int {
+	flagSet := newFlagSet("compliance", stderr)
+	source := sourceFlags{}
+	source.register(flagSet)
+	output := flagSet.String("output", outputText, "(optional) output format: text or json")
+	thresholds := complianceThresholds{}
+	flagSet.Float64Var(&thresholds.minIngressIsolation, "min-ingress-isolation", 0,
+		"(optional) minimum percentage of ingress isolated pods in the cluster")
+	flagSet.Float64Var(&thresholds.minEgressIsolation, "min-egress-isolation", 0,
+		"(optional) minimum percentage of egress isolated pods in the cluster")
+	flagSet.BoolVar(&thresholds.requireIngressDefaultDeny, "require-ingress-default-deny", false,
+		"(optional) require a default-deny ingress policy in every namespace")
+	flagSet.BoolVar(&thresholds.requireEgressDefaultDeny, "require-egress-default-deny", false,
+		"(optional) require a default-deny egress policy in every namespace")
+	flagSet.IntVar(&thresholds.maxReachableFromAllNamespaces, "max-reachable-from-all-namespaces", unchecked,
+		"(optional) maximum number of pods reachable from every namespace, -1 to disable the check")
+	flagSet.IntVar(&thresholds.maxUnrestrictedEgress, "max-unrestricted-egress", unchecked,
+		"(optional) maximum number of pods with unrestricted egress, -1 to disable the check")
+	err := flagSet.Parse(args)
+	if err != nil {
+		return exitError
+	}
+	err = checkOutputFormat(*output)
+	if err != nil {
+		return printError(stderr, err)
+	}
+	clusterState, err := source.load()
+	if err != nil {
+		return printError(stderr, err)
+	}
+	report := analyze(clusterState).Compliance
+	violations := thresholds.violationsOf(report)
+	if *output == outputJson {
+		err = json.NewEncoder(stdout).Encode(complianceOutput{Report: report, Violations: violations})
+	} else {
+		err = printComplianceReport(stdout, report, violations)
+	}
+	if err != nil {
+		return printError(stderr, err)
+	}
+	if len(violations) != 0 {
+		return exitCheckFailed
+	}
+	return exitOk
+}
+
+func (thresholds complianceThresholds) violationsOf(report *types.ComplianceReport) []string {
+	violations := make([]string, 0)
+	coverage := report.Coverage
+	if coverage.IngressIsolatedPercentage < thresholds.minIngressIsolation {
+		violations = append(violations, fmt.Sprintf("%.1f%% of pods are ingress isolated, %.1f%% required",
+			coverage.IngressIsolatedPercentage, thresholds.minIngressIsolation))
+	}
+	if coverage.EgressIsolatedPercentage < thresholds.minEgressIsolation {
+		violations = append(violations, fmt.Sprintf("%.1f%% of pods are egress isolated, %.1f%% required",
+			coverage.EgressIsolatedPercentage, thresholds.minEgressIsolation))
+	}
+	if thresholds.requireIngressDefaultDeny && len(report.NamespacesWithoutIngressDefaultDeny) != 0 {
+		violations = append(violations, fmt.Sprintf("namespaces without default-deny ingress policy: %s",
+			strings.Join(report.NamespacesWithoutIngressDefaultDeny, ", ")))
+	}
+	if thresholds.requireEgressDefaultDeny && len(report.NamespacesWithoutEgressDefaultDeny) != 0 {
+		violations = append(violations, fmt.Sprintf("namespaces without default-deny egress policy: %s",
+			strings.Join(report.NamespacesWithoutEgressDefaultDeny, ", ")))
+	}
+	if thresholds.maxReachableFromAllNamespaces != unchecked &&
+		len(coverage.PodsReachableFromAllNamespaces) > thresholds.maxReachableFromAllNamespaces {
+		violations = append(violations, fmt.Sprintf("%d pods are reachable from every namespace, at most %d allowed",
+			len(coverage.PodsReachableFromAllNamespaces), thresholds.maxReachableFromAllNamespaces))
+	}
+	if thresholds.maxUnrestrictedEgress != unchecked &&
+		len(coverage.PodsWithUnrestrictedEgress) > thresholds.maxUnrestrictedEgress {
+		violations = append(violations, fmt.Sprintf("%d pods have unrestricted egress, at most %d allowed",
+			len(coverage.PodsWithUnrestrictedEgress), thresholds.maxUnrestrictedEgress))
+	}
+	return violations
+}
+
+func printComplianceReport(stdout io.Writer, report *types.ComplianceReport, violations []string) error {
+	writer := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "NAMESPACE\tPODS\tINGRESS ISOLATED\tEGRESS ISOLATED\tINGRESS DEFAULT-DENY\t"+
+		"EGRESS DEFAULT-DENY\tREACHABLE FROM ALL\tUNRESTRICTED EGRESS")
+	for _, namespace := range report.Namespaces {
+		printCoverage(writer, namespace.Namespace, namespace.Coverage, fmt.Sprint(namespace.HasIngressDefaultDeny),
+			fmt.Sprint(namespace.HasEgressDefaultDeny))
+	}
+	printCoverage(writer, "(cluster)", report.Coverage, "-", "-")
+	err := writer.Flush()
+	if err != nil {
+		return err
+	}
+	for _, violation := range violations {
+		_, err = fmt.Fprintf(stdout, "VIOLATION %s\n", violation)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func printCoverage(writer io.Writer, name string, coverage types.IsolationCoverage, ingressDefaultDeny string,
+	egressDefaultDeny string) {
+	_, _ = fmt.Fprintf(writer, "%s\t%d\t%.1f%%\t%.1f%%\t%s\t%s\t%d\t%d\n", name, coverage.Pods,
+		coverage.IngressIsolatedPercentage, coverage.EgressIsolatedPercentage, ingressDefaultDeny, egressDefaultDeny,
+		len(coverage.PodsReachableFromAllNamespaces), len(coverage.PodsWithUnrestrictedEgress))
+}
@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/google/go-cmp/cmp"
+	"karto/types"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunCompliance(t *testing.T) {
+	type args struct {
+		flags []string
+	}
+	report := &types.ComplianceReport{
+		Coverage: types.IsolationCoverage{Pods: 4, IngressIsolatedPods: 3, EgressIsolatedPods: 1,
+			IngressIsolatedPercentage: 75, EgressIsolatedPercentage: 25,
+			PodsReachableFromAllNamespaces: []types.PodRef{{Name: "pod1", Namespace: "ns"}},
+			PodsWithUnrestrictedEgress: []types.PodRef{{Name: "pod1", Namespace: "ns"},
+				{Name: "pod2", Namespace: "ns"}, {Name: "pod3", Namespace: "ns"}}},
+		NamespacesWithoutIngressDefaultDeny: []string{},
+		NamespacesWithoutEgressDefaultDeny:  []string{"ns"},
+		Namespaces:                          []*types.NamespaceCompliance{},
+	}
+	tests := []struct {
+		name               string
+		args               args
+		expectedExitCode   int
+		expectedViolations []string
+	}{
+		{
+			name: "succeeds when all thresholds are met",
+			args: args{
+				flags: []string{"--min-ingress-isolation", "75", "--require-ingress-default-deny",
+					"--max-reachable-from-all-namespaces", "1"},
+			},
+			expectedExitCode:   exitOk,
+			expectedViolations: []string{},
+		},
+		{
+			name: "fails and reports every threshold which is not met",
+			args: args{
+				flags: []string{"--min-ingress-isolation", "80", "--min-egress-isolation", "50",
+					"--require-egress-default-deny", "--max-reachable-from-all-namespaces", "0",
+					"--max-unrestricted-egress", "2"},
+			},
+			expectedExitCode: exitCheckFailed,
+			expectedViolations: []string{
+				"75.0% of pods are ingress isolated, 80.0% required",
+				"25.0% of pods are egress isolated, 50.0% required",
+				"namespaces without default-deny egress policy: ns",
+				"1 pods are reachable from every namespace, at most 0 allowed",
+				"3 pods have unrestricted egress, at most 2 allowed",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifestPath := filepath.Join(t.TempDir(), "namespace.yaml")
+			err := os.WriteFile(manifestPath, []byte("apiVersion: v1\nkind: Namespace\nmetadata: {name: ns}\n"), 0o600)
+			if err != nil {
+				t.Fatal(err)
+			}
+			analyze := func(clusterState types.ClusterState) types.AnalysisResult {
+				return types.AnalysisResult{Compliance: report}
+			}
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			args := append([]string{"compliance", "--manifests", manifestPath, "--output", "json"}, tt.args.flags...)
+			exitCode := Run(args, analyze, stdout, stderr)
+			if diff := cmp.Diff(tt.expectedExitCode, exitCode); diff != "" {
+				t.Errorf("Run() exit code mismatch (-want +got):\n%s", diff)
+			}
+			output := complianceOutput{}
+			err = json.Unmarshal(stdout.Bytes(), &output)
+			if err != nil {
+				t.Fatalf("Run() printed an invalid json output: %s", err)
+			}
+			if diff := cmp.Diff(report, output.Report); diff != "" {
+				t.Errorf("Run() report mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.expectedViolations, output.Violations); diff != "" {
+				t.Errorf("Run() violations mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"karto/flows"
+	"karto/types"
+	"strings"
+)
+
+func runFlows(args []string, analyze AnalyzeFunc, stdout io.Writer, stderr io.Writer) int {
+	flagSet := newFlagSet("flows", stderr)
+	source := sourceFlags{}
+	source.register(flagSet)
+	flowPaths := stringList{}
+	flagSet.Var(&flowPaths, "flows", "flow files to import: hubble JSON exports or CSV files (repeatable)")
+	output := flagSet.String("output", outputText, "(optional) output format: text or json")
+	failOnDenied := flagSet.Bool("fail-on-denied", false,
+		"(optional) exit with code 1 when observed flows would be denied by the network policies")
+	err := flagSet.Parse(args)
+	if err != nil {
+		return exitError
+	}
+	err = checkOutputFormat(*output)
+	if err != nil {
+		return printError(stderr, err)
+	}
+	if len(flowPaths) == 0 {
+		return printError(stderr, fmt.Errorf("at least one flow file must be given with --flows"))
+	}
+	observedFlows, err := flows.Load(flowPaths)
+	if err != nil {
+		return printError(stderr, err)
+	}
+	clusterState, err := source.load()
+	if err != nil {
+		return printError(stderr, err)
+	}
+	analysisResult := analyze(clusterState)
+	report := flows.Compare(observedFlows, clusterState.Pods, analysisResult.AllowedRoutes)
+	if *output == outputJson {
+		err = json.NewEncoder(stdout).Encode(report)
+	} else {
+		err = printFlowReport(stdout, report)
+	}
+	if err != nil {
+		return printError(stderr, err)
+	}
+	if *failOnDenied && report.DeniedFlows != 0 {
+		return exitCheckFailed
+	}
+	return exitOk
+}
+
+func printFlowReport(stdout io.Writer, report *flows.Report) error {
+	lines := make([]string, 0)
+	for _, usage := range report.Routes {
+		if !usage.Used {
+			lines = append(lines, fmt.Sprintf("UNUSED %s/%s -> %s/%s on %s", usage.SourcePod.Namespace,
+				usage.SourcePod.Name, usage.TargetPod.Namespace, usage.TargetPod.Name,
+				describePorts(usage.Ports, usage.PortRanges)))
+		}
+	}
+	for _, observedFlow := range report.Flows {
+		if observedFlow.Verdict == flows.FlowAllowed {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s %s -> %s on %d/%s (%d flow(s))",
+			strings.ToUpper(string(observedFlow.Verdict)), describeFlowEnd(observedFlow.SourcePod, observedFlow.SourceIP),
+			describeFlowEnd(observedFlow.TargetPod, observedFlow.DestinationIP), observedFlow.Port,
+			observedFlow.Protocol, observedFlow.Count))
+	}
+	lines = append(lines, fmt.Sprintf("%d of %d allowed route(s) used, %d flow(s) allowed, %d denied, %d unmapped",
+		report.UsedRoutes, report.UsedRoutes+report.UnusedRoutes, report.AllowedFlows, report.DeniedFlows,
+		report.UnmappedFlows))
+	_, err := fmt.Fprintln(stdout, strings.Join(lines, "\n"))
+	return err
+}
+
+func describePorts(ports []int32, portRanges []types.PortRange) string {
+	if ports == nil {
+		return "all ports"
+	}
+	values := make([]string, 0, len(ports)+len(portRanges))
+	for _, port := range ports {
+		values = append(values, fmt.Sprint(port))
+	}
+	for _, portRange := range portRanges {
+		values = append(values, fmt.Sprintf("%d-%d", portRange.Start, portRange.End))
+	}
+	return "ports " + strings.Join(values, ",")
+}
+
+func describeFlowEnd(pod *types.PodRef, ip string) string {
+	if pod == nil {
+		return ip
+	}
+	return pod.Namespace + "/" + pod.Name
+}
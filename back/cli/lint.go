@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"karto/types"
+	"strings"
+)
+
+var severityRanks = map[types.FindingSeverity]int{
+	types.FindingSeverityInfo:    1,
+	types.FindingSeverityWarning: 2,
+	types.FindingSeverityError:   3,
+}
+
+func runLint(args []string, analyze AnalyzeFunc, stdout io.Writer, stderr io.Writer) int {
+	flagSet := newFlagSet("lint", stderr)
+	source := sourceFlags{}
+	source.register(flagSet)
+	output := flagSet.String("output", outputText, "(optional) output format: text or json")
+	failOn := flagSet.String("fail-on", string(types.FindingSeverityError),
+		"(optional) minimum severity of findings making the command fail: info, warning, error or none")
+	err := flagSet.Parse(args)
+	if err != nil {
+		return exitError
+	}
+	err = checkOutputFormat(*output)
+	if err != nil {
+		return printError(stderr, err)
+	}
+	threshold, isKnownSeverity := severityRanks[types.FindingSeverity(*failOn)]
+	if !isKnownSeverity && *failOn != failOnNone {
+		return printError(stderr, fmt.Errorf("unsupported severity: %s", *failOn))
+	}
+	clusterState, err := source.load()
+	if err != nil {
+		return printError(stderr, err)
+	}
+	findings := analyze(clusterState).Findings
+	if *output == outputJson {
+		err = json.NewEncoder(stdout).Encode(findings)
+	} else {
+		err = printFindings(stdout, findings)
+	}
+	if err != nil {
+		return printError(stderr, err)
+	}
+	if isKnownSeverity {
+		for _, finding := range findings {
+			if severityRanks[finding.Severity] >= threshold {
+				return exitCheckFailed
+			}
+		}
+	}
+	return exitOk
+}
+
+func printFindings(stdout io.Writer, findings []*types.Finding) error {
+	for _, finding := range findings {
+		objects := make([]string, 0, len(finding.Objects))
+		for _, object := range finding.Objects {
+			objects = append(objects, formatObjectRef(object))
+		}
+		_, err := fmt.Fprintf(stdout, "%-7s %s %s: %s\n", strings.ToUpper(string(finding.Severity)), finding.ID,
+			strings.Join(objects, ", "), finding.Message)
+		if err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(stdout, "%d finding(s)\n", len(findings))
+	return err
+}
+
+func formatObjectRef(object types.ObjectRef) string {
+	if object.Namespace == "" {
+		return fmt.Sprintf("%s/%s", object.Kind, object.Name)
+	}
+	return fmt.Sprintf("%s/%s/%s", object.Kind, object.Namespace, object.Name)
+}
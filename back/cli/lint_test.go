@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"bytes"
+	"github.com/google/go-cmp/cmp"
+	"karto/types"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunLint(t *testing.T) {
+	type args struct {
+		flags    []string
+		findings []*types.Finding
+	}
+	warningFinding := &types.Finding{ID: "NP001", Severity: types.FindingSeverityWarning,
+		Objects: []types.ObjectRef{{Kind: "NetworkPolicy", Name: "pol", Namespace: "ns"}},
+		Message: "network policy selects no pod"}
+	infoFinding := &types.Finding{ID: "NP007", Severity: types.FindingSeverityInfo,
+		Objects: []types.ObjectRef{{Kind: "Namespace", Name: "ns"}},
+		Message: "namespace has no default-deny egress policy"}
+	tests := []struct {
+		name             string
+		args             args
+		expectedExitCode int
+		expectedOutput   string
+	}{
+		{
+			name: "prints findings as text and succeeds when none reaches the threshold",
+			args: args{
+				flags:    []string{},
+				findings: []*types.Finding{warningFinding, infoFinding},
+			},
+			expectedExitCode: exitOk,
+			expectedOutput: "WARNING NP001 NetworkPolicy/ns/pol: network policy selects no pod\n" +
+				"INFO    NP007 Namespace/ns: namespace has no default-deny egress policy\n" +
+				"2 finding(s)\n",
+		},
+		{
+			name: "fails when a finding reaches the threshold",
+			args: args{
+				flags:    []string{"--fail-on", "warning"},
+				findings: []*types.Finding{warningFinding},
+			},
+			expectedExitCode: exitCheckFailed,
+			expectedOutput: "WARNING NP001 NetworkPolicy/ns/pol: network policy selects no pod\n" +
+				"1 finding(s)\n",
+		},
+		{
+			name: "prints findings as json",
+			args: args{
+				flags:    []string{"--output", "json", "--fail-on", "none"},
+				findings: []*types.Finding{infoFinding},
+			},
+			expectedExitCode: exitOk,
+			expectedOutput: "[{\"id\":\"NP007\",\"severity\":\"info\"," +
+				"\"objects\":[{\"kind\":\"Namespace\",\"name\":\"ns\",\"namespace\":\"\"}]," +
+				"\"message\":\"namespace has no default-deny egress policy\"}]\n",
+		},
+		{
+			name: "rejects an unknown severity",
+			args: args{
+				flags:    []string{"--fail-on", "critical"},
+				findings: []*types.Finding{},
+			},
+			expectedExitCode: exitError,
+			expectedOutput:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifestPath := filepath.Join(t.TempDir(), "namespace.yaml")
+			err := os.WriteFile(manifestPath, []byte("apiVersion: v1\nkind: Namespace\nmetadata: {name: ns}\n"), 0o600)
+			if err != nil {
+				t.Fatal(err)
+			}
+			analyze := func(clusterState types.ClusterState) types.AnalysisResult {
+				if len(clusterState.Namespaces) != 1 || clusterState.Namespaces[0].Name != "ns" {
+					t.Errorf("runLint() analyzed an unexpected cluster state: %v", clusterState.Namespaces)
+				}
+				return types.AnalysisResult{Findings: tt.args.findings}
+			}
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			exitCode := Run(append([]string{"lint", "--manifests", manifestPath}, tt.args.flags...), analyze,
+				stdout, stderr)
+			if diff := cmp.Diff(tt.expectedExitCode, exitCode); diff != "" {
+				t.Errorf("Run() exit code mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.expectedOutput, stdout.String()); diff != "" {
+				t.Errorf("Run() output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"encoding/json"
+	"io"
+	"karto/types"
+	"os"
+)
+
+func runSnapshot(args []string, _ AnalyzeFunc, stdout io.Writer, stderr io.Writer) int {
+	flagSet := newFlagSet("snapshot", stderr)
+	source := sourceFlags{}
+	source.register(flagSet)
+	outputFile := flagSet.String("output-file", "", "(optional) file to write the snapshot to instead of stdout")
+	err := flagSet.Parse(args)
+	if err != nil {
+		return exitError
+	}
+	clusterState, err := source.load()
+	if err != nil {
+		return printError(stderr, err)
+	}
+	writer := stdout
+	if *outputFile != "" {
+		file, err := os.Create(*outputFile)
+		if err != nil {
+			return printError(stderr, err)
+		}
+		defer func() {
+			_ = file.Close()
+		}()
+		writer = file
+	}
+	err = json.NewEncoder(writer).Encode(clusterState)
+	if err != nil {
+		return printError(stderr, err)
+	}
+	return exitOk
+}
+
+func readSnapshot(snapshotPath string) (types.ClusterState, error) {
+	file, err := os.Open(snapshotPath)
+	if err != nil {
+		return types.ClusterState{}, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	clusterState := types.ClusterState{}
+	err = json.NewDecoder(file).Decode(&clusterState)
+	return clusterState, err
+}
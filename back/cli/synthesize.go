@@ -0,0 +1,202 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	networkingv1 "k8s.io/api/networking/v1"
+	"karto/commons"
+	"karto/synthesis"
+	"karto/types"
+	"karto/verification"
+	"os"
+	"path/filepath"
+	"sigs.k8s.io/yaml"
+	"strings"
+)
+
+const yamlDocumentSeparator = "---\n"
+
+func runSynthesize(args []string, analyze AnalyzeFunc, stdout io.Writer, stderr io.Writer) int {
+	flagSet := newFlagSet("synthesize", stderr)
+	source := sourceFlags{}
+	source.register(flagSet)
+	assertionPaths := stringList{}
+	flagSet.Var(&assertionPaths, "assertions",
+		"(optional) assertion files whose allowed routes are the desired routes (repeatable)")
+	sources := stringList{}
+	flagSet.Var(&sources, "from", "(optional) keep only the current routes from these pods, "+
+		"as <namespace>/<pod name> patterns (repeatable)")
+	targets := stringList{}
+	flagSet.Var(&targets, "to", "(optional) keep only the current routes to these pods, "+
+		"as <namespace>/<pod name> patterns (repeatable)")
+	allowDNS := flagSet.Bool("allow-dns", false, "(optional) also allow all pods to query the cluster DNS")
+	outputDir := flagSet.String("output-dir", "",
+		"(optional) directory to write one manifest file per namespace to, instead of stdout")
+	err := flagSet.Parse(args)
+	if err != nil {
+		return exitError
+	}
+	if len(assertionPaths) != 0 && (len(sources) != 0 || len(targets) != 0) {
+		return printError(stderr, fmt.Errorf("--assertions cannot be used together with --from or --to"))
+	}
+	clusterState, err := source.load()
+	if err != nil {
+		return printError(stderr, err)
+	}
+	analysisResult := analyze(clusterState)
+	var desiredRoutes []synthesis.Route
+	if len(assertionPaths) != 0 {
+		assertions, err := verification.LoadAssertions(assertionPaths)
+		if err != nil {
+			return printError(stderr, err)
+		}
+		desiredRoutes = synthesis.RoutesFromAssertions(assertions, analysisResult.Pods)
+	} else {
+		desiredRoutes = synthesis.RoutesFromAllowedRoutes(analysisResult.AllowedRoutes, analysisResult.Pods,
+			toEndpoints(sources), toEndpoints(targets))
+	}
+	result := synthesis.Synthesize(desiredRoutes, analysisResult.Pods, synthesis.Options{AllowDNS: *allowDNS})
+	validationState := clusterState
+	validationState.NetworkPolicies = policiesOutside(clusterState.NetworkPolicies, result.Namespaces)
+	validationState.NetworkPolicies = append(validationState.NetworkPolicies, result.Policies...)
+	diff := synthesis.Diff(desiredRoutes, routesInvolving(analyze(validationState).AllowedRoutes, result.Namespaces))
+	reportWriter := stdout
+	if *outputDir == "" {
+		err = writePolicies(stdout, result.Policies)
+		reportWriter = stderr
+	} else {
+		err = writePoliciesByNamespace(*outputDir, result.Policies)
+	}
+	if err != nil {
+		return printError(stderr, err)
+	}
+	err = printSynthesisReport(reportWriter, desiredRoutes, result, diff)
+	if err != nil {
+		return printError(stderr, err)
+	}
+	if len(diff.Missing) != 0 || len(diff.Unexpected) != 0 {
+		return exitCheckFailed
+	}
+	return exitOk
+}
+
+func policiesOutside(policies []*networkingv1.NetworkPolicy, namespaces []string) []*networkingv1.NetworkPolicy {
+	namespaceSet := setOf(namespaces)
+	return commons.Filter(policies, func(policy *networkingv1.NetworkPolicy) bool {
+		return !namespaceSet.Contains(policy.Namespace)
+	})
+}
+
+func routesInvolving(allowedRoutes []*types.AllowedRoute, namespaces []string) []*types.AllowedRoute {
+	namespaceSet := setOf(namespaces)
+	return commons.Filter(allowedRoutes, func(allowedRoute *types.AllowedRoute) bool {
+		return namespaceSet.Contains(allowedRoute.SourcePod.Namespace) ||
+			namespaceSet.Contains(allowedRoute.TargetPod.Namespace)
+	})
+}
+
+func setOf(values []string) *commons.Set[string] {
+	set := commons.NewSet[string]()
+	for _, value := range values {
+		set.Add(value)
+	}
+	return set
+}
+
+func toEndpoints(shorthands []string) []verification.Endpoint {
+	endpoints := make([]verification.Endpoint, 0, len(shorthands))
+	for _, shorthand := range shorthands {
+		endpoints = append(endpoints, verification.ParseEndpoint(shorthand))
+	}
+	return endpoints
+}
+
+func writePolicies(writer io.Writer, policies []*networkingv1.NetworkPolicy) error {
+	for _, policy := range policies {
+		content, err := yaml.Marshal(policy)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(writer, yamlDocumentSeparator+string(content))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writePoliciesByNamespace(outputDir string, policies []*networkingv1.NetworkPolicy) error {
+	err := os.MkdirAll(outputDir, 0o755)
+	if err != nil {
+		return err
+	}
+	policiesByNamespace := map[string][]*networkingv1.NetworkPolicy{}
+	namespaces := make([]string, 0)
+	for _, policy := range policies {
+		if _, ok := policiesByNamespace[policy.Namespace]; !ok {
+			namespaces = append(namespaces, policy.Namespace)
+		}
+		policiesByNamespace[policy.Namespace] = append(policiesByNamespace[policy.Namespace], policy)
+	}
+	for _, namespace := range namespaces {
+		file, err := os.Create(filepath.Join(outputDir, namespace+".yaml"))
+		if err != nil {
+			return err
+		}
+		err = writePolicies(file, policiesByNamespace[namespace])
+		closeErr := file.Close()
+		if err != nil {
+			return err
+		}
+		if closeErr != nil {
+			return closeErr
+		}
+	}
+	return nil
+}
+
+func printSynthesisReport(writer io.Writer, desiredRoutes []synthesis.Route, result synthesis.Result,
+	diff synthesis.RouteDiff) error {
+	lines := []string{
+		fmt.Sprintf("Generated %d network policies for %d desired routes", len(result.Policies), len(desiredRoutes)),
+	}
+	for _, skipped := range result.Skipped {
+		lines = append(lines, "SKIPPED "+skipped)
+	}
+	for _, warning := range result.Warnings {
+		lines = append(lines, "WARNING "+warning)
+	}
+	for _, route := range diff.Missing {
+		lines = append(lines, "- "+formatRoute(route))
+	}
+	for _, route := range diff.Unexpected {
+		lines = append(lines, "+ "+formatRoute(route))
+	}
+	if len(diff.Missing) == 0 && len(diff.Unexpected) == 0 {
+		lines = append(lines, "Validation passed: the generated policies allow exactly the desired routes")
+	} else {
+		lines = append(lines, fmt.Sprintf("Validation failed: %d desired routes are denied (-), "+
+			"%d undesired routes are allowed (+)", len(diff.Missing), len(diff.Unexpected)))
+	}
+	_, err := fmt.Fprintln(writer, strings.Join(lines, "\n"))
+	return err
+}
+
+func formatRoute(route synthesis.Route) string {
+	ports := "all ports"
+	if route.Ports != nil {
+		portStrings := make([]string, 0, len(route.Ports)+len(route.PortRanges))
+		for _, port := range route.Ports {
+			portStrings = append(portStrings, fmt.Sprint(port))
+		}
+		for _, portRange := range route.PortRanges {
+			portStrings = append(portStrings, fmt.Sprintf("%d-%d", portRange.Start, portRange.End))
+		}
+		ports = "ports " + strings.Join(portStrings, ",")
+	}
+	return fmt.Sprintf("%s -> %s on %s", formatPodRef(route.Source), formatPodRef(route.Target), ports)
+}
+
+func formatPodRef(podRef types.PodRef) string {
+	return podRef.Namespace + "/" + podRef.Name
+}
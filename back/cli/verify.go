@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"karto/verification"
+	"strings"
+)
+
+const (
+	outputJunit    = "junit"
+	junitSuiteName = "karto verify"
+)
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Content string `xml:",chardata"`
+}
+
+func runVerify(args []string, analyze AnalyzeFunc, stdout io.Writer, stderr io.Writer) int {
+	flagSet := newFlagSet("verify", stderr)
+	source := sourceFlags{}
+	source.register(flagSet)
+	assertionPaths := stringList{}
+	flagSet.Var(&assertionPaths, "assertions", "assertion files to verify (repeatable)")
+	output := flagSet.String("output", outputText, "(optional) output format: text, json or junit")
+	err := flagSet.Parse(args)
+	if err != nil {
+		return exitError
+	}
+	if *output != outputJunit {
+		err = checkOutputFormat(*output)
+		if err != nil {
+			return printError(stderr, err)
+		}
+	}
+	if len(assertionPaths) == 0 {
+		return printError(stderr, fmt.Errorf("at least one assertion file must be given with --assertions"))
+	}
+	assertions, err := verification.LoadAssertions(assertionPaths)
+	if err != nil {
+		return printError(stderr, err)
+	}
+	clusterState, err := source.load()
+	if err != nil {
+		return printError(stderr, err)
+	}
+	analysisResult := analyze(clusterState)
+	report := verification.Verify(assertions, analysisResult.Pods, analysisResult.AllowedRoutes)
+	switch *output {
+	case outputJson:
+		err = json.NewEncoder(stdout).Encode(report)
+	case outputJunit:
+		err = printJunitReport(stdout, report)
+	default:
+		err = printVerificationReport(stdout, report)
+	}
+	if err != nil {
+		return printError(stderr, err)
+	}
+	if report.Failed != 0 {
+		return exitCheckFailed
+	}
+	return exitOk
+}
+
+func printVerificationReport(stdout io.Writer, report *verification.Report) error {
+	lines := make([]string, 0)
+	for _, result := range report.Results {
+		status := "PASS"
+		if !result.Passed {
+			status = "FAIL"
+		}
+		lines = append(lines, fmt.Sprintf("%s %s (%s)", status, result.Name, result.Message))
+		for _, violation := range result.Violations {
+			lines = append(lines, "    "+violation)
+		}
+	}
+	lines = append(lines, fmt.Sprintf("%d passed, %d failed", report.Passed, report.Failed))
+	_, err := fmt.Fprintln(stdout, strings.Join(lines, "\n"))
+	return err
+}
+
+func printJunitReport(stdout io.Writer, report *verification.Report) error {
+	suite := junitTestSuite{
+		Name:      junitSuiteName,
+		Tests:     len(report.Results),
+		Failures:  report.Failed,
+		TestCases: make([]junitTestCase, 0, len(report.Results)),
+	}
+	for _, result := range report.Results {
+		testCase := junitTestCase{Name: result.Name, ClassName: junitSuiteName}
+		if !result.Passed {
+			testCase.Failure = &junitFailure{
+				Message: result.Message,
+				Content: strings.Join(result.Violations, "\n"),
+			}
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+	_, err := io.WriteString(stdout, xml.Header)
+	if err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(stdout)
+	encoder.Indent("", "  ")
+	err = encoder.Encode(junitTestSuites{Suites: []junitTestSuite{suite}})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(stdout)
+	return err
+}
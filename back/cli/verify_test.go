@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"bytes"
+	"github.com/google/go-cmp/cmp"
+	"karto/types"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunVerify(t *testing.T) {
+	type args struct {
+		output string
+	}
+	tests := []struct {
+		name             string
+		args             args
+		expectedExitCode int
+		expectedOutput   string
+	}{
+		{
+			name:             "prints a text report and fails when an assertion fails",
+			args:             args{output: "text"},
+			expectedExitCode: exitCheckFailed,
+			expectedOutput: "PASS front reaches api (1 route(s) checked)\n" +
+				"FAIL api isolated from front (1 of 1 route(s) violate the assertion)\n" +
+				"    ns/api -> ns/front: traffic is allowed on ports 53\n" +
+				"1 passed, 1 failed\n",
+		},
+		{
+			name:             "prints a junit report",
+			args:             args{output: "junit"},
+			expectedExitCode: exitCheckFailed,
+			expectedOutput: "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n" +
+				"<testsuites>\n" +
+				"  <testsuite name=\"karto verify\" tests=\"2\" failures=\"1\">\n" +
+				"    <testcase name=\"front reaches api\" classname=\"karto verify\"></testcase>\n" +
+				"    <testcase name=\"api isolated from front\" classname=\"karto verify\">\n" +
+				"      <failure message=\"1 of 1 route(s) violate the assertion\">" +
+				"ns/api -&gt; ns/front: traffic is allowed on ports 53</failure>\n" +
+				"    </testcase>\n" +
+				"  </testsuite>\n" +
+				"</testsuites>\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			manifestPath := filepath.Join(dir, "namespace.yaml")
+			err := os.WriteFile(manifestPath, []byte("apiVersion: v1\nkind: Namespace\nmetadata: {name: ns}\n"), 0o600)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertionsPath := filepath.Join(dir, "assertions.yaml")
+			err = os.WriteFile(assertionsPath, []byte(""+
+				"assertions:\n"+
+				"  - {name: front reaches api, from: ns/front, to: ns/api, expect: allowed}\n"+
+				"  - {name: api isolated from front, from: ns/api, to: ns/front, expect: denied}\n"), 0o600)
+			if err != nil {
+				t.Fatal(err)
+			}
+			front := types.PodRef{Name: "front", Namespace: "ns"}
+			api := types.PodRef{Name: "api", Namespace: "ns"}
+			analyze := func(clusterState types.ClusterState) types.AnalysisResult {
+				return types.AnalysisResult{
+					Pods: []*types.Pod{{Name: "front", Namespace: "ns"}, {Name: "api", Namespace: "ns"}},
+					AllowedRoutes: []*types.AllowedRoute{
+						{SourcePod: front, TargetPod: api, Ports: []int32{8080}},
+						{SourcePod: api, TargetPod: front, Ports: []int32{53}},
+					},
+				}
+			}
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			exitCode := Run([]string{"verify", "--manifests", manifestPath, "--assertions", assertionsPath,
+				"--output", tt.args.output}, analyze, stdout, stderr)
+			if diff := cmp.Diff(tt.expectedExitCode, exitCode); diff != "" {
+				t.Errorf("Run() exit code mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.expectedOutput, stdout.String()); diff != "" {
+				t.Errorf("Run() output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package clusterlistener
+
+import (
+	"fmt"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const DefaultClusterName = "default"
+
+type Cluster struct {
+	Name          string
+	K8sConfigPath string
+	Context       string
+	inCluster     bool
+}
+
+func Clusters(config Config) ([]Cluster, error) {
+	if config.K8sConfigDir != "" && len(config.K8sContexts) != 0 {
+		return nil, fmt.Errorf("a kubeconfig directory and kubeconfig contexts cannot be used together")
+	}
+	if config.K8sConfigDir != "" {
+		return clustersOfDirectory(config.K8sConfigDir)
+	}
+	if len(config.K8sContexts) != 0 {
+		result := make([]Cluster, 0, len(config.K8sContexts))
+		seen := make(map[string]bool)
+		for _, context := range config.K8sContexts {
+			if seen[context] {
+				continue
+			}
+			seen[context] = true
+			result = append(result, Cluster{Name: context, K8sConfigPath: config.K8sConfigPath, Context: context})
+		}
+		return result, nil
+	}
+	return []Cluster{defaultCluster(config)}, nil
+}
+
+func defaultCluster(config Config) Cluster {
+	return Cluster{Name: DefaultClusterName, K8sConfigPath: config.K8sConfigPath, inCluster: true}
+}
+
+func clustersOfDirectory(dir string) ([]Cluster, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Cluster, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		result = append(result, Cluster{Name: name, K8sConfigPath: filepath.Join(dir, entry.Name())})
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no kubeconfig file found in %s", dir)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+func getK8sConfig(cluster Cluster) (*rest.Config, error) {
+	if cluster.inCluster {
+		config, err := rest.InClusterConfig()
+		if err == nil {
+			return config, nil
+		}
+		log.Println("Unable to connect to Kubernetes service, fallback to kubeconfig file")
+	}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		&clientcmd.ClientConfigLoadingRules{ExplicitPath: cluster.K8sConfigPath},
+		&clientcmd.ConfigOverrides{CurrentContext: cluster.Context},
+	).ClientConfig()
+}
@@ -0,0 +1,85 @@
+package clusterlistener
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestClusters(t *testing.T) {
+	type args struct {
+		config Config
+		files  []string
+	}
+	tests := []struct {
+		name             string
+		args             args
+		expectedClusters []Cluster
+		expectedError    bool
+	}{
+		{
+			name: "watches the default cluster of the kubeconfig file",
+			args: args{
+				config: Config{K8sConfigPath: "/home/user/.kube/config"},
+			},
+			expectedClusters: []Cluster{
+				{Name: "default", K8sConfigPath: "/home/user/.kube/config", inCluster: true},
+			},
+		},
+		{
+			name: "watches one cluster per kubeconfig context",
+			args: args{
+				config: Config{K8sConfigPath: "/home/user/.kube/config", K8sContexts: []string{"staging", "prod",
+					"staging"}},
+			},
+			expectedClusters: []Cluster{
+				{Name: "staging", K8sConfigPath: "/home/user/.kube/config", Context: "staging"},
+				{Name: "prod", K8sConfigPath: "/home/user/.kube/config", Context: "prod"},
+			},
+		},
+		{
+			name: "watches one cluster per file of the kubeconfig directory",
+			args: args{
+				files: []string{"staging.yaml", "prod", ".hidden"},
+			},
+			expectedClusters: []Cluster{
+				{Name: "prod", K8sConfigPath: "prod"},
+				{Name: "staging", K8sConfigPath: "staging.yaml"},
+			},
+		},
+		{
+			name: "rejects a kubeconfig directory used with contexts",
+			args: args{
+				config: Config{K8sContexts: []string{"prod"}},
+				files:  []string{"prod"},
+			},
+			expectedError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.args.config
+			if tt.args.files != nil {
+				config.K8sConfigDir = t.TempDir()
+				for _, file := range tt.args.files {
+					err := os.WriteFile(filepath.Join(config.K8sConfigDir, file), []byte{}, 0o600)
+					if err != nil {
+						t.Fatal(err)
+					}
+				}
+				for i := range tt.expectedClusters {
+					tt.expectedClusters[i].K8sConfigPath = filepath.Join(config.K8sConfigDir,
+						tt.expectedClusters[i].K8sConfigPath)
+				}
+			}
+			clusters, err := Clusters(config)
+			if diff := cmp.Diff(tt.expectedError, err != nil); diff != "" {
+				t.Errorf("Clusters() error mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.expectedClusters, clusters, cmp.AllowUnexported(Cluster{})); diff != "" {
+				t.Errorf("Clusters() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
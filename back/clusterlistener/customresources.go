@@ -0,0 +1,180 @@
+package clusterlistener
+
+import (
+	"context"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"karto/crds/calico"
+	"karto/crds/cilium"
+	"karto/crds/istio"
+	"karto/crds/mcs"
+	"karto/crds/policyapi"
+	"log"
+)
+
+func availableResources(
+	k8sClient kubernetes.Interface,
+	groupVersion schema.GroupVersion,
+	candidates ...schema.GroupVersionResource,
+) []schema.GroupVersionResource {
+	resourceList, err := k8sClient.Discovery().ServerResourcesForGroupVersion(groupVersion.String())
+	if err != nil {
+		log.Printf("Resources of %s are not available and will be ignored\n", groupVersion)
+		return nil
+	}
+	result := make([]schema.GroupVersionResource, 0, len(candidates))
+	for _, candidate := range candidates {
+		for _, resource := range resourceList.APIResources {
+			if resource.Name == candidate.Resource {
+				result = append(result, candidate)
+				break
+			}
+		}
+	}
+	return result
+}
+
+func ciliumResources(k8sClient kubernetes.Interface) []schema.GroupVersionResource {
+	return availableResources(k8sClient, cilium.GroupVersion, cilium.NetworkPolicyResource,
+		cilium.ClusterwideNetworkPolicyResource)
+}
+
+func calicoPolicyResources(k8sClient kubernetes.Interface) []schema.GroupVersionResource {
+	return availableResources(k8sClient, calico.GroupVersion, calico.NetworkPolicyResource,
+		calico.GlobalNetworkPolicyResource)
+}
+
+func calicoTierResources(k8sClient kubernetes.Interface) []schema.GroupVersionResource {
+	return availableResources(k8sClient, calico.GroupVersion, calico.TierResource)
+}
+
+func adminPolicyResources(k8sClient kubernetes.Interface) []schema.GroupVersionResource {
+	return availableResources(k8sClient, policyapi.GroupVersion, policyapi.AdminNetworkPolicyResource,
+		policyapi.BaselineAdminNetworkPolicyResource)
+}
+
+func authorizationPolicyResources(k8sClient kubernetes.Interface) []schema.GroupVersionResource {
+	return availableResources(k8sClient, istio.GroupVersion, istio.AuthorizationPolicyResource)
+}
+
+func peerAuthenticationResources(k8sClient kubernetes.Interface) []schema.GroupVersionResource {
+	return availableResources(k8sClient, istio.GroupVersion, istio.PeerAuthenticationResource)
+}
+
+func serviceEntryResources(k8sClient kubernetes.Interface) []schema.GroupVersionResource {
+	return availableResources(k8sClient, istio.NetworkingGroupVersion, istio.ServiceEntryResource)
+}
+
+func serviceExportResources(k8sClient kubernetes.Interface) []schema.GroupVersionResource {
+	return availableResources(k8sClient, mcs.GroupVersion, mcs.ServiceExportResource)
+}
+
+func serviceImportResources(k8sClient kubernetes.Interface) []schema.GroupVersionResource {
+	return availableResources(k8sClient, mcs.GroupVersion, mcs.ServiceImportResource)
+}
+
+func namespacedResources(
+	k8sClient kubernetes.Interface,
+	resources []schema.GroupVersionResource,
+) []schema.GroupVersionResource {
+	return resourcesOfScope(k8sClient, resources, true)
+}
+
+func clusterScopedResources(
+	k8sClient kubernetes.Interface,
+	resources []schema.GroupVersionResource,
+) []schema.GroupVersionResource {
+	return resourcesOfScope(k8sClient, resources, false)
+}
+
+func resourcesOfScope(
+	k8sClient kubernetes.Interface,
+	resources []schema.GroupVersionResource,
+	namespaced bool,
+) []schema.GroupVersionResource {
+	result := make([]schema.GroupVersionResource, 0, len(resources))
+	for _, resource := range resources {
+		resourceList, err := k8sClient.Discovery().ServerResourcesForGroupVersion(resource.GroupVersion().String())
+		if err != nil {
+			continue
+		}
+		for _, apiResource := range resourceList.APIResources {
+			if apiResource.Name == resource.Resource && apiResource.Namespaced == namespaced {
+				result = append(result, resource)
+				break
+			}
+		}
+	}
+	return result
+}
+
+func informersFor(
+	factory dynamicinformer.DynamicSharedInformerFactory,
+	resources []schema.GroupVersionResource,
+) []informers.GenericInformer {
+	result := make([]informers.GenericInformer, 0, len(resources))
+	for _, resource := range resources {
+		result = append(result, factory.ForResource(resource))
+	}
+	return result
+}
+
+func listCustomResources[T any](customInformers []informers.GenericInformer) []*T {
+	objects := make([]runtime.Object, 0)
+	for _, informer := range customInformers {
+		informerObjects, err := informer.Lister().List(labels.Everything())
+		if err != nil {
+			log.Printf("Unable to list cached custom resources, they are ignored until the next analysis: %s\n", err)
+			continue
+		}
+		objects = append(objects, informerObjects...)
+	}
+	return fromUnstructured[T](objects)
+}
+
+func fetchCustomResources[T any](
+	ctx context.Context,
+	dynamicClient dynamic.Interface,
+	namespace string,
+	options metav1.ListOptions,
+	resources []schema.GroupVersionResource,
+) ([]*T, error) {
+	objects := make([]runtime.Object, 0)
+	for _, resource := range resources {
+		list, err := dynamicClient.Resource(resource).Namespace(namespace).List(ctx, options)
+		if err != nil {
+			return nil, err
+		}
+		for i := range list.Items {
+			objects = append(objects, &list.Items[i])
+		}
+	}
+	return fromUnstructured[T](objects), nil
+}
+
+func fromUnstructured[T any](objects []runtime.Object) []*T {
+	result := make([]*T, 0, len(objects))
+	for _, object := range objects {
+		unstructuredObject, ok := object.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		typedObject := new(T)
+		err := runtime.DefaultUnstructuredConverter.FromUnstructured(unstructuredObject.UnstructuredContent(),
+			typedObject)
+		if err != nil {
+			log.Printf("Ignoring %s %s/%s: %s\n", unstructuredObject.GetKind(), unstructuredObject.GetNamespace(),
+				unstructuredObject.GetName(), err)
+			continue
+		}
+		result = append(result, typedObject)
+	}
+	return result
+}
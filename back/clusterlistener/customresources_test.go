@@ -0,0 +1,70 @@
+package clusterlistener
+
+import (
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	"karto/crds/calico"
+	"karto/crds/cilium"
+	"testing"
+)
+
+func TestResourcesOfScope(t *testing.T) {
+	type args struct {
+		resources  []schema.GroupVersionResource
+		namespaced bool
+	}
+	k8sClient := fake.NewSimpleClientset()
+	k8sClient.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: cilium.GroupVersion.String(),
+			APIResources: []metav1.APIResource{
+				{Name: cilium.NetworkPolicyResource.Resource, Namespaced: true},
+				{Name: cilium.ClusterwideNetworkPolicyResource.Resource, Namespaced: false},
+			},
+		},
+		{
+			GroupVersion: calico.GroupVersion.String(),
+			APIResources: []metav1.APIResource{
+				{Name: calico.NetworkPolicyResource.Resource, Namespaced: true},
+				{Name: calico.GlobalNetworkPolicyResource.Resource, Namespaced: false},
+				{Name: calico.TierResource.Resource, Namespaced: false},
+			},
+		},
+	}
+	resources := []schema.GroupVersionResource{cilium.NetworkPolicyResource, cilium.ClusterwideNetworkPolicyResource,
+		calico.NetworkPolicyResource, calico.GlobalNetworkPolicyResource, calico.TierResource}
+	tests := []struct {
+		name              string
+		args              args
+		expectedResources []schema.GroupVersionResource
+	}{
+		{
+			name: "keeps namespaced resources",
+			args: args{
+				resources:  resources,
+				namespaced: true,
+			},
+			expectedResources: []schema.GroupVersionResource{cilium.NetworkPolicyResource,
+				calico.NetworkPolicyResource},
+		},
+		{
+			name: "keeps cluster-scoped resources",
+			args: args{
+				resources:  resources,
+				namespaced: false,
+			},
+			expectedResources: []schema.GroupVersionResource{cilium.ClusterwideNetworkPolicyResource,
+				calico.GlobalNetworkPolicyResource, calico.TierResource},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := resourcesOfScope(k8sClient, tt.args.resources, tt.args.namespaced)
+			if diff := cmp.Diff(tt.expectedResources, result); diff != "" {
+				t.Errorf("resourcesOfScope() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package clusterlistener
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"karto/analyzer/shared"
+	"time"
+)
+
+type eventExpiry struct {
+	retention time.Duration
+	onExpiry  func()
+	timer     *time.Timer
+}
+
+func newEventExpiry(retention time.Duration, onExpiry func()) *eventExpiry {
+	return &eventExpiry{
+		retention: retention,
+		onExpiry:  onExpiry,
+	}
+}
+
+func (expiry *eventExpiry) recentEvents(stores []cache.Store, now time.Time) []*corev1.Event {
+	events := make([]*corev1.Event, 0)
+	for _, store := range stores {
+		for _, object := range store.List() {
+			if event, ok := object.(*corev1.Event); ok {
+				events = append(events, event)
+			}
+		}
+	}
+	result, nextExpiry := recentEvents(events, expiry.retention, now)
+	expiry.schedule(nextExpiry, now)
+	return result
+}
+
+func (expiry *eventExpiry) schedule(nextExpiry time.Time, now time.Time) {
+	if expiry.timer != nil {
+		expiry.timer.Stop()
+		expiry.timer = nil
+	}
+	if !nextExpiry.IsZero() {
+		expiry.timer = time.AfterFunc(nextExpiry.Sub(now), expiry.onExpiry)
+	}
+}
+
+func recentEvents(events []*corev1.Event, retention time.Duration, now time.Time) ([]*corev1.Event, time.Time) {
+	result := make([]*corev1.Event, 0, len(events))
+	var nextExpiry time.Time
+	for _, event := range events {
+		expiresAt := shared.EventLastSeen(event).Add(retention)
+		if !expiresAt.After(now) {
+			continue
+		}
+		if nextExpiry.IsZero() || expiresAt.Before(nextExpiry) {
+			nextExpiry = expiresAt
+		}
+		result = append(result, event)
+	}
+	return result, nextExpiry
+}
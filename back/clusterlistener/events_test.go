@@ -0,0 +1,50 @@
+package clusterlistener
+
+import (
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"karto/testutils"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestEventExpiryRecentEvents(t *testing.T) {
+	now := time.Now()
+	retention := time.Hour
+	event := func(name string, lastSeen time.Time) *corev1.Event {
+		result := testutils.NewEventBuilder().WithInvolvedObject("Pod", "ns", name).
+			WithTimestamps(lastSeen, lastSeen).Build()
+		result.Name = name
+		return result
+	}
+	store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	_ = store.Add(event("expired", now.Add(-2*time.Hour)))
+	_ = store.Add(event("expiring", now.Add(-retention+100*time.Millisecond)))
+	_ = store.Add(event("recent", now.Add(-time.Minute)))
+	expired := make(chan struct{}, 1)
+	expiry := newEventExpiry(retention, func() { expired <- struct{}{} })
+	events := expiry.recentEvents([]cache.Store{store}, now)
+	names := make(map[string]bool)
+	for _, event := range events {
+		names[event.Name] = true
+	}
+	if diff := cmp.Diff(map[string]bool{"expiring": true, "recent": true}, names); diff != "" {
+		t.Errorf("recentEvents() result mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"ns/expired", "ns/expiring", "ns/recent"}, sortedKeys(store)); diff != "" {
+		t.Errorf("recentEvents() modified the cached events (-want +got):\n%s", diff)
+	}
+	select {
+	case <-expired:
+	case <-time.After(time.Second):
+		t.Errorf("recentEvents() did not schedule a notification at the next expiry")
+	}
+}
+
+func sortedKeys(store cache.Store) []string {
+	result := store.ListKeys()
+	sort.Strings(result)
+	return result
+}
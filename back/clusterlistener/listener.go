@@ -1,113 +1,363 @@
 package clusterlistener
 
 import (
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
-	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
-	"k8s.io/client-go/tools/clientcmd"
-	"k8s.io/client-go/util/workqueue"
+	"karto/crds/calico"
+	"karto/crds/cilium"
+	"karto/crds/istio"
+	"karto/crds/mcs"
+	"karto/crds/policyapi"
 	"karto/types"
 	"log"
+	"sync"
+	"time"
 )
 
-func Listen(k8sConfigPath string, clusterStateChannel chan<- types.ClusterState) {
-	k8sClient := getK8sClient(k8sConfigPath)
-	analyzeQueue := workqueue.NewRateLimitingQueue(workqueue.DefaultItemBasedRateLimiter())
-	informerFactory := informers.NewSharedInformerFactory(k8sClient, 0)
-	namespacesInformer := informerFactory.Core().V1().Namespaces()
-	podInformer := informerFactory.Core().V1().Pods()
-	servicesInformer := informerFactory.Core().V1().Services()
-	ingressInformer := informerFactory.Networking().V1().Ingresses()
-	replicaSetsInformer := informerFactory.Apps().V1().ReplicaSets()
-	statefulSetsInformer := informerFactory.Apps().V1().StatefulSets()
-	daemonSetsInformer := informerFactory.Apps().V1().DaemonSets()
-	deploymentsInformer := informerFactory.Apps().V1().Deployments()
-	policiesInformer := informerFactory.Networking().V1().NetworkPolicies()
+type Config struct {
+	K8sConfigPath      string
+	K8sConfigDir       string
+	K8sContexts        []string
+	Namespaces         []string
+	ExcludedNamespaces []string
+	LabelSelector      string
+	EventsRetention    time.Duration
+	AnalysisDebounce   time.Duration
+	AnalysisMaxLatency time.Duration
+}
+
+func Listen(config Config, clusterStateChannel chan<- types.ClusterState) {
+	clusters, err := Clusters(config)
+	if err != nil {
+		log.Fatalf("Unable to determine the clusters to watch: %s\n", err)
+	}
+	if _, err = informerScopes(config); err != nil {
+		log.Fatalf("Unable to determine the objects to watch: %s\n", err)
+	}
+	waitGroup := sync.WaitGroup{}
+	for _, cluster := range clusters {
+		waitGroup.Add(1)
+		go func(cluster Cluster) {
+			defer waitGroup.Done()
+			listenToCluster(cluster, config, clusterStateChannel)
+		}(cluster)
+	}
+	waitGroup.Wait()
+}
+
+type lister[T any] interface {
+	List(selector labels.Selector) ([]*T, error)
+}
+
+type clusterInformers struct {
+	factories                    []informers.SharedInformerFactory
+	dynamicFactories             []dynamicinformer.DynamicSharedInformerFactory
+	namespaces                   []lister[corev1.Namespace]
+	pods                         []lister[corev1.Pod]
+	services                     []lister[corev1.Service]
+	ingresses                    []lister[networkingv1.Ingress]
+	replicaSets                  []lister[appsv1.ReplicaSet]
+	statefulSets                 []lister[appsv1.StatefulSet]
+	daemonSets                   []lister[appsv1.DaemonSet]
+	deployments                  []lister[appsv1.Deployment]
+	policies                     []lister[networkingv1.NetworkPolicy]
+	events                       []cache.Store
+	ciliumInformers              []informers.GenericInformer
+	calicoPolicyInformers        []informers.GenericInformer
+	calicoTierInformers          []informers.GenericInformer
+	adminPolicyInformers         []informers.GenericInformer
+	authorizationPolicyInformers []informers.GenericInformer
+	peerAuthenticationInformers  []informers.GenericInformer
+	serviceEntryInformers        []informers.GenericInformer
+	serviceExportInformers       []informers.GenericInformer
+	serviceImportInformers       []informers.GenericInformer
+}
+
+func listenToCluster(cluster Cluster, config Config, clusterStateChannel chan<- types.ClusterState) {
+	log.Printf("Listening to cluster %s...\n", cluster.Name)
+	scopes, _ := informerScopes(config)
+	k8sConfig, err := getK8sConfig(cluster)
+	if err != nil {
+		log.Printf("Unable to connect to cluster %s, it will not be watched: %s\n", cluster.Name, err)
+		return
+	}
+	k8sClient := kubernetes.NewForConfigOrDie(k8sConfig)
+	dynamicClient := dynamic.NewForConfigOrDie(k8sConfig)
+	trigger := newAnalysisTrigger(config.AnalysisDebounce, config.AnalysisMaxLatency)
 	eventHandler := cache.ResourceEventHandlerFuncs{
-		AddFunc:    func(obj interface{}) { analyzeQueue.Add(nil) },
-		UpdateFunc: func(oldObj, newObj interface{}) { analyzeQueue.Add(nil) },
-		DeleteFunc: func(obj interface{}) { analyzeQueue.Add(nil) },
-	}
-	namespacesInformer.Informer().AddEventHandler(eventHandler)
-	podInformer.Informer().AddEventHandler(eventHandler)
-	servicesInformer.Informer().AddEventHandler(eventHandler)
-	ingressInformer.Informer().AddEventHandler(eventHandler)
-	replicaSetsInformer.Informer().AddEventHandler(eventHandler)
-	statefulSetsInformer.Informer().AddEventHandler(eventHandler)
-	daemonSetsInformer.Informer().AddEventHandler(eventHandler)
-	deploymentsInformer.Informer().AddEventHandler(eventHandler)
-	policiesInformer.Informer().AddEventHandler(eventHandler)
-	informerFactory.Start(wait.NeverStop)
-	informerFactory.WaitForCacheSync(wait.NeverStop)
+		AddFunc:    func(obj interface{}) { trigger.notify() },
+		UpdateFunc: func(oldObj, newObj interface{}) { trigger.notify() },
+		DeleteFunc: func(obj interface{}) { trigger.notify() },
+	}
+	permissions := newPermissions(k8sClient)
+	clusterInformers := &clusterInformers{}
+	for _, scope := range scopes {
+		clusterInformers.addScope(k8sClient, dynamicClient, permissions, scope, eventHandler)
+	}
+	if !scopes[0].isClusterWide() {
+		clusterInformers.addClusterScopedPolicies(k8sClient, dynamicClient, permissions, eventHandler)
+	}
+	for _, factory := range clusterInformers.factories {
+		factory.Start(wait.NeverStop)
+	}
+	for _, factory := range clusterInformers.dynamicFactories {
+		factory.Start(wait.NeverStop)
+	}
+	for _, factory := range clusterInformers.factories {
+		factory.WaitForCacheSync(wait.NeverStop)
+	}
+	for _, factory := range clusterInformers.dynamicFactories {
+		factory.WaitForCacheSync(wait.NeverStop)
+	}
+	eventExpiry := newEventExpiry(config.EventsRetention, trigger.notify)
 	for {
-		obj, _ := analyzeQueue.Get()
-		namespaces, err := namespacesInformer.Lister().List(labels.Everything())
-		if err != nil {
-			panic(err.Error())
-		}
-		pods, err := podInformer.Lister().List(labels.Everything())
-		if err != nil {
-			panic(err.Error())
-		}
-		services, err := servicesInformer.Lister().List(labels.Everything())
-		if err != nil {
-			panic(err.Error())
-		}
-		ingresses, err := ingressInformer.Lister().List(labels.Everything())
-		if err != nil {
-			panic(err.Error())
-		}
-		replicaSets, err := replicaSetsInformer.Lister().List(labels.Everything())
-		if err != nil {
-			panic(err.Error())
+		trigger.wait()
+		clusterStateChannel <- types.ClusterState{
+			Cluster:               cluster.Name,
+			Namespaces:            listAll(clusterInformers.namespaces),
+			Pods:                  listAll(clusterInformers.pods),
+			Services:              listAll(clusterInformers.services),
+			Ingresses:             listAll(clusterInformers.ingresses),
+			ReplicaSets:           listAll(clusterInformers.replicaSets),
+			StatefulSets:          listAll(clusterInformers.statefulSets),
+			DaemonSets:            listAll(clusterInformers.daemonSets),
+			Deployments:           listAll(clusterInformers.deployments),
+			NetworkPolicies:       listAll(clusterInformers.policies),
+			Events:                eventExpiry.recentEvents(clusterInformers.events, time.Now()),
+			CiliumNetworkPolicies: listCustomResources[cilium.NetworkPolicy](clusterInformers.ciliumInformers),
+			CalicoNetworkPolicies: listCustomResources[calico.NetworkPolicy](clusterInformers.calicoPolicyInformers),
+			CalicoTiers:           listCustomResources[calico.Tier](clusterInformers.calicoTierInformers),
+			AdminNetworkPolicies: listCustomResources[policyapi.AdminNetworkPolicy](
+				clusterInformers.adminPolicyInformers),
+			AuthorizationPolicies: listCustomResources[istio.AuthorizationPolicy](
+				clusterInformers.authorizationPolicyInformers),
+			PeerAuthentications: listCustomResources[istio.PeerAuthentication](
+				clusterInformers.peerAuthenticationInformers),
+			ServiceEntries:     listCustomResources[istio.ServiceEntry](clusterInformers.serviceEntryInformers),
+			ServiceExports:     listCustomResources[mcs.ServiceExport](clusterInformers.serviceExportInformers),
+			ServiceImports:     listCustomResources[mcs.ServiceImport](clusterInformers.serviceImportInformers),
+			ForbiddenResources: permissions.forbidden,
 		}
-		statefulSets, err := statefulSetsInformer.Lister().List(labels.Everything())
-		if err != nil {
-			panic(err.Error())
+	}
+}
+
+func (clusterInformers *clusterInformers) addScope(
+	k8sClient kubernetes.Interface,
+	dynamicClient dynamic.Interface,
+	permissions *permissions,
+	scope informerScope,
+	eventHandler cache.ResourceEventHandler,
+) {
+	namespaceFactory := informers.NewSharedInformerFactoryWithOptions(k8sClient, 0,
+		scope.informerOptions(scope.namespaceOptions)...)
+	workloadFactory := informers.NewSharedInformerFactoryWithOptions(k8sClient, 0,
+		scope.informerOptions(scope.workloadOptions)...)
+	policyFactory := informers.NewSharedInformerFactoryWithOptions(k8sClient, 0,
+		scope.informerOptions(scope.policyOptions)...)
+	eventFactory := informers.NewSharedInformerFactoryWithOptions(k8sClient, 0,
+		scope.informerOptions(scope.eventOptions)...)
+	clusterInformers.factories = append(clusterInformers.factories, namespaceFactory, workloadFactory, policyFactory,
+		eventFactory)
+	allows := func(group string, resource string) bool {
+		return permissions.allows(schema.GroupResource{Group: group, Resource: resource}, scope.namespace)
+	}
+	if allows(corev1.GroupName, "namespaces") {
+		registerStrippedInformer(namespaceFactory, &corev1.Namespace{}, scope.namespaceOptions,
+			func(k8sClient kubernetes.Interface) listWatcher[*corev1.NamespaceList] {
+				return k8sClient.CoreV1().Namespaces()
+			})
+		informer := namespaceFactory.Core().V1().Namespaces()
+		informer.Informer().AddEventHandler(eventHandler)
+		clusterInformers.namespaces = append(clusterInformers.namespaces, informer.Lister())
+	} else if !scope.isClusterWide() {
+		clusterInformers.namespaces = append(clusterInformers.namespaces, staticLister[corev1.Namespace]{
+			objects: []*corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: scope.namespace}}},
+		})
+	}
+	if allows(corev1.GroupName, "pods") {
+		registerStrippedInformer(workloadFactory, &corev1.Pod{}, scope.workloadOptions,
+			func(k8sClient kubernetes.Interface) listWatcher[*corev1.PodList] {
+				return k8sClient.CoreV1().Pods(scope.namespace)
+			})
+		informer := workloadFactory.Core().V1().Pods()
+		informer.Informer().AddEventHandler(eventHandler)
+		clusterInformers.pods = append(clusterInformers.pods, informer.Lister())
+	}
+	if allows(corev1.GroupName, "services") {
+		registerStrippedInformer(workloadFactory, &corev1.Service{}, scope.workloadOptions,
+			func(k8sClient kubernetes.Interface) listWatcher[*corev1.ServiceList] {
+				return k8sClient.CoreV1().Services(scope.namespace)
+			})
+		informer := workloadFactory.Core().V1().Services()
+		informer.Informer().AddEventHandler(eventHandler)
+		clusterInformers.services = append(clusterInformers.services, informer.Lister())
+	}
+	if allows(networkingv1.GroupName, "ingresses") {
+		registerStrippedInformer(workloadFactory, &networkingv1.Ingress{}, scope.workloadOptions,
+			func(k8sClient kubernetes.Interface) listWatcher[*networkingv1.IngressList] {
+				return k8sClient.NetworkingV1().Ingresses(scope.namespace)
+			})
+		informer := workloadFactory.Networking().V1().Ingresses()
+		informer.Informer().AddEventHandler(eventHandler)
+		clusterInformers.ingresses = append(clusterInformers.ingresses, informer.Lister())
+	}
+	if allows(appsv1.GroupName, "replicasets") {
+		registerStrippedInformer(workloadFactory, &appsv1.ReplicaSet{}, scope.workloadOptions,
+			func(k8sClient kubernetes.Interface) listWatcher[*appsv1.ReplicaSetList] {
+				return k8sClient.AppsV1().ReplicaSets(scope.namespace)
+			})
+		informer := workloadFactory.Apps().V1().ReplicaSets()
+		informer.Informer().AddEventHandler(eventHandler)
+		clusterInformers.replicaSets = append(clusterInformers.replicaSets, informer.Lister())
+	}
+	if allows(appsv1.GroupName, "statefulsets") {
+		registerStrippedInformer(workloadFactory, &appsv1.StatefulSet{}, scope.workloadOptions,
+			func(k8sClient kubernetes.Interface) listWatcher[*appsv1.StatefulSetList] {
+				return k8sClient.AppsV1().StatefulSets(scope.namespace)
+			})
+		informer := workloadFactory.Apps().V1().StatefulSets()
+		informer.Informer().AddEventHandler(eventHandler)
+		clusterInformers.statefulSets = append(clusterInformers.statefulSets, informer.Lister())
+	}
+	if allows(appsv1.GroupName, "daemonsets") {
+		registerStrippedInformer(workloadFactory, &appsv1.DaemonSet{}, scope.workloadOptions,
+			func(k8sClient kubernetes.Interface) listWatcher[*appsv1.DaemonSetList] {
+				return k8sClient.AppsV1().DaemonSets(scope.namespace)
+			})
+		informer := workloadFactory.Apps().V1().DaemonSets()
+		informer.Informer().AddEventHandler(eventHandler)
+		clusterInformers.daemonSets = append(clusterInformers.daemonSets, informer.Lister())
+	}
+	if allows(appsv1.GroupName, "deployments") {
+		registerStrippedInformer(workloadFactory, &appsv1.Deployment{}, scope.workloadOptions,
+			func(k8sClient kubernetes.Interface) listWatcher[*appsv1.DeploymentList] {
+				return k8sClient.AppsV1().Deployments(scope.namespace)
+			})
+		informer := workloadFactory.Apps().V1().Deployments()
+		informer.Informer().AddEventHandler(eventHandler)
+		clusterInformers.deployments = append(clusterInformers.deployments, informer.Lister())
+	}
+	if allows(networkingv1.GroupName, "networkpolicies") {
+		registerStrippedInformer(policyFactory, &networkingv1.NetworkPolicy{}, scope.policyOptions,
+			func(k8sClient kubernetes.Interface) listWatcher[*networkingv1.NetworkPolicyList] {
+				return k8sClient.NetworkingV1().NetworkPolicies(scope.namespace)
+			})
+		informer := policyFactory.Networking().V1().NetworkPolicies()
+		informer.Informer().AddEventHandler(eventHandler)
+		clusterInformers.policies = append(clusterInformers.policies, informer.Lister())
+	}
+	if allows(corev1.GroupName, "events") {
+		registerStrippedInformer(eventFactory, &corev1.Event{}, scope.eventOptions,
+			func(k8sClient kubernetes.Interface) listWatcher[*corev1.EventList] {
+				return k8sClient.CoreV1().Events(scope.namespace)
+			})
+		informer := eventFactory.Core().V1().Events()
+		informer.Informer().AddEventHandler(eventHandler)
+		clusterInformers.events = append(clusterInformers.events, informer.Informer().GetStore())
+	}
+	dynamicFactory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(dynamicClient, 0, scope.namespace,
+		tweakListOptions(scope.policyOptions))
+	clusterInformers.dynamicFactories = append(clusterInformers.dynamicFactories, dynamicFactory)
+	customInformers := func(resources []schema.GroupVersionResource) []informers.GenericInformer {
+		if !scope.isClusterWide() {
+			resources = namespacedResources(k8sClient, resources)
 		}
-		daemonSets, err := daemonSetsInformer.Lister().List(labels.Everything())
-		if err != nil {
-			panic(err.Error())
+		result := informersFor(dynamicFactory, permissions.allowedResources(resources, scope.namespace))
+		for _, customInformer := range result {
+			customInformer.Informer().AddEventHandler(eventHandler)
 		}
-		deployments, err := deploymentsInformer.Lister().List(labels.Everything())
-		if err != nil {
-			panic(err.Error())
+		return result
+	}
+	clusterInformers.ciliumInformers = append(clusterInformers.ciliumInformers,
+		customInformers(ciliumResources(k8sClient))...)
+	clusterInformers.calicoPolicyInformers = append(clusterInformers.calicoPolicyInformers,
+		customInformers(calicoPolicyResources(k8sClient))...)
+	clusterInformers.calicoTierInformers = append(clusterInformers.calicoTierInformers,
+		customInformers(calicoTierResources(k8sClient))...)
+	clusterInformers.adminPolicyInformers = append(clusterInformers.adminPolicyInformers,
+		customInformers(adminPolicyResources(k8sClient))...)
+	clusterInformers.authorizationPolicyInformers = append(clusterInformers.authorizationPolicyInformers,
+		customInformers(authorizationPolicyResources(k8sClient))...)
+	clusterInformers.peerAuthenticationInformers = append(clusterInformers.peerAuthenticationInformers,
+		customInformers(peerAuthenticationResources(k8sClient))...)
+	clusterInformers.serviceEntryInformers = append(clusterInformers.serviceEntryInformers,
+		customInformers(serviceEntryResources(k8sClient))...)
+	clusterInformers.serviceExportInformers = append(clusterInformers.serviceExportInformers,
+		customInformers(serviceExportResources(k8sClient))...)
+	clusterInformers.serviceImportInformers = append(clusterInformers.serviceImportInformers,
+		customInformers(serviceImportResources(k8sClient))...)
+}
+
+func (clusterInformers *clusterInformers) addClusterScopedPolicies(
+	k8sClient kubernetes.Interface,
+	dynamicClient dynamic.Interface,
+	permissions *permissions,
+	eventHandler cache.ResourceEventHandler,
+) {
+	dynamicFactory := dynamicinformer.NewDynamicSharedInformerFactory(dynamicClient, 0)
+	clusterInformers.dynamicFactories = append(clusterInformers.dynamicFactories, dynamicFactory)
+	customInformers := func(resources []schema.GroupVersionResource) []informers.GenericInformer {
+		resources = clusterScopedResources(k8sClient, resources)
+		allowedResources := permissions.allowedResources(resources, metav1.NamespaceAll)
+		for _, resource := range resources {
+			if !containsResource(allowedResources, resource) {
+				log.Printf("Cluster-scoped %s are not watched, the policies they define are ignored\n",
+					resource.GroupResource())
+			}
 		}
-		policies, err := policiesInformer.Lister().List(labels.Everything())
-		if err != nil {
-			panic(err.Error())
+		result := informersFor(dynamicFactory, allowedResources)
+		for _, customInformer := range result {
+			customInformer.Informer().AddEventHandler(eventHandler)
 		}
-		clusterStateChannel <- types.ClusterState{
-			Namespaces:      namespaces,
-			Pods:            pods,
-			Services:        services,
-			Ingresses:       ingresses,
-			ReplicaSets:     replicaSets,
-			StatefulSets:    statefulSets,
-			DaemonSets:      daemonSets,
-			Deployments:     deployments,
-			NetworkPolicies: policies,
+		return result
+	}
+	clusterInformers.ciliumInformers = append(clusterInformers.ciliumInformers,
+		customInformers(ciliumResources(k8sClient))...)
+	clusterInformers.calicoPolicyInformers = append(clusterInformers.calicoPolicyInformers,
+		customInformers(calicoPolicyResources(k8sClient))...)
+	clusterInformers.calicoTierInformers = append(clusterInformers.calicoTierInformers,
+		customInformers(calicoTierResources(k8sClient))...)
+	clusterInformers.adminPolicyInformers = append(clusterInformers.adminPolicyInformers,
+		customInformers(adminPolicyResources(k8sClient))...)
+}
+
+func containsResource(resources []schema.GroupVersionResource, resource schema.GroupVersionResource) bool {
+	for _, candidate := range resources {
+		if candidate == resource {
+			return true
 		}
-		analyzeQueue.Forget(obj)
-		analyzeQueue.Done(obj)
 	}
+	return false
+}
+
+type staticLister[T any] struct {
+	objects []*T
 }
 
-func getK8sClient(k8sClientConfig string) *kubernetes.Clientset {
-	var config *rest.Config
-	var err1InsideCluster, errOutsideCluster error
-	config, err1InsideCluster = rest.InClusterConfig()
-	if err1InsideCluster != nil {
-		log.Println("Unable to connect to Kubernetes service, fallback to kubeconfig file")
-		config, errOutsideCluster = clientcmd.BuildConfigFromFlags("", k8sClientConfig)
-		if errOutsideCluster != nil {
-			panic(errOutsideCluster.Error())
+func (lister staticLister[T]) List(_ labels.Selector) ([]*T, error) {
+	return lister.objects, nil
+}
+
+func listAll[T any](listers []lister[T]) []*T {
+	result := make([]*T, 0)
+	for _, lister := range listers {
+		objects, err := lister.List(labels.Everything())
+		if err != nil {
+			log.Printf("Unable to list cached objects, they are ignored until the next analysis: %s\n", err)
+			continue
 		}
+		result = append(result, objects...)
 	}
-	k8sClient := kubernetes.NewForConfigOrDie(config)
-	return k8sClient
+	return result
 }
@@ -0,0 +1,68 @@
+package clusterlistener
+
+import (
+	"context"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"karto/types"
+	"log"
+)
+
+var watchVerbs = []string{"list", "watch"}
+
+type permissions struct {
+	k8sClient kubernetes.Interface
+	forbidden []types.ForbiddenResource
+}
+
+func newPermissions(k8sClient kubernetes.Interface) *permissions {
+	return &permissions{k8sClient: k8sClient}
+}
+
+func (permissions *permissions) allows(resource schema.GroupResource, namespace string) bool {
+	for _, verb := range watchVerbs {
+		review := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Namespace: namespace,
+					Verb:      verb,
+					Group:     resource.Group,
+					Resource:  resource.Resource,
+				},
+			},
+		}
+		result, err := permissions.k8sClient.AuthorizationV1().SelfSubjectAccessReviews().Create(context.Background(),
+			review, metav1.CreateOptions{})
+		if err != nil {
+			log.Printf("Unable to check the permission to %s %s, assuming it is granted: %s\n", verb, resource, err)
+			continue
+		}
+		if !result.Status.Allowed {
+			forbidden := types.ForbiddenResource{Resource: resource.String(), Namespace: namespace}
+			if namespace == metav1.NamespaceAll {
+				log.Printf("Not allowed to %s %s, the analyses relying on them will be partial\n", verb, resource)
+			} else {
+				log.Printf("Not allowed to %s %s in namespace %s, the analyses relying on them will be partial\n",
+					verb, resource, namespace)
+			}
+			permissions.forbidden = append(permissions.forbidden, forbidden)
+			return false
+		}
+	}
+	return true
+}
+
+func (permissions *permissions) allowedResources(
+	resources []schema.GroupVersionResource,
+	namespace string,
+) []schema.GroupVersionResource {
+	result := make([]schema.GroupVersionResource, 0, len(resources))
+	for _, resource := range resources {
+		if permissions.allows(resource.GroupResource(), namespace) {
+			result = append(result, resource)
+		}
+	}
+	return result
+}
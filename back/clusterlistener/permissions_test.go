@@ -0,0 +1,84 @@
+package clusterlistener
+
+import (
+	"github.com/google/go-cmp/cmp"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"karto/types"
+	"testing"
+)
+
+func TestPermissionsAllows(t *testing.T) {
+	type args struct {
+		resource  schema.GroupResource
+		namespace string
+	}
+	granted := map[string]bool{
+		"list pods":                              true,
+		"watch pods":                             true,
+		"list networkpolicies.networking.k8s.io": true,
+		"list ingresses.networking.k8s.io shop":  true,
+		"watch ingresses.networking.k8s.io shop": true,
+	}
+	tests := []struct {
+		name              string
+		args              args
+		expectedAllowed   bool
+		expectedForbidden []types.ForbiddenResource
+	}{
+		{
+			name: "allows resources that can be listed and watched",
+			args: args{
+				resource: schema.GroupResource{Resource: "pods"},
+			},
+			expectedAllowed: true,
+		},
+		{
+			name: "forbids resources that cannot be watched",
+			args: args{
+				resource: schema.GroupResource{Group: "networking.k8s.io", Resource: "networkpolicies"},
+			},
+			expectedAllowed:   false,
+			expectedForbidden: []types.ForbiddenResource{{Resource: "networkpolicies.networking.k8s.io"}},
+		},
+		{
+			name: "checks permissions in the namespace",
+			args: args{
+				resource:  schema.GroupResource{Group: "networking.k8s.io", Resource: "ingresses"},
+				namespace: "billing",
+			},
+			expectedAllowed: false,
+			expectedForbidden: []types.ForbiddenResource{
+				{Resource: "ingresses.networking.k8s.io", Namespace: "billing"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k8sClient := fake.NewSimpleClientset()
+			k8sClient.PrependReactor("create", "selfsubjectaccessreviews",
+				func(action k8stesting.Action) (bool, runtime.Object, error) {
+					review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+					attributes := review.Spec.ResourceAttributes
+					key := attributes.Verb + " " + schema.GroupResource{Group: attributes.Group,
+						Resource: attributes.Resource}.String()
+					if attributes.Namespace != "" {
+						key += " " + attributes.Namespace
+					}
+					review.Status.Allowed = granted[key]
+					return true, review, nil
+				})
+			permissions := newPermissions(k8sClient)
+			allowed := permissions.allows(tt.args.resource, tt.args.namespace)
+			if diff := cmp.Diff(tt.expectedAllowed, allowed); diff != "" {
+				t.Errorf("allows() result mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.expectedForbidden, permissions.forbidden); diff != "" {
+				t.Errorf("allows() forbidden resources mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
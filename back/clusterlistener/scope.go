@@ -0,0 +1,84 @@
+package clusterlistener
+
+import (
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+)
+
+type informerScope struct {
+	namespace        string
+	namespaceOptions metav1.ListOptions
+	workloadOptions  metav1.ListOptions
+	policyOptions    metav1.ListOptions
+	eventOptions     metav1.ListOptions
+}
+
+func informerScopes(config Config) ([]informerScope, error) {
+	if _, err := labels.Parse(config.LabelSelector); err != nil {
+		return nil, fmt.Errorf("invalid label selector %q: %w", config.LabelSelector, err)
+	}
+	warningEvents := fields.OneTermEqualSelector("type", corev1.EventTypeWarning)
+	if len(config.Namespaces) == 0 {
+		excludedNamespaces := make([]fields.Selector, 0, len(config.ExcludedNamespaces))
+		excludedNames := make([]fields.Selector, 0, len(config.ExcludedNamespaces))
+		for _, namespace := range config.ExcludedNamespaces {
+			excludedNamespaces = append(excludedNamespaces, fields.OneTermNotEqualSelector("metadata.namespace",
+				namespace))
+			excludedNames = append(excludedNames, fields.OneTermNotEqualSelector("metadata.name", namespace))
+		}
+		namespaceSelector := fields.AndSelectors(excludedNamespaces...).String()
+		return []informerScope{{
+			namespace:        metav1.NamespaceAll,
+			namespaceOptions: metav1.ListOptions{FieldSelector: fields.AndSelectors(excludedNames...).String()},
+			workloadOptions:  metav1.ListOptions{FieldSelector: namespaceSelector, LabelSelector: config.LabelSelector},
+			policyOptions:    metav1.ListOptions{FieldSelector: namespaceSelector},
+			eventOptions: metav1.ListOptions{
+				FieldSelector: fields.AndSelectors(append([]fields.Selector{warningEvents},
+					excludedNamespaces...)...).String(),
+			},
+		}}, nil
+	}
+	excluded := make(map[string]bool)
+	for _, namespace := range config.ExcludedNamespaces {
+		excluded[namespace] = true
+	}
+	result := make([]informerScope, 0, len(config.Namespaces))
+	for _, namespace := range config.Namespaces {
+		if excluded[namespace] {
+			continue
+		}
+		excluded[namespace] = true
+		result = append(result, informerScope{
+			namespace:        namespace,
+			namespaceOptions: metav1.ListOptions{FieldSelector: fields.OneTermEqualSelector("metadata.name", namespace).String()},
+			workloadOptions:  metav1.ListOptions{LabelSelector: config.LabelSelector},
+			eventOptions:     metav1.ListOptions{FieldSelector: warningEvents.String()},
+		})
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("all namespaces to watch are excluded")
+	}
+	return result, nil
+}
+
+func (scope informerScope) isClusterWide() bool {
+	return scope.namespace == metav1.NamespaceAll
+}
+
+func (scope informerScope) informerOptions(options metav1.ListOptions) []informers.SharedInformerOption {
+	return []informers.SharedInformerOption{
+		informers.WithNamespace(scope.namespace),
+		informers.WithTweakListOptions(tweakListOptions(options)),
+	}
+}
+
+func tweakListOptions(options metav1.ListOptions) func(*metav1.ListOptions) {
+	return func(listOptions *metav1.ListOptions) {
+		listOptions.FieldSelector = options.FieldSelector
+		listOptions.LabelSelector = options.LabelSelector
+	}
+}
@@ -0,0 +1,100 @@
+package clusterlistener
+
+import (
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+func TestInformerScopes(t *testing.T) {
+	type args struct {
+		config Config
+	}
+	tests := []struct {
+		name           string
+		args           args
+		expectedScopes []informerScope
+		expectedError  bool
+	}{
+		{
+			name: "watches the whole cluster by default",
+			args: args{
+				config: Config{},
+			},
+			expectedScopes: []informerScope{
+				{
+					eventOptions: metav1.ListOptions{FieldSelector: "type=Warning"},
+				},
+			},
+		},
+		{
+			name: "excludes namespaces and restricts workloads to the label selector",
+			args: args{
+				config: Config{ExcludedNamespaces: []string{"kube-system", "monitoring"}, LabelSelector: "team=shop"},
+			},
+			expectedScopes: []informerScope{
+				{
+					namespaceOptions: metav1.ListOptions{
+						FieldSelector: "metadata.name!=kube-system,metadata.name!=monitoring",
+					},
+					workloadOptions: metav1.ListOptions{
+						FieldSelector: "metadata.namespace!=kube-system,metadata.namespace!=monitoring",
+						LabelSelector: "team=shop",
+					},
+					policyOptions: metav1.ListOptions{
+						FieldSelector: "metadata.namespace!=kube-system,metadata.namespace!=monitoring",
+					},
+					eventOptions: metav1.ListOptions{
+						FieldSelector: "type=Warning,metadata.namespace!=kube-system,metadata.namespace!=monitoring",
+					},
+				},
+			},
+		},
+		{
+			name: "watches every namespace not excluded on its own",
+			args: args{
+				config: Config{Namespaces: []string{"shop", "billing", "shop", "monitoring"},
+					ExcludedNamespaces: []string{"monitoring"}, LabelSelector: "team=shop"},
+			},
+			expectedScopes: []informerScope{
+				{
+					namespace:        "shop",
+					namespaceOptions: metav1.ListOptions{FieldSelector: "metadata.name=shop"},
+					workloadOptions:  metav1.ListOptions{LabelSelector: "team=shop"},
+					eventOptions:     metav1.ListOptions{FieldSelector: "type=Warning"},
+				},
+				{
+					namespace:        "billing",
+					namespaceOptions: metav1.ListOptions{FieldSelector: "metadata.name=billing"},
+					workloadOptions:  metav1.ListOptions{LabelSelector: "team=shop"},
+					eventOptions:     metav1.ListOptions{FieldSelector: "type=Warning"},
+				},
+			},
+		},
+		{
+			name: "rejects namespaces all excluded",
+			args: args{
+				config: Config{Namespaces: []string{"shop"}, ExcludedNamespaces: []string{"shop"}},
+			},
+			expectedError: true,
+		},
+		{
+			name: "rejects an invalid label selector",
+			args: args{
+				config: Config{LabelSelector: "team in shop"},
+			},
+			expectedError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scopes, err := informerScopes(tt.args.config)
+			if diff := cmp.Diff(tt.expectedError, err != nil); diff != "" {
+				t.Errorf("informerScopes() error mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.expectedScopes, scopes, cmp.AllowUnexported(informerScope{})); diff != "" {
+				t.Errorf("informerScopes() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
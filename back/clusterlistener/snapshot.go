@@ -0,0 +1,268 @@
+package clusterlistener
+
+import (
+	"context"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"karto/crds/calico"
+	"karto/crds/cilium"
+	"karto/crds/istio"
+	"karto/crds/mcs"
+	"karto/crds/policyapi"
+	"karto/types"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+func DefaultK8sConfigPath() string {
+	home := os.Getenv("HOME")
+	if home == "" {
+		home = os.Getenv("USERPROFILE")
+	}
+	if home == "" {
+		return ""
+	}
+	return filepath.Join(home, ".kube", "config")
+}
+
+func Snapshot(config Config) (types.ClusterState, error) {
+	scopes, err := informerScopes(config)
+	if err != nil {
+		return types.ClusterState{}, err
+	}
+	k8sConfig, err := getK8sConfig(defaultCluster(config))
+	if err != nil {
+		return types.ClusterState{}, err
+	}
+	k8sClient, err := kubernetes.NewForConfig(k8sConfig)
+	if err != nil {
+		return types.ClusterState{}, err
+	}
+	dynamicClient, err := dynamic.NewForConfig(k8sConfig)
+	if err != nil {
+		return types.ClusterState{}, err
+	}
+	permissions := newPermissions(k8sClient)
+	clusterState := types.ClusterState{}
+	for _, scope := range scopes {
+		if err = snapshotScope(&clusterState, k8sClient, dynamicClient, permissions, scope); err != nil {
+			return types.ClusterState{}, err
+		}
+	}
+	if !scopes[0].isClusterWide() {
+		err = snapshotPolicyResources(&clusterState, k8sClient, dynamicClient, metav1.NamespaceAll,
+			metav1.ListOptions{}, func(resources []schema.GroupVersionResource) []schema.GroupVersionResource {
+				return permissions.allowedResources(clusterScopedResources(k8sClient, resources), metav1.NamespaceAll)
+			})
+		if err != nil {
+			return types.ClusterState{}, err
+		}
+	}
+	clusterState.Events, _ = recentEvents(clusterState.Events, config.EventsRetention, time.Now())
+	clusterState.ForbiddenResources = permissions.forbidden
+	return clusterState, nil
+}
+
+func snapshotScope(
+	clusterState *types.ClusterState,
+	k8sClient kubernetes.Interface,
+	dynamicClient dynamic.Interface,
+	permissions *permissions,
+	scope informerScope,
+) error {
+	ctx := context.Background()
+	allows := func(group string, resource string) bool {
+		return permissions.allows(schema.GroupResource{Group: group, Resource: resource}, scope.namespace)
+	}
+	namespaces, err := listObjects[*corev1.NamespaceList, corev1.Namespace](ctx,
+		allows(corev1.GroupName, "namespaces"), k8sClient.CoreV1().Namespaces(), scope.namespaceOptions)
+	if err != nil {
+		return err
+	}
+	if namespaces == nil && !scope.isClusterWide() {
+		namespaces = []*corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: scope.namespace}}}
+	}
+	clusterState.Namespaces = append(clusterState.Namespaces, namespaces...)
+	pods, err := listObjects[*corev1.PodList, corev1.Pod](ctx, allows(corev1.GroupName, "pods"),
+		k8sClient.CoreV1().Pods(scope.namespace), scope.workloadOptions)
+	if err != nil {
+		return err
+	}
+	clusterState.Pods = append(clusterState.Pods, pods...)
+	services, err := listObjects[*corev1.ServiceList, corev1.Service](ctx, allows(corev1.GroupName, "services"),
+		k8sClient.CoreV1().Services(scope.namespace), scope.workloadOptions)
+	if err != nil {
+		return err
+	}
+	clusterState.Services = append(clusterState.Services, services...)
+	ingresses, err := listObjects[*networkingv1.IngressList, networkingv1.Ingress](ctx,
+		allows(networkingv1.GroupName, "ingresses"), k8sClient.NetworkingV1().Ingresses(scope.namespace),
+		scope.workloadOptions)
+	if err != nil {
+		return err
+	}
+	clusterState.Ingresses = append(clusterState.Ingresses, ingresses...)
+	replicaSets, err := listObjects[*appsv1.ReplicaSetList, appsv1.ReplicaSet](ctx,
+		allows(appsv1.GroupName, "replicasets"), k8sClient.AppsV1().ReplicaSets(scope.namespace),
+		scope.workloadOptions)
+	if err != nil {
+		return err
+	}
+	clusterState.ReplicaSets = append(clusterState.ReplicaSets, replicaSets...)
+	statefulSets, err := listObjects[*appsv1.StatefulSetList, appsv1.StatefulSet](ctx,
+		allows(appsv1.GroupName, "statefulsets"), k8sClient.AppsV1().StatefulSets(scope.namespace),
+		scope.workloadOptions)
+	if err != nil {
+		return err
+	}
+	clusterState.StatefulSets = append(clusterState.StatefulSets, statefulSets...)
+	daemonSets, err := listObjects[*appsv1.DaemonSetList, appsv1.DaemonSet](ctx,
+		allows(appsv1.GroupName, "daemonsets"), k8sClient.AppsV1().DaemonSets(scope.namespace),
+		scope.workloadOptions)
+	if err != nil {
+		return err
+	}
+	clusterState.DaemonSets = append(clusterState.DaemonSets, daemonSets...)
+	deployments, err := listObjects[*appsv1.DeploymentList, appsv1.Deployment](ctx,
+		allows(appsv1.GroupName, "deployments"), k8sClient.AppsV1().Deployments(scope.namespace),
+		scope.workloadOptions)
+	if err != nil {
+		return err
+	}
+	clusterState.Deployments = append(clusterState.Deployments, deployments...)
+	policies, err := listObjects[*networkingv1.NetworkPolicyList, networkingv1.NetworkPolicy](ctx,
+		allows(networkingv1.GroupName, "networkpolicies"), k8sClient.NetworkingV1().NetworkPolicies(scope.namespace),
+		scope.policyOptions)
+	if err != nil {
+		return err
+	}
+	clusterState.NetworkPolicies = append(clusterState.NetworkPolicies, policies...)
+	events, err := listObjects[*corev1.EventList, corev1.Event](ctx, allows(corev1.GroupName, "events"),
+		k8sClient.CoreV1().Events(scope.namespace), scope.eventOptions)
+	if err != nil {
+		return err
+	}
+	clusterState.Events = append(clusterState.Events, events...)
+	watchedResources := func(resources []schema.GroupVersionResource) []schema.GroupVersionResource {
+		if !scope.isClusterWide() {
+			resources = namespacedResources(k8sClient, resources)
+		}
+		return permissions.allowedResources(resources, scope.namespace)
+	}
+	err = snapshotPolicyResources(clusterState, k8sClient, dynamicClient, scope.namespace, scope.policyOptions,
+		watchedResources)
+	if err != nil {
+		return err
+	}
+	return snapshotMeshResources(clusterState, k8sClient, dynamicClient, scope.namespace, scope.policyOptions,
+		watchedResources)
+}
+
+func snapshotPolicyResources(
+	clusterState *types.ClusterState,
+	k8sClient kubernetes.Interface,
+	dynamicClient dynamic.Interface,
+	namespace string,
+	options metav1.ListOptions,
+	watchedResources func(resources []schema.GroupVersionResource) []schema.GroupVersionResource,
+) error {
+	ctx := context.Background()
+	ciliumPolicies, err := fetchCustomResources[cilium.NetworkPolicy](ctx, dynamicClient, namespace, options,
+		watchedResources(ciliumResources(k8sClient)))
+	if err != nil {
+		return err
+	}
+	clusterState.CiliumNetworkPolicies = append(clusterState.CiliumNetworkPolicies, ciliumPolicies...)
+	calicoPolicies, err := fetchCustomResources[calico.NetworkPolicy](ctx, dynamicClient, namespace, options,
+		watchedResources(calicoPolicyResources(k8sClient)))
+	if err != nil {
+		return err
+	}
+	clusterState.CalicoNetworkPolicies = append(clusterState.CalicoNetworkPolicies, calicoPolicies...)
+	calicoTiers, err := fetchCustomResources[calico.Tier](ctx, dynamicClient, namespace, options,
+		watchedResources(calicoTierResources(k8sClient)))
+	if err != nil {
+		return err
+	}
+	clusterState.CalicoTiers = append(clusterState.CalicoTiers, calicoTiers...)
+	adminPolicies, err := fetchCustomResources[policyapi.AdminNetworkPolicy](ctx, dynamicClient, namespace, options,
+		watchedResources(adminPolicyResources(k8sClient)))
+	if err != nil {
+		return err
+	}
+	clusterState.AdminNetworkPolicies = append(clusterState.AdminNetworkPolicies, adminPolicies...)
+	return nil
+}
+
+func snapshotMeshResources(
+	clusterState *types.ClusterState,
+	k8sClient kubernetes.Interface,
+	dynamicClient dynamic.Interface,
+	namespace string,
+	options metav1.ListOptions,
+	watchedResources func(resources []schema.GroupVersionResource) []schema.GroupVersionResource,
+) error {
+	ctx := context.Background()
+	authorizationPolicies, err := fetchCustomResources[istio.AuthorizationPolicy](ctx, dynamicClient, namespace,
+		options, watchedResources(authorizationPolicyResources(k8sClient)))
+	if err != nil {
+		return err
+	}
+	clusterState.AuthorizationPolicies = append(clusterState.AuthorizationPolicies, authorizationPolicies...)
+	peerAuthentications, err := fetchCustomResources[istio.PeerAuthentication](ctx, dynamicClient, namespace,
+		options, watchedResources(peerAuthenticationResources(k8sClient)))
+	if err != nil {
+		return err
+	}
+	clusterState.PeerAuthentications = append(clusterState.PeerAuthentications, peerAuthentications...)
+	serviceEntries, err := fetchCustomResources[istio.ServiceEntry](ctx, dynamicClient, namespace, options,
+		watchedResources(serviceEntryResources(k8sClient)))
+	if err != nil {
+		return err
+	}
+	clusterState.ServiceEntries = append(clusterState.ServiceEntries, serviceEntries...)
+	serviceExports, err := fetchCustomResources[mcs.ServiceExport](ctx, dynamicClient, namespace, options,
+		watchedResources(serviceExportResources(k8sClient)))
+	if err != nil {
+		return err
+	}
+	clusterState.ServiceExports = append(clusterState.ServiceExports, serviceExports...)
+	serviceImports, err := fetchCustomResources[mcs.ServiceImport](ctx, dynamicClient, namespace, options,
+		watchedResources(serviceImportResources(k8sClient)))
+	if err != nil {
+		return err
+	}
+	clusterState.ServiceImports = append(clusterState.ServiceImports, serviceImports...)
+	return nil
+}
+
+func listObjects[L runtime.Object, T any](
+	ctx context.Context,
+	allowed bool,
+	resource listWatcher[L],
+	options metav1.ListOptions,
+) ([]*T, error) {
+	if !allowed {
+		return nil, nil
+	}
+	list, err := resource.List(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*T, 0)
+	err = meta.EachListItem(list, func(object runtime.Object) error {
+		if typedObject, ok := any(object).(*T); ok {
+			result = append(result, typedObject)
+		}
+		return nil
+	})
+	return result, err
+}
@@ -0,0 +1,94 @@
+package clusterlistener
+
+import (
+	"github.com/google/go-cmp/cmp"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"karto/testutils"
+	"karto/types"
+	"testing"
+)
+
+func TestSnapshotScope(t *testing.T) {
+	type args struct {
+		config Config
+	}
+	objects := []runtime.Object{
+		testutils.NewNamespaceBuilder().WithName("shop").Build(),
+		testutils.NewNamespaceBuilder().WithName("billing").Build(),
+		testutils.NewPodBuilder().WithName("web").WithNamespace("shop").WithLabel("app", "web").Build(),
+		testutils.NewPodBuilder().WithName("db").WithNamespace("shop").WithLabel("app", "db").Build(),
+		testutils.NewPodBuilder().WithName("web").WithNamespace("billing").WithLabel("app", "web").Build(),
+	}
+	tests := []struct {
+		name                       string
+		args                       args
+		forbidden                  map[string]bool
+		expectedPods               []string
+		expectedNamespaces         []string
+		expectedForbiddenResources []types.ForbiddenResource
+	}{
+		{
+			name: "lists the objects of the watched namespaces matching the label selector",
+			args: args{
+				config: Config{Namespaces: []string{"shop"}, LabelSelector: "app=web"},
+			},
+			forbidden:    map[string]bool{},
+			expectedPods: []string{"shop/web"},
+		},
+		{
+			name: "skips and reports forbidden resources instead of failing",
+			args: args{
+				config: Config{Namespaces: []string{"billing"}},
+			},
+			forbidden:          map[string]bool{"namespaces": true, "pods": true},
+			expectedPods:       []string{},
+			expectedNamespaces: []string{"billing"},
+			expectedForbiddenResources: []types.ForbiddenResource{
+				{Resource: "namespaces", Namespace: "billing"},
+				{Resource: "pods", Namespace: "billing"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k8sClient := fake.NewSimpleClientset(objects...)
+			k8sClient.PrependReactor("create", "selfsubjectaccessreviews",
+				func(action k8stesting.Action) (bool, runtime.Object, error) {
+					review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+					review.Status.Allowed = !tt.forbidden[review.Spec.ResourceAttributes.Resource]
+					return true, review, nil
+				})
+			scopes, err := informerScopes(tt.args.config)
+			if err != nil {
+				t.Fatalf("informerScopes() unexpected error: %s", err)
+			}
+			permissions := newPermissions(k8sClient)
+			clusterState := types.ClusterState{}
+			if err = snapshotScope(&clusterState, k8sClient, nil, permissions, scopes[0]); err != nil {
+				t.Fatalf("snapshotScope() unexpected error: %s", err)
+			}
+			pods := make([]string, 0)
+			for _, pod := range clusterState.Pods {
+				pods = append(pods, pod.Namespace+"/"+pod.Name)
+			}
+			if diff := cmp.Diff(tt.expectedPods, pods); diff != "" {
+				t.Errorf("snapshotScope() pods mismatch (-want +got):\n%s", diff)
+			}
+			if tt.expectedNamespaces != nil {
+				namespaces := make([]string, 0)
+				for _, namespace := range clusterState.Namespaces {
+					namespaces = append(namespaces, namespace.Name)
+				}
+				if diff := cmp.Diff(tt.expectedNamespaces, namespaces); diff != "" {
+					t.Errorf("snapshotScope() namespaces mismatch (-want +got):\n%s", diff)
+				}
+			}
+			if diff := cmp.Diff(tt.expectedForbiddenResources, permissions.forbidden); diff != "" {
+				t.Errorf("snapshotScope() forbidden resources mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
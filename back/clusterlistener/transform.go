@@ -0,0 +1,146 @@
+package clusterlistener
+
+import (
+	"context"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"time"
+)
+
+const (
+	lastAppliedConfigAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+	maxAnnotationLength         = 256
+)
+
+var analyzedAnnotations = map[string]bool{
+	"sidecar.istio.io/status":  true,
+	"sidecar.istio.io/inject":  true,
+	"service.cilium.io/global": true,
+	"service.cilium.io/shared": true,
+	"io.cilium/global-service": true,
+}
+
+type listWatcher[L runtime.Object] interface {
+	List(ctx context.Context, options metav1.ListOptions) (L, error)
+	Watch(ctx context.Context, options metav1.ListOptions) (watch.Interface, error)
+}
+
+func registerStrippedInformer[L runtime.Object](
+	factory informers.SharedInformerFactory,
+	exampleObject runtime.Object,
+	options metav1.ListOptions,
+	resource func(k8sClient kubernetes.Interface) listWatcher[L],
+) {
+	factory.InformerFor(exampleObject, func(k8sClient kubernetes.Interface, resync time.Duration) cache.SharedIndexInformer {
+		return newStrippedInformer(exampleObject, resync, tweakListOptions(options), resource(k8sClient))
+	})
+}
+
+func newStrippedInformer[L runtime.Object](
+	exampleObject runtime.Object,
+	resync time.Duration,
+	tweakListOptions func(*metav1.ListOptions),
+	resource listWatcher[L],
+) cache.SharedIndexInformer {
+	listWatch := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			tweakListOptions(&options)
+			list, err := resource.List(context.Background(), options)
+			if err != nil {
+				return list, err
+			}
+			err = meta.EachListItem(list, func(object runtime.Object) error {
+				stripObject(object)
+				return nil
+			})
+			return list, err
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			tweakListOptions(&options)
+			watcher, err := resource.Watch(context.Background(), options)
+			if err != nil {
+				return nil, err
+			}
+			return watch.Filter(watcher, func(event watch.Event) (watch.Event, bool) {
+				stripObject(event.Object)
+				return event, true
+			}), nil
+		},
+	}
+	return cache.NewSharedIndexInformer(listWatch, exampleObject, resync,
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+}
+
+func stripObject(object runtime.Object) {
+	if metadata, err := meta.Accessor(object); err == nil {
+		metadata.SetManagedFields(nil)
+		metadata.SetAnnotations(strippedAnnotations(metadata.GetAnnotations()))
+	}
+	switch typedObject := object.(type) {
+	case *corev1.Pod:
+		stripPodSpec(&typedObject.Spec)
+	case *appsv1.ReplicaSet:
+		stripPodTemplate(&typedObject.Spec.Template)
+	case *appsv1.StatefulSet:
+		stripPodTemplate(&typedObject.Spec.Template)
+	case *appsv1.DaemonSet:
+		stripPodTemplate(&typedObject.Spec.Template)
+	case *appsv1.Deployment:
+		stripPodTemplate(&typedObject.Spec.Template)
+	}
+}
+
+func strippedAnnotations(annotations map[string]string) map[string]string {
+	if len(annotations) == 0 {
+		return annotations
+	}
+	delete(annotations, lastAppliedConfigAnnotation)
+	for key, value := range annotations {
+		if len(value) > maxAnnotationLength && !analyzedAnnotations[key] {
+			annotations[key] = ""
+		}
+	}
+	return annotations
+}
+
+func stripPodTemplate(template *corev1.PodTemplateSpec) {
+	template.ManagedFields = nil
+	template.Annotations = strippedAnnotations(template.Annotations)
+	stripPodSpec(&template.Spec)
+}
+
+func stripPodSpec(spec *corev1.PodSpec) {
+	spec.Affinity = nil
+	spec.Tolerations = nil
+	spec.TopologySpreadConstraints = nil
+	stripContainers(spec.InitContainers)
+	stripContainers(spec.Containers)
+	for i := range spec.EphemeralContainers {
+		stripContainer((*corev1.Container)(&spec.EphemeralContainers[i].EphemeralContainerCommon))
+	}
+}
+
+func stripContainers(containers []corev1.Container) {
+	for i := range containers {
+		stripContainer(&containers[i])
+	}
+}
+
+func stripContainer(container *corev1.Container) {
+	container.Command = nil
+	container.Args = nil
+	var env []corev1.EnvVar
+	for _, envVar := range container.Env {
+		if envVar.ValueFrom != nil {
+			env = append(env, envVar)
+		}
+	}
+	container.Env = env
+}
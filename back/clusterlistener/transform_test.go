@@ -0,0 +1,216 @@
+package clusterlistener
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/google/go-cmp/cmp"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+	goruntime "runtime"
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+func TestStripObject(t *testing.T) {
+	type args struct {
+		object runtime.Object
+	}
+	largeValue := strings.Repeat("x", maxAnnotationLength+1)
+	fullContainer := corev1.Container{
+		Name:    "app",
+		Image:   "app:1.0",
+		Command: []string{"/app"},
+		Args:    []string{"--verbose"},
+		Ports:   []corev1.ContainerPort{{ContainerPort: 8080}},
+		Env: []corev1.EnvVar{
+			{Name: "MODE", Value: "production"},
+			{Name: "PASSWORD", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{Key: "password"}}},
+		},
+	}
+	strippedContainer := corev1.Container{
+		Name:  "app",
+		Image: "app:1.0",
+		Ports: []corev1.ContainerPort{{ContainerPort: 8080}},
+		Env: []corev1.EnvVar{
+			{Name: "PASSWORD", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{Key: "password"}}},
+		},
+	}
+	fullMetadata := metav1.ObjectMeta{
+		Name:          "app",
+		Namespace:     "shop",
+		Labels:        map[string]string{"app": "app"},
+		ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl"}},
+		Annotations: map[string]string{
+			lastAppliedConfigAnnotation: "{}",
+			"sidecar.istio.io/status":   largeValue,
+			"example.com/description":   largeValue,
+			"service.cilium.io/global":  "true",
+		},
+	}
+	strippedMetadata := metav1.ObjectMeta{
+		Name:      "app",
+		Namespace: "shop",
+		Labels:    map[string]string{"app": "app"},
+		Annotations: map[string]string{
+			"sidecar.istio.io/status":  largeValue,
+			"example.com/description":  "",
+			"service.cilium.io/global": "true",
+		},
+	}
+	privileged := true
+	fullEphemeralContainer := corev1.EphemeralContainer{
+		EphemeralContainerCommon: corev1.EphemeralContainerCommon(fullContainer),
+		TargetContainerName:      "app",
+	}
+	fullEphemeralContainer.Name = "debugger"
+	fullEphemeralContainer.SecurityContext = &corev1.SecurityContext{Privileged: &privileged}
+	strippedEphemeralContainer := corev1.EphemeralContainer{
+		EphemeralContainerCommon: corev1.EphemeralContainerCommon(strippedContainer),
+		TargetContainerName:      "app",
+	}
+	strippedEphemeralContainer.Name = "debugger"
+	strippedEphemeralContainer.SecurityContext = &corev1.SecurityContext{Privileged: &privileged}
+	fullSpec := corev1.PodSpec{
+		Containers:          []corev1.Container{fullContainer},
+		EphemeralContainers: []corev1.EphemeralContainer{fullEphemeralContainer},
+		Tolerations:         []corev1.Toleration{{Key: "dedicated"}},
+		Affinity:            &corev1.Affinity{},
+		HostNetwork:         true,
+	}
+	strippedSpec := corev1.PodSpec{
+		Containers:          []corev1.Container{strippedContainer},
+		EphemeralContainers: []corev1.EphemeralContainer{strippedEphemeralContainer},
+		HostNetwork:         true,
+	}
+	tests := []struct {
+		name           string
+		args           args
+		expectedObject runtime.Object
+	}{
+		{
+			name: "strips metadata and container details unused by the analysis of pods",
+			args: args{
+				object: &corev1.Pod{ObjectMeta: *fullMetadata.DeepCopy(), Spec: *fullSpec.DeepCopy(),
+					Status: corev1.PodStatus{PodIP: "10.0.0.1"}},
+			},
+			expectedObject: &corev1.Pod{ObjectMeta: strippedMetadata, Spec: strippedSpec,
+				Status: corev1.PodStatus{PodIP: "10.0.0.1"}},
+		},
+		{
+			name: "strips the pod template of workloads",
+			args: args{
+				object: &appsv1.Deployment{ObjectMeta: *fullMetadata.DeepCopy(), Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{ObjectMeta: *fullMetadata.DeepCopy(), Spec: *fullSpec.DeepCopy()},
+				}},
+			},
+			expectedObject: &appsv1.Deployment{ObjectMeta: strippedMetadata, Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{ObjectMeta: strippedMetadata, Spec: strippedSpec},
+			}},
+		},
+		{
+			name: "strips the metadata of other objects",
+			args: args{
+				object: &corev1.Service{ObjectMeta: *fullMetadata.DeepCopy()},
+			},
+			expectedObject: &corev1.Service{ObjectMeta: strippedMetadata},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stripObject(tt.args.object)
+			if diff := cmp.Diff(tt.expectedObject, tt.args.object); diff != "" {
+				t.Errorf("stripObject() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func BenchmarkPodInformerMemory(b *testing.B) {
+	pods := syntheticPods(10000)
+	newInformers := map[string]func(k8sClient kubernetes.Interface) cache.SharedIndexInformer{
+		"full": func(k8sClient kubernetes.Interface) cache.SharedIndexInformer {
+			return informers.NewSharedInformerFactory(k8sClient, 0).Core().V1().Pods().Informer()
+		},
+		"stripped": func(k8sClient kubernetes.Interface) cache.SharedIndexInformer {
+			factory := informers.NewSharedInformerFactory(k8sClient, 0)
+			registerStrippedInformer(factory, &corev1.Pod{}, metav1.ListOptions{},
+				func(k8sClient kubernetes.Interface) listWatcher[*corev1.PodList] {
+					return k8sClient.CoreV1().Pods(metav1.NamespaceAll)
+				})
+			return factory.Core().V1().Pods().Informer()
+		},
+	}
+	for _, name := range []string{"full", "stripped"} {
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				k8sClient := fake.NewSimpleClientset(pods...)
+				before := heapInUse()
+				informer := newInformers[name](k8sClient)
+				stop := make(chan struct{})
+				go informer.Run(stop)
+				cache.WaitForCacheSync(stop, informer.HasSynced)
+				b.ReportMetric(float64(heapInUse()-before)/float64(len(pods)), "B/pod")
+				if cached := len(informer.GetStore().List()); cached != len(pods) {
+					b.Fatalf("expected %d cached pods, got %d", len(pods), cached)
+				}
+				close(stop)
+			}
+		})
+	}
+}
+
+func heapInUse() int64 {
+	goruntime.GC()
+	debug.FreeOSMemory()
+	memStats := goruntime.MemStats{}
+	goruntime.ReadMemStats(&memStats)
+	return int64(memStats.HeapInuse)
+}
+
+func syntheticPods(count int) []runtime.Object {
+	result := make([]runtime.Object, 0, count)
+	for i := 0; i < count; i++ {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("pod-%d", i),
+				Namespace: fmt.Sprintf("ns-%d", i%100),
+				Labels:    map[string]string{"app": fmt.Sprintf("app-%d", i%500), "tier": "backend"},
+				ManagedFields: []metav1.ManagedFieldsEntry{
+					{Manager: "kube-controller-manager", Operation: metav1.ManagedFieldsOperationUpdate,
+						FieldsType: "FieldsV1", FieldsV1: &metav1.FieldsV1{Raw: []byte(strings.Repeat("{}", 1000))}},
+					{Manager: "kubelet", Operation: metav1.ManagedFieldsOperationUpdate,
+						FieldsType: "FieldsV1", FieldsV1: &metav1.FieldsV1{Raw: []byte(strings.Repeat("{}", 1000))}},
+				},
+			},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{
+					Name:    "app",
+					Image:   "registry.example.com/app:1.0",
+					Command: []string{"/bin/app", "--config", "/etc/app/config.yaml"},
+					Ports:   []corev1.ContainerPort{{ContainerPort: 8080}},
+					Env:     make([]corev1.EnvVar, 0, 30),
+				}},
+				Tolerations: []corev1.Toleration{
+					{Key: "node.kubernetes.io/not-ready", Operator: corev1.TolerationOpExists},
+					{Key: "node.kubernetes.io/unreachable", Operator: corev1.TolerationOpExists},
+				},
+			},
+			Status: corev1.PodStatus{PodIP: fmt.Sprintf("10.0.%d.%d", i/256%256, i%256)},
+		}
+		for j := 0; j < 30; j++ {
+			pod.Spec.Containers[0].Env = append(pod.Spec.Containers[0].Env, corev1.EnvVar{
+				Name: fmt.Sprintf("SETTING_%d", j), Value: strings.Repeat("v", 40)})
+		}
+		lastApplied, _ := json.Marshal(pod)
+		pod.Annotations = map[string]string{lastAppliedConfigAnnotation: string(lastApplied)}
+		result = append(result, pod)
+	}
+	return result
+}
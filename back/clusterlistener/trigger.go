@@ -0,0 +1,67 @@
+package clusterlistener
+
+import (
+	"expvar"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	receivedEvents    = expvar.NewInt("clusterEventsReceived")
+	coalescedEvents   = expvar.NewInt("clusterEventsCoalesced")
+	triggeredAnalyses = expvar.NewInt("analysesTriggered")
+)
+
+type analysisTrigger struct {
+	debounce      time.Duration
+	maxLatency    time.Duration
+	events        chan struct{}
+	pendingEvents int64
+}
+
+func newAnalysisTrigger(debounce time.Duration, maxLatency time.Duration) *analysisTrigger {
+	return &analysisTrigger{
+		debounce:   debounce,
+		maxLatency: maxLatency,
+		events:     make(chan struct{}, 1),
+	}
+}
+
+func (trigger *analysisTrigger) notify() {
+	atomic.AddInt64(&trigger.pendingEvents, 1)
+	receivedEvents.Add(1)
+	select {
+	case trigger.events <- struct{}{}:
+	default:
+	}
+}
+
+func (trigger *analysisTrigger) wait() int64 {
+	<-trigger.events
+	maxLatencyTimer := time.NewTimer(trigger.maxLatency)
+	defer maxLatencyTimer.Stop()
+	debounceTimer := time.NewTimer(trigger.debounce)
+	defer debounceTimer.Stop()
+	for {
+		select {
+		case <-trigger.events:
+			if !debounceTimer.Stop() {
+				<-debounceTimer.C
+			}
+			debounceTimer.Reset(trigger.debounce)
+		case <-debounceTimer.C:
+			return trigger.flush()
+		case <-maxLatencyTimer.C:
+			return trigger.flush()
+		}
+	}
+}
+
+func (trigger *analysisTrigger) flush() int64 {
+	events := atomic.SwapInt64(&trigger.pendingEvents, 0)
+	if events > 1 {
+		coalescedEvents.Add(events - 1)
+	}
+	triggeredAnalyses.Add(1)
+	return events
+}
@@ -0,0 +1,71 @@
+package clusterlistener
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAnalysisTrigger(t *testing.T) {
+	type args struct {
+		debounce      time.Duration
+		maxLatency    time.Duration
+		events        int
+		eventInterval time.Duration
+	}
+	tests := []struct {
+		name        string
+		args        args
+		minEvents   int64
+		maxEvents   int64
+		maxDuration time.Duration
+	}{
+		{
+			name: "coalesces a burst of events into a single analysis",
+			args: args{
+				debounce:      50 * time.Millisecond,
+				maxLatency:    time.Second,
+				events:        200,
+				eventInterval: 0,
+			},
+			minEvents:   200,
+			maxEvents:   200,
+			maxDuration: 500 * time.Millisecond,
+		},
+		{
+			name: "triggers an analysis once the max latency is reached despite continuous events",
+			args: args{
+				debounce:      50 * time.Millisecond,
+				maxLatency:    200 * time.Millisecond,
+				events:        100,
+				eventInterval: 10 * time.Millisecond,
+			},
+			minEvents:   1,
+			maxEvents:   99,
+			maxDuration: 600 * time.Millisecond,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trigger := newAnalysisTrigger(tt.args.debounce, tt.args.maxLatency)
+			start := time.Now()
+			notified := make(chan struct{})
+			go func(events int, eventInterval time.Duration) {
+				defer close(notified)
+				for i := 0; i < events; i++ {
+					trigger.notify()
+					time.Sleep(eventInterval)
+				}
+			}(tt.args.events, tt.args.eventInterval)
+			events := trigger.wait()
+			elapsed := time.Since(start)
+			<-notified
+			if events < tt.minEvents || events > tt.maxEvents {
+				t.Errorf("wait() expected between %d and %d coalesced events, got %d", tt.minEvents, tt.maxEvents,
+					events)
+			}
+			if elapsed > tt.maxDuration {
+				t.Errorf("wait() expected to return within %s, took %s", tt.maxDuration, elapsed)
+			}
+		})
+	}
+}
@@ -12,6 +12,10 @@ func (set *Set[T1]) Contains(value T1) bool {
 	return set.internal[value]
 }
 
+func (set *Set[T1]) Size() int {
+	return len(set.internal)
+}
+
 func (set *Set[T1]) ToSlice() []T1 {
 	result := make([]T1, 0, len(set.internal))
 	for value := range set.internal {
@@ -2,11 +2,30 @@ package main
 
 import (
 	"karto/analyzer"
+	"karto/analyzer/compliance"
+	"karto/analyzer/configuration"
+	"karto/analyzer/configuration/podconfiguration"
+	"karto/analyzer/configuration/workloadconfiguration"
+	"karto/analyzer/crosscluster"
+	"karto/analyzer/exposure"
 	"karto/analyzer/health"
+	"karto/analyzer/health/daemonsethealth"
+	"karto/analyzer/health/deploymenthealth"
 	"karto/analyzer/health/podhealth"
+	"karto/analyzer/health/servicehealth"
+	"karto/analyzer/health/statefulsethealth"
+	"karto/analyzer/health/warnings"
+	"karto/analyzer/lint"
+	"karto/analyzer/mesh"
 	"karto/analyzer/pod"
+	"karto/analyzer/podsecurity"
 	"karto/analyzer/traffic"
+	"karto/analyzer/traffic/adminpolicy"
 	"karto/analyzer/traffic/allowedroute"
+	"karto/analyzer/traffic/calicopolicy"
+	"karto/analyzer/traffic/ciliumpolicy"
+	"karto/analyzer/traffic/externaldestination"
+	"karto/analyzer/traffic/externalroute"
 	"karto/analyzer/traffic/podisolation"
 	"karto/analyzer/workload"
 	"karto/analyzer/workload/daemonset"
@@ -25,7 +44,13 @@ func dependencyInjection() Container {
 	podAnalyzer := pod.NewAnalyzer()
 	podIsolationAnalyzer := podisolation.NewAnalyzer()
 	allowedRouteAnalyzer := allowedroute.NewAnalyzer()
-	trafficAnalyzer := traffic.NewAnalyzer(podIsolationAnalyzer, allowedRouteAnalyzer)
+	externalRouteAnalyzer := externalroute.NewAnalyzer()
+	externalDestinationAnalyzer := externaldestination.NewAnalyzer()
+	ciliumPolicySource := ciliumpolicy.NewSource()
+	calicoPolicySource := calicopolicy.NewSource()
+	adminPolicySource := adminpolicy.NewSource()
+	trafficAnalyzer := traffic.NewAnalyzer(podIsolationAnalyzer, allowedRouteAnalyzer, externalRouteAnalyzer,
+		externalDestinationAnalyzer, ciliumPolicySource, calicoPolicySource, adminPolicySource)
 	serviceAnalyzer := service.NewAnalyzer()
 	ingressAnalyzer := ingress.NewAnalyzer()
 	replicaSetAnalyzer := replicaset.NewAnalyzer()
@@ -35,8 +60,25 @@ func dependencyInjection() Container {
 	workloadAnalyzer := workload.NewAnalyzer(serviceAnalyzer, ingressAnalyzer, replicaSetAnalyzer, statefulSetAnalyzer,
 		daemonSetAnalyzer, deploymentAnalyzer)
 	podHealthAnalyzer := podhealth.NewAnalyzer()
-	healthAnalyzer := health.NewAnalyzer(podHealthAnalyzer)
-	analysisScheduler := analyzer.NewAnalysisScheduler(podAnalyzer, trafficAnalyzer, workloadAnalyzer, healthAnalyzer)
+	serviceHealthAnalyzer := servicehealth.NewAnalyzer()
+	statefulSetHealthAnalyzer := statefulsethealth.NewAnalyzer()
+	daemonSetHealthAnalyzer := daemonsethealth.NewAnalyzer()
+	deploymentHealthAnalyzer := deploymenthealth.NewAnalyzer()
+	warningsAnalyzer := warnings.NewAnalyzer()
+	healthAnalyzer := health.NewAnalyzer(podHealthAnalyzer, serviceHealthAnalyzer, statefulSetHealthAnalyzer,
+		daemonSetHealthAnalyzer, deploymentHealthAnalyzer, warningsAnalyzer)
+	podConfigurationAnalyzer := podconfiguration.NewAnalyzer()
+	workloadConfigurationAnalyzer := workloadconfiguration.NewAnalyzer()
+	configurationAnalyzer := configuration.NewAnalyzer(podConfigurationAnalyzer, workloadConfigurationAnalyzer)
+	lintAnalyzer := lint.NewAnalyzer()
+	complianceAnalyzer := compliance.NewAnalyzer()
+	meshAnalyzer := mesh.NewAnalyzer()
+	crossClusterAnalyzer := crosscluster.NewAnalyzer(allowedRouteAnalyzer)
+	exposureAnalyzer := exposure.NewAnalyzer()
+	podSecurityAnalyzer := podsecurity.NewAnalyzer()
+	analysisScheduler := analyzer.NewAnalysisScheduler(podAnalyzer, trafficAnalyzer, workloadAnalyzer, healthAnalyzer,
+		configurationAnalyzer, lintAnalyzer, complianceAnalyzer, meshAnalyzer, crossClusterAnalyzer, exposureAnalyzer,
+		podSecurityAnalyzer)
 	return Container{
 		AnalysisScheduler: analysisScheduler,
 	}
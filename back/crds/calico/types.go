@@ -0,0 +1,87 @@
+package calico
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	NetworkPolicyKind       = "NetworkPolicy"
+	GlobalNetworkPolicyKind = "GlobalNetworkPolicy"
+	TierKind                = "Tier"
+	ActionAllow             = "Allow"
+	ActionDeny              = "Deny"
+	ActionLog               = "Log"
+	ActionPass              = "Pass"
+	PolicyTypeIngress       = "Ingress"
+	PolicyTypeEgress        = "Egress"
+)
+
+var (
+	GroupVersion                = schema.GroupVersion{Group: "projectcalico.org", Version: "v3"}
+	NetworkPolicyResource       = GroupVersion.WithResource("networkpolicies")
+	GlobalNetworkPolicyResource = GroupVersion.WithResource("globalnetworkpolicies")
+	TierResource                = GroupVersion.WithResource("tiers")
+)
+
+type NetworkPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              PolicySpec `json:"spec,omitempty"`
+}
+
+type PolicySpec struct {
+	Tier                   string   `json:"tier,omitempty"`
+	Order                  *float64 `json:"order,omitempty"`
+	Selector               string   `json:"selector,omitempty"`
+	NamespaceSelector      string   `json:"namespaceSelector,omitempty"`
+	ServiceAccountSelector string   `json:"serviceAccountSelector,omitempty"`
+	Types                  []string `json:"types,omitempty"`
+	Ingress                []Rule   `json:"ingress,omitempty"`
+	Egress                 []Rule   `json:"egress,omitempty"`
+	DoNotTrack             bool     `json:"doNotTrack,omitempty"`
+	PreDNAT                bool     `json:"preDNAT,omitempty"`
+	ApplyOnForward         bool     `json:"applyOnForward,omitempty"`
+}
+
+type Rule struct {
+	Action      string     `json:"action"`
+	Source      EntityRule `json:"source,omitempty"`
+	Destination EntityRule `json:"destination,omitempty"`
+}
+
+type EntityRule struct {
+	Nets              []string             `json:"nets,omitempty"`
+	NotNets           []string             `json:"notNets,omitempty"`
+	Selector          string               `json:"selector,omitempty"`
+	NotSelector       string               `json:"notSelector,omitempty"`
+	NamespaceSelector string               `json:"namespaceSelector,omitempty"`
+	Ports             []intstr.IntOrString `json:"ports,omitempty"`
+	NotPorts          []intstr.IntOrString `json:"notPorts,omitempty"`
+	ServiceAccounts   *ServiceAccountMatch `json:"serviceAccounts,omitempty"`
+	Domains           []string             `json:"domains,omitempty"`
+}
+
+type ServiceAccountMatch struct {
+	Names    []string `json:"names,omitempty"`
+	Selector string   `json:"selector,omitempty"`
+}
+
+type Tier struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              TierSpec `json:"spec,omitempty"`
+}
+
+type TierSpec struct {
+	Order         *float64 `json:"order,omitempty"`
+	DefaultAction string   `json:"defaultAction,omitempty"`
+}
+
+func (policy *NetworkPolicy) TierName() string {
+	if policy.Spec.Tier == "" {
+		return "default"
+	}
+	return policy.Spec.Tier
+}
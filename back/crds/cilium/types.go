@@ -0,0 +1,90 @@
+package cilium
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	NetworkPolicyKind            = "CiliumNetworkPolicy"
+	ClusterwideNetworkPolicyKind = "CiliumClusterwideNetworkPolicy"
+)
+
+var (
+	GroupVersion                     = schema.GroupVersion{Group: "cilium.io", Version: "v2"}
+	NetworkPolicyResource            = GroupVersion.WithResource("ciliumnetworkpolicies")
+	ClusterwideNetworkPolicyResource = GroupVersion.WithResource("ciliumclusterwidenetworkpolicies")
+)
+
+type NetworkPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              *Rule  `json:"spec,omitempty"`
+	Specs             []Rule `json:"specs,omitempty"`
+}
+
+type Rule struct {
+	EndpointSelector *metav1.LabelSelector `json:"endpointSelector,omitempty"`
+	NodeSelector     *metav1.LabelSelector `json:"nodeSelector,omitempty"`
+	Ingress          []IngressRule         `json:"ingress,omitempty"`
+	IngressDeny      []IngressRule         `json:"ingressDeny,omitempty"`
+	Egress           []EgressRule          `json:"egress,omitempty"`
+	EgressDeny       []EgressRule          `json:"egressDeny,omitempty"`
+}
+
+type IngressRule struct {
+	FromEndpoints []metav1.LabelSelector `json:"fromEndpoints,omitempty"`
+	FromCIDR      []string               `json:"fromCIDR,omitempty"`
+	FromCIDRSet   []CIDRRule             `json:"fromCIDRSet,omitempty"`
+	FromEntities  []string               `json:"fromEntities,omitempty"`
+	ToPorts       []PortRule             `json:"toPorts,omitempty"`
+}
+
+type EgressRule struct {
+	ToEndpoints []metav1.LabelSelector `json:"toEndpoints,omitempty"`
+	ToCIDR      []string               `json:"toCIDR,omitempty"`
+	ToCIDRSet   []CIDRRule             `json:"toCIDRSet,omitempty"`
+	ToEntities  []string               `json:"toEntities,omitempty"`
+	ToFQDNs     []FQDNSelector         `json:"toFQDNs,omitempty"`
+	ToPorts     []PortRule             `json:"toPorts,omitempty"`
+}
+
+type CIDRRule struct {
+	Cidr   string   `json:"cidr"`
+	Except []string `json:"except,omitempty"`
+}
+
+type FQDNSelector struct {
+	MatchName    string `json:"matchName,omitempty"`
+	MatchPattern string `json:"matchPattern,omitempty"`
+}
+
+type PortRule struct {
+	Ports []PortProtocol `json:"ports,omitempty"`
+	Rules *L7Rules       `json:"rules,omitempty"`
+}
+
+type PortProtocol struct {
+	Port     string `json:"port"`
+	EndPort  int32  `json:"endPort,omitempty"`
+	Protocol string `json:"protocol,omitempty"`
+}
+
+type L7Rules struct {
+	HTTP []HTTPRule     `json:"http,omitempty"`
+	DNS  []FQDNSelector `json:"dns,omitempty"`
+}
+
+type HTTPRule struct {
+	Method string `json:"method,omitempty"`
+	Path   string `json:"path,omitempty"`
+	Host   string `json:"host,omitempty"`
+}
+
+func (policy *NetworkPolicy) Rules() []Rule {
+	rules := make([]Rule, 0, len(policy.Specs)+1)
+	if policy.Spec != nil {
+		rules = append(rules, *policy.Spec)
+	}
+	return append(rules, policy.Specs...)
+}
@@ -0,0 +1,150 @@
+package istio
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	AuthorizationPolicyKind = "AuthorizationPolicy"
+	PeerAuthenticationKind  = "PeerAuthentication"
+	ServiceEntryKind        = "ServiceEntry"
+	ActionAllow             = "ALLOW"
+	ActionDeny              = "DENY"
+	ActionAudit             = "AUDIT"
+	ActionCustom            = "CUSTOM"
+	MTLSModeUnset           = "UNSET"
+	MTLSModeDisable         = "DISABLE"
+	MTLSModePermissive      = "PERMISSIVE"
+	MTLSModeStrict          = "STRICT"
+	LocationMeshExternal    = "MESH_EXTERNAL"
+	LocationMeshInternal    = "MESH_INTERNAL"
+)
+
+var (
+	GroupVersion                = schema.GroupVersion{Group: "security.istio.io", Version: "v1beta1"}
+	AuthorizationPolicyResource = GroupVersion.WithResource("authorizationpolicies")
+	PeerAuthenticationResource  = GroupVersion.WithResource("peerauthentications")
+	NetworkingGroupVersion      = schema.GroupVersion{Group: "networking.istio.io", Version: "v1beta1"}
+	ServiceEntryResource        = NetworkingGroupVersion.WithResource("serviceentries")
+)
+
+type WorkloadSelector struct {
+	MatchLabels map[string]string `json:"matchLabels,omitempty"`
+}
+
+type AuthorizationPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              AuthorizationPolicySpec `json:"spec,omitempty"`
+}
+
+type AuthorizationPolicySpec struct {
+	Selector *WorkloadSelector `json:"selector,omitempty"`
+	Action   string            `json:"action,omitempty"`
+	Provider *Provider         `json:"provider,omitempty"`
+	Rules    []Rule            `json:"rules,omitempty"`
+}
+
+type Provider struct {
+	Name string `json:"name,omitempty"`
+}
+
+type Rule struct {
+	From []From      `json:"from,omitempty"`
+	To   []To        `json:"to,omitempty"`
+	When []Condition `json:"when,omitempty"`
+}
+
+type From struct {
+	Source Source `json:"source,omitempty"`
+}
+
+type Source struct {
+	Principals           []string `json:"principals,omitempty"`
+	NotPrincipals        []string `json:"notPrincipals,omitempty"`
+	RequestPrincipals    []string `json:"requestPrincipals,omitempty"`
+	NotRequestPrincipals []string `json:"notRequestPrincipals,omitempty"`
+	Namespaces           []string `json:"namespaces,omitempty"`
+	NotNamespaces        []string `json:"notNamespaces,omitempty"`
+	IPBlocks             []string `json:"ipBlocks,omitempty"`
+	NotIPBlocks          []string `json:"notIpBlocks,omitempty"`
+	RemoteIPBlocks       []string `json:"remoteIpBlocks,omitempty"`
+	NotRemoteIPBlocks    []string `json:"notRemoteIpBlocks,omitempty"`
+}
+
+type To struct {
+	Operation Operation `json:"operation,omitempty"`
+}
+
+type Operation struct {
+	Hosts      []string `json:"hosts,omitempty"`
+	NotHosts   []string `json:"notHosts,omitempty"`
+	Ports      []string `json:"ports,omitempty"`
+	NotPorts   []string `json:"notPorts,omitempty"`
+	Methods    []string `json:"methods,omitempty"`
+	NotMethods []string `json:"notMethods,omitempty"`
+	Paths      []string `json:"paths,omitempty"`
+	NotPaths   []string `json:"notPaths,omitempty"`
+}
+
+type Condition struct {
+	Key       string   `json:"key"`
+	Values    []string `json:"values,omitempty"`
+	NotValues []string `json:"notValues,omitempty"`
+}
+
+type PeerAuthentication struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              PeerAuthenticationSpec `json:"spec,omitempty"`
+}
+
+type PeerAuthenticationSpec struct {
+	Selector      *WorkloadSelector `json:"selector,omitempty"`
+	MTLS          *MTLS             `json:"mtls,omitempty"`
+	PortLevelMTLS map[string]MTLS   `json:"portLevelMtls,omitempty"`
+}
+
+type MTLS struct {
+	Mode string `json:"mode,omitempty"`
+}
+
+type ServiceEntry struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              ServiceEntrySpec `json:"spec,omitempty"`
+}
+
+type ServiceEntrySpec struct {
+	Hosts      []string        `json:"hosts,omitempty"`
+	Addresses  []string        `json:"addresses,omitempty"`
+	Ports      []ServicePort   `json:"ports,omitempty"`
+	Location   string          `json:"location,omitempty"`
+	Resolution string          `json:"resolution,omitempty"`
+	Endpoints  []WorkloadEntry `json:"endpoints,omitempty"`
+	ExportTo   []string        `json:"exportTo,omitempty"`
+}
+
+type ServicePort struct {
+	Number   int32  `json:"number"`
+	Protocol string `json:"protocol,omitempty"`
+	Name     string `json:"name,omitempty"`
+}
+
+type WorkloadEntry struct {
+	Address string            `json:"address,omitempty"`
+	Ports   map[string]int32  `json:"ports,omitempty"`
+	Labels  map[string]string `json:"labels,omitempty"`
+}
+
+func (policy *AuthorizationPolicy) ActionName() string {
+	if policy.Spec.Action == "" {
+		return ActionAllow
+	}
+	return policy.Spec.Action
+}
+
+func (serviceEntry *ServiceEntry) IsExternal() bool {
+	return serviceEntry.Spec.Location != LocationMeshInternal
+}
@@ -0,0 +1,40 @@
+package mcs
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	ServiceExportKind = "ServiceExport"
+	ServiceImportKind = "ServiceImport"
+)
+
+var (
+	GroupVersion          = schema.GroupVersion{Group: "multicluster.x-k8s.io", Version: "v1alpha1"}
+	ServiceExportResource = GroupVersion.WithResource("serviceexports")
+	ServiceImportResource = GroupVersion.WithResource("serviceimports")
+)
+
+type ServiceExport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+}
+
+type ServiceImport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              ServiceImportSpec `json:"spec,omitempty"`
+}
+
+type ServiceImportSpec struct {
+	Type  string        `json:"type,omitempty"`
+	IPs   []string      `json:"ips,omitempty"`
+	Ports []ServicePort `json:"ports,omitempty"`
+}
+
+type ServicePort struct {
+	Name     string `json:"name,omitempty"`
+	Protocol string `json:"protocol,omitempty"`
+	Port     int32  `json:"port"`
+}
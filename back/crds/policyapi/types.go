@@ -0,0 +1,91 @@
+package policyapi
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	AdminNetworkPolicyKind         = "AdminNetworkPolicy"
+	BaselineAdminNetworkPolicyKind = "BaselineAdminNetworkPolicy"
+	ActionAllow                    = "Allow"
+	ActionDeny                     = "Deny"
+	ActionPass                     = "Pass"
+)
+
+var (
+	GroupVersion                       = schema.GroupVersion{Group: "policy.networking.k8s.io", Version: "v1alpha1"}
+	AdminNetworkPolicyResource         = GroupVersion.WithResource("adminnetworkpolicies")
+	BaselineAdminNetworkPolicyResource = GroupVersion.WithResource("baselineadminnetworkpolicies")
+)
+
+type AdminNetworkPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              Spec `json:"spec,omitempty"`
+}
+
+type Spec struct {
+	Priority int32         `json:"priority,omitempty"`
+	Subject  Subject       `json:"subject"`
+	Ingress  []IngressRule `json:"ingress,omitempty"`
+	Egress   []EgressRule  `json:"egress,omitempty"`
+}
+
+type Subject struct {
+	Namespaces *metav1.LabelSelector `json:"namespaces,omitempty"`
+	Pods       *NamespacedPod        `json:"pods,omitempty"`
+}
+
+type NamespacedPod struct {
+	NamespaceSelector metav1.LabelSelector `json:"namespaceSelector"`
+	PodSelector       metav1.LabelSelector `json:"podSelector"`
+}
+
+type IngressRule struct {
+	Name   string        `json:"name,omitempty"`
+	Action string        `json:"action"`
+	From   []IngressPeer `json:"from"`
+	Ports  []Port        `json:"ports,omitempty"`
+}
+
+type IngressPeer struct {
+	Namespaces *metav1.LabelSelector `json:"namespaces,omitempty"`
+	Pods       *NamespacedPod        `json:"pods,omitempty"`
+}
+
+type EgressRule struct {
+	Name   string       `json:"name,omitempty"`
+	Action string       `json:"action"`
+	To     []EgressPeer `json:"to"`
+	Ports  []Port       `json:"ports,omitempty"`
+}
+
+type EgressPeer struct {
+	Namespaces  *metav1.LabelSelector `json:"namespaces,omitempty"`
+	Pods        *NamespacedPod        `json:"pods,omitempty"`
+	Nodes       *metav1.LabelSelector `json:"nodes,omitempty"`
+	Networks    []string              `json:"networks,omitempty"`
+	DomainNames []string              `json:"domainNames,omitempty"`
+}
+
+type Port struct {
+	PortNumber *PortNumber `json:"portNumber,omitempty"`
+	NamedPort  *string     `json:"namedPort,omitempty"`
+	PortRange  *PortRange  `json:"portRange,omitempty"`
+}
+
+type PortNumber struct {
+	Protocol string `json:"protocol,omitempty"`
+	Port     int32  `json:"port"`
+}
+
+type PortRange struct {
+	Protocol string `json:"protocol,omitempty"`
+	Start    int32  `json:"start"`
+	End      int32  `json:"end"`
+}
+
+func (policy *AdminNetworkPolicy) IsBaseline() bool {
+	return policy.Kind == BaselineAdminNetworkPolicyKind
+}
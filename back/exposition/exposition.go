@@ -3,35 +3,81 @@ package exposition
 import (
 	"embed"
 	"encoding/json"
+	"expvar"
 	"fmt"
 	"io/fs"
+	"karto/commons"
+	"karto/graph"
 	"karto/types"
 	"log"
 	"net/http"
+	"sort"
+	"strconv"
 	"sync"
+	"time"
 )
 
+const (
+	defaultMaxPathHops = 8
+	defaultMaxPaths    = 100
+	defaultMinRisk     = types.ExposureRiskMedium
+)
+
+var exposureRisks = []types.ExposureRisk{types.ExposureRiskCritical, types.ExposureRiskHigh,
+	types.ExposureRiskMedium, types.ExposureRiskLow}
+
 //go:embed frontend
 var embeddedFrontend embed.FS
 
 type handler struct {
-	mutex              sync.RWMutex
-	lastAnalysisResult types.AnalysisResult
+	mutex               sync.RWMutex
+	lastAnalysisResults map[string]types.AnalysisResult
+	history             *history
+	views               map[string]*resultView
+	fullResults         map[string]streamMessage
+	subscribers         map[*subscriber]bool
+	emptyAnalysisResult types.AnalysisResult
 }
 
-func newHandler() *handler {
+func newHandler(historyConfig HistoryConfig) *handler {
 	handler := &handler{
-		lastAnalysisResult: types.AnalysisResult{
-			Pods:          []*types.Pod{},
-			PodIsolations: []*types.PodIsolation{},
-			AllowedRoutes: []*types.AllowedRoute{},
-			Services:      []*types.Service{},
-			Ingresses:     []*types.Ingress{},
-			ReplicaSets:   []*types.ReplicaSet{},
-			StatefulSets:  []*types.StatefulSet{},
-			DaemonSets:    []*types.DaemonSet{},
-			Deployments:   []*types.Deployment{},
-			PodHealths:    []*types.PodHealth{},
+		lastAnalysisResults: map[string]types.AnalysisResult{},
+		history:             newHistory(historyConfig),
+		views:               map[string]*resultView{},
+		fullResults:         map[string]streamMessage{},
+		subscribers:         map[*subscriber]bool{},
+		emptyAnalysisResult: types.AnalysisResult{
+			Pods:                   []*types.Pod{},
+			PodIsolations:          []*types.PodIsolation{},
+			AllowedRoutes:          []*types.AllowedRoute{},
+			ExternalRoutes:         []*types.ExternalRoute{},
+			ExternalNodes:          []*types.ExternalNode{},
+			ExternalAccesses:       []*types.ExternalAccess{},
+			MeshRoutes:             []*types.MeshRoute{},
+			Services:               []*types.Service{},
+			Ingresses:              []*types.Ingress{},
+			ReplicaSets:            []*types.ReplicaSet{},
+			StatefulSets:           []*types.StatefulSet{},
+			DaemonSets:             []*types.DaemonSet{},
+			Deployments:            []*types.Deployment{},
+			PodHealths:             []*types.PodHealth{},
+			DeploymentHealths:      []*types.DeploymentHealth{},
+			StatefulSetHealths:     []*types.StatefulSetHealth{},
+			DaemonSetHealths:       []*types.DaemonSetHealth{},
+			ServiceHealths:         []*types.ServiceHealth{},
+			Warnings:               []*types.ObjectWarnings{},
+			PodConfigurations:      []*types.PodConfiguration{},
+			WorkloadConfigurations: []*types.WorkloadConfiguration{},
+			Findings:               []*types.Finding{},
+			Compliance: &types.ComplianceReport{
+				Coverage: types.IsolationCoverage{
+					PodsReachableFromAllNamespaces: []types.PodRef{},
+					PodsWithUnrestrictedEgress:     []types.PodRef{},
+				},
+				NamespacesWithoutIngressDefaultDeny: []string{},
+				NamespacesWithoutEgressDefaultDeny:  []string{},
+				Namespaces:                          []*types.NamespaceCompliance{},
+			},
 		},
 	}
 	return handler
@@ -40,16 +86,279 @@ func newHandler() *handler {
 func (handler *handler) keepUpdated(resultsChannel <-chan types.AnalysisResult) {
 	for {
 		newResults := <-resultsChannel
+		record, recorded := handler.history.newRecord(time.Now(), newResults)
+		publication, err := handler.prepare(newResults)
+		if err != nil {
+			log.Println(err)
+		}
 		handler.mutex.Lock()
-		handler.lastAnalysisResult = newResults
+		handler.lastAnalysisResults[newResults.Cluster] = newResults
+		kept := recorded && handler.history.keep(record)
+		if err == nil {
+			handler.publish(publication)
+		}
 		handler.mutex.Unlock()
+		if kept {
+			handler.history.persist(record)
+		}
+	}
+}
+
+func (handler *handler) clusters() []string {
+	result := make([]string, 0, len(handler.lastAnalysisResults))
+	for cluster := range handler.lastAnalysisResults {
+		result = append(result, cluster)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func (handler *handler) clusterOf(r *http.Request) (string, bool) {
+	cluster := r.URL.Query().Get("cluster")
+	if cluster != "" {
+		return cluster, true
+	}
+	return handler.defaultCluster()
+}
+
+func (handler *handler) defaultCluster() (string, bool) {
+	clusters := handler.clusters()
+	if len(clusters) == 0 {
+		return "", false
+	}
+	return clusters[0], true
+}
+
+func (handler *handler) analysisResultOf(w http.ResponseWriter, r *http.Request) (types.AnalysisResult, bool) {
+	cluster, ok := handler.clusterOf(r)
+	if !ok {
+		return handler.emptyAnalysisResult, true
+	}
+	if at := r.URL.Query().Get("at"); at != "" {
+		return handler.analysisResultAt(w, cluster, at)
+	}
+	analysisResult, ok := handler.lastAnalysisResults[cluster]
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown cluster %s", cluster), http.StatusNotFound)
+	}
+	return analysisResult, ok
+}
+
+func (handler *handler) analysisResultAt(w http.ResponseWriter, cluster string, at string) (types.AnalysisResult,
+	bool) {
+	timestamp, err := time.Parse(time.RFC3339, at)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid timestamp %s, expected RFC 3339", at), http.StatusBadRequest)
+		return types.AnalysisResult{}, false
+	}
+	analysisResult, ok := handler.history.analysisResultAt(cluster, timestamp)
+	if !ok {
+		http.Error(w, fmt.Sprintf("no analysis result of cluster %s at %s", cluster, at), http.StatusNotFound)
+	}
+	return analysisResult, ok
+}
+
+func (handler *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query, err := parseResultQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	handler.mutex.RLock()
+	defer handler.mutex.RUnlock()
+	analysisResult, ok := handler.analysisResultOf(w, r)
+	if !ok {
+		return
+	}
+	if query.filtering {
+		var matchingPods int
+		analysisResult, matchingPods = query.filter(analysisResult)
+		w.Header().Set("X-Total-Count", strconv.Itoa(matchingPods))
+	}
+	response, err := query.selectSections(analysisResult)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (handler *handler) serveClusters(w http.ResponseWriter, _ *http.Request) {
+	handler.mutex.RLock()
+	defer handler.mutex.RUnlock()
+	err := json.NewEncoder(w).Encode(handler.clusters())
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (handler *handler) serveHistory(w http.ResponseWriter, r *http.Request) {
+	handler.mutex.RLock()
+	defer handler.mutex.RUnlock()
+	cluster, _ := handler.clusterOf(r)
+	err := json.NewEncoder(w).Encode(handler.history.entries(cluster))
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (handler *handler) serveFindings(w http.ResponseWriter, r *http.Request) {
+	handler.mutex.RLock()
+	defer handler.mutex.RUnlock()
+	analysisResult, ok := handler.analysisResultOf(w, r)
+	if !ok {
+		return
 	}
+	err := json.NewEncoder(w).Encode(analysisResult.Findings)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (handler *handler) serveCompliance(w http.ResponseWriter, r *http.Request) {
+	handler.mutex.RLock()
+	defer handler.mutex.RUnlock()
+	analysisResult, ok := handler.analysisResultOf(w, r)
+	if !ok {
+		return
+	}
+	err := json.NewEncoder(w).Encode(analysisResult.Compliance)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func acceptedRisks(minRisk types.ExposureRisk) (map[types.ExposureRisk]bool, bool) {
+	result := make(map[types.ExposureRisk]bool, len(exposureRisks))
+	for _, risk := range exposureRisks {
+		result[risk] = true
+		if risk == minRisk {
+			return result, true
+		}
+	}
+	return nil, false
+}
+
+func (handler *handler) serveExposure(w http.ResponseWriter, r *http.Request) {
+	minRisk := defaultMinRisk
+	if value := r.URL.Query().Get("minRisk"); value != "" {
+		minRisk = types.ExposureRisk(value)
+	}
+	risks, ok := acceptedRisks(minRisk)
+	if !ok {
+		http.Error(w, fmt.Sprintf("invalid minRisk %s, expected one of %v", minRisk, exposureRisks),
+			http.StatusBadRequest)
+		return
+	}
+	handler.mutex.RLock()
+	defer handler.mutex.RUnlock()
+	analysisResult, ok := handler.analysisResultOf(w, r)
+	if !ok {
+		return
+	}
+	exposedPods := commons.Filter(analysisResult.ExposedPods, func(exposedPod *types.ExposedPod) bool {
+		return risks[exposedPod.Risk]
+	})
+	err := json.NewEncoder(w).Encode(exposedPods)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (handler *handler) analysisGraphOf(w http.ResponseWriter, r *http.Request,
+	nodeParameters ...string) (*graph.Graph, []types.ObjectRef, bool) {
+	nodes := make([]types.ObjectRef, 0, len(nodeParameters))
+	for _, parameter := range nodeParameters {
+		node, err := graph.ParseNode(r.URL.Query().Get(parameter))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid %s: %s", parameter, err), http.StatusBadRequest)
+			return nil, nil, false
+		}
+		nodes = append(nodes, node)
+	}
+	analysisResult, ok := handler.analysisResultOf(w, r)
+	if !ok {
+		return nil, nil, false
+	}
+	analysisGraph := graph.New(analysisResult)
+	for _, node := range nodes {
+		if !analysisGraph.Contains(node) {
+			http.Error(w, fmt.Sprintf("unknown node %s/%s/%s", node.Kind, node.Namespace, node.Name),
+				http.StatusNotFound)
+			return nil, nil, false
+		}
+	}
+	return analysisGraph, nodes, true
 }
 
-func (handler *handler) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+func intParameter(w http.ResponseWriter, r *http.Request, parameter string, defaultValue int) (int, bool) {
+	value := r.URL.Query().Get(parameter)
+	if value == "" {
+		return defaultValue, true
+	}
+	result, err := strconv.Atoi(value)
+	if err != nil || result < 0 {
+		http.Error(w, fmt.Sprintf("invalid %s %s, expected a positive integer", parameter, value),
+			http.StatusBadRequest)
+		return 0, false
+	}
+	return result, true
+}
+
+func (handler *handler) serveNeighborhood(w http.ResponseWriter, r *http.Request) {
+	hops, ok := intParameter(w, r, "hops", 1)
+	if !ok {
+		return
+	}
 	handler.mutex.RLock()
 	defer handler.mutex.RUnlock()
-	err := json.NewEncoder(w).Encode(handler.lastAnalysisResult)
+	analysisGraph, nodes, ok := handler.analysisGraphOf(w, r, "node")
+	if !ok {
+		return
+	}
+	err := json.NewEncoder(w).Encode(analysisGraph.Neighborhood(nodes[0], hops))
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (handler *handler) serveIngressPaths(w http.ResponseWriter, r *http.Request) {
+	maxHops, ok := intParameter(w, r, "maxHops", defaultMaxPathHops)
+	if !ok {
+		return
+	}
+	maxPaths, ok := intParameter(w, r, "maxPaths", defaultMaxPaths)
+	if !ok {
+		return
+	}
+	handler.mutex.RLock()
+	defer handler.mutex.RUnlock()
+	analysisGraph, nodes, ok := handler.analysisGraphOf(w, r, "node")
+	if !ok {
+		return
+	}
+	err := json.NewEncoder(w).Encode(analysisGraph.PathsFromIngresses(nodes[0], maxHops, maxPaths))
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (handler *handler) serveShortestPath(w http.ResponseWriter, r *http.Request) {
+	handler.mutex.RLock()
+	defer handler.mutex.RUnlock()
+	analysisGraph, nodes, ok := handler.analysisGraphOf(w, r, "from", "to")
+	if !ok {
+		return
+	}
+	path := analysisGraph.ShortestPath(nodes[0], nodes[1])
+	if path == nil {
+		http.Error(w, "no allowed path", http.StatusNotFound)
+		return
+	}
+	err := json.NewEncoder(w).Encode(path)
 	if err != nil {
 		log.Println(err)
 	}
@@ -62,15 +371,25 @@ func healthCheck(w http.ResponseWriter, _ *http.Request) {
 	}
 }
 
-func Expose(address string, resultsChannel <-chan types.AnalysisResult) {
+func Expose(address string, historyConfig HistoryConfig, resultsChannel <-chan types.AnalysisResult) {
 	frontendDir, _ := fs.Sub(embeddedFrontend, "frontend")
 	frontendHandler := http.FileServer(http.FS(frontendDir))
-	apiHandler := newHandler()
+	apiHandler := newHandler(historyConfig)
 	go apiHandler.keepUpdated(resultsChannel)
 	mux := http.NewServeMux()
 	mux.Handle("/", frontendHandler)
 	mux.Handle("/api/analysisResult", apiHandler)
+	mux.HandleFunc("/api/analysisResult/stream", apiHandler.serveStream)
+	mux.HandleFunc("/api/clusters", apiHandler.serveClusters)
+	mux.HandleFunc("/api/history", apiHandler.serveHistory)
+	mux.HandleFunc("/api/graph/neighborhood", apiHandler.serveNeighborhood)
+	mux.HandleFunc("/api/graph/ingressPaths", apiHandler.serveIngressPaths)
+	mux.HandleFunc("/api/graph/shortestPath", apiHandler.serveShortestPath)
+	mux.HandleFunc("/api/findings", apiHandler.serveFindings)
+	mux.HandleFunc("/api/compliance", apiHandler.serveCompliance)
+	mux.HandleFunc("/api/exposure", apiHandler.serveExposure)
 	mux.HandleFunc("/health", healthCheck)
+	mux.Handle("/debug/vars", expvar.Handler())
 	log.Printf("Listening to incoming requests on %s...\n", address)
 	err := http.ListenAndServe(address, mux)
 	if err != nil {
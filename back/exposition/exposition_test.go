@@ -14,8 +14,9 @@ import (
 
 func TestExpose(t *testing.T) {
 	type args struct {
-		endPoint       string
-		analysisResult types.AnalysisResult
+		endPoint             string
+		analysisResult       types.AnalysisResult
+		otherAnalysisResults []types.AnalysisResult
 	}
 	pod1 := &types.Pod{Name: "pod1", Namespace: "ns", Labels: map[string]string{"k1": "v1"}}
 	pod2 := &types.Pod{Name: "pod2", Namespace: "ns", Labels: map[string]string{"k2": "v2"}}
@@ -27,6 +28,18 @@ func TestExpose(t *testing.T) {
 	networkPolicy2 := types.NetworkPolicy{Name: "in", Namespace: "ns", Labels: map[string]string{"k4": "v4"}}
 	allowedRoute := &types.AllowedRoute{SourcePod: podRef1, EgressPolicies: []types.NetworkPolicy{networkPolicy1},
 		TargetPod: podRef2, IngressPolicies: []types.NetworkPolicy{networkPolicy2}, Ports: []int32{80, 443}}
+	externalRoute := &types.ExternalRoute{Pod: podRef1, Direction: types.TrafficEgress,
+		Peer:     types.ExternalPeer{Kind: types.ExternalPeerFQDN, Name: "api.example.com"},
+		Policies: []types.NetworkPolicy{networkPolicy1}, Ports: []int32{443}}
+	externalNode := &types.ExternalNode{Kind: types.ExternalPeerFQDN, Name: "api.example.com",
+		Addresses: []string{"203.0.113.10"}, Ports: []int32{443},
+		Sources: []types.ObjectRef{{Kind: "ServiceEntry.networking.istio.io", Name: "api", Namespace: "ns"}}}
+	externalAccess := &types.ExternalAccess{Pod: podRef1,
+		Node:     types.ExternalPeer{Kind: types.ExternalPeerFQDN, Name: "api.example.com"},
+		Policies: []types.NetworkPolicy{networkPolicy1}, Ports: []int32{443}}
+	meshRoute := &types.MeshRoute{SourcePod: podRef1, TargetPod: podRef2, Decision: types.MeshDecisionConditional,
+		MTLSMode: "STRICT", MutualTLS: true, Policies: []types.NetworkPolicy{}, Ports: []int32{80},
+		DeniedPorts: []int32{443}}
 	service1 := &types.Service{Name: "svc1", Namespace: "ns", TargetPods: []types.PodRef{podRef1}}
 	service2 := &types.Service{Name: "svc2", Namespace: "ns", TargetPods: []types.PodRef{podRef2}}
 	serviceRef1 := types.ServiceRef{Name: "svc1", Namespace: "ns"}
@@ -51,6 +64,57 @@ func TestExpose(t *testing.T) {
 		ContainersWithoutRestart: 1}
 	podHealth2 := &types.PodHealth{Pod: podRef2, Containers: 2, ContainersRunning: 1, ContainersReady: 0,
 		ContainersWithoutRestart: 2}
+	deploymentHealth := &types.DeploymentHealth{Deployment: types.DeploymentRef{Name: "deploy1", Namespace: "ns"},
+		DesiredReplicas: 2, AvailableReplicas: 1, RolloutInProgress: true, RolloutStuck: true,
+		Status: types.HealthStatusDegraded}
+	statefulSetHealth := &types.StatefulSetHealth{StatefulSet: types.StatefulSetRef{Name: "ss1", Namespace: "ns"},
+		DesiredReplicas: 1, AvailableReplicas: 1, RolloutInProgress: false, Status: types.HealthStatusHealthy}
+	daemonSetHealth := &types.DaemonSetHealth{DaemonSet: types.DaemonSetRef{Name: "ds1", Namespace: "ns"},
+		DesiredReplicas: 3, AvailableReplicas: 0, RolloutInProgress: false, Status: types.HealthStatusDown}
+	serviceHealth := &types.ServiceHealth{Service: serviceRef1, Endpoints: 1, EndpointsAvailable: 1,
+		Status: types.HealthStatusHealthy}
+	objectWarnings := &types.ObjectWarnings{Object: types.ObjectRef{Kind: "Pod", Name: "pod1", Namespace: "ns"},
+		Warnings: []types.Warning{{Reason: "BackOff", Message: "restarting", Count: 3,
+			FirstTimestamp: time.Date(2022, 5, 1, 10, 0, 0, 0, time.UTC),
+			LastTimestamp:  time.Date(2022, 5, 1, 10, 5, 0, 0, time.UTC)}}}
+	podConfiguration := &types.PodConfiguration{Pod: podRef1, QoSClass: "Burstable",
+		Findings: []types.ConfigurationFinding{{Code: "NoMemoryLimit", Severity: types.FindingSeverityWarning,
+			Container: "app", Message: "unbounded"}}}
+	workloadConfiguration := &types.WorkloadConfiguration{
+		Workload: types.ObjectRef{Kind: "Deployment", Name: "deploy1", Namespace: "ns"}, Pods: 2,
+		Findings: podConfiguration.Findings}
+	finding := &types.Finding{ID: "NP001", Severity: types.FindingSeverityWarning,
+		Objects: []types.ObjectRef{{Kind: "NetworkPolicy", Name: "netpol", Namespace: "ns"}},
+		Message: "noselectedpod"}
+	complianceReport := &types.ComplianceReport{
+		Coverage: types.IsolationCoverage{Pods: 2, IngressIsolatedPods: 1, EgressIsolatedPods: 1,
+			IngressIsolatedPercentage: 50, EgressIsolatedPercentage: 50,
+			PodsReachableFromAllNamespaces: []types.PodRef{podRef1}, PodsWithUnrestrictedEgress: []types.PodRef{podRef2}},
+		NamespacesWithoutIngressDefaultDeny: []string{"ns"},
+		NamespacesWithoutEgressDefaultDeny:  []string{},
+		Namespaces: []*types.NamespaceCompliance{{Namespace: "ns", HasIngressDefaultDeny: false,
+			HasEgressDefaultDeny: true, Coverage: types.IsolationCoverage{Pods: 2, IngressIsolatedPods: 1,
+				EgressIsolatedPods: 1, IngressIsolatedPercentage: 50, EgressIsolatedPercentage: 50,
+				PodsReachableFromAllNamespaces: []types.PodRef{podRef1},
+				PodsWithUnrestrictedEgress:     []types.PodRef{podRef2}}}},
+	}
+	expectedCoverage := "{" +
+		"    \"pods\":2,\"ingressIsolatedPods\":1,\"egressIsolatedPods\":1," +
+		"    \"ingressIsolatedPercentage\":50,\"egressIsolatedPercentage\":50," +
+		"    \"podsReachableFromAllNamespaces\":[{\"name\":\"pod1\",\"namespace\":\"ns\"}]," +
+		"    \"podsWithUnrestrictedEgress\":[{\"name\":\"pod2\",\"namespace\":\"ns\"}]" +
+		"}"
+	expectedComplianceReport := "{" +
+		"\"coverage\":" + expectedCoverage + "," +
+		"\"namespacesWithoutIngressDefaultDeny\":[\"ns\"]," +
+		"\"namespacesWithoutEgressDefaultDeny\":[]," +
+		"\"namespaces\":[" +
+		"    {" +
+		"        \"namespace\":\"ns\",\"hasIngressDefaultDeny\":false,\"hasEgressDefaultDeny\":true," +
+		"        \"coverage\":" + expectedCoverage +
+		"    }" +
+		"]" +
+		"}"
 	tests := []struct {
 		name         string
 		args         args
@@ -69,16 +133,29 @@ func TestExpose(t *testing.T) {
 			args: args{
 				endPoint: "/api/analysisResult",
 				analysisResult: types.AnalysisResult{
-					Pods:          []*types.Pod{pod1, pod2},
-					PodIsolations: []*types.PodIsolation{podIsolation1, podIsolation2},
-					AllowedRoutes: []*types.AllowedRoute{allowedRoute},
-					Services:      []*types.Service{service1, service2},
-					Ingresses:     []*types.Ingress{ingress1, ingress2},
-					ReplicaSets:   []*types.ReplicaSet{replicaSet1, replicaSet2},
-					StatefulSets:  []*types.StatefulSet{statefulSet1, statefulSet2},
-					DaemonSets:    []*types.DaemonSet{daemonSet1, daemonSet2},
-					Deployments:   []*types.Deployment{deployment1, deployment2},
-					PodHealths:    []*types.PodHealth{podHealth1, podHealth2},
+					Pods:                   []*types.Pod{pod1, pod2},
+					PodIsolations:          []*types.PodIsolation{podIsolation1, podIsolation2},
+					AllowedRoutes:          []*types.AllowedRoute{allowedRoute},
+					ExternalRoutes:         []*types.ExternalRoute{externalRoute},
+					ExternalNodes:          []*types.ExternalNode{externalNode},
+					ExternalAccesses:       []*types.ExternalAccess{externalAccess},
+					MeshRoutes:             []*types.MeshRoute{meshRoute},
+					Services:               []*types.Service{service1, service2},
+					Ingresses:              []*types.Ingress{ingress1, ingress2},
+					ReplicaSets:            []*types.ReplicaSet{replicaSet1, replicaSet2},
+					StatefulSets:           []*types.StatefulSet{statefulSet1, statefulSet2},
+					DaemonSets:             []*types.DaemonSet{daemonSet1, daemonSet2},
+					Deployments:            []*types.Deployment{deployment1, deployment2},
+					PodHealths:             []*types.PodHealth{podHealth1, podHealth2},
+					DeploymentHealths:      []*types.DeploymentHealth{deploymentHealth},
+					StatefulSetHealths:     []*types.StatefulSetHealth{statefulSetHealth},
+					DaemonSetHealths:       []*types.DaemonSetHealth{daemonSetHealth},
+					ServiceHealths:         []*types.ServiceHealth{serviceHealth},
+					Warnings:               []*types.ObjectWarnings{objectWarnings},
+					PodConfigurations:      []*types.PodConfiguration{podConfiguration},
+					WorkloadConfigurations: []*types.WorkloadConfiguration{workloadConfiguration},
+					Findings:               []*types.Finding{finding},
+					Compliance:             complianceReport,
 				},
 			},
 			expectedBody: "{" +
@@ -107,6 +184,44 @@ func TestExpose(t *testing.T) {
 				"\"ports\":[80,443]" +
 				"    }" +
 				"]," +
+				"\"externalRoutes\":[" +
+				"    {" +
+				"\"pod\":{\"name\":\"pod1\",\"namespace\":\"ns\"}," +
+				"\"direction\":\"egress\"," +
+				"\"peer\":{\"kind\":\"fqdn\",\"name\":\"api.example.com\"}," +
+				"\"policies\":[{\"name\":\"eg\",\"namespace\":\"ns\",\"labels\":{\"k3\":\"v3\"}}]," +
+				"\"ports\":[443]" +
+				"    }" +
+				"]," +
+				"\"externalNodes\":[" +
+				"    {" +
+				"\"kind\":\"fqdn\"," +
+				"\"name\":\"api.example.com\"," +
+				"\"addresses\":[\"203.0.113.10\"]," +
+				"\"ports\":[443]," +
+				"\"sources\":[{\"kind\":\"ServiceEntry.networking.istio.io\",\"name\":\"api\",\"namespace\":\"ns\"}]" +
+				"    }" +
+				"]," +
+				"\"externalAccesses\":[" +
+				"    {" +
+				"\"pod\":{\"name\":\"pod1\",\"namespace\":\"ns\"}," +
+				"\"node\":{\"kind\":\"fqdn\",\"name\":\"api.example.com\"}," +
+				"\"policies\":[{\"name\":\"eg\",\"namespace\":\"ns\",\"labels\":{\"k3\":\"v3\"}}]," +
+				"\"ports\":[443]" +
+				"    }" +
+				"]," +
+				"\"meshRoutes\":[" +
+				"    {" +
+				"\"sourcePod\":{\"name\":\"pod1\",\"namespace\":\"ns\"}," +
+				"\"targetPod\":{\"name\":\"pod2\",\"namespace\":\"ns\"}," +
+				"\"decision\":\"conditional\"," +
+				"\"mtlsMode\":\"STRICT\"," +
+				"\"mutualTls\":true," +
+				"\"policies\":[]," +
+				"\"ports\":[80]," +
+				"\"deniedPorts\":[443]" +
+				"    }" +
+				"]," +
 				"\"services\":[" +
 				"    {" +
 				"        \"name\":\"svc1\"," +
@@ -194,16 +309,217 @@ func TestExpose(t *testing.T) {
 				"        \"containersReady\":0," +
 				"        \"containersWithoutRestart\":2" +
 				"    }" +
+				"]," +
+				"\"deploymentHealths\":[" +
+				"    {" +
+				"        \"deployment\":{\"name\":\"deploy1\",\"namespace\":\"ns\"}," +
+				"        \"desiredReplicas\":2," +
+				"        \"availableReplicas\":1," +
+				"        \"rolloutInProgress\":true," +
+				"        \"rolloutStuck\":true," +
+				"        \"status\":\"degraded\"" +
+				"    }" +
+				"]," +
+				"\"statefulSetHealths\":[" +
+				"    {" +
+				"        \"statefulSet\":{\"name\":\"ss1\",\"namespace\":\"ns\"}," +
+				"        \"desiredReplicas\":1," +
+				"        \"availableReplicas\":1," +
+				"        \"rolloutInProgress\":false," +
+				"        \"status\":\"healthy\"" +
+				"    }" +
+				"]," +
+				"\"daemonSetHealths\":[" +
+				"    {" +
+				"        \"daemonSet\":{\"name\":\"ds1\",\"namespace\":\"ns\"}," +
+				"        \"desiredReplicas\":3," +
+				"        \"availableReplicas\":0," +
+				"        \"rolloutInProgress\":false," +
+				"        \"status\":\"down\"" +
+				"    }" +
+				"]," +
+				"\"serviceHealths\":[" +
+				"    {" +
+				"        \"service\":{\"name\":\"svc1\",\"namespace\":\"ns\"}," +
+				"        \"endpoints\":1," +
+				"        \"endpointsAvailable\":1," +
+				"        \"status\":\"healthy\"" +
+				"    }" +
+				"]," +
+				"\"warnings\":[" +
+				"    {" +
+				"        \"object\":{\"kind\":\"Pod\",\"name\":\"pod1\",\"namespace\":\"ns\"}," +
+				"        \"warnings\":[{" +
+				"            \"reason\":\"BackOff\"," +
+				"            \"message\":\"restarting\"," +
+				"            \"count\":3," +
+				"            \"firstTimestamp\":\"2022-05-01T10:00:00Z\"," +
+				"            \"lastTimestamp\":\"2022-05-01T10:05:00Z\"" +
+				"        }]" +
+				"    }" +
+				"]," +
+				"\"podConfigurations\":[" +
+				"    {" +
+				"        \"pod\":{\"name\":\"pod1\",\"namespace\":\"ns\"}," +
+				"        \"qosClass\":\"Burstable\"," +
+				"        \"findings\":[{" +
+				"            \"code\":\"NoMemoryLimit\",\"severity\":\"warning\"," +
+				"            \"container\":\"app\",\"message\":\"unbounded\"" +
+				"        }]" +
+				"    }" +
+				"]," +
+				"\"workloadConfigurations\":[" +
+				"    {" +
+				"        \"workload\":{\"kind\":\"Deployment\",\"name\":\"deploy1\",\"namespace\":\"ns\"}," +
+				"        \"pods\":2," +
+				"        \"findings\":[{" +
+				"            \"code\":\"NoMemoryLimit\",\"severity\":\"warning\"," +
+				"            \"container\":\"app\",\"message\":\"unbounded\"" +
+				"        }]" +
+				"    }" +
+				"]," +
+				"\"findings\":[" +
+				"    {" +
+				"        \"id\":\"NP001\",\"severity\":\"warning\"," +
+				"        \"objects\":[{\"kind\":\"NetworkPolicy\",\"name\":\"netpol\",\"namespace\":\"ns\"}]," +
+				"        \"message\":\"noselectedpod\"" +
+				"    }" +
+				"]," +
+				"\"compliance\":" + expectedComplianceReport +
+				"}\n",
+		},
+		{
+			name: "exposes the findings of the last published analysis result",
+			args: args{
+				endPoint: "/api/findings",
+				analysisResult: types.AnalysisResult{
+					Findings: []*types.Finding{finding},
+				},
+			},
+			expectedBody: "[" +
+				"    {" +
+				"        \"id\":\"NP001\",\"severity\":\"warning\"," +
+				"        \"objects\":[{\"kind\":\"NetworkPolicy\",\"name\":\"netpol\",\"namespace\":\"ns\"}]," +
+				"        \"message\":\"noselectedpod\"" +
+				"    }" +
+				"]\n",
+		},
+		{
+			name: "exposes the compliance report of the last published analysis result",
+			args: args{
+				endPoint: "/api/compliance",
+				analysisResult: types.AnalysisResult{
+					Compliance: complianceReport,
+				},
+			},
+			expectedBody: expectedComplianceReport + "\n",
+		},
+		{
+			name: "exposes the exposed pods of the last published analysis result at or above the requested risk",
+			args: args{
+				endPoint: "/api/exposure?minRisk=high",
+				analysisResult: types.AnalysisResult{
+					ExposedPods: []*types.ExposedPod{
+						{Pod: podRef2, Hops: 1, EntryPoints: []types.ObjectRef{{Kind: "Ingress", Name: "ing1",
+							Namespace: "ns"}}, Path: []types.PodRef{podRef1, podRef2}, Secrets: []string{},
+							Privileged: true, Risk: types.ExposureRiskHigh},
+						{Pod: podRef1, Hops: 0, EntryPoints: []types.ObjectRef{{Kind: "Ingress", Name: "ing1",
+							Namespace: "ns"}}, Path: []types.PodRef{podRef1}, Secrets: []string{},
+							Risk: types.ExposureRiskLow},
+					},
+				},
+			},
+			expectedBody: "[" +
+				"    {" +
+				"        \"pod\":{\"name\":\"pod2\",\"namespace\":\"ns\"},\"hops\":1," +
+				"        \"entryPoints\":[{\"kind\":\"Ingress\",\"name\":\"ing1\",\"namespace\":\"ns\"}]," +
+				"        \"path\":[{\"name\":\"pod1\",\"namespace\":\"ns\"},{\"name\":\"pod2\",\"namespace\":\"ns\"}]," +
+				"        \"secrets\":[],\"privileged\":true,\"risk\":\"high\"" +
+				"    }" +
+				"]\n",
+		},
+		{
+			name: "exposes the last published analysis result of the requested cluster",
+			args: args{
+				endPoint:       "/api/findings?cluster=staging",
+				analysisResult: types.AnalysisResult{Cluster: "prod", Findings: []*types.Finding{finding}},
+				otherAnalysisResults: []types.AnalysisResult{
+					{Cluster: "staging", Findings: []*types.Finding{}},
+				},
+			},
+			expectedBody: "[]\n",
+		},
+		{
+			name: "exposes the requested sections of the analysis result filtered by pod",
+			args: args{
+				endPoint: "/api/analysisResult?sections=pods,podIsolations&name=pod1",
+				analysisResult: types.AnalysisResult{
+					Pods:          []*types.Pod{pod1, pod2},
+					PodIsolations: []*types.PodIsolation{podIsolation1, podIsolation2},
+					AllowedRoutes: []*types.AllowedRoute{allowedRoute},
+				},
+			},
+			expectedBody: "{" +
+				"\"podIsolations\":[{\"pod\":{\"name\":\"pod1\",\"namespace\":\"ns\"}," +
+				"\"isIngressIsolated\":false,\"isEgressIsolated\":true}]," +
+				"\"pods\":[{\"name\":\"pod1\",\"namespace\":\"ns\",\"labels\":{\"k1\":\"v1\"}}]" +
+				"}\n",
+		},
+		{
+			name: "exposes the neighborhood of a node of the analysis graph",
+			args: args{
+				endPoint: "/api/graph/neighborhood?node=Pod/ns/pod1&hops=1",
+				analysisResult: types.AnalysisResult{
+					Pods:          []*types.Pod{pod1, pod2},
+					AllowedRoutes: []*types.AllowedRoute{allowedRoute},
+				},
+			},
+			expectedBody: "{" +
+				"\"nodes\":[" +
+				"    {\"kind\":\"Pod\",\"name\":\"pod1\",\"namespace\":\"ns\"}," +
+				"    {\"kind\":\"Pod\",\"name\":\"pod2\",\"namespace\":\"ns\"}" +
+				"]," +
+				"\"edges\":[" +
+				"    {" +
+				"        \"source\":{\"kind\":\"Pod\",\"name\":\"pod1\",\"namespace\":\"ns\"}," +
+				"        \"target\":{\"kind\":\"Pod\",\"name\":\"pod2\",\"namespace\":\"ns\"}," +
+				"        \"kind\":\"allows\",\"ports\":[80,443]" +
+				"    }" +
 				"]" +
 				"}\n",
 		},
+		{
+			name: "exposes the analysis result published before the requested time",
+			args: args{
+				endPoint:       "/api/findings?cluster=staging&at=2100-01-01T00:00:00Z",
+				analysisResult: types.AnalysisResult{Cluster: "staging", Findings: []*types.Finding{finding}},
+				otherAnalysisResults: []types.AnalysisResult{
+					{Cluster: "staging", Findings: []*types.Finding{}},
+				},
+			},
+			expectedBody: "[]\n",
+		},
+		{
+			name: "lists the clusters having published an analysis result",
+			args: args{
+				endPoint:       "/api/clusters",
+				analysisResult: types.AnalysisResult{Cluster: "staging"},
+				otherAnalysisResults: []types.AnalysisResult{
+					{Cluster: "prod"},
+				},
+			},
+			expectedBody: "[\"prod\",\"staging\"]\n",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			address := "localhost:" + strconv.Itoa(findAvailablePort())
 			resultsChannel := make(chan types.AnalysisResult)
-			go Expose(address, resultsChannel)
+			go Expose(address, HistoryConfig{Size: 10}, resultsChannel)
 			resultsChannel <- tt.args.analysisResult
+			for _, analysisResult := range tt.args.otherAnalysisResults {
+				resultsChannel <- analysisResult
+			}
 			time.Sleep(10 * time.Millisecond)
 			response, _ := http.Get("http://" + address + tt.args.endPoint)
 			defer func() {
@@ -0,0 +1,197 @@
+package exposition
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"karto/types"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type HistoryConfig struct {
+	Size     int
+	FilePath string
+}
+
+type historyRecord struct {
+	Cluster string             `json:"cluster,omitempty"`
+	Entry   types.HistoryEntry `json:"entry"`
+	Result  json.RawMessage    `json:"result"`
+}
+
+type history struct {
+	size        int
+	filePath    string
+	records     map[string][]historyRecord
+	fileRecords int
+}
+
+func newHistory(config HistoryConfig) *history {
+	history := &history{
+		size:     config.Size,
+		filePath: config.FilePath,
+		records:  map[string][]historyRecord{},
+	}
+	if history.filePath != "" && history.size > 0 {
+		if err := history.load(); err != nil {
+			log.Printf("Unable to load the analysis history from %s, starting from scratch: %s\n",
+				history.filePath, err)
+		}
+	}
+	return history
+}
+
+func (history *history) add(timestamp time.Time, analysisResult types.AnalysisResult) {
+	record, ok := history.newRecord(timestamp, analysisResult)
+	if ok && history.keep(record) {
+		history.persist(record)
+	}
+}
+
+func (history *history) newRecord(timestamp time.Time, analysisResult types.AnalysisResult) (historyRecord, bool) {
+	if history.size <= 0 {
+		return historyRecord{}, false
+	}
+	content, err := json.Marshal(analysisResult)
+	if err != nil {
+		log.Println(err)
+		return historyRecord{}, false
+	}
+	return historyRecord{
+		Cluster: analysisResult.Cluster,
+		Entry: types.HistoryEntry{
+			Timestamp:     timestamp,
+			Pods:          len(analysisResult.Pods),
+			AllowedRoutes: len(analysisResult.AllowedRoutes),
+			Findings:      len(analysisResult.Findings),
+		},
+		Result: content,
+	}, true
+}
+
+func (history *history) keep(record historyRecord) bool {
+	records := history.records[record.Cluster]
+	if len(records) > 0 && bytes.Equal(records[len(records)-1].Result, record.Result) {
+		return false
+	}
+	records = append(records, record)
+	if len(records) > history.size {
+		records = append(make([]historyRecord, 0, history.size), records[len(records)-history.size:]...)
+	}
+	history.records[record.Cluster] = records
+	return true
+}
+
+func (history *history) entries(cluster string) []types.HistoryEntry {
+	records := history.records[cluster]
+	result := make([]types.HistoryEntry, 0, len(records))
+	for _, record := range records {
+		result = append(result, record.Entry)
+	}
+	return result
+}
+
+func (history *history) analysisResultAt(cluster string, timestamp time.Time) (types.AnalysisResult, bool) {
+	records := history.records[cluster]
+	for i := len(records) - 1; i >= 0; i-- {
+		if records[i].Entry.Timestamp.After(timestamp) {
+			continue
+		}
+		analysisResult := types.AnalysisResult{}
+		if err := json.Unmarshal(records[i].Result, &analysisResult); err != nil {
+			log.Println(err)
+			return analysisResult, false
+		}
+		return analysisResult, true
+	}
+	return types.AnalysisResult{}, false
+}
+
+func (history *history) load() error {
+	file, err := os.Open(history.filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	decoder := json.NewDecoder(file)
+	for {
+		record := historyRecord{}
+		err = decoder.Decode(&record)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		history.keep(record)
+		history.fileRecords++
+	}
+}
+
+func (history *history) persist(record historyRecord) {
+	if history.filePath == "" {
+		return
+	}
+	var err error
+	if history.fileRecords >= 2*history.size*len(history.records) {
+		err = history.rewriteFile()
+	} else {
+		err = history.appendToFile(record)
+	}
+	if err != nil {
+		log.Printf("Unable to save the analysis history to %s: %s\n", history.filePath, err)
+	}
+}
+
+func (history *history) appendToFile(record historyRecord) error {
+	file, err := os.OpenFile(history.filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	err = json.NewEncoder(file).Encode(record)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		history.fileRecords++
+	}
+	return err
+}
+
+func (history *history) rewriteFile() error {
+	file, err := os.CreateTemp(filepath.Dir(history.filePath), filepath.Base(history.filePath)+".*")
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(file)
+	fileRecords := 0
+	for _, records := range history.records {
+		for _, record := range records {
+			if err = encoder.Encode(record); err != nil {
+				break
+			}
+			fileRecords++
+		}
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), history.filePath)
+	}
+	if err != nil {
+		_ = os.Remove(file.Name())
+		return err
+	}
+	history.fileRecords = fileRecords
+	return nil
+}
@@ -0,0 +1,104 @@
+package exposition
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"karto/types"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHistory(t *testing.T) {
+	type args struct {
+		size            int
+		analysisResults []types.AnalysisResult
+		at              time.Time
+	}
+	start := time.Date(2022, 5, 1, 10, 0, 0, 0, time.UTC)
+	pod1 := &types.Pod{Name: "pod1", Namespace: "ns"}
+	pod2 := &types.Pod{Name: "pod2", Namespace: "ns"}
+	tests := []struct {
+		name                   string
+		args                   args
+		expectedEntries        []types.HistoryEntry
+		expectedAnalysisResult *types.AnalysisResult
+	}{
+		{
+			name: "keeps only the analysis results that changed",
+			args: args{
+				size: 10,
+				analysisResults: []types.AnalysisResult{
+					{Pods: []*types.Pod{pod1}},
+					{Pods: []*types.Pod{pod1}},
+					{Pods: []*types.Pod{pod1, pod2}},
+				},
+				at: start.Add(90 * time.Second),
+			},
+			expectedEntries: []types.HistoryEntry{
+				{Timestamp: start, Pods: 1},
+				{Timestamp: start.Add(2 * time.Minute), Pods: 2},
+			},
+			expectedAnalysisResult: &types.AnalysisResult{Pods: []*types.Pod{pod1}},
+		},
+		{
+			name: "drops the oldest analysis results beyond the history size",
+			args: args{
+				size: 2,
+				analysisResults: []types.AnalysisResult{
+					{Pods: []*types.Pod{pod1}},
+					{Pods: []*types.Pod{pod1, pod2}},
+					{Pods: []*types.Pod{pod2}},
+				},
+				at: start.Add(30 * time.Second),
+			},
+			expectedEntries: []types.HistoryEntry{
+				{Timestamp: start.Add(time.Minute), Pods: 2},
+				{Timestamp: start.Add(2 * time.Minute), Pods: 1},
+			},
+			expectedAnalysisResult: nil,
+		},
+		{
+			name: "compacts the history file once it holds twice the history size",
+			args: args{
+				size: 1,
+				analysisResults: []types.AnalysisResult{
+					{Pods: []*types.Pod{pod1}},
+					{Pods: []*types.Pod{pod2}},
+					{Pods: []*types.Pod{pod1, pod2}},
+					{Pods: []*types.Pod{}},
+				},
+				at: start.Add(time.Hour),
+			},
+			expectedEntries: []types.HistoryEntry{
+				{Timestamp: start.Add(3 * time.Minute), Pods: 0},
+			},
+			expectedAnalysisResult: &types.AnalysisResult{Pods: []*types.Pod{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "history.json")
+			savedHistory := newHistory(HistoryConfig{Size: tt.args.size, FilePath: filePath})
+			for i, analysisResult := range tt.args.analysisResults {
+				savedHistory.add(start.Add(time.Duration(i)*time.Minute), analysisResult)
+			}
+			if savedHistory.fileRecords > 2*tt.args.size {
+				t.Errorf("add() expected at most %d records in file, got %d", 2*tt.args.size, savedHistory.fileRecords)
+			}
+			reloadedHistory := newHistory(HistoryConfig{Size: tt.args.size, FilePath: filePath})
+			for _, history := range []*history{savedHistory, reloadedHistory} {
+				if diff := cmp.Diff(tt.expectedEntries, history.entries("")); diff != "" {
+					t.Errorf("entries() result mismatch (-want +got):\n%s", diff)
+				}
+				analysisResult, ok := history.analysisResultAt("", tt.args.at)
+				if tt.expectedAnalysisResult == nil {
+					if ok {
+						t.Errorf("analysisResultAt() expected no result, got %v", analysisResult)
+					}
+				} else if diff := cmp.Diff(*tt.expectedAnalysisResult, analysisResult); diff != "" {
+					t.Errorf("analysisResultAt() result mismatch (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
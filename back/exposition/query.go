@@ -0,0 +1,273 @@
+package exposition
+
+import (
+	"encoding/json"
+	"fmt"
+	"k8s.io/apimachinery/pkg/labels"
+	"karto/commons"
+	"karto/types"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	podKind         = "Pod"
+	serviceKind     = "Service"
+	ingressKind     = "Ingress"
+	replicaSetKind  = "ReplicaSet"
+	statefulSetKind = "StatefulSet"
+	daemonSetKind   = "DaemonSet"
+	deploymentKind  = "Deployment"
+)
+
+var filterParameters = []string{"namespace", "labelSelector", "name", "ingressNeighbors", "egressNeighbors",
+	"offset", "limit"}
+
+type resultQuery struct {
+	namespaces       []string
+	labelSelector    labels.Selector
+	namePattern      *regexp.Regexp
+	ingressNeighbors int
+	egressNeighbors  int
+	offset           int
+	limit            int
+	sections         []string
+	filtering        bool
+}
+
+func parseResultQuery(values url.Values) (*resultQuery, error) {
+	query := &resultQuery{
+		namespaces:    listParameter(values, "namespace"),
+		labelSelector: labels.Everything(),
+		sections:      listParameter(values, "sections"),
+		filtering: commons.AnyMatch(filterParameters, func(parameter string) bool {
+			return values.Get(parameter) != ""
+		}),
+	}
+	var err error
+	if labelSelector := values.Get("labelSelector"); labelSelector != "" {
+		if query.labelSelector, err = labels.Parse(labelSelector); err != nil {
+			return nil, fmt.Errorf("invalid label selector %s: %w", labelSelector, err)
+		}
+	}
+	if name := values.Get("name"); name != "" {
+		if query.namePattern, err = regexp.Compile(name); err != nil {
+			return nil, fmt.Errorf("invalid name pattern %s: %w", name, err)
+		}
+	}
+	for parameter, value := range map[string]*int{
+		"ingressNeighbors": &query.ingressNeighbors,
+		"egressNeighbors":  &query.egressNeighbors,
+		"offset":           &query.offset,
+		"limit":            &query.limit,
+	} {
+		if values.Get(parameter) == "" {
+			continue
+		}
+		if *value, err = strconv.Atoi(values.Get(parameter)); err != nil || *value < 0 {
+			return nil, fmt.Errorf("invalid %s %s, expected a positive integer", parameter, values.Get(parameter))
+		}
+	}
+	return query, nil
+}
+
+func listParameter(values url.Values, parameter string) []string {
+	result := make([]string, 0)
+	for _, value := range values[parameter] {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				result = append(result, item)
+			}
+		}
+	}
+	return result
+}
+
+func (query *resultQuery) matches(pod *types.Pod) bool {
+	if len(query.namespaces) > 0 && !commons.AnyMatch(query.namespaces, func(namespace string) bool {
+		return namespace == pod.Namespace
+	}) {
+		return false
+	}
+	if query.namePattern != nil && !query.namePattern.MatchString(pod.Name) {
+		return false
+	}
+	return query.labelSelector.Matches(labels.Set(pod.Labels))
+}
+
+func (query *resultQuery) selectPods(pods []*types.Pod) ([]*types.Pod, int) {
+	matchingPods := commons.Filter(pods, query.matches)
+	total := len(matchingPods)
+	if query.offset >= total {
+		return []*types.Pod{}, total
+	}
+	matchingPods = matchingPods[query.offset:]
+	if query.limit > 0 && query.limit < len(matchingPods) {
+		matchingPods = matchingPods[:query.limit]
+	}
+	return matchingPods, total
+}
+
+func (query *resultQuery) neighbors(selectedPods *commons.Set[types.PodRef],
+	allowedRoutes []*types.AllowedRoute) *commons.Set[types.PodRef] {
+	result := commons.NewSet[types.PodRef]()
+	for _, podRef := range selectedPods.ToSlice() {
+		result.Add(podRef)
+	}
+	expand := func(hops int, from func(*types.AllowedRoute) types.PodRef, to func(*types.AllowedRoute) types.PodRef) {
+		frontier := selectedPods
+		for hop := 0; hop < hops && frontier.Size() > 0; hop++ {
+			nextFrontier := commons.NewSet[types.PodRef]()
+			for _, allowedRoute := range allowedRoutes {
+				if frontier.Contains(to(allowedRoute)) && !result.Contains(from(allowedRoute)) {
+					result.Add(from(allowedRoute))
+					nextFrontier.Add(from(allowedRoute))
+				}
+			}
+			frontier = nextFrontier
+		}
+	}
+	expand(query.ingressNeighbors,
+		func(allowedRoute *types.AllowedRoute) types.PodRef { return allowedRoute.SourcePod },
+		func(allowedRoute *types.AllowedRoute) types.PodRef { return allowedRoute.TargetPod })
+	expand(query.egressNeighbors,
+		func(allowedRoute *types.AllowedRoute) types.PodRef { return allowedRoute.TargetPod },
+		func(allowedRoute *types.AllowedRoute) types.PodRef { return allowedRoute.SourcePod })
+	return result
+}
+
+func (query *resultQuery) filter(analysisResult types.AnalysisResult) (types.AnalysisResult, int) {
+	selectedPods, total := query.selectPods(analysisResult.Pods)
+	selectedPodRefs := commons.NewSet[types.PodRef]()
+	for _, pod := range selectedPods {
+		selectedPodRefs.Add(types.PodRef{Name: pod.Name, Namespace: pod.Namespace})
+	}
+	visiblePods := query.neighbors(selectedPodRefs, analysisResult.AllowedRoutes)
+	isVisible := func(podRef types.PodRef) bool { return visiblePods.Contains(podRef) }
+	isSelected := func(podRefs []types.PodRef) bool { return commons.AnyMatch(podRefs, selectedPodRefs.Contains) }
+	objects := commons.NewSet[types.ObjectRef]()
+	keep := func(kind string, name string, namespace string, kept bool) bool {
+		if kept {
+			objects.Add(types.ObjectRef{Kind: kind, Name: name, Namespace: namespace})
+		}
+		return kept
+	}
+	isKept := func(kind string, name string, namespace string) bool {
+		return objects.Contains(types.ObjectRef{Kind: kind, Name: name, Namespace: namespace})
+	}
+	result := analysisResult
+	result.Pods = commons.Filter(analysisResult.Pods, func(pod *types.Pod) bool {
+		return keep(podKind, pod.Name, pod.Namespace, isVisible(types.PodRef{Name: pod.Name, Namespace: pod.Namespace}))
+	})
+	result.PodIsolations = commons.Filter(analysisResult.PodIsolations, func(podIsolation *types.PodIsolation) bool {
+		return isVisible(podIsolation.Pod)
+	})
+	result.AllowedRoutes = commons.Filter(analysisResult.AllowedRoutes, func(allowedRoute *types.AllowedRoute) bool {
+		return isVisible(allowedRoute.SourcePod) && isVisible(allowedRoute.TargetPod)
+	})
+	result.ExternalRoutes = commons.Filter(analysisResult.ExternalRoutes, func(route *types.ExternalRoute) bool {
+		return isVisible(route.Pod)
+	})
+	result.ExternalAccesses = commons.Filter(analysisResult.ExternalAccesses, func(access *types.ExternalAccess) bool {
+		return isVisible(access.Pod)
+	})
+	result.MeshRoutes = commons.Filter(analysisResult.MeshRoutes, func(route *types.MeshRoute) bool {
+		return isVisible(route.SourcePod) && isVisible(route.TargetPod)
+	})
+	if analysisResult.CrossClusterRoutes != nil {
+		result.CrossClusterRoutes = commons.Filter(analysisResult.CrossClusterRoutes,
+			func(route *types.CrossClusterRoute) bool {
+				return route.SourceCluster == analysisResult.Cluster && isVisible(route.SourcePod) ||
+					route.TargetCluster == analysisResult.Cluster && isVisible(route.TargetPod)
+			})
+	}
+	if analysisResult.ExposedPods != nil {
+		result.ExposedPods = commons.Filter(analysisResult.ExposedPods, func(exposedPod *types.ExposedPod) bool {
+			return isVisible(exposedPod.Pod)
+		})
+	}
+	result.Services = commons.Filter(analysisResult.Services, func(service *types.Service) bool {
+		return keep(serviceKind, service.Name, service.Namespace, isSelected(service.TargetPods))
+	})
+	result.Ingresses = commons.Filter(analysisResult.Ingresses, func(ingress *types.Ingress) bool {
+		return keep(ingressKind, ingress.Name, ingress.Namespace, commons.AnyMatch(ingress.TargetServices,
+			func(service types.ServiceRef) bool { return isKept(serviceKind, service.Name, service.Namespace) }))
+	})
+	result.ReplicaSets = commons.Filter(analysisResult.ReplicaSets, func(replicaSet *types.ReplicaSet) bool {
+		return keep(replicaSetKind, replicaSet.Name, replicaSet.Namespace, isSelected(replicaSet.TargetPods))
+	})
+	result.StatefulSets = commons.Filter(analysisResult.StatefulSets, func(statefulSet *types.StatefulSet) bool {
+		return keep(statefulSetKind, statefulSet.Name, statefulSet.Namespace, isSelected(statefulSet.TargetPods))
+	})
+	result.DaemonSets = commons.Filter(analysisResult.DaemonSets, func(daemonSet *types.DaemonSet) bool {
+		return keep(daemonSetKind, daemonSet.Name, daemonSet.Namespace, isSelected(daemonSet.TargetPods))
+	})
+	result.Deployments = commons.Filter(analysisResult.Deployments, func(deployment *types.Deployment) bool {
+		return keep(deploymentKind, deployment.Name, deployment.Namespace, commons.AnyMatch(
+			deployment.TargetReplicaSets, func(replicaSet types.ReplicaSetRef) bool {
+				return isKept(replicaSetKind, replicaSet.Name, replicaSet.Namespace)
+			}))
+	})
+	result.PodHealths = commons.Filter(analysisResult.PodHealths, func(podHealth *types.PodHealth) bool {
+		return isVisible(podHealth.Pod)
+	})
+	result.DeploymentHealths = commons.Filter(analysisResult.DeploymentHealths,
+		func(health *types.DeploymentHealth) bool {
+			return isKept(deploymentKind, health.Deployment.Name, health.Deployment.Namespace)
+		})
+	result.StatefulSetHealths = commons.Filter(analysisResult.StatefulSetHealths,
+		func(health *types.StatefulSetHealth) bool {
+			return isKept(statefulSetKind, health.StatefulSet.Name, health.StatefulSet.Namespace)
+		})
+	result.DaemonSetHealths = commons.Filter(analysisResult.DaemonSetHealths, func(health *types.DaemonSetHealth) bool {
+		return isKept(daemonSetKind, health.DaemonSet.Name, health.DaemonSet.Namespace)
+	})
+	result.ServiceHealths = commons.Filter(analysisResult.ServiceHealths, func(health *types.ServiceHealth) bool {
+		return isKept(serviceKind, health.Service.Name, health.Service.Namespace)
+	})
+	result.Warnings = commons.Filter(analysisResult.Warnings, func(warnings *types.ObjectWarnings) bool {
+		return objects.Contains(warnings.Object)
+	})
+	result.PodConfigurations = commons.Filter(analysisResult.PodConfigurations,
+		func(podConfiguration *types.PodConfiguration) bool {
+			return isVisible(podConfiguration.Pod)
+		})
+	result.WorkloadConfigurations = commons.Filter(analysisResult.WorkloadConfigurations,
+		func(workloadConfiguration *types.WorkloadConfiguration) bool {
+			return objects.Contains(workloadConfiguration.Workload)
+		})
+	if analysisResult.PodSecurities != nil {
+		result.PodSecurities = commons.Filter(analysisResult.PodSecurities, func(podSecurity *types.PodSecurity) bool {
+			return isVisible(podSecurity.Pod)
+		})
+	}
+	return result, total
+}
+
+func (query *resultQuery) selectSections(analysisResult types.AnalysisResult) (interface{}, error) {
+	if len(query.sections) == 0 {
+		return analysisResult, nil
+	}
+	content, err := json.Marshal(analysisResult)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]json.RawMessage{}
+	if err = json.Unmarshal(content, &fields); err != nil {
+		return nil, err
+	}
+	result := map[string]json.RawMessage{}
+	if cluster, ok := fields["cluster"]; ok {
+		result["cluster"] = cluster
+	}
+	for _, section := range query.sections {
+		value, ok := fields[section]
+		if !ok {
+			return nil, fmt.Errorf("unknown section %s", section)
+		}
+		result[section] = value
+	}
+	return result, nil
+}
@@ -0,0 +1,166 @@
+package exposition
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"karto/types"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestResultQueryFilter(t *testing.T) {
+	type args struct {
+		query string
+	}
+	podA := &types.Pod{Name: "pod-a", Namespace: "ns1", Labels: map[string]string{"app": "web"}}
+	podB := &types.Pod{Name: "pod-b", Namespace: "ns1", Labels: map[string]string{"app": "api"}}
+	podC := &types.Pod{Name: "pod-c", Namespace: "ns2", Labels: map[string]string{"app": "db"}}
+	podRefA := types.PodRef{Name: podA.Name, Namespace: podA.Namespace}
+	podRefB := types.PodRef{Name: podB.Name, Namespace: podB.Namespace}
+	podRefC := types.PodRef{Name: podC.Name, Namespace: podC.Namespace}
+	routeAB := &types.AllowedRoute{SourcePod: podRefA, TargetPod: podRefB, Ports: []int32{8080}}
+	routeBC := &types.AllowedRoute{SourcePod: podRefB, TargetPod: podRefC, Ports: []int32{5432}}
+	service := &types.Service{Name: "api", Namespace: "ns1", TargetPods: []types.PodRef{podRefB}}
+	ingress := &types.Ingress{Name: "api", Namespace: "ns1",
+		TargetServices: []types.ServiceRef{{Name: "api", Namespace: "ns1"}}}
+	replicaSet := &types.ReplicaSet{Name: "web-1", Namespace: "ns1", TargetPods: []types.PodRef{podRefA}}
+	deployment := &types.Deployment{Name: "web", Namespace: "ns1",
+		TargetReplicaSets: []types.ReplicaSetRef{{Name: "web-1", Namespace: "ns1"}}}
+	serviceHealth := &types.ServiceHealth{Service: types.ServiceRef{Name: "api", Namespace: "ns1"}}
+	podWarnings := &types.ObjectWarnings{Object: types.ObjectRef{Kind: "Pod", Name: "pod-c", Namespace: "ns2"}}
+	analysisResult := types.AnalysisResult{
+		Pods:           []*types.Pod{podA, podB, podC},
+		AllowedRoutes:  []*types.AllowedRoute{routeAB, routeBC},
+		Services:       []*types.Service{service},
+		Ingresses:      []*types.Ingress{ingress},
+		ReplicaSets:    []*types.ReplicaSet{replicaSet},
+		Deployments:    []*types.Deployment{deployment},
+		ServiceHealths: []*types.ServiceHealth{serviceHealth},
+		Warnings:       []*types.ObjectWarnings{podWarnings},
+	}
+	tests := []struct {
+		name                 string
+		args                 args
+		expectedPods         []*types.Pod
+		expectedRoutes       []*types.AllowedRoute
+		expectedServices     []*types.Service
+		expectedIngresses    []*types.Ingress
+		expectedReplicaSets  []*types.ReplicaSet
+		expectedDeployments  []*types.Deployment
+		expectedHealths      []*types.ServiceHealth
+		expectedWarnings     []*types.ObjectWarnings
+		expectedMatchingPods int
+	}{
+		{
+			name:                 "keeps the pods matching the namespace and label selector and their workloads",
+			args:                 args{query: "namespace=ns1&labelSelector=app%20in%20(api)"},
+			expectedPods:         []*types.Pod{podB},
+			expectedRoutes:       []*types.AllowedRoute{},
+			expectedServices:     []*types.Service{service},
+			expectedIngresses:    []*types.Ingress{ingress},
+			expectedReplicaSets:  []*types.ReplicaSet{},
+			expectedDeployments:  []*types.Deployment{},
+			expectedHealths:      []*types.ServiceHealth{serviceHealth},
+			expectedWarnings:     []*types.ObjectWarnings{},
+			expectedMatchingPods: 1,
+		},
+		{
+			name:                 "expands the pods matching the name pattern to their egress neighbors",
+			args:                 args{query: "name=-b$&egressNeighbors=1"},
+			expectedPods:         []*types.Pod{podB, podC},
+			expectedRoutes:       []*types.AllowedRoute{routeBC},
+			expectedServices:     []*types.Service{service},
+			expectedIngresses:    []*types.Ingress{ingress},
+			expectedReplicaSets:  []*types.ReplicaSet{},
+			expectedDeployments:  []*types.Deployment{},
+			expectedHealths:      []*types.ServiceHealth{serviceHealth},
+			expectedWarnings:     []*types.ObjectWarnings{podWarnings},
+			expectedMatchingPods: 1,
+		},
+		{
+			name:                 "expands the matching pods to ingress neighbors over several hops",
+			args:                 args{query: "name=-c$&ingressNeighbors=2"},
+			expectedPods:         []*types.Pod{podA, podB, podC},
+			expectedRoutes:       []*types.AllowedRoute{routeAB, routeBC},
+			expectedServices:     []*types.Service{},
+			expectedIngresses:    []*types.Ingress{},
+			expectedReplicaSets:  []*types.ReplicaSet{},
+			expectedDeployments:  []*types.Deployment{},
+			expectedHealths:      []*types.ServiceHealth{},
+			expectedWarnings:     []*types.ObjectWarnings{podWarnings},
+			expectedMatchingPods: 1,
+		},
+		{
+			name:                 "pages through the matching pods",
+			args:                 args{query: "namespace=ns1&offset=0&limit=1"},
+			expectedPods:         []*types.Pod{podA},
+			expectedRoutes:       []*types.AllowedRoute{},
+			expectedServices:     []*types.Service{},
+			expectedIngresses:    []*types.Ingress{},
+			expectedReplicaSets:  []*types.ReplicaSet{replicaSet},
+			expectedDeployments:  []*types.Deployment{deployment},
+			expectedHealths:      []*types.ServiceHealth{},
+			expectedWarnings:     []*types.ObjectWarnings{},
+			expectedMatchingPods: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, _ := url.ParseQuery(tt.args.query)
+			query, err := parseResultQuery(values)
+			if err != nil {
+				t.Fatalf("parseResultQuery() unexpected error: %s", err)
+			}
+			result, matchingPods := query.filter(analysisResult)
+			if diff := cmp.Diff(tt.expectedPods, result.Pods); diff != "" {
+				t.Errorf("filter() pods mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.expectedRoutes, result.AllowedRoutes); diff != "" {
+				t.Errorf("filter() allowed routes mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.expectedServices, result.Services); diff != "" {
+				t.Errorf("filter() services mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.expectedIngresses, result.Ingresses); diff != "" {
+				t.Errorf("filter() ingresses mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.expectedReplicaSets, result.ReplicaSets); diff != "" {
+				t.Errorf("filter() replicaSets mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.expectedDeployments, result.Deployments); diff != "" {
+				t.Errorf("filter() deployments mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.expectedHealths, result.ServiceHealths); diff != "" {
+				t.Errorf("filter() service healths mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.expectedWarnings, result.Warnings); diff != "" {
+				t.Errorf("filter() warnings mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.expectedMatchingPods, matchingPods); diff != "" {
+				t.Errorf("filter() matching pods mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseResultQuery(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		expectedError string
+	}{
+		{name: "rejects an invalid label selector", query: "labelSelector=app%3D%3D%3D",
+			expectedError: "invalid label selector app===: "},
+		{name: "rejects a negative neighbor count", query: "ingressNeighbors=-1",
+			expectedError: "invalid ingressNeighbors -1, expected a positive integer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, _ := url.ParseQuery(tt.query)
+			_, err := parseResultQuery(values)
+			if err == nil || !strings.HasPrefix(err.Error(), tt.expectedError) {
+				t.Errorf("parseResultQuery() expected error starting with %q, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,294 @@
+package exposition
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"karto/types"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+)
+
+const (
+	streamBufferSize = 16
+	streamKeepAlive  = 15 * time.Second
+	fullResultEvent  = "full"
+	resultDeltaEvent = "delta"
+)
+
+type resultView struct {
+	sequence int64
+	fields   map[string]json.RawMessage
+	lists    map[string][]json.RawMessage
+}
+
+type fullResult struct {
+	Sequence int64                      `json:"sequence"`
+	Result   map[string]json.RawMessage `json:"result"`
+}
+
+type resultDelta struct {
+	Sequence int64                      `json:"sequence"`
+	Lists    map[string]listDelta       `json:"lists,omitempty"`
+	Set      map[string]json.RawMessage `json:"set,omitempty"`
+	Unset    []string                   `json:"unset,omitempty"`
+}
+
+type listDelta struct {
+	Removed []int             `json:"removed"`
+	Added   []json.RawMessage `json:"added"`
+}
+
+type streamMessage struct {
+	event    string
+	sequence int64
+	data     []byte
+}
+
+type publication struct {
+	cluster string
+	full    streamMessage
+	delta   *streamMessage
+}
+
+type subscriber struct {
+	cluster  string
+	resolved bool
+	messages chan streamMessage
+}
+
+func newResultView() *resultView {
+	return &resultView{
+		fields: map[string]json.RawMessage{},
+		lists:  map[string][]json.RawMessage{},
+	}
+}
+
+func (view *resultView) update(analysisResult types.AnalysisResult) (resultDelta, bool, error) {
+	content, err := json.Marshal(analysisResult)
+	if err != nil {
+		return resultDelta{}, false, err
+	}
+	values := map[string]json.RawMessage{}
+	if err = json.Unmarshal(content, &values); err != nil {
+		return resultDelta{}, false, err
+	}
+	delta := resultDelta{Lists: map[string]listDelta{}, Set: map[string]json.RawMessage{}}
+	for key, value := range values {
+		if len(value) > 0 && value[0] == '[' {
+			var elements []json.RawMessage
+			if err = json.Unmarshal(value, &elements); err != nil {
+				return resultDelta{}, false, err
+			}
+			if _, ok := view.fields[key]; ok {
+				delete(view.fields, key)
+				delta.Set[key] = json.RawMessage("[]")
+				view.lists[key] = nil
+			}
+			listChanges, kept := diffList(view.lists[key], elements)
+			if len(listChanges.Removed) > 0 || len(listChanges.Added) > 0 {
+				delta.Lists[key] = listChanges
+			}
+			view.lists[key] = kept
+			continue
+		}
+		if _, ok := view.lists[key]; ok {
+			delete(view.lists, key)
+		} else if previous, ok := view.fields[key]; ok && bytes.Equal(previous, value) {
+			continue
+		}
+		view.fields[key] = value
+		delta.Set[key] = value
+	}
+	for key := range view.fields {
+		if _, ok := values[key]; !ok {
+			delete(view.fields, key)
+			delta.Unset = append(delta.Unset, key)
+		}
+	}
+	for key := range view.lists {
+		if _, ok := values[key]; !ok {
+			delete(view.lists, key)
+			delta.Unset = append(delta.Unset, key)
+		}
+	}
+	sort.Strings(delta.Unset)
+	if len(delta.Lists) == 0 && len(delta.Set) == 0 && len(delta.Unset) == 0 {
+		return resultDelta{}, false, nil
+	}
+	view.sequence++
+	delta.Sequence = view.sequence
+	return delta, true, nil
+}
+
+func diffList(previous []json.RawMessage, next []json.RawMessage) (listDelta, []json.RawMessage) {
+	remaining := make(map[string]int, len(next))
+	for _, element := range next {
+		remaining[string(element)]++
+	}
+	delta := listDelta{Removed: []int{}, Added: []json.RawMessage{}}
+	kept := make([]json.RawMessage, 0, len(next))
+	for i, element := range previous {
+		if remaining[string(element)] > 0 {
+			remaining[string(element)]--
+			kept = append(kept, element)
+		} else {
+			delta.Removed = append(delta.Removed, i)
+		}
+	}
+	for _, element := range next {
+		if remaining[string(element)] > 0 {
+			remaining[string(element)]--
+			delta.Added = append(delta.Added, element)
+			kept = append(kept, element)
+		}
+	}
+	return delta, kept
+}
+
+func (view *resultView) full() fullResult {
+	result := make(map[string]json.RawMessage, len(view.fields)+len(view.lists))
+	for key, value := range view.fields {
+		result[key] = value
+	}
+	for key, elements := range view.lists {
+		content, _ := json.Marshal(elements)
+		result[key] = content
+	}
+	return fullResult{Sequence: view.sequence, Result: result}
+}
+
+func newStreamMessage(event string, sequence int64, value interface{}) (streamMessage, error) {
+	data, err := json.Marshal(value)
+	return streamMessage{event: event, sequence: sequence, data: data}, err
+}
+
+func (handler *handler) prepare(analysisResult types.AnalysisResult) (publication, error) {
+	view, ok := handler.views[analysisResult.Cluster]
+	if !ok {
+		view = newResultView()
+		handler.views[analysisResult.Cluster] = view
+	}
+	delta, changed, err := view.update(analysisResult)
+	if err != nil {
+		return publication{}, err
+	}
+	result := publication{cluster: analysisResult.Cluster}
+	if result.full, err = newStreamMessage(fullResultEvent, view.sequence, view.full()); err != nil {
+		return publication{}, err
+	}
+	if changed {
+		deltaMessage, err := newStreamMessage(resultDeltaEvent, delta.Sequence, delta)
+		if err != nil {
+			return publication{}, err
+		}
+		result.delta = &deltaMessage
+	}
+	return result, nil
+}
+
+func (handler *handler) publish(publication publication) {
+	handler.fullResults[publication.cluster] = publication.full
+	for subscriber := range handler.subscribers {
+		var message streamMessage
+		if !subscriber.resolved {
+			subscriber.cluster, subscriber.resolved = handler.defaultCluster()
+			if subscriber.cluster != publication.cluster {
+				continue
+			}
+			message = publication.full
+		} else if subscriber.cluster == publication.cluster && publication.delta != nil {
+			message = *publication.delta
+		} else {
+			continue
+		}
+		select {
+		case subscriber.messages <- message:
+		default:
+			close(subscriber.messages)
+			delete(handler.subscribers, subscriber)
+		}
+	}
+}
+
+func (handler *handler) subscribe(w http.ResponseWriter, r *http.Request) (*subscriber, streamMessage, bool) {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+	subscriber := &subscriber{messages: make(chan streamMessage, streamBufferSize)}
+	subscriber.cluster, subscriber.resolved = handler.clusterOf(r)
+	var message streamMessage
+	var err error
+	if subscriber.resolved {
+		var ok bool
+		message, ok = handler.fullResults[subscriber.cluster]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown cluster %s", subscriber.cluster), http.StatusNotFound)
+			return nil, message, false
+		}
+	} else {
+		message, err = newStreamMessage(fullResultEvent, 0,
+			fullResult{Sequence: 0, Result: handler.emptyResultFields()})
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, message, false
+	}
+	handler.subscribers[subscriber] = true
+	return subscriber, message, true
+}
+
+func (handler *handler) unsubscribe(subscriber *subscriber) {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+	delete(handler.subscribers, subscriber)
+}
+
+func (handler *handler) emptyResultFields() map[string]json.RawMessage {
+	content, _ := json.Marshal(handler.emptyAnalysisResult)
+	result := map[string]json.RawMessage{}
+	_ = json.Unmarshal(content, &result)
+	return result
+}
+
+func (handler *handler) serveStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	subscriber, message, ok := handler.subscribe(w, r)
+	if !ok {
+		return
+	}
+	defer handler.unsubscribe(subscriber)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		var err error
+		if message.data != nil {
+			_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", message.sequence, message.event, message.data)
+			message = streamMessage{}
+		}
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		flusher.Flush()
+		select {
+		case message, ok = <-subscriber.messages:
+			if !ok {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err = fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package exposition
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/google/go-cmp/cmp"
+	"karto/types"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResultViewUpdate(t *testing.T) {
+	type args struct {
+		previousAnalysisResult types.AnalysisResult
+		analysisResult         types.AnalysisResult
+	}
+	pod1 := &types.Pod{Name: "pod1", Namespace: "ns"}
+	pod2 := &types.Pod{Name: "pod2", Namespace: "ns"}
+	pod3 := &types.Pod{Name: "pod3", Namespace: "ns"}
+	tests := []struct {
+		name            string
+		args            args
+		expectedDelta   resultDelta
+		expectedChanged bool
+	}{
+		{
+			name: "lists the indexes of removed elements and the added elements of lists",
+			args: args{
+				previousAnalysisResult: types.AnalysisResult{Pods: []*types.Pod{pod1, pod2}},
+				analysisResult:         types.AnalysisResult{Pods: []*types.Pod{pod2, pod3}},
+			},
+			expectedDelta: resultDelta{
+				Sequence: 2,
+				Lists: map[string]listDelta{
+					"pods": {Removed: []int{0}, Added: []json.RawMessage{marshal(pod3)}},
+				},
+				Set: map[string]json.RawMessage{},
+			},
+			expectedChanged: true,
+		},
+		{
+			name: "sets changed fields and unsets removed ones",
+			args: args{
+				previousAnalysisResult: types.AnalysisResult{
+					Capabilities: &types.Capabilities{PartialAnalyses: []string{"health"}},
+				},
+				analysisResult: types.AnalysisResult{
+					Compliance: &types.ComplianceReport{Coverage: types.IsolationCoverage{Pods: 1}},
+				},
+			},
+			expectedDelta: resultDelta{
+				Sequence: 2,
+				Lists:    map[string]listDelta{},
+				Set: map[string]json.RawMessage{
+					"compliance": marshal(&types.ComplianceReport{Coverage: types.IsolationCoverage{Pods: 1}}),
+				},
+				Unset: []string{"capabilities"},
+			},
+			expectedChanged: true,
+		},
+		{
+			name: "reports no change for an identical analysis result",
+			args: args{
+				previousAnalysisResult: types.AnalysisResult{Pods: []*types.Pod{pod1}},
+				analysisResult:         types.AnalysisResult{Pods: []*types.Pod{pod1}},
+			},
+			expectedDelta:   resultDelta{},
+			expectedChanged: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			view := newResultView()
+			if _, _, err := view.update(tt.args.previousAnalysisResult); err != nil {
+				t.Fatalf("update() unexpected error: %s", err)
+			}
+			delta, changed, err := view.update(tt.args.analysisResult)
+			if err != nil {
+				t.Fatalf("update() unexpected error: %s", err)
+			}
+			if diff := cmp.Diff(tt.expectedDelta, delta); diff != "" {
+				t.Errorf("update() result mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.expectedChanged, changed); diff != "" {
+				t.Errorf("update() changed mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestServeStream(t *testing.T) {
+	pod1 := &types.Pod{Name: "pod1", Namespace: "ns"}
+	pod2 := &types.Pod{Name: "pod2", Namespace: "ns"}
+	address := "localhost:" + strconv.Itoa(findAvailablePort())
+	resultsChannel := make(chan types.AnalysisResult)
+	go Expose(address, HistoryConfig{}, resultsChannel)
+	resultsChannel <- types.AnalysisResult{Pods: []*types.Pod{pod1}}
+	time.Sleep(10 * time.Millisecond)
+	response, err := http.Get("http://" + address + "/api/analysisResult/stream")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer func() {
+		_ = response.Body.Close()
+	}()
+	reader := bufio.NewReader(response.Body)
+	fullEvent := readEvent(t, reader)
+	fullEventPrefix := "id: 1\nevent: full\ndata: "
+	if !strings.HasPrefix(fullEvent, fullEventPrefix) {
+		t.Fatalf("Expected a full event, got %s", fullEvent)
+	}
+	full := fullResult{}
+	if err = json.Unmarshal([]byte(strings.TrimPrefix(fullEvent, fullEventPrefix)), &full); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if diff := cmp.Diff(int64(1), full.Sequence); diff != "" {
+		t.Errorf("Full event sequence mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(marshal([]*types.Pod{pod1}), full.Result["pods"]); diff != "" {
+		t.Errorf("Full event pods mismatch (-want +got):\n%s", diff)
+	}
+	resultsChannel <- types.AnalysisResult{Pods: []*types.Pod{pod2}}
+	expectedDeltaEvent := "id: 2\nevent: delta\ndata: {\"sequence\":2,\"lists\":{\"pods\":{\"removed\":[0]," +
+		"\"added\":[" + string(marshal(pod2)) + "]}}}\n\n"
+	if diff := cmp.Diff(expectedDeltaEvent, readEvent(t, reader)); diff != "" {
+		t.Errorf("Delta event mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func marshal(value interface{}) json.RawMessage {
+	content, _ := json.Marshal(value)
+	return content
+}
+
+func readEvent(t *testing.T, reader *bufio.Reader) string {
+	result := strings.Builder{}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		result.WriteString(line)
+		if line == "\n" {
+			return result.String()
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package flows
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"karto/types"
+	"sort"
+	"time"
+)
+
+type FlowVerdict string
+
+const (
+	FlowAllowed  FlowVerdict = "allowed"
+	FlowDenied   FlowVerdict = "denied"
+	FlowUnmapped FlowVerdict = "unmapped"
+)
+
+type ObservedFlow struct {
+	SourcePod     *types.PodRef `json:"sourcePod"`
+	SourceIP      string        `json:"sourceIP,omitempty"`
+	TargetPod     *types.PodRef `json:"targetPod"`
+	DestinationIP string        `json:"destinationIP,omitempty"`
+	Port          int32         `json:"port"`
+	Protocol      string        `json:"protocol"`
+	Count         int           `json:"count"`
+	Verdict       FlowVerdict   `json:"verdict"`
+}
+
+type RouteUsage struct {
+	SourcePod        types.PodRef      `json:"sourcePod"`
+	TargetPod        types.PodRef      `json:"targetPod"`
+	Ports            []int32           `json:"ports"`
+	PortRanges       []types.PortRange `json:"portRanges,omitempty"`
+	DeniedPorts      []int32           `json:"deniedPorts,omitempty"`
+	DeniedPortRanges []types.PortRange `json:"deniedPortRanges,omitempty"`
+	Used             bool              `json:"used"`
+	ObservedPorts    []int32           `json:"observedPorts"`
+	FlowCount        int               `json:"flowCount"`
+}
+
+type Report struct {
+	UsedRoutes    int             `json:"usedRoutes"`
+	UnusedRoutes  int             `json:"unusedRoutes"`
+	AllowedFlows  int             `json:"allowedFlows"`
+	DeniedFlows   int             `json:"deniedFlows"`
+	UnmappedFlows int             `json:"unmappedFlows"`
+	Routes        []*RouteUsage   `json:"routes"`
+	Flows         []*ObservedFlow `json:"flows"`
+}
+
+type routeKey struct {
+	source types.PodRef
+	target types.PodRef
+}
+
+type flowKey struct {
+	source        types.PodRef
+	sourceIP      string
+	target        types.PodRef
+	destinationIP string
+	port          int32
+	protocol      string
+}
+
+func Compare(flows []Flow, pods []*corev1.Pod, allowedRoutes []*types.AllowedRoute) *Report {
+	resolver := newPodResolver(pods)
+	routes := make(map[routeKey]*RouteUsage, len(allowedRoutes))
+	report := &Report{
+		Routes: make([]*RouteUsage, 0, len(allowedRoutes)),
+		Flows:  make([]*ObservedFlow, 0),
+	}
+	for _, allowedRoute := range allowedRoutes {
+		usage := &RouteUsage{
+			SourcePod:        allowedRoute.SourcePod,
+			TargetPod:        allowedRoute.TargetPod,
+			Ports:            allowedRoute.Ports,
+			PortRanges:       allowedRoute.PortRanges,
+			DeniedPorts:      allowedRoute.DeniedPorts,
+			DeniedPortRanges: allowedRoute.DeniedPortRanges,
+			ObservedPorts:    []int32{},
+		}
+		routes[routeKey{source: usage.SourcePod, target: usage.TargetPod}] = usage
+		report.Routes = append(report.Routes, usage)
+	}
+	observedFlows := make(map[flowKey]*ObservedFlow)
+	for _, flow := range flows {
+		sourcePod := resolver.resolve(flow.SourceIP, flow.Time, flow.SourceHint)
+		targetPod := resolver.resolve(flow.DestinationIP, flow.Time, flow.TargetHint)
+		key := flowKey{port: flow.Port, protocol: flow.Protocol}
+		if sourcePod != nil {
+			key.source = *sourcePod
+		} else {
+			key.sourceIP = flow.SourceIP
+		}
+		if targetPod != nil {
+			key.target = *targetPod
+		} else {
+			key.destinationIP = flow.DestinationIP
+		}
+		observedFlow, ok := observedFlows[key]
+		if !ok {
+			observedFlow = &ObservedFlow{
+				SourcePod:     sourcePod,
+				SourceIP:      key.sourceIP,
+				TargetPod:     targetPod,
+				DestinationIP: key.destinationIP,
+				Port:          flow.Port,
+				Protocol:      flow.Protocol,
+				Verdict:       classify(sourcePod, targetPod, flow.Port, routes),
+			}
+			observedFlows[key] = observedFlow
+			report.Flows = append(report.Flows, observedFlow)
+		}
+		observedFlow.Count++
+		if observedFlow.Verdict == FlowAllowed {
+			if usage, ok := routes[routeKey{source: *sourcePod, target: *targetPod}]; ok {
+				usage.Used = true
+				usage.FlowCount++
+				if !containsPort(usage.ObservedPorts, flow.Port) {
+					usage.ObservedPorts = append(usage.ObservedPorts, flow.Port)
+				}
+			}
+		}
+	}
+	for _, usage := range report.Routes {
+		sort.Slice(usage.ObservedPorts, func(i, j int) bool {
+			return usage.ObservedPorts[i] < usage.ObservedPorts[j]
+		})
+		if usage.Used {
+			report.UsedRoutes++
+		} else {
+			report.UnusedRoutes++
+		}
+	}
+	for _, observedFlow := range report.Flows {
+		switch observedFlow.Verdict {
+		case FlowAllowed:
+			report.AllowedFlows += observedFlow.Count
+		case FlowDenied:
+			report.DeniedFlows += observedFlow.Count
+		default:
+			report.UnmappedFlows += observedFlow.Count
+		}
+	}
+	return report
+}
+
+func classify(sourcePod *types.PodRef, targetPod *types.PodRef, port int32, routes map[routeKey]*RouteUsage) FlowVerdict {
+	if sourcePod == nil || targetPod == nil {
+		return FlowUnmapped
+	}
+	if *sourcePod == *targetPod {
+		return FlowAllowed
+	}
+	usage, ok := routes[routeKey{source: *sourcePod, target: *targetPod}]
+	if !ok || !usage.allowsPort(port) {
+		return FlowDenied
+	}
+	return FlowAllowed
+}
+
+func (usage *RouteUsage) allowsPort(port int32) bool {
+	route := types.AllowedRoute{Ports: usage.Ports, PortRanges: usage.PortRanges, DeniedPorts: usage.DeniedPorts,
+		DeniedPortRanges: usage.DeniedPortRanges}
+	return route.AllowsPort(port)
+}
+
+func containsPort(ports []int32, port int32) bool {
+	for _, candidate := range ports {
+		if candidate == port {
+			return true
+		}
+	}
+	return false
+}
+
+type podAddress struct {
+	pod       types.PodRef
+	createdAt time.Time
+	deletedAt *time.Time
+}
+
+type podResolver struct {
+	byIP   map[string][]podAddress
+	byName map[types.PodRef]bool
+}
+
+func newPodResolver(pods []*corev1.Pod) *podResolver {
+	resolver := &podResolver{
+		byIP:   make(map[string][]podAddress),
+		byName: make(map[types.PodRef]bool),
+	}
+	for _, pod := range pods {
+		ref := types.PodRef{Name: pod.Name, Namespace: pod.Namespace}
+		resolver.byName[ref] = true
+		if pod.Spec.HostNetwork {
+			continue
+		}
+		address := podAddress{pod: ref, createdAt: pod.CreationTimestamp.Time}
+		if pod.DeletionTimestamp != nil {
+			address.deletedAt = &pod.DeletionTimestamp.Time
+		}
+		ips := make(map[string]bool)
+		if pod.Status.PodIP != "" {
+			ips[pod.Status.PodIP] = true
+		}
+		for _, podIP := range pod.Status.PodIPs {
+			ips[podIP.IP] = true
+		}
+		for ip := range ips {
+			resolver.byIP[ip] = append(resolver.byIP[ip], address)
+		}
+	}
+	return resolver
+}
+
+func (resolver *podResolver) resolve(ip string, at time.Time, hint *types.PodRef) *types.PodRef {
+	var result *podAddress
+	for i, address := range resolver.byIP[ip] {
+		if !at.IsZero() && (at.Before(address.createdAt) ||
+			(address.deletedAt != nil && at.After(*address.deletedAt))) {
+			continue
+		}
+		if result == nil || address.createdAt.After(result.createdAt) {
+			result = &resolver.byIP[ip][i]
+		}
+	}
+	if result != nil {
+		pod := result.pod
+		return &pod
+	}
+	if hint != nil && resolver.byName[*hint] {
+		pod := *hint
+		return &pod
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package flows
+
+import (
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"karto/testutils"
+	"karto/types"
+	"testing"
+	"time"
+)
+
+func TestCompare(t *testing.T) {
+	type args struct {
+		flows         []Flow
+		pods          []*corev1.Pod
+		allowedRoutes []*types.AllowedRoute
+	}
+	start := time.Date(2022, 5, 1, 10, 0, 0, 0, time.UTC)
+	front := types.PodRef{Name: "front", Namespace: "ns"}
+	api := types.PodRef{Name: "api", Namespace: "ns"}
+	db := types.PodRef{Name: "db", Namespace: "ns"}
+	pods := []*corev1.Pod{
+		testutils.NewPodBuilder().WithName("front").WithNamespace("ns").WithIP("10.0.0.1").
+			WithCreationTime(start).Build(),
+		testutils.NewPodBuilder().WithName("api").WithNamespace("ns").WithIP("10.0.0.2").
+			WithCreationTime(start).Build(),
+		testutils.NewPodBuilder().WithName("db").WithNamespace("ns").WithIP("10.0.0.3").
+			WithCreationTime(start).Build(),
+	}
+	tests := []struct {
+		name           string
+		args           args
+		expectedReport *Report
+	}{
+		{
+			name: "classifies allowed routes as used or unused and flows as allowed or denied",
+			args: args{
+				flows: []Flow{
+					{SourceIP: "10.0.0.1", DestinationIP: "10.0.0.2", Port: 8080, Protocol: "TCP"},
+					{SourceIP: "10.0.0.1", DestinationIP: "10.0.0.2", Port: 8080, Protocol: "TCP"},
+					{SourceIP: "10.0.0.1", DestinationIP: "10.0.0.3", Port: 5432, Protocol: "TCP"},
+				},
+				pods: pods,
+				allowedRoutes: []*types.AllowedRoute{
+					{SourcePod: front, TargetPod: api, Ports: []int32{8080}},
+					{SourcePod: api, TargetPod: db, Ports: nil},
+				},
+			},
+			expectedReport: &Report{
+				UsedRoutes:   1,
+				UnusedRoutes: 1,
+				AllowedFlows: 2,
+				DeniedFlows:  1,
+				Routes: []*RouteUsage{
+					{SourcePod: front, TargetPod: api, Ports: []int32{8080}, Used: true, ObservedPorts: []int32{8080},
+						FlowCount: 2},
+					{SourcePod: api, TargetPod: db, Ports: nil, ObservedPorts: []int32{}},
+				},
+				Flows: []*ObservedFlow{
+					{SourcePod: &front, TargetPod: &api, Port: 8080, Protocol: "TCP", Count: 2, Verdict: FlowAllowed},
+					{SourcePod: &front, TargetPod: &db, Port: 5432, Protocol: "TCP", Count: 1, Verdict: FlowDenied},
+				},
+			},
+		},
+		{
+			name: "does not map flows to pods created after them and falls back to pod hints",
+			args: args{
+				flows: []Flow{
+					{Time: start.Add(-time.Hour), SourceIP: "10.0.0.1", DestinationIP: "10.0.0.2", Port: 8080,
+						Protocol: "TCP"},
+					{Time: start.Add(time.Hour), SourceIP: "10.0.0.9", DestinationIP: "10.0.0.2", Port: 9090,
+						Protocol: "TCP", SourceHint: &front},
+				},
+				pods: pods,
+				allowedRoutes: []*types.AllowedRoute{
+					{SourcePod: front, TargetPod: api, Ports: []int32{8080}},
+				},
+			},
+			expectedReport: &Report{
+				UnusedRoutes:  1,
+				DeniedFlows:   1,
+				UnmappedFlows: 1,
+				Routes: []*RouteUsage{
+					{SourcePod: front, TargetPod: api, Ports: []int32{8080}, ObservedPorts: []int32{}},
+				},
+				Flows: []*ObservedFlow{
+					{SourceIP: "10.0.0.1", DestinationIP: "10.0.0.2", Port: 8080, Protocol: "TCP", Count: 1,
+						Verdict: FlowUnmapped},
+					{SourcePod: &front, TargetPod: &api, Port: 9090, Protocol: "TCP", Count: 1, Verdict: FlowDenied},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := Compare(tt.args.flows, tt.args.pods, tt.args.allowedRoutes)
+			if diff := cmp.Diff(tt.expectedReport, report); diff != "" {
+				t.Errorf("Compare() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
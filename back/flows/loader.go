@@ -0,0 +1,250 @@
+package flows
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"karto/types"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const csvExtension = ".csv"
+
+type Flow struct {
+	Time          time.Time     `json:"time"`
+	SourceIP      string        `json:"sourceIP"`
+	DestinationIP string        `json:"destinationIP"`
+	Port          int32         `json:"port"`
+	Protocol      string        `json:"protocol"`
+	SourceHint    *types.PodRef `json:"sourceHint,omitempty"`
+	TargetHint    *types.PodRef `json:"targetHint,omitempty"`
+}
+
+type hubbleRecord struct {
+	Flow *hubbleFlow `json:"flow"`
+	hubbleFlow
+}
+
+type hubbleFlow struct {
+	Time        time.Time       `json:"time"`
+	IP          *hubbleIP       `json:"IP"`
+	L4          *hubbleL4       `json:"l4"`
+	Source      *hubbleEndpoint `json:"source"`
+	Destination *hubbleEndpoint `json:"destination"`
+	IsReply     *bool           `json:"is_reply"`
+	IsReplyPb   *bool           `json:"isReply"`
+}
+
+type hubbleIP struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+}
+
+type hubbleL4 struct {
+	TCP  *hubblePorts `json:"TCP"`
+	UDP  *hubblePorts `json:"UDP"`
+	SCTP *hubblePorts `json:"SCTP"`
+}
+
+type hubblePorts struct {
+	DestinationPort   int32 `json:"destination_port"`
+	DestinationPortPb int32 `json:"destinationPort"`
+}
+
+type hubbleEndpoint struct {
+	Namespace string `json:"namespace"`
+	PodName   string `json:"pod_name"`
+	PodNamePb string `json:"podName"`
+}
+
+func Load(filePaths []string) ([]Flow, error) {
+	flows := make([]Flow, 0)
+	for _, filePath := range filePaths {
+		content, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		var fileFlows []Flow
+		if strings.EqualFold(filepath.Ext(filePath), csvExtension) {
+			fileFlows, err = parseCsv(content)
+		} else {
+			fileFlows, err = parseHubble(content)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filePath, err)
+		}
+		flows = append(flows, fileFlows...)
+	}
+	return flows, nil
+}
+
+func parseHubble(content []byte) ([]Flow, error) {
+	records := make([]hubbleRecord, 0)
+	trimmed := bytes.TrimSpace(content)
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		err := json.Unmarshal(trimmed, &records)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		decoder := json.NewDecoder(bytes.NewReader(trimmed))
+		for {
+			record := hubbleRecord{}
+			err := decoder.Decode(&record)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("flow #%d: %w", len(records)+1, err)
+			}
+			records = append(records, record)
+		}
+	}
+	flows := make([]Flow, 0, len(records))
+	for _, record := range records {
+		flow := record.hubbleFlow
+		if record.Flow != nil {
+			flow = *record.Flow
+		}
+		if flow.IP == nil || isTrue(flow.IsReply) || isTrue(flow.IsReplyPb) {
+			continue
+		}
+		port, protocol := flow.L4.destination()
+		flows = append(flows, Flow{
+			Time:          flow.Time,
+			SourceIP:      flow.IP.Source,
+			DestinationIP: flow.IP.Destination,
+			Port:          port,
+			Protocol:      protocol,
+			SourceHint:    flow.Source.podRef(),
+			TargetHint:    flow.Destination.podRef(),
+		})
+	}
+	return flows, nil
+}
+
+func (l4 *hubbleL4) destination() (int32, string) {
+	if l4 == nil {
+		return 0, ""
+	}
+	for _, candidate := range []struct {
+		ports    *hubblePorts
+		protocol string
+	}{{l4.TCP, "TCP"}, {l4.UDP, "UDP"}, {l4.SCTP, "SCTP"}} {
+		if candidate.ports != nil {
+			port := candidate.ports.DestinationPort
+			if port == 0 {
+				port = candidate.ports.DestinationPortPb
+			}
+			return port, candidate.protocol
+		}
+	}
+	return 0, ""
+}
+
+func (endpoint *hubbleEndpoint) podRef() *types.PodRef {
+	if endpoint == nil {
+		return nil
+	}
+	name := endpoint.PodName
+	if name == "" {
+		name = endpoint.PodNamePb
+	}
+	if name == "" || endpoint.Namespace == "" {
+		return nil
+	}
+	return &types.PodRef{Name: name, Namespace: endpoint.Namespace}
+}
+
+func isTrue(value *bool) bool {
+	return value != nil && *value
+}
+
+func parseCsv(content []byte) ([]Flow, error) {
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	columns := map[string]int{"src": 0, "dst": 1, "port": 2, "protocol": 3, "time": 4}
+	if len(rows) != 0 && len(rows[0]) > 2 {
+		if _, err = strconv.Atoi(rows[0][2]); err != nil {
+			columns, err = csvColumns(rows[0])
+			if err != nil {
+				return nil, err
+			}
+			rows = rows[1:]
+		}
+	}
+	flows := make([]Flow, 0, len(rows))
+	for i, row := range rows {
+		flow, err := parseCsvRow(row, columns)
+		if err != nil {
+			return nil, fmt.Errorf("row #%d: %w", i+1, err)
+		}
+		flows = append(flows, flow)
+	}
+	return flows, nil
+}
+
+func csvColumns(header []string) (map[string]int, error) {
+	aliases := map[string]string{"source": "src", "destination": "dst", "dest": "dst", "proto": "protocol"}
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if alias, ok := aliases[name]; ok {
+			name = alias
+		}
+		columns[name] = i
+	}
+	for _, required := range []string{"src", "dst", "port"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing %s column in header", required)
+		}
+	}
+	return columns, nil
+}
+
+func parseCsvRow(row []string, columns map[string]int) (Flow, error) {
+	value := func(column string) string {
+		index, ok := columns[column]
+		if !ok || index >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[index])
+	}
+	flow := Flow{
+		SourceIP:      value("src"),
+		DestinationIP: value("dst"),
+		Protocol:      strings.ToUpper(value("protocol")),
+	}
+	if flow.SourceIP == "" || flow.DestinationIP == "" {
+		return Flow{}, fmt.Errorf("src and dst are required")
+	}
+	if port := value("port"); port != "" {
+		parsedPort, err := strconv.ParseInt(port, 10, 32)
+		if err != nil || parsedPort < 0 || parsedPort > 65535 {
+			return Flow{}, fmt.Errorf("invalid port %q", port)
+		}
+		flow.Port = int32(parsedPort)
+	}
+	if flow.Protocol == "" {
+		flow.Protocol = "TCP"
+	}
+	if timestamp := value("time"); timestamp != "" {
+		parsedTime, err := time.Parse(time.RFC3339, timestamp)
+		if err != nil {
+			return Flow{}, fmt.Errorf("invalid time %q: %w", timestamp, err)
+		}
+		flow.Time = parsedTime
+	}
+	return flow, nil
+}
@@ -0,0 +1,80 @@
+package flows
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"karto/types"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	type args struct {
+		fileName string
+		content  string
+	}
+	flowTime := time.Date(2022, 5, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		args          args
+		expectedFlows []Flow
+	}{
+		{
+			name: "loads hubble flows and ignores replies",
+			args: args{
+				fileName: "flows.json",
+				content: `{"flow":{"time":"2022-05-01T10:00:00Z","IP":{"source":"10.0.0.1","destination":"10.0.0.2"},` +
+					`"l4":{"TCP":{"source_port":40000,"destination_port":8080}},` +
+					`"source":{"namespace":"ns","pod_name":"front"},"destination":{"namespace":"ns"}}}
+{"flow":{"time":"2022-05-01T10:00:00Z","IP":{"source":"10.0.0.2","destination":"10.0.0.1"},` +
+					`"l4":{"TCP":{"source_port":8080,"destination_port":40000}},"is_reply":true}}
+{"time":"2022-05-01T10:00:00Z","IP":{"source":"10.0.0.1","destination":"10.0.0.3"},` +
+					`"l4":{"UDP":{"sourcePort":40000,"destinationPort":53}}}
+`,
+			},
+			expectedFlows: []Flow{
+				{Time: flowTime, SourceIP: "10.0.0.1", DestinationIP: "10.0.0.2", Port: 8080, Protocol: "TCP",
+					SourceHint: &types.PodRef{Name: "front", Namespace: "ns"}},
+				{Time: flowTime, SourceIP: "10.0.0.1", DestinationIP: "10.0.0.3", Port: 53, Protocol: "UDP"},
+			},
+		},
+		{
+			name: "loads csv flows with a header",
+			args: args{
+				fileName: "flows.csv",
+				content:  "time,source,destination,port,protocol\n2022-05-01T10:00:00Z,10.0.0.1,10.0.0.2,8080,tcp\n",
+			},
+			expectedFlows: []Flow{
+				{Time: flowTime, SourceIP: "10.0.0.1", DestinationIP: "10.0.0.2", Port: 8080, Protocol: "TCP"},
+			},
+		},
+		{
+			name: "loads csv flows without a header",
+			args: args{
+				fileName: "flows.csv",
+				content:  "10.0.0.1,10.0.0.2,53,UDP\n10.0.0.1,10.0.0.2,80\n",
+			},
+			expectedFlows: []Flow{
+				{SourceIP: "10.0.0.1", DestinationIP: "10.0.0.2", Port: 53, Protocol: "UDP"},
+				{SourceIP: "10.0.0.1", DestinationIP: "10.0.0.2", Port: 80, Protocol: "TCP"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), tt.args.fileName)
+			err := os.WriteFile(filePath, []byte(tt.args.content), 0o600)
+			if err != nil {
+				t.Fatal(err)
+			}
+			flows, err := Load([]string{filePath})
+			if err != nil {
+				t.Fatalf("Load() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.expectedFlows, flows); diff != "" {
+				t.Errorf("Load() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,317 @@
+package graph
+
+import (
+	"fmt"
+	"karto/types"
+	"sort"
+	"strings"
+)
+
+type EdgeKind string
+
+const (
+	EdgeRoutes  EdgeKind = "routes"
+	EdgeSelects EdgeKind = "selects"
+	EdgeAllows  EdgeKind = "allows"
+	EdgeOwns    EdgeKind = "owns"
+)
+
+const (
+	podKind         = "Pod"
+	serviceKind     = "Service"
+	ingressKind     = "Ingress"
+	replicaSetKind  = "ReplicaSet"
+	statefulSetKind = "StatefulSet"
+	daemonSetKind   = "DaemonSet"
+	deploymentKind  = "Deployment"
+)
+
+type Edge struct {
+	Source     types.ObjectRef   `json:"source"`
+	Target     types.ObjectRef   `json:"target"`
+	Kind       EdgeKind          `json:"kind"`
+	Ports      []int32           `json:"ports,omitempty"`
+	PortRanges []types.PortRange `json:"portRanges,omitempty"`
+}
+
+type Subgraph struct {
+	Nodes []types.ObjectRef `json:"nodes"`
+	Edges []*Edge           `json:"edges"`
+}
+
+type Path struct {
+	Nodes []types.ObjectRef `json:"nodes"`
+	Edges []*Edge           `json:"edges"`
+}
+
+type Graph struct {
+	nodes    map[types.ObjectRef]bool
+	outgoing map[types.ObjectRef][]*Edge
+	incoming map[types.ObjectRef][]*Edge
+}
+
+func New(analysisResult types.AnalysisResult) *Graph {
+	graph := &Graph{
+		nodes:    map[types.ObjectRef]bool{},
+		outgoing: map[types.ObjectRef][]*Edge{},
+		incoming: map[types.ObjectRef][]*Edge{},
+	}
+	for _, pod := range analysisResult.Pods {
+		graph.addNode(types.ObjectRef{Kind: podKind, Name: pod.Name, Namespace: pod.Namespace})
+	}
+	for _, allowedRoute := range analysisResult.AllowedRoutes {
+		graph.addEdge(&Edge{Source: podNode(allowedRoute.SourcePod), Target: podNode(allowedRoute.TargetPod),
+			Kind: EdgeAllows, Ports: allowedRoute.Ports, PortRanges: allowedRoute.PortRanges})
+	}
+	for _, service := range analysisResult.Services {
+		serviceNode := types.ObjectRef{Kind: serviceKind, Name: service.Name, Namespace: service.Namespace}
+		graph.addOwnedPods(serviceNode, EdgeSelects, service.TargetPods)
+	}
+	for _, ingress := range analysisResult.Ingresses {
+		ingressNode := types.ObjectRef{Kind: ingressKind, Name: ingress.Name, Namespace: ingress.Namespace}
+		graph.addNode(ingressNode)
+		for _, service := range ingress.TargetServices {
+			graph.addEdge(&Edge{Source: ingressNode, Kind: EdgeRoutes,
+				Target: types.ObjectRef{Kind: serviceKind, Name: service.Name, Namespace: service.Namespace}})
+		}
+	}
+	for _, replicaSet := range analysisResult.ReplicaSets {
+		graph.addOwnedPods(types.ObjectRef{Kind: replicaSetKind, Name: replicaSet.Name,
+			Namespace: replicaSet.Namespace}, EdgeOwns, replicaSet.TargetPods)
+	}
+	for _, statefulSet := range analysisResult.StatefulSets {
+		graph.addOwnedPods(types.ObjectRef{Kind: statefulSetKind, Name: statefulSet.Name,
+			Namespace: statefulSet.Namespace}, EdgeOwns, statefulSet.TargetPods)
+	}
+	for _, daemonSet := range analysisResult.DaemonSets {
+		graph.addOwnedPods(types.ObjectRef{Kind: daemonSetKind, Name: daemonSet.Name,
+			Namespace: daemonSet.Namespace}, EdgeOwns, daemonSet.TargetPods)
+	}
+	for _, deployment := range analysisResult.Deployments {
+		deploymentNode := types.ObjectRef{Kind: deploymentKind, Name: deployment.Name,
+			Namespace: deployment.Namespace}
+		graph.addNode(deploymentNode)
+		for _, replicaSet := range deployment.TargetReplicaSets {
+			graph.addEdge(&Edge{Source: deploymentNode, Kind: EdgeOwns,
+				Target: types.ObjectRef{Kind: replicaSetKind, Name: replicaSet.Name, Namespace: replicaSet.Namespace}})
+		}
+	}
+	return graph
+}
+
+func ParseNode(value string) (types.ObjectRef, error) {
+	parts := strings.Split(value, "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return types.ObjectRef{}, fmt.Errorf("invalid node %s, expected <kind>/<namespace>/<name>", value)
+	}
+	return types.ObjectRef{Kind: parts[0], Namespace: parts[1], Name: parts[2]}, nil
+}
+
+func podNode(podRef types.PodRef) types.ObjectRef {
+	return types.ObjectRef{Kind: podKind, Name: podRef.Name, Namespace: podRef.Namespace}
+}
+
+func (graph *Graph) addNode(node types.ObjectRef) {
+	graph.nodes[node] = true
+}
+
+func (graph *Graph) addEdge(edge *Edge) {
+	graph.addNode(edge.Source)
+	graph.addNode(edge.Target)
+	graph.outgoing[edge.Source] = append(graph.outgoing[edge.Source], edge)
+	graph.incoming[edge.Target] = append(graph.incoming[edge.Target], edge)
+}
+
+func (graph *Graph) addOwnedPods(owner types.ObjectRef, kind EdgeKind, podRefs []types.PodRef) {
+	graph.addNode(owner)
+	for _, podRef := range podRefs {
+		graph.addEdge(&Edge{Source: owner, Target: podNode(podRef), Kind: kind})
+	}
+}
+
+func (graph *Graph) Contains(node types.ObjectRef) bool {
+	return graph.nodes[node]
+}
+
+func (graph *Graph) Neighborhood(node types.ObjectRef, hops int) Subgraph {
+	visited := map[types.ObjectRef]bool{node: true}
+	edges := map[*Edge]bool{}
+	frontier := []types.ObjectRef{node}
+	for hop := 0; hop < hops && len(frontier) > 0; hop++ {
+		nextFrontier := make([]types.ObjectRef, 0)
+		for _, current := range frontier {
+			visit := func(edge *Edge, neighbor types.ObjectRef) {
+				edges[edge] = true
+				if !visited[neighbor] {
+					visited[neighbor] = true
+					nextFrontier = append(nextFrontier, neighbor)
+				}
+			}
+			for _, edge := range graph.outgoing[current] {
+				visit(edge, edge.Target)
+			}
+			for _, edge := range graph.incoming[current] {
+				visit(edge, edge.Source)
+			}
+		}
+		frontier = nextFrontier
+	}
+	result := Subgraph{Nodes: make([]types.ObjectRef, 0, len(visited)), Edges: make([]*Edge, 0, len(edges))}
+	for visitedNode := range visited {
+		result.Nodes = append(result.Nodes, visitedNode)
+	}
+	for edge := range edges {
+		result.Edges = append(result.Edges, edge)
+	}
+	sortNodes(result.Nodes)
+	sort.Slice(result.Edges, func(i, j int) bool {
+		return edgeKey(result.Edges[i]) < edgeKey(result.Edges[j])
+	})
+	return result
+}
+
+func (graph *Graph) PathsFromIngresses(node types.ObjectRef, maxHops int, maxPaths int) []Path {
+	result := make([]Path, 0)
+	hopsFromIngresses := graph.hopsFromIngresses()
+	onPath := map[types.ObjectRef]bool{node: true}
+	reversedEdges := make([]*Edge, 0, maxHops)
+	var walk func(current types.ObjectRef)
+	walk = func(current types.ObjectRef) {
+		if len(result) >= maxPaths {
+			return
+		}
+		if current.Kind == ingressKind {
+			result = append(result, newPath(reversedEdges))
+			return
+		}
+		if len(reversedEdges) >= maxHops {
+			return
+		}
+		for _, edge := range graph.incoming[current] {
+			hops, ok := hopsFromIngresses[edge.Source]
+			if edge.Kind == EdgeOwns || onPath[edge.Source] || !ok || len(reversedEdges)+1+hops > maxHops {
+				continue
+			}
+			onPath[edge.Source] = true
+			reversedEdges = append(reversedEdges, edge)
+			walk(edge.Source)
+			reversedEdges = reversedEdges[:len(reversedEdges)-1]
+			onPath[edge.Source] = false
+		}
+	}
+	walk(node)
+	sort.SliceStable(result, func(i, j int) bool {
+		return len(result[i].Edges) < len(result[j].Edges)
+	})
+	return result
+}
+
+func (graph *Graph) hopsFromIngresses() map[types.ObjectRef]int {
+	result := map[types.ObjectRef]int{}
+	frontier := make([]types.ObjectRef, 0)
+	for node := range graph.nodes {
+		if node.Kind == ingressKind {
+			result[node] = 0
+			frontier = append(frontier, node)
+		}
+	}
+	for hops := 1; len(frontier) > 0; hops++ {
+		nextFrontier := make([]types.ObjectRef, 0)
+		for _, node := range frontier {
+			for _, edge := range graph.outgoing[node] {
+				if _, ok := result[edge.Target]; edge.Kind == EdgeOwns || ok {
+					continue
+				}
+				result[edge.Target] = hops
+				nextFrontier = append(nextFrontier, edge.Target)
+			}
+		}
+		frontier = nextFrontier
+	}
+	return result
+}
+
+func (graph *Graph) ShortestPath(source types.ObjectRef, target types.ObjectRef) *Path {
+	targetPods := map[types.ObjectRef]bool{}
+	for _, pod := range graph.podsOf(target) {
+		targetPods[pod] = true
+	}
+	reachedBy := map[types.ObjectRef]*Edge{}
+	frontier := graph.podsOf(source)
+	for _, pod := range frontier {
+		reachedBy[pod] = nil
+	}
+	for len(frontier) > 0 {
+		nextFrontier := make([]types.ObjectRef, 0)
+		for _, pod := range frontier {
+			if targetPods[pod] {
+				return pathTo(pod, reachedBy)
+			}
+			for _, edge := range graph.outgoing[pod] {
+				if _, ok := reachedBy[edge.Target]; edge.Kind != EdgeAllows || ok {
+					continue
+				}
+				reachedBy[edge.Target] = edge
+				nextFrontier = append(nextFrontier, edge.Target)
+			}
+		}
+		frontier = nextFrontier
+	}
+	return nil
+}
+
+func (graph *Graph) podsOf(node types.ObjectRef) []types.ObjectRef {
+	if node.Kind == podKind {
+		if graph.nodes[node] {
+			return []types.ObjectRef{node}
+		}
+		return []types.ObjectRef{}
+	}
+	result := make([]types.ObjectRef, 0)
+	for _, edge := range graph.outgoing[node] {
+		if edge.Kind == EdgeOwns || edge.Kind == EdgeSelects {
+			result = append(result, graph.podsOf(edge.Target)...)
+		}
+	}
+	sortNodes(result)
+	return result
+}
+
+func pathTo(node types.ObjectRef, reachedBy map[types.ObjectRef]*Edge) *Path {
+	reversedEdges := make([]*Edge, 0)
+	for edge := reachedBy[node]; edge != nil; edge = reachedBy[edge.Source] {
+		reversedEdges = append(reversedEdges, edge)
+	}
+	if len(reversedEdges) == 0 {
+		return &Path{Nodes: []types.ObjectRef{node}, Edges: []*Edge{}}
+	}
+	path := newPath(reversedEdges)
+	return &path
+}
+
+func newPath(reversedEdges []*Edge) Path {
+	path := Path{
+		Nodes: make([]types.ObjectRef, 0, len(reversedEdges)+1),
+		Edges: make([]*Edge, 0, len(reversedEdges)),
+	}
+	for i := len(reversedEdges) - 1; i >= 0; i-- {
+		path.Edges = append(path.Edges, reversedEdges[i])
+		path.Nodes = append(path.Nodes, reversedEdges[i].Source)
+	}
+	path.Nodes = append(path.Nodes, reversedEdges[0].Target)
+	return path
+}
+
+func sortNodes(nodes []types.ObjectRef) {
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodeKey(nodes[i]) < nodeKey(nodes[j])
+	})
+}
+
+func nodeKey(node types.ObjectRef) string {
+	return node.Kind + "/" + node.Namespace + "/" + node.Name
+}
+
+func edgeKey(edge *Edge) string {
+	return nodeKey(edge.Source) + " " + nodeKey(edge.Target)
+}
@@ -0,0 +1,178 @@
+package graph
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"karto/types"
+	"testing"
+)
+
+var (
+	webPod       = types.PodRef{Name: "web", Namespace: "shop"}
+	apiPod       = types.PodRef{Name: "api", Namespace: "shop"}
+	dbPod        = types.PodRef{Name: "db", Namespace: "shop"}
+	webNode      = types.ObjectRef{Kind: "Pod", Name: "web", Namespace: "shop"}
+	apiNode      = types.ObjectRef{Kind: "Pod", Name: "api", Namespace: "shop"}
+	dbNode       = types.ObjectRef{Kind: "Pod", Name: "db", Namespace: "shop"}
+	frontNode    = types.ObjectRef{Kind: "Service", Name: "front", Namespace: "shop"}
+	apiSvcNode   = types.ObjectRef{Kind: "Service", Name: "api", Namespace: "shop"}
+	frontIngNode = types.ObjectRef{Kind: "Ingress", Name: "front", Namespace: "shop"}
+	apiIngNode   = types.ObjectRef{Kind: "Ingress", Name: "api", Namespace: "shop"}
+	webToApi     = &Edge{Source: webNode, Target: apiNode, Kind: EdgeAllows, Ports: []int32{8080}}
+	apiToDb      = &Edge{Source: apiNode, Target: dbNode, Kind: EdgeAllows, Ports: []int32{5432}}
+	frontToWeb   = &Edge{Source: frontNode, Target: webNode, Kind: EdgeSelects}
+	apiSvcToApi  = &Edge{Source: apiSvcNode, Target: apiNode, Kind: EdgeSelects}
+	frontRoute   = &Edge{Source: frontIngNode, Target: frontNode, Kind: EdgeRoutes}
+	apiRoute     = &Edge{Source: apiIngNode, Target: apiSvcNode, Kind: EdgeRoutes}
+)
+
+func shopAnalysisResult() types.AnalysisResult {
+	return types.AnalysisResult{
+		Pods: []*types.Pod{
+			{Name: "web", Namespace: "shop"}, {Name: "api", Namespace: "shop"}, {Name: "db", Namespace: "shop"},
+		},
+		AllowedRoutes: []*types.AllowedRoute{
+			{SourcePod: webPod, TargetPod: apiPod, Ports: []int32{8080}},
+			{SourcePod: apiPod, TargetPod: dbPod, Ports: []int32{5432}},
+		},
+		Services: []*types.Service{
+			{Name: "front", Namespace: "shop", TargetPods: []types.PodRef{webPod}},
+			{Name: "api", Namespace: "shop", TargetPods: []types.PodRef{apiPod}},
+		},
+		Ingresses: []*types.Ingress{
+			{Name: "front", Namespace: "shop", TargetServices: []types.ServiceRef{{Name: "front", Namespace: "shop"}}},
+			{Name: "api", Namespace: "shop", TargetServices: []types.ServiceRef{{Name: "api", Namespace: "shop"}}},
+		},
+		ReplicaSets: []*types.ReplicaSet{
+			{Name: "web-1", Namespace: "shop", TargetPods: []types.PodRef{webPod}},
+		},
+		StatefulSets: []*types.StatefulSet{
+			{Name: "db", Namespace: "shop", TargetPods: []types.PodRef{dbPod}},
+		},
+		Deployments: []*types.Deployment{
+			{Name: "web", Namespace: "shop", TargetReplicaSets: []types.ReplicaSetRef{{Name: "web-1", Namespace: "shop"}}},
+		},
+	}
+}
+
+func TestNeighborhood(t *testing.T) {
+	type args struct {
+		node types.ObjectRef
+		hops int
+	}
+	tests := []struct {
+		name             string
+		args             args
+		expectedSubgraph Subgraph
+	}{
+		{
+			name: "returns the direct neighbors of a pod",
+			args: args{node: apiNode, hops: 1},
+			expectedSubgraph: Subgraph{
+				Nodes: []types.ObjectRef{apiNode, dbNode, webNode, apiSvcNode},
+				Edges: []*Edge{apiToDb, webToApi, apiSvcToApi},
+			},
+		},
+		{
+			name: "follows edges in both directions over several hops",
+			args: args{node: apiSvcNode, hops: 2},
+			expectedSubgraph: Subgraph{
+				Nodes: []types.ObjectRef{apiIngNode, apiNode, dbNode, webNode, apiSvcNode},
+				Edges: []*Edge{apiRoute, apiToDb, webToApi, apiSvcToApi},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subgraph := New(shopAnalysisResult()).Neighborhood(tt.args.node, tt.args.hops)
+			if diff := cmp.Diff(tt.expectedSubgraph, subgraph); diff != "" {
+				t.Errorf("Neighborhood() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPathsFromIngresses(t *testing.T) {
+	type args struct {
+		node     types.ObjectRef
+		maxHops  int
+		maxPaths int
+	}
+	tests := []struct {
+		name          string
+		args          args
+		expectedPaths []Path
+	}{
+		{
+			name: "returns every path from an ingress to a pod, shortest first",
+			args: args{node: dbNode, maxHops: 10, maxPaths: 10},
+			expectedPaths: []Path{
+				{
+					Nodes: []types.ObjectRef{apiIngNode, apiSvcNode, apiNode, dbNode},
+					Edges: []*Edge{apiRoute, apiSvcToApi, apiToDb},
+				},
+				{
+					Nodes: []types.ObjectRef{frontIngNode, frontNode, webNode, apiNode, dbNode},
+					Edges: []*Edge{frontRoute, frontToWeb, webToApi, apiToDb},
+				},
+			},
+		},
+		{
+			name: "ignores paths longer than the max hops",
+			args: args{node: dbNode, maxHops: 3, maxPaths: 10},
+			expectedPaths: []Path{
+				{
+					Nodes: []types.ObjectRef{apiIngNode, apiSvcNode, apiNode, dbNode},
+					Edges: []*Edge{apiRoute, apiSvcToApi, apiToDb},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths := New(shopAnalysisResult()).PathsFromIngresses(tt.args.node, tt.args.maxHops, tt.args.maxPaths)
+			if diff := cmp.Diff(tt.expectedPaths, paths); diff != "" {
+				t.Errorf("PathsFromIngresses() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestShortestPath(t *testing.T) {
+	type args struct {
+		source types.ObjectRef
+		target types.ObjectRef
+	}
+	tests := []struct {
+		name         string
+		args         args
+		expectedPath *Path
+	}{
+		{
+			name: "returns the shortest allowed path between the pods of two workloads",
+			args: args{
+				source: types.ObjectRef{Kind: "Deployment", Name: "web", Namespace: "shop"},
+				target: types.ObjectRef{Kind: "StatefulSet", Name: "db", Namespace: "shop"},
+			},
+			expectedPath: &Path{
+				Nodes: []types.ObjectRef{webNode, apiNode, dbNode},
+				Edges: []*Edge{webToApi, apiToDb},
+			},
+		},
+		{
+			name: "returns no path when the routes do not allow it",
+			args: args{
+				source: types.ObjectRef{Kind: "StatefulSet", Name: "db", Namespace: "shop"},
+				target: types.ObjectRef{Kind: "Deployment", Name: "web", Namespace: "shop"},
+			},
+			expectedPath: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := New(shopAnalysisResult()).ShortestPath(tt.args.source, tt.args.target)
+			if diff := cmp.Diff(tt.expectedPath, path); diff != "" {
+				t.Errorf("ShortestPath() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
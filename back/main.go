@@ -3,17 +3,23 @@ package main
 import (
 	"flag"
 	"fmt"
+	"karto/cli"
 	"karto/clusterlistener"
 	"karto/exposition"
 	"karto/types"
 	"os"
-	"path/filepath"
+	"strings"
+	"time"
 )
 
 const version = "1.8.0"
 
 func main() {
-	versionFlag, k8sConfigPath := parseCmd()
+	if len(os.Args) > 1 && cli.IsCommand(os.Args[1]) {
+		analysisScheduler := dependencyInjection().AnalysisScheduler
+		os.Exit(cli.Run(os.Args[1:], analysisScheduler.Analyze, os.Stdout, os.Stderr))
+	}
+	versionFlag, listenerConfig, historyConfig := parseCmd()
 	if versionFlag {
 		fmt.Printf("Karto v%s\n", version)
 		os.Exit(0)
@@ -22,25 +28,64 @@ func main() {
 	analysisScheduler := container.AnalysisScheduler
 	analysisResultsChannel := make(chan types.AnalysisResult)
 	clusterStateChannel := make(chan types.ClusterState)
-	go clusterlistener.Listen(k8sConfigPath, clusterStateChannel)
+	go clusterlistener.Listen(listenerConfig, clusterStateChannel)
 	go analysisScheduler.AnalyzeOnClusterStateChange(clusterStateChannel, analysisResultsChannel)
-	exposition.Expose(":8000", analysisResultsChannel)
+	exposition.Expose(":8000", historyConfig, analysisResultsChannel)
 }
 
-func parseCmd() (bool, string) {
+func parseCmd() (bool, clusterlistener.Config, exposition.HistoryConfig) {
 	versionFlag := flag.Bool("version", false, "prints Karto's current version")
-	home := os.Getenv("HOME")
-	if home == "" {
-		home = os.Getenv("USERPROFILE")
-	}
 	var k8sConfigPath *string
-	if home != "" {
-		k8sConfigPath = flag.String("kubeconfig", filepath.Join(home, ".kube", "config"),
+	if defaultK8sConfigPath := clusterlistener.DefaultK8sConfigPath(); defaultK8sConfigPath != "" {
+		k8sConfigPath = flag.String("kubeconfig", defaultK8sConfigPath,
 			"(optional) absolute path to the kubeconfig file")
 	} else {
 		k8sConfigPath = flag.String("kubeconfig", "", "absolute path to the kubeconfig file")
 	}
+	k8sConfigDir := flag.String("kubeconfig-dir", "",
+		"(optional) directory of kubeconfig files, one per cluster to watch (named after the file)")
+	k8sContexts := flag.String("contexts", "",
+		"(optional) comma-separated kubeconfig contexts, one per cluster to watch (named after the context)")
+	namespaces := flag.String("namespaces", "",
+		"(optional) comma-separated namespaces to watch, only requiring namespace-level permissions")
+	excludedNamespaces := flag.String("exclude-namespaces", "",
+		"(optional) comma-separated namespaces to ignore")
+	labelSelector := flag.String("label-selector", "",
+		"(optional) label selector restricting the pods, services, ingresses and workloads watched")
+	eventsRetention := flag.Duration("events-retention", time.Hour,
+		"(optional) how long warning events are kept and reported by the health analysis")
+	analysisDebounce := flag.Duration("analysis-debounce", time.Second,
+		"(optional) quiet period after a cluster change before analyzing, coalescing the changes of a burst")
+	analysisMaxLatency := flag.Duration("analysis-max-latency", 10*time.Second,
+		"(optional) maximum delay between a cluster change and its analysis, even if changes keep coming")
+	historySize := flag.Int("history-size", 20,
+		"(optional) number of distinct analysis results kept per cluster for /api/history, 0 to disable")
+	historyFile := flag.String("history-file", "",
+		"(optional) file where the analysis history is saved, to keep it across restarts")
 	flag.Parse()
 
-	return *versionFlag, *k8sConfigPath
+	return *versionFlag, clusterlistener.Config{
+		K8sConfigPath:      *k8sConfigPath,
+		K8sConfigDir:       *k8sConfigDir,
+		K8sContexts:        splitList(*k8sContexts),
+		Namespaces:         splitList(*namespaces),
+		ExcludedNamespaces: splitList(*excludedNamespaces),
+		LabelSelector:      *labelSelector,
+		EventsRetention:    *eventsRetention,
+		AnalysisDebounce:   *analysisDebounce,
+		AnalysisMaxLatency: *analysisMaxLatency,
+	}, exposition.HistoryConfig{
+		Size:     *historySize,
+		FilePath: *historyFile,
+	}
+}
+
+func splitList(value string) []string {
+	result := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
 }
@@ -0,0 +1,440 @@
+package manifests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes/scheme"
+	"karto/crds/calico"
+	"karto/crds/cilium"
+	"karto/crds/istio"
+	"karto/crds/mcs"
+	"karto/crds/policyapi"
+	"karto/types"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	defaultNamespace        = "default"
+	namespaceNameLabel      = "kubernetes.io/metadata.name"
+	decoderBufferSize       = 4096
+	synthesizedUIDSeparator = "/"
+)
+
+var manifestExtensions = []string{".yaml", ".yml", ".json"}
+
+func Load(paths []string) (types.ClusterState, error) {
+	loader := newLoader()
+	for _, path := range paths {
+		err := filepath.WalkDir(path, func(filePath string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() || (filePath != path && !isManifestFile(filePath)) {
+				return nil
+			}
+			return loader.loadFile(filePath)
+		})
+		if err != nil {
+			return types.ClusterState{}, err
+		}
+	}
+	return loader.clusterState(), nil
+}
+
+type loader struct {
+	state types.ClusterState
+}
+
+func newLoader() *loader {
+	return &loader{
+		state: types.ClusterState{
+			Namespaces:            []*corev1.Namespace{},
+			Pods:                  []*corev1.Pod{},
+			Services:              []*corev1.Service{},
+			Ingresses:             []*networkingv1.Ingress{},
+			ReplicaSets:           []*appsv1.ReplicaSet{},
+			StatefulSets:          []*appsv1.StatefulSet{},
+			DaemonSets:            []*appsv1.DaemonSet{},
+			Deployments:           []*appsv1.Deployment{},
+			NetworkPolicies:       []*networkingv1.NetworkPolicy{},
+			Events:                []*corev1.Event{},
+			CiliumNetworkPolicies: []*cilium.NetworkPolicy{},
+			CalicoNetworkPolicies: []*calico.NetworkPolicy{},
+			CalicoTiers:           []*calico.Tier{},
+			AdminNetworkPolicies:  []*policyapi.AdminNetworkPolicy{},
+			AuthorizationPolicies: []*istio.AuthorizationPolicy{},
+			PeerAuthentications:   []*istio.PeerAuthentication{},
+			ServiceEntries:        []*istio.ServiceEntry{},
+			ServiceExports:        []*mcs.ServiceExport{},
+			ServiceImports:        []*mcs.ServiceImport{},
+		},
+	}
+}
+
+func isManifestFile(filePath string) bool {
+	extension := strings.ToLower(filepath.Ext(filePath))
+	for _, manifestExtension := range manifestExtensions {
+		if extension == manifestExtension {
+			return true
+		}
+	}
+	return false
+}
+
+func (loader *loader) loadFile(filePath string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	decoder := yaml.NewYAMLOrJSONDecoder(file, decoderBufferSize)
+	for {
+		var rawObject runtime.RawExtension
+		err = decoder.Decode(&rawObject)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", filePath, err)
+		}
+		if len(bytes.TrimSpace(rawObject.Raw)) == 0 {
+			continue
+		}
+		err = loader.loadObject(rawObject.Raw)
+		if err != nil {
+			return fmt.Errorf("%s: %w", filePath, err)
+		}
+	}
+}
+
+func (loader *loader) loadObject(raw []byte) error {
+	object, _, err := scheme.Codecs.UniversalDeserializer().Decode(raw, nil, nil)
+	if runtime.IsNotRegisteredError(err) {
+		return loader.loadCustomObject(raw)
+	}
+	if err != nil {
+		return err
+	}
+	switch typedObject := object.(type) {
+	case *corev1.List:
+		for _, item := range typedObject.Items {
+			err = loader.loadObject(item.Raw)
+			if err != nil {
+				return err
+			}
+		}
+	case *corev1.Namespace:
+		loader.state.Namespaces = append(loader.state.Namespaces, typedObject)
+	case *corev1.Pod:
+		loader.state.Pods = append(loader.state.Pods, typedObject)
+	case *corev1.Service:
+		loader.state.Services = append(loader.state.Services, typedObject)
+	case *networkingv1.Ingress:
+		loader.state.Ingresses = append(loader.state.Ingresses, typedObject)
+	case *appsv1.ReplicaSet:
+		loader.state.ReplicaSets = append(loader.state.ReplicaSets, typedObject)
+	case *appsv1.StatefulSet:
+		loader.state.StatefulSets = append(loader.state.StatefulSets, typedObject)
+	case *appsv1.DaemonSet:
+		loader.state.DaemonSets = append(loader.state.DaemonSets, typedObject)
+	case *appsv1.Deployment:
+		loader.state.Deployments = append(loader.state.Deployments, typedObject)
+	case *networkingv1.NetworkPolicy:
+		loader.state.NetworkPolicies = append(loader.state.NetworkPolicies, typedObject)
+	}
+	return nil
+}
+
+func (loader *loader) loadCustomObject(raw []byte) error {
+	typeMeta := metav1.TypeMeta{}
+	err := json.Unmarshal(raw, &typeMeta)
+	if err != nil {
+		return err
+	}
+	groupVersion, err := schema.ParseGroupVersion(typeMeta.APIVersion)
+	if err != nil {
+		return err
+	}
+	if groupVersion.Group == cilium.GroupVersion.Group && (typeMeta.Kind == cilium.NetworkPolicyKind ||
+		typeMeta.Kind == cilium.ClusterwideNetworkPolicyKind) {
+		policy := &cilium.NetworkPolicy{}
+		err = json.Unmarshal(raw, policy)
+		if err != nil {
+			return err
+		}
+		if typeMeta.Kind == cilium.ClusterwideNetworkPolicyKind {
+			policy.Namespace = ""
+		}
+		loader.state.CiliumNetworkPolicies = append(loader.state.CiliumNetworkPolicies, policy)
+	}
+	if strings.HasSuffix(groupVersion.Group, calico.GroupVersion.Group) {
+		return loader.loadCalicoObject(typeMeta, raw)
+	}
+	if groupVersion.Group == policyapi.GroupVersion.Group && (typeMeta.Kind == policyapi.AdminNetworkPolicyKind ||
+		typeMeta.Kind == policyapi.BaselineAdminNetworkPolicyKind) {
+		policy := &policyapi.AdminNetworkPolicy{}
+		err = json.Unmarshal(raw, policy)
+		if err != nil {
+			return err
+		}
+		policy.Namespace = ""
+		loader.state.AdminNetworkPolicies = append(loader.state.AdminNetworkPolicies, policy)
+	}
+	if groupVersion.Group == istio.GroupVersion.Group || groupVersion.Group == istio.NetworkingGroupVersion.Group {
+		return loader.loadIstioObject(typeMeta, raw)
+	}
+	return nil
+}
+
+func (loader *loader) loadIstioObject(typeMeta metav1.TypeMeta, raw []byte) error {
+	switch typeMeta.Kind {
+	case istio.AuthorizationPolicyKind:
+		policy := &istio.AuthorizationPolicy{}
+		err := json.Unmarshal(raw, policy)
+		if err != nil {
+			return err
+		}
+		loader.state.AuthorizationPolicies = append(loader.state.AuthorizationPolicies, policy)
+	case istio.PeerAuthenticationKind:
+		peerAuthentication := &istio.PeerAuthentication{}
+		err := json.Unmarshal(raw, peerAuthentication)
+		if err != nil {
+			return err
+		}
+		loader.state.PeerAuthentications = append(loader.state.PeerAuthentications, peerAuthentication)
+	case istio.ServiceEntryKind:
+		serviceEntry := &istio.ServiceEntry{}
+		err := json.Unmarshal(raw, serviceEntry)
+		if err != nil {
+			return err
+		}
+		loader.state.ServiceEntries = append(loader.state.ServiceEntries, serviceEntry)
+	}
+	return nil
+}
+
+func (loader *loader) loadCalicoObject(typeMeta metav1.TypeMeta, raw []byte) error {
+	switch typeMeta.Kind {
+	case calico.NetworkPolicyKind, calico.GlobalNetworkPolicyKind:
+		policy := &calico.NetworkPolicy{}
+		err := json.Unmarshal(raw, policy)
+		if err != nil {
+			return err
+		}
+		if typeMeta.Kind == calico.GlobalNetworkPolicyKind {
+			policy.Namespace = ""
+		}
+		loader.state.CalicoNetworkPolicies = append(loader.state.CalicoNetworkPolicies, policy)
+	case calico.TierKind:
+		tier := &calico.Tier{}
+		err := json.Unmarshal(raw, tier)
+		if err != nil {
+			return err
+		}
+		loader.state.CalicoTiers = append(loader.state.CalicoTiers, tier)
+	}
+	return nil
+}
+
+func (loader *loader) clusterState() types.ClusterState {
+	state := loader.state
+	for _, object := range loader.namespacedObjects() {
+		if object.GetNamespace() == "" {
+			object.SetNamespace(defaultNamespace)
+		}
+		if object.GetUID() == "" {
+			object.SetUID(synthesizedUID(object))
+		}
+	}
+	for _, policy := range state.NetworkPolicies {
+		defaultPolicyTypes(policy)
+	}
+	for _, deployment := range state.Deployments {
+		replicaSet := replicaSetOf(deployment)
+		state.ReplicaSets = append(state.ReplicaSets, replicaSet)
+		state.Pods = append(state.Pods, podOf(replicaSet, replicaSet.Name, replicaSet.Spec.Template))
+	}
+	for _, replicaSet := range loader.state.ReplicaSets {
+		state.Pods = append(state.Pods, podOf(replicaSet, replicaSet.Name, replicaSet.Spec.Template))
+	}
+	for _, statefulSet := range state.StatefulSets {
+		state.Pods = append(state.Pods, podOf(statefulSet, statefulSet.Name+"-0", statefulSet.Spec.Template))
+	}
+	for _, daemonSet := range state.DaemonSets {
+		state.Pods = append(state.Pods, podOf(daemonSet, daemonSet.Name, daemonSet.Spec.Template))
+	}
+	state.Namespaces = withReferencedNamespaces(state)
+	return state
+}
+
+func (loader *loader) namespacedObjects() []metav1.Object {
+	objects := make([]metav1.Object, 0)
+	for _, pod := range loader.state.Pods {
+		objects = append(objects, pod)
+	}
+	for _, service := range loader.state.Services {
+		objects = append(objects, service)
+	}
+	for _, ingress := range loader.state.Ingresses {
+		objects = append(objects, ingress)
+	}
+	for _, replicaSet := range loader.state.ReplicaSets {
+		objects = append(objects, replicaSet)
+	}
+	for _, statefulSet := range loader.state.StatefulSets {
+		objects = append(objects, statefulSet)
+	}
+	for _, daemonSet := range loader.state.DaemonSets {
+		objects = append(objects, daemonSet)
+	}
+	for _, deployment := range loader.state.Deployments {
+		objects = append(objects, deployment)
+	}
+	for _, policy := range loader.state.NetworkPolicies {
+		objects = append(objects, policy)
+	}
+	for _, policy := range loader.state.CiliumNetworkPolicies {
+		if policy.Kind == cilium.NetworkPolicyKind {
+			objects = append(objects, policy)
+		}
+	}
+	for _, policy := range loader.state.CalicoNetworkPolicies {
+		if policy.Kind == calico.NetworkPolicyKind {
+			objects = append(objects, policy)
+		}
+	}
+	for _, policy := range loader.state.AuthorizationPolicies {
+		objects = append(objects, policy)
+	}
+	for _, peerAuthentication := range loader.state.PeerAuthentications {
+		objects = append(objects, peerAuthentication)
+	}
+	for _, serviceEntry := range loader.state.ServiceEntries {
+		objects = append(objects, serviceEntry)
+	}
+	return objects
+}
+
+func synthesizedUID(object metav1.Object) k8stypes.UID {
+	kind := ""
+	if runtimeObject, ok := object.(runtime.Object); ok {
+		kind = fmt.Sprintf("%T", runtimeObject)
+	}
+	return k8stypes.UID(strings.Join([]string{kind, object.GetNamespace(), object.GetName()},
+		synthesizedUIDSeparator))
+}
+
+func defaultPolicyTypes(policy *networkingv1.NetworkPolicy) {
+	if len(policy.Spec.PolicyTypes) != 0 {
+		return
+	}
+	policy.Spec.PolicyTypes = []networkingv1.PolicyType{networkingv1.PolicyTypeIngress}
+	if len(policy.Spec.Egress) != 0 {
+		policy.Spec.PolicyTypes = append(policy.Spec.PolicyTypes, networkingv1.PolicyTypeEgress)
+	}
+}
+
+func replicaSetOf(deployment *appsv1.Deployment) *appsv1.ReplicaSet {
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	replicaSet := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            deployment.Name,
+			Namespace:       deployment.Namespace,
+			Labels:          deployment.Spec.Template.Labels,
+			OwnerReferences: []metav1.OwnerReference{ownerReference("Deployment", deployment)},
+		},
+		Spec: appsv1.ReplicaSetSpec{
+			Replicas: &replicas,
+			Selector: deployment.Spec.Selector,
+			Template: deployment.Spec.Template,
+		},
+	}
+	replicaSet.UID = synthesizedUID(replicaSet)
+	return replicaSet
+}
+
+func podOf(owner metav1.Object, name string, template corev1.PodTemplateSpec) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       owner.GetNamespace(),
+			Labels:          template.Labels,
+			Annotations:     template.Annotations,
+			OwnerReferences: []metav1.OwnerReference{ownerReference("", owner)},
+		},
+		Spec: template.Spec,
+	}
+	pod.UID = synthesizedUID(pod)
+	return pod
+}
+
+func ownerReference(kind string, owner metav1.Object) metav1.OwnerReference {
+	return metav1.OwnerReference{
+		Kind: kind,
+		Name: owner.GetName(),
+		UID:  owner.GetUID(),
+	}
+}
+
+func withReferencedNamespaces(state types.ClusterState) []*corev1.Namespace {
+	namespaces := state.Namespaces
+	declared := map[string]bool{}
+	for _, namespace := range namespaces {
+		declared[namespace.Name] = true
+	}
+	referenced := make([]string, 0)
+	for _, pod := range state.Pods {
+		referenced = append(referenced, pod.Namespace)
+	}
+	for _, policy := range state.NetworkPolicies {
+		referenced = append(referenced, policy.Namespace)
+	}
+	for _, policy := range state.CiliumNetworkPolicies {
+		if policy.Namespace != "" {
+			referenced = append(referenced, policy.Namespace)
+		}
+	}
+	for _, policy := range state.CalicoNetworkPolicies {
+		if policy.Namespace != "" {
+			referenced = append(referenced, policy.Namespace)
+		}
+	}
+	for _, service := range state.Services {
+		referenced = append(referenced, service.Namespace)
+	}
+	for _, namespaceName := range referenced {
+		if !declared[namespaceName] {
+			declared[namespaceName] = true
+			namespaces = append(namespaces, &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{Name: namespaceName},
+			})
+		}
+	}
+	for _, namespace := range namespaces {
+		if namespace.Labels == nil {
+			namespace.Labels = map[string]string{}
+		}
+		if _, ok := namespace.Labels[namespaceNameLabel]; !ok {
+			namespace.Labels[namespaceNameLabel] = namespace.Name
+		}
+	}
+	return namespaces
+}
@@ -0,0 +1,351 @@
+package manifests
+
+import (
+	"fmt"
+	"github.com/google/go-cmp/cmp"
+	networkingv1 "k8s.io/api/networking/v1"
+	"karto/crds/istio"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	type expectedPod struct {
+		Name      string
+		Namespace string
+		Labels    map[string]string
+		OwnerName string
+	}
+	tests := []struct {
+		name                       string
+		files                      map[string]string
+		expectedNamespaces         []string
+		expectedPods               []expectedPod
+		expectedPolicyTypes        [][]networkingv1.PolicyType
+		expectedReplicaSetsOwnerOf map[string]string
+		expectedCiliumPolicies     []string
+		expectedCalicoObjects      []string
+		expectedAdminPolicies      []string
+		expectedIstioObjects       []string
+	}{
+		{
+			name: "synthesizes pods from workload templates and missing namespaces",
+			files: map[string]string{
+				"app.yaml": "" +
+					"apiVersion: apps/v1\n" +
+					"kind: Deployment\n" +
+					"metadata:\n" +
+					"  name: web\n" +
+					"  namespace: shop\n" +
+					"spec:\n" +
+					"  selector:\n" +
+					"    matchLabels: {app: web}\n" +
+					"  template:\n" +
+					"    metadata:\n" +
+					"      labels: {app: web}\n" +
+					"    spec:\n" +
+					"      containers: [{name: web, image: nginx}]\n" +
+					"---\n" +
+					"apiVersion: apps/v1\n" +
+					"kind: StatefulSet\n" +
+					"metadata:\n" +
+					"  name: db\n" +
+					"spec:\n" +
+					"  selector:\n" +
+					"    matchLabels: {app: db}\n" +
+					"  template:\n" +
+					"    metadata:\n" +
+					"      labels: {app: db}\n" +
+					"    spec:\n" +
+					"      containers: [{name: db, image: postgres}]\n",
+				"ignored.txt": "not a manifest",
+			},
+			expectedNamespaces: []string{"shop", "default"},
+			expectedPods: []expectedPod{
+				{Name: "web", Namespace: "shop", Labels: map[string]string{"app": "web"}, OwnerName: "web"},
+				{Name: "db-0", Namespace: "default", Labels: map[string]string{"app": "db"}, OwnerName: "db"},
+			},
+			expectedPolicyTypes:        [][]networkingv1.PolicyType{},
+			expectedReplicaSetsOwnerOf: map[string]string{"web": "web"},
+			expectedCiliumPolicies:     []string{},
+			expectedCalicoObjects:      []string{},
+			expectedAdminPolicies:      []string{},
+			expectedIstioObjects:       []string{},
+		},
+		{
+			name: "defaults policy types and reads lists and json files",
+			files: map[string]string{
+				"policies.yml": "" +
+					"apiVersion: v1\n" +
+					"kind: List\n" +
+					"items:\n" +
+					"  - apiVersion: networking.k8s.io/v1\n" +
+					"    kind: NetworkPolicy\n" +
+					"    metadata: {name: ingress, namespace: ns}\n" +
+					"    spec:\n" +
+					"      podSelector: {}\n" +
+					"  - apiVersion: networking.k8s.io/v1\n" +
+					"    kind: NetworkPolicy\n" +
+					"    metadata: {name: both, namespace: ns}\n" +
+					"    spec:\n" +
+					"      podSelector: {}\n" +
+					"      egress: [{}]\n",
+				"namespace.json": "{\"apiVersion\":\"v1\",\"kind\":\"Namespace\",\"metadata\":{\"name\":\"ns\"}}",
+			},
+			expectedNamespaces: []string{"ns"},
+			expectedPods:       []expectedPod{},
+			expectedPolicyTypes: [][]networkingv1.PolicyType{
+				{networkingv1.PolicyTypeIngress},
+				{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+			},
+			expectedReplicaSetsOwnerOf: map[string]string{},
+			expectedCiliumPolicies:     []string{},
+			expectedCalicoObjects:      []string{},
+			expectedAdminPolicies:      []string{},
+			expectedIstioObjects:       []string{},
+		},
+		{
+			name: "reads cilium network policies",
+			files: map[string]string{
+				"cilium.yaml": "" +
+					"apiVersion: cilium.io/v2\n" +
+					"kind: CiliumNetworkPolicy\n" +
+					"metadata: {name: api, namespace: back}\n" +
+					"spec:\n" +
+					"  endpointSelector: {matchLabels: {app: api}}\n" +
+					"  ingress: [{fromEntities: [world]}]\n" +
+					"---\n" +
+					"apiVersion: cilium.io/v2\n" +
+					"kind: CiliumClusterwideNetworkPolicy\n" +
+					"metadata: {name: all}\n" +
+					"specs:\n" +
+					"  - endpointSelector: {}\n" +
+					"    egress: [{toEntities: [cluster]}]\n" +
+					"---\n" +
+					"apiVersion: cilium.io/v2\n" +
+					"kind: CiliumNetworkPolicy\n" +
+					"metadata: {name: defaulted}\n" +
+					"spec:\n" +
+					"  endpointSelector: {}\n",
+			},
+			expectedNamespaces:         []string{"back", "default"},
+			expectedPods:               []expectedPod{},
+			expectedPolicyTypes:        [][]networkingv1.PolicyType{},
+			expectedReplicaSetsOwnerOf: map[string]string{},
+			expectedCiliumPolicies: []string{
+				"CiliumNetworkPolicy back/api (1 rule(s))",
+				"CiliumClusterwideNetworkPolicy /all (1 rule(s))",
+				"CiliumNetworkPolicy default/defaulted (1 rule(s))",
+			},
+			expectedCalicoObjects: []string{},
+			expectedAdminPolicies: []string{},
+			expectedIstioObjects:  []string{},
+		},
+		{
+			name: "reads calico network policies and tiers",
+			files: map[string]string{
+				"calico.yaml": "" +
+					"apiVersion: projectcalico.org/v3\n" +
+					"kind: Tier\n" +
+					"metadata: {name: security}\n" +
+					"spec: {order: 100}\n" +
+					"---\n" +
+					"apiVersion: projectcalico.org/v3\n" +
+					"kind: GlobalNetworkPolicy\n" +
+					"metadata: {name: security.deny-db, namespace: ignored}\n" +
+					"spec:\n" +
+					"  tier: security\n" +
+					"  selector: app == 'db'\n" +
+					"  ingress: [{action: Deny, destination: {ports: [5432, '8000:8080']}}]\n" +
+					"---\n" +
+					"apiVersion: crd.projectcalico.org/v1\n" +
+					"kind: NetworkPolicy\n" +
+					"metadata: {name: api, namespace: back}\n" +
+					"spec:\n" +
+					"  selector: all()\n" +
+					"  egress: [{action: Allow}]\n",
+			},
+			expectedNamespaces:         []string{"back"},
+			expectedPods:               []expectedPod{},
+			expectedPolicyTypes:        [][]networkingv1.PolicyType{},
+			expectedReplicaSetsOwnerOf: map[string]string{},
+			expectedCiliumPolicies:     []string{},
+			expectedCalicoObjects: []string{
+				"Tier security",
+				"GlobalNetworkPolicy /security.deny-db in tier security (1 ingress, 0 egress rule(s))",
+				"NetworkPolicy back/api in tier default (0 ingress, 1 egress rule(s))",
+			},
+			expectedAdminPolicies: []string{},
+			expectedIstioObjects:  []string{},
+		},
+		{
+			name: "reads admin and baseline admin network policies",
+			files: map[string]string{
+				"admin.yaml": "" +
+					"apiVersion: policy.networking.k8s.io/v1alpha1\n" +
+					"kind: AdminNetworkPolicy\n" +
+					"metadata: {name: deny-db, namespace: ignored}\n" +
+					"spec:\n" +
+					"  priority: 10\n" +
+					"  subject: {namespaces: {matchLabels: {team: db}}}\n" +
+					"  ingress: [{action: Deny, from: [{namespaces: {}}], ports: [{portNumber: {port: 5432}}]}]\n" +
+					"---\n" +
+					"apiVersion: policy.networking.k8s.io/v1alpha1\n" +
+					"kind: BaselineAdminNetworkPolicy\n" +
+					"metadata: {name: default}\n" +
+					"spec:\n" +
+					"  subject: {namespaces: {}}\n" +
+					"  egress: [{action: Deny, to: [{networks: [0.0.0.0/0]}]}]\n",
+			},
+			expectedNamespaces:         []string{},
+			expectedPods:               []expectedPod{},
+			expectedPolicyTypes:        [][]networkingv1.PolicyType{},
+			expectedReplicaSetsOwnerOf: map[string]string{},
+			expectedCiliumPolicies:     []string{},
+			expectedCalicoObjects:      []string{},
+			expectedAdminPolicies: []string{
+				"AdminNetworkPolicy /deny-db with priority 10 (1 ingress, 0 egress rule(s))",
+				"BaselineAdminNetworkPolicy /default with priority 0 (0 ingress, 1 egress rule(s))",
+			},
+			expectedIstioObjects: []string{},
+		},
+		{
+			name: "reads istio authorization policies, peer authentications and service entries",
+			files: map[string]string{
+				"istio.yaml": "" +
+					"apiVersion: security.istio.io/v1beta1\n" +
+					"kind: AuthorizationPolicy\n" +
+					"metadata: {name: api, namespace: shop}\n" +
+					"spec:\n" +
+					"  selector: {matchLabels: {app: api}}\n" +
+					"  action: DENY\n" +
+					"  rules: [{to: [{operation: {methods: [DELETE]}}]}]\n" +
+					"---\n" +
+					"apiVersion: security.istio.io/v1beta1\n" +
+					"kind: PeerAuthentication\n" +
+					"metadata: {name: strict}\n" +
+					"spec:\n" +
+					"  mtls: {mode: STRICT}\n" +
+					"  portLevelMtls: {\"8080\": {mode: DISABLE}}\n" +
+					"---\n" +
+					"apiVersion: networking.istio.io/v1beta1\n" +
+					"kind: ServiceEntry\n" +
+					"metadata: {name: payments, namespace: shop}\n" +
+					"spec:\n" +
+					"  hosts: [payments.example.com]\n" +
+					"  ports: [{number: 443, name: https, protocol: TLS}]\n" +
+					"  resolution: DNS\n",
+			},
+			expectedNamespaces:         []string{},
+			expectedPods:               []expectedPod{},
+			expectedPolicyTypes:        [][]networkingv1.PolicyType{},
+			expectedReplicaSetsOwnerOf: map[string]string{},
+			expectedCiliumPolicies:     []string{},
+			expectedCalicoObjects:      []string{},
+			expectedAdminPolicies:      []string{},
+			expectedIstioObjects: []string{
+				"AuthorizationPolicy shop/api DENY (1 rule(s))",
+				"PeerAuthentication default/strict STRICT (1 port level mode(s))",
+				"ServiceEntry shop/payments MESH_EXTERNAL (1 host(s))",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for fileName, content := range tt.files {
+				err := os.WriteFile(filepath.Join(dir, fileName), []byte(content), 0o600)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			clusterState, err := Load([]string{dir})
+			if err != nil {
+				t.Fatalf("Load() returned an unexpected error: %s", err)
+			}
+			namespaces := make([]string, 0)
+			for _, namespace := range clusterState.Namespaces {
+				namespaces = append(namespaces, namespace.Name)
+				if namespace.Labels[namespaceNameLabel] != namespace.Name {
+					t.Errorf("Load() namespace %s is missing its name label", namespace.Name)
+				}
+			}
+			if diff := cmp.Diff(tt.expectedNamespaces, namespaces); diff != "" {
+				t.Errorf("Load() namespaces mismatch (-want +got):\n%s", diff)
+			}
+			pods := make([]expectedPod, 0)
+			for _, pod := range clusterState.Pods {
+				pods = append(pods, expectedPod{Name: pod.Name, Namespace: pod.Namespace, Labels: pod.Labels,
+					OwnerName: pod.OwnerReferences[0].Name})
+			}
+			if diff := cmp.Diff(tt.expectedPods, pods); diff != "" {
+				t.Errorf("Load() pods mismatch (-want +got):\n%s", diff)
+			}
+			policyTypes := make([][]networkingv1.PolicyType, 0)
+			for _, policy := range clusterState.NetworkPolicies {
+				policyTypes = append(policyTypes, policy.Spec.PolicyTypes)
+			}
+			if diff := cmp.Diff(tt.expectedPolicyTypes, policyTypes); diff != "" {
+				t.Errorf("Load() policy types mismatch (-want +got):\n%s", diff)
+			}
+			replicaSetsOwnerOf := map[string]string{}
+			for _, replicaSet := range clusterState.ReplicaSets {
+				replicaSetsOwnerOf[replicaSet.Name] = replicaSet.OwnerReferences[0].Name
+			}
+			if diff := cmp.Diff(tt.expectedReplicaSetsOwnerOf, replicaSetsOwnerOf); diff != "" {
+				t.Errorf("Load() replicaSets mismatch (-want +got):\n%s", diff)
+			}
+			ciliumPolicies := make([]string, 0)
+			for _, policy := range clusterState.CiliumNetworkPolicies {
+				ciliumPolicies = append(ciliumPolicies, fmt.Sprintf("%s %s/%s (%d rule(s))", policy.Kind,
+					policy.Namespace, policy.Name, len(policy.Rules())))
+			}
+			if diff := cmp.Diff(tt.expectedCiliumPolicies, ciliumPolicies); diff != "" {
+				t.Errorf("Load() cilium policies mismatch (-want +got):\n%s", diff)
+			}
+			calicoObjects := make([]string, 0)
+			for _, tier := range clusterState.CalicoTiers {
+				calicoObjects = append(calicoObjects, "Tier "+tier.Name)
+			}
+			for _, policy := range clusterState.CalicoNetworkPolicies {
+				calicoObjects = append(calicoObjects, fmt.Sprintf("%s %s/%s in tier %s (%d ingress, %d egress rule(s))",
+					policy.Kind, policy.Namespace, policy.Name, policy.TierName(), len(policy.Spec.Ingress),
+					len(policy.Spec.Egress)))
+			}
+			if diff := cmp.Diff(tt.expectedCalicoObjects, calicoObjects); diff != "" {
+				t.Errorf("Load() calico objects mismatch (-want +got):\n%s", diff)
+			}
+			adminPolicies := make([]string, 0)
+			for _, policy := range clusterState.AdminNetworkPolicies {
+				adminPolicies = append(adminPolicies, fmt.Sprintf("%s %s/%s with priority %d (%d ingress, %d egress rule(s))",
+					policy.Kind, policy.Namespace, policy.Name, policy.Spec.Priority, len(policy.Spec.Ingress),
+					len(policy.Spec.Egress)))
+			}
+			if diff := cmp.Diff(tt.expectedAdminPolicies, adminPolicies); diff != "" {
+				t.Errorf("Load() admin policies mismatch (-want +got):\n%s", diff)
+			}
+			istioObjects := make([]string, 0)
+			for _, policy := range clusterState.AuthorizationPolicies {
+				istioObjects = append(istioObjects, fmt.Sprintf("%s %s/%s %s (%d rule(s))", policy.Kind,
+					policy.Namespace, policy.Name, policy.ActionName(), len(policy.Spec.Rules)))
+			}
+			for _, peerAuthentication := range clusterState.PeerAuthentications {
+				istioObjects = append(istioObjects, fmt.Sprintf("%s %s/%s %s (%d port level mode(s))",
+					peerAuthentication.Kind, peerAuthentication.Namespace, peerAuthentication.Name,
+					peerAuthentication.Spec.MTLS.Mode, len(peerAuthentication.Spec.PortLevelMTLS)))
+			}
+			for _, serviceEntry := range clusterState.ServiceEntries {
+				location := istio.LocationMeshInternal
+				if serviceEntry.IsExternal() {
+					location = istio.LocationMeshExternal
+				}
+				istioObjects = append(istioObjects, fmt.Sprintf("%s %s/%s %s (%d host(s))", serviceEntry.Kind,
+					serviceEntry.Namespace, serviceEntry.Name, location, len(serviceEntry.Spec.Hosts)))
+			}
+			if diff := cmp.Diff(tt.expectedIstioObjects, istioObjects); diff != "" {
+				t.Errorf("Load() istio objects mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package synthesis
+
+import (
+	"karto/types"
+	"karto/verification"
+	"sort"
+)
+
+type Route struct {
+	Source     types.PodRef      `json:"source"`
+	Target     types.PodRef      `json:"target"`
+	Ports      []int32           `json:"ports"`
+	PortRanges []types.PortRange `json:"portRanges,omitempty"`
+}
+
+type RouteDiff struct {
+	Missing    []Route `json:"missing"`
+	Unexpected []Route `json:"unexpected"`
+}
+
+type routeKey struct {
+	source types.PodRef
+	target types.PodRef
+}
+
+func RoutesFromAssertions(assertions []verification.Assertion, pods []*types.Pod) []Route {
+	routes := make([]Route, 0)
+	for _, assertion := range assertions {
+		if assertion.Expect != verification.ExpectAllowed {
+			continue
+		}
+		for _, source := range verification.SelectPods(assertion.From, pods) {
+			for _, target := range verification.SelectPods(assertion.To, pods) {
+				if source != target {
+					routes = append(routes, Route{Source: source, Target: target, Ports: assertion.Ports})
+				}
+			}
+		}
+	}
+	return mergeRoutes(routes)
+}
+
+func RoutesFromAllowedRoutes(allowedRoutes []*types.AllowedRoute, pods []*types.Pod,
+	sources []verification.Endpoint, targets []verification.Endpoint) []Route {
+	podsByRef := make(map[types.PodRef]*types.Pod, len(pods))
+	for _, pod := range pods {
+		podsByRef[types.PodRef{Name: pod.Name, Namespace: pod.Namespace}] = pod
+	}
+	routes := make([]Route, 0)
+	for _, allowedRoute := range allowedRoutes {
+		source, target := podsByRef[allowedRoute.SourcePod], podsByRef[allowedRoute.TargetPod]
+		if source == nil || target == nil || !anyEndpointMatches(sources, source) ||
+			!anyEndpointMatches(targets, target) {
+			continue
+		}
+		routes = append(routes, Route{Source: allowedRoute.SourcePod, Target: allowedRoute.TargetPod,
+			Ports: allowedRoute.Ports, PortRanges: allowedRoute.PortRanges})
+	}
+	return mergeRoutes(routes)
+}
+
+func anyEndpointMatches(endpoints []verification.Endpoint, pod *types.Pod) bool {
+	if len(endpoints) == 0 {
+		return true
+	}
+	for _, endpoint := range endpoints {
+		if endpoint.Matches(pod) {
+			return true
+		}
+	}
+	return false
+}
+
+func Diff(desiredRoutes []Route, allowedRoutes []*types.AllowedRoute) RouteDiff {
+	actualRoutes := make([]Route, 0, len(allowedRoutes))
+	for _, allowedRoute := range allowedRoutes {
+		actualRoutes = append(actualRoutes, Route{Source: allowedRoute.SourcePod, Target: allowedRoute.TargetPod,
+			Ports: allowedRoute.Ports, PortRanges: allowedRoute.PortRanges})
+	}
+	return RouteDiff{
+		Missing:    subtractRoutes(mergeRoutes(desiredRoutes), mergeRoutes(actualRoutes)),
+		Unexpected: subtractRoutes(mergeRoutes(actualRoutes), mergeRoutes(desiredRoutes)),
+	}
+}
+
+func subtractRoutes(routes []Route, removedRoutes []Route) []Route {
+	removedRoutesByKey := make(map[routeKey]Route, len(removedRoutes))
+	for _, removedRoute := range removedRoutes {
+		removedRoutesByKey[routeKey{source: removedRoute.Source, target: removedRoute.Target}] = removedRoute
+	}
+	result := make([]Route, 0)
+	for _, route := range routes {
+		removedRoute, isRemoved := removedRoutesByKey[routeKey{source: route.Source, target: route.Target}]
+		if !isRemoved {
+			result = append(result, route)
+			continue
+		}
+		if removedRoute.Ports == nil {
+			continue
+		}
+		if route.Ports == nil {
+			result = append(result, route)
+			continue
+		}
+		remainingPorts := make([]int32, 0)
+		for _, port := range route.Ports {
+			if !removedRoute.coversPort(port) {
+				remainingPorts = append(remainingPorts, port)
+			}
+		}
+		remainingPortRanges := subtractPortRanges(route.PortRanges, removedRoute)
+		if len(remainingPorts) != 0 || len(remainingPortRanges) != 0 {
+			result = append(result, Route{Source: route.Source, Target: route.Target, Ports: remainingPorts,
+				PortRanges: remainingPortRanges})
+		}
+	}
+	return result
+}
+
+func mergeRoutes(routes []Route) []Route {
+	mergedRoutes := map[routeKey]*Route{}
+	keys := make([]routeKey, 0)
+	for _, route := range routes {
+		key := routeKey{source: route.Source, target: route.Target}
+		mergedRoute, isKnown := mergedRoutes[key]
+		if !isKnown {
+			mergedRoutes[key] = &Route{Source: route.Source, Target: route.Target, Ports: copyPorts(route.Ports),
+				PortRanges: mergePortRanges(nil, route.PortRanges)}
+			keys = append(keys, key)
+			continue
+		}
+		mergedRoute.Ports = mergePorts(mergedRoute.Ports, route.Ports)
+		mergedRoute.PortRanges = mergePortRanges(mergedRoute.PortRanges, route.PortRanges)
+		if mergedRoute.Ports == nil {
+			mergedRoute.PortRanges = nil
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].source != keys[j].source {
+			return podRefLess(keys[i].source, keys[j].source)
+		}
+		return podRefLess(keys[i].target, keys[j].target)
+	})
+	result := make([]Route, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, *mergedRoutes[key])
+	}
+	return result
+}
+
+func mergePorts(ports []int32, otherPorts []int32) []int32 {
+	if ports == nil || otherPorts == nil {
+		return nil
+	}
+	result := copyPorts(ports)
+	for _, port := range otherPorts {
+		if !containsPort(result, port) {
+			result = append(result, port)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
+
+func copyPorts(ports []int32) []int32 {
+	if ports == nil {
+		return nil
+	}
+	result := make([]int32, len(ports))
+	copy(result, ports)
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
+
+func mergePortRanges(portRanges []types.PortRange, otherPortRanges []types.PortRange) []types.PortRange {
+	sorted := append(append([]types.PortRange{}, portRanges...), otherPortRanges...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+	var result []types.PortRange
+	for _, portRange := range sorted {
+		last := len(result) - 1
+		if last >= 0 && portRange.Start <= result[last].End+1 {
+			if portRange.End > result[last].End {
+				result[last].End = portRange.End
+			}
+			continue
+		}
+		result = append(result, portRange)
+	}
+	return result
+}
+
+func subtractPortRanges(portRanges []types.PortRange, removedRoute Route) []types.PortRange {
+	removedPortRanges := append([]types.PortRange{}, removedRoute.PortRanges...)
+	for _, port := range removedRoute.Ports {
+		removedPortRanges = append(removedPortRanges, types.PortRange{Start: port, End: port})
+	}
+	removedPortRanges = mergePortRanges(nil, removedPortRanges)
+	var result []types.PortRange
+	for _, portRange := range portRanges {
+		start := portRange.Start
+		for _, removedPortRange := range removedPortRanges {
+			if removedPortRange.End < start || removedPortRange.Start > portRange.End {
+				continue
+			}
+			if removedPortRange.Start > start {
+				result = append(result, types.PortRange{Start: start, End: removedPortRange.Start - 1})
+			}
+			start = removedPortRange.End + 1
+		}
+		if start <= portRange.End {
+			result = append(result, types.PortRange{Start: start, End: portRange.End})
+		}
+	}
+	return result
+}
+
+func (route Route) coversPort(port int32) bool {
+	if containsPort(route.Ports, port) {
+		return true
+	}
+	for _, portRange := range route.PortRanges {
+		if portRange.Contains(port) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsPort(ports []int32, port int32) bool {
+	for _, candidate := range ports {
+		if candidate == port {
+			return true
+		}
+	}
+	return false
+}
+
+func podRefLess(podRef types.PodRef, otherPodRef types.PodRef) bool {
+	if podRef.Namespace != otherPodRef.Namespace {
+		return podRef.Namespace < otherPodRef.Namespace
+	}
+	return podRef.Name < otherPodRef.Name
+}
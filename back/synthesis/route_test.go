@@ -0,0 +1,85 @@
+package synthesis
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"karto/types"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	type args struct {
+		desiredRoutes []Route
+		allowedRoutes []*types.AllowedRoute
+	}
+	pod1 := types.PodRef{Name: "pod1", Namespace: "ns"}
+	pod2 := types.PodRef{Name: "pod2", Namespace: "ns"}
+	pod3 := types.PodRef{Name: "pod3", Namespace: "ns"}
+	tests := []struct {
+		name         string
+		args         args
+		expectedDiff RouteDiff
+	}{
+		{
+			name: "no difference when allowed routes match the desired routes",
+			args: args{
+				desiredRoutes: []Route{
+					{Source: pod1, Target: pod2, Ports: []int32{443}},
+					{Source: pod1, Target: pod2, Ports: []int32{80}},
+				},
+				allowedRoutes: []*types.AllowedRoute{{SourcePod: pod1, TargetPod: pod2, Ports: []int32{80, 443}}},
+			},
+			expectedDiff: RouteDiff{Missing: []Route{}, Unexpected: []Route{}},
+		},
+		{
+			name: "reports missing and unexpected routes and ports",
+			args: args{
+				desiredRoutes: []Route{
+					{Source: pod1, Target: pod2, Ports: []int32{80, 8080}},
+					{Source: pod2, Target: pod3, Ports: nil},
+				},
+				allowedRoutes: []*types.AllowedRoute{
+					{SourcePod: pod1, TargetPod: pod2, Ports: []int32{80, 443}},
+					{SourcePod: pod2, TargetPod: pod3, Ports: []int32{80}},
+					{SourcePod: pod3, TargetPod: pod1, Ports: nil},
+				},
+			},
+			expectedDiff: RouteDiff{
+				Missing: []Route{
+					{Source: pod1, Target: pod2, Ports: []int32{8080}},
+					{Source: pod2, Target: pod3, Ports: nil},
+				},
+				Unexpected: []Route{
+					{Source: pod1, Target: pod2, Ports: []int32{443}},
+					{Source: pod3, Target: pod1, Ports: nil},
+				},
+			},
+		},
+		{
+			name: "port ranges of allowed routes cover desired ports and are reported without them",
+			args: args{
+				desiredRoutes: []Route{
+					{Source: pod1, Target: pod2, Ports: []int32{80, 8080}},
+				},
+				allowedRoutes: []*types.AllowedRoute{
+					{SourcePod: pod1, TargetPod: pod2, Ports: []int32{80},
+						PortRanges: []types.PortRange{{Start: 2000, End: 65535}}},
+				},
+			},
+			expectedDiff: RouteDiff{
+				Missing: []Route{},
+				Unexpected: []Route{
+					{Source: pod1, Target: pod2, Ports: []int32{},
+						PortRanges: []types.PortRange{{Start: 2000, End: 8079}, {Start: 8081, End: 65535}}},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := Diff(tt.args.desiredRoutes, tt.args.allowedRoutes)
+			if diff := cmp.Diff(tt.expectedDiff, diff); diff != "" {
+				t.Errorf("Diff() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,339 @@
+package synthesis
+
+import (
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"karto/types"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	namespaceNameLabel   = "kubernetes.io/metadata.name"
+	defaultDenyName      = "default-deny"
+	allowDNSName         = "allow-dns"
+	ingressPolicyPrefix  = "allow-ingress-to-"
+	egressPolicyPrefix   = "allow-egress-from-"
+	maxGroupNameLength   = 40
+	dnsPort              = 53
+	generatedByLabel     = "app.kubernetes.io/managed-by"
+	generatedByValue     = "karto"
+	networkPolicyKind    = "NetworkPolicy"
+	networkPolicyVersion = "networking.k8s.io/v1"
+)
+
+var (
+	generatedLabels = map[string]bool{
+		"pod-template-hash":                  true,
+		"controller-revision-hash":           true,
+		"pod-template-generation":            true,
+		"statefulset.kubernetes.io/pod-name": true,
+	}
+	groupNameLabels       = []string{"app.kubernetes.io/name", "app", "k8s-app", "name"}
+	invalidGroupNameChars = regexp.MustCompile("[^a-z0-9-]+")
+	dnsServerLabels       = map[string]string{"k8s-app": "kube-dns"}
+	defaultProtocol       = corev1.ProtocolTCP
+	dnsProtocols          = []corev1.Protocol{corev1.ProtocolUDP, corev1.ProtocolTCP}
+)
+
+type Options struct {
+	AllowDNS bool
+}
+
+type Result struct {
+	Policies   []*networkingv1.NetworkPolicy `json:"policies"`
+	Namespaces []string                      `json:"namespaces"`
+	Skipped    []string                      `json:"skipped"`
+	Warnings   []string                      `json:"warnings"`
+}
+
+type podGroup struct {
+	namespace string
+	labels    map[string]string
+	key       string
+	name      string
+}
+
+type groupPeers struct {
+	group *podGroup
+	peers map[string]*peerPorts
+}
+
+type peerPorts struct {
+	peer       *podGroup
+	ports      []int32
+	portRanges []types.PortRange
+}
+
+func Synthesize(routes []Route, pods []*types.Pod, options Options) Result {
+	sortedPods := make([]*types.Pod, len(pods))
+	copy(sortedPods, pods)
+	sort.Slice(sortedPods, func(i, j int) bool {
+		return podRefLess(types.PodRef{Name: sortedPods[i].Name, Namespace: sortedPods[i].Namespace},
+			types.PodRef{Name: sortedPods[j].Name, Namespace: sortedPods[j].Namespace})
+	})
+	groups := newGroupRegistry()
+	podGroups := map[types.PodRef]*podGroup{}
+	for _, pod := range sortedPods {
+		if group := groups.groupOf(pod); group != nil {
+			podGroups[types.PodRef{Name: pod.Name, Namespace: pod.Namespace}] = group
+		}
+	}
+	result := Result{
+		Policies: make([]*networkingv1.NetworkPolicy, 0),
+		Skipped:  make([]string, 0),
+		Warnings: make([]string, 0),
+	}
+	namespaces := map[string]bool{}
+	restrictsPorts := false
+	ingressPeers := map[string]*groupPeers{}
+	egressPeers := map[string]*groupPeers{}
+	for _, route := range routes {
+		namespaces[route.Source.Namespace] = true
+		namespaces[route.Target.Namespace] = true
+		sourceGroup, targetGroup := podGroups[route.Source], podGroups[route.Target]
+		if sourceGroup == nil || targetGroup == nil {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("route %s/%s -> %s/%s: pods without labels "+
+				"cannot be selected by a network policy", route.Source.Namespace, route.Source.Name,
+				route.Target.Namespace, route.Target.Name))
+			continue
+		}
+		restrictsPorts = restrictsPorts || route.Ports != nil
+		addPeer(ingressPeers, targetGroup, sourceGroup, route)
+		addPeer(egressPeers, sourceGroup, targetGroup, route)
+	}
+	if restrictsPorts {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("ports are only allowed over %s: "+
+			"routes over other protocols are denied by the generated policies", defaultProtocol))
+	}
+	result.Namespaces = sortedKeys(namespaces)
+	for _, namespace := range result.Namespaces {
+		result.Policies = append(result.Policies, defaultDenyPolicy(namespace))
+		if options.AllowDNS {
+			result.Policies = append(result.Policies, allowDNSPolicy(namespace))
+		}
+		result.Policies = append(result.Policies, policiesOf(namespace, ingressPeers, networkingv1.PolicyTypeIngress)...)
+		result.Policies = append(result.Policies, policiesOf(namespace, egressPeers, networkingv1.PolicyTypeEgress)...)
+	}
+	return result
+}
+
+func addPeer(peersByGroup map[string]*groupPeers, group *podGroup, peer *podGroup, route Route) {
+	entry, ok := peersByGroup[group.key]
+	if !ok {
+		entry = &groupPeers{group: group, peers: map[string]*peerPorts{}}
+		peersByGroup[group.key] = entry
+	}
+	existing, ok := entry.peers[peer.key]
+	if !ok {
+		entry.peers[peer.key] = &peerPorts{peer: peer, ports: copyPorts(route.Ports),
+			portRanges: mergePortRanges(nil, route.PortRanges)}
+		return
+	}
+	existing.ports = mergePorts(existing.ports, route.Ports)
+	existing.portRanges = mergePortRanges(existing.portRanges, route.PortRanges)
+	if existing.ports == nil {
+		existing.portRanges = nil
+	}
+}
+
+func policiesOf(namespace string, peersByGroup map[string]*groupPeers,
+	policyType networkingv1.PolicyType) []*networkingv1.NetworkPolicy {
+	policies := make([]*networkingv1.NetworkPolicy, 0)
+	for _, groupKey := range sortedKeys(peersByGroup) {
+		entry := peersByGroup[groupKey]
+		if entry.group.namespace != namespace {
+			continue
+		}
+		policy := newPolicy(namespace, "", metav1.LabelSelector{MatchLabels: entry.group.labels}, policyType)
+		for _, peerKey := range sortedKeys(entry.peers) {
+			peer := entry.peers[peerKey]
+			policyPeers := []networkingv1.NetworkPolicyPeer{peerOf(peer.peer)}
+			policyPorts := append(portsOf(peer.ports, defaultProtocol), portRangesOf(peer.portRanges, defaultProtocol)...)
+			if policyType == networkingv1.PolicyTypeIngress {
+				policy.Spec.Ingress = append(policy.Spec.Ingress, networkingv1.NetworkPolicyIngressRule{
+					From:  policyPeers,
+					Ports: policyPorts,
+				})
+			} else {
+				policy.Spec.Egress = append(policy.Spec.Egress, networkingv1.NetworkPolicyEgressRule{
+					To:    policyPeers,
+					Ports: policyPorts,
+				})
+			}
+		}
+		if policyType == networkingv1.PolicyTypeIngress {
+			policy.Name = ingressPolicyPrefix + entry.group.name
+		} else {
+			policy.Name = egressPolicyPrefix + entry.group.name
+		}
+		policies = append(policies, policy)
+	}
+	return policies
+}
+
+func peerOf(group *podGroup) networkingv1.NetworkPolicyPeer {
+	return networkingv1.NetworkPolicyPeer{
+		PodSelector: &metav1.LabelSelector{MatchLabels: group.labels},
+		NamespaceSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{namespaceNameLabel: group.namespace},
+		},
+	}
+}
+
+func portsOf(ports []int32, protocol corev1.Protocol) []networkingv1.NetworkPolicyPort {
+	if ports == nil {
+		return nil
+	}
+	result := make([]networkingv1.NetworkPolicyPort, 0, len(ports))
+	for _, port := range ports {
+		portValue := intstr.FromInt(int(port))
+		portProtocol := protocol
+		result = append(result, networkingv1.NetworkPolicyPort{Protocol: &portProtocol, Port: &portValue})
+	}
+	return result
+}
+
+func portRangesOf(portRanges []types.PortRange, protocol corev1.Protocol) []networkingv1.NetworkPolicyPort {
+	result := make([]networkingv1.NetworkPolicyPort, 0, len(portRanges))
+	for _, portRange := range portRanges {
+		portValue := intstr.FromInt(int(portRange.Start))
+		endPort := portRange.End
+		portProtocol := protocol
+		result = append(result, networkingv1.NetworkPolicyPort{Protocol: &portProtocol, Port: &portValue,
+			EndPort: &endPort})
+	}
+	return result
+}
+
+func defaultDenyPolicy(namespace string) *networkingv1.NetworkPolicy {
+	return newPolicy(namespace, defaultDenyName, metav1.LabelSelector{}, networkingv1.PolicyTypeIngress,
+		networkingv1.PolicyTypeEgress)
+}
+
+func allowDNSPolicy(namespace string) *networkingv1.NetworkPolicy {
+	policy := newPolicy(namespace, allowDNSName, metav1.LabelSelector{}, networkingv1.PolicyTypeEgress)
+	ports := make([]networkingv1.NetworkPolicyPort, 0, len(dnsProtocols))
+	for _, protocol := range dnsProtocols {
+		ports = append(ports, portsOf([]int32{dnsPort}, protocol)...)
+	}
+	policy.Spec.Egress = []networkingv1.NetworkPolicyEgressRule{
+		{
+			To: []networkingv1.NetworkPolicyPeer{
+				{
+					PodSelector:       &metav1.LabelSelector{MatchLabels: dnsServerLabels},
+					NamespaceSelector: &metav1.LabelSelector{},
+				},
+			},
+			Ports: ports,
+		},
+	}
+	return policy
+}
+
+func newPolicy(namespace string, name string, podSelector metav1.LabelSelector,
+	policyTypes ...networkingv1.PolicyType) *networkingv1.NetworkPolicy {
+	return &networkingv1.NetworkPolicy{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       networkPolicyKind,
+			APIVersion: networkPolicyVersion,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{generatedByLabel: generatedByValue},
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: podSelector,
+			PolicyTypes: policyTypes,
+		},
+	}
+}
+
+type groupRegistry struct {
+	groups    map[string]*podGroup
+	usedNames map[string]bool
+}
+
+func newGroupRegistry() *groupRegistry {
+	return &groupRegistry{
+		groups:    map[string]*podGroup{},
+		usedNames: map[string]bool{},
+	}
+}
+
+func (registry *groupRegistry) groupOf(pod *types.Pod) *podGroup {
+	labels := map[string]string{}
+	for key, value := range pod.Labels {
+		if !generatedLabels[key] {
+			labels[key] = value
+		}
+	}
+	if len(labels) == 0 {
+		return nil
+	}
+	labelPairs := make([]string, 0, len(labels))
+	for _, key := range sortedKeys(labels) {
+		labelPairs = append(labelPairs, key+"="+labels[key])
+	}
+	key := pod.Namespace + "/" + strings.Join(labelPairs, ",")
+	group, ok := registry.groups[key]
+	if ok {
+		return group
+	}
+	group = &podGroup{
+		namespace: pod.Namespace,
+		labels:    labels,
+		key:       key,
+		name:      registry.uniqueName(pod.Namespace, groupName(labels)),
+	}
+	registry.groups[key] = group
+	return group
+}
+
+func (registry *groupRegistry) uniqueName(namespace string, name string) string {
+	candidate := name
+	for i := 2; registry.usedNames[namespace+"/"+candidate]; i++ {
+		candidate = fmt.Sprintf("%s-%d", name, i)
+	}
+	registry.usedNames[namespace+"/"+candidate] = true
+	return candidate
+}
+
+func groupName(labels map[string]string) string {
+	name := ""
+	for _, key := range groupNameLabels {
+		if value, ok := labels[key]; ok {
+			name = value
+			break
+		}
+	}
+	if name == "" {
+		values := make([]string, 0, len(labels))
+		for _, key := range sortedKeys(labels) {
+			values = append(values, labels[key])
+		}
+		name = strings.Join(values, "-")
+	}
+	name = strings.Trim(invalidGroupNameChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if len(name) > maxGroupNameLength {
+		name = strings.Trim(name[:maxGroupNameLength], "-")
+	}
+	if name == "" {
+		name = "pods"
+	}
+	return name
+}
+
+func sortedKeys[T any](values map[string]T) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,122 @@
+package synthesis
+
+import (
+	"fmt"
+	"github.com/google/go-cmp/cmp"
+	networkingv1 "k8s.io/api/networking/v1"
+	"karto/types"
+	"testing"
+)
+
+func TestSynthesize(t *testing.T) {
+	type args struct {
+		routes  []Route
+		pods    []*types.Pod
+		options Options
+	}
+	type expectedPolicy struct {
+		Namespace string
+		Name      string
+		Selector  map[string]string
+		Peers     []string
+	}
+	front := &types.Pod{Name: "front-abc", Namespace: "web",
+		Labels: map[string]string{"app": "front", "pod-template-hash": "abc"}}
+	api := &types.Pod{Name: "api-0", Namespace: "back", Labels: map[string]string{"app": "api"}}
+	unlabeled := &types.Pod{Name: "job", Namespace: "back", Labels: map[string]string{}}
+	dns := &types.Pod{Name: "coredns-0", Namespace: "kube-system", Labels: map[string]string{"k8s-app": "kube-dns"}}
+	frontRef := types.PodRef{Name: front.Name, Namespace: front.Namespace}
+	apiRef := types.PodRef{Name: api.Name, Namespace: api.Namespace}
+	unlabeledRef := types.PodRef{Name: unlabeled.Name, Namespace: unlabeled.Namespace}
+	tests := []struct {
+		name             string
+		args             args
+		expectedPolicies []expectedPolicy
+		expectedSkipped  []string
+		expectedWarnings []string
+	}{
+		{
+			name: "generates default-deny policies and policies allowing the desired routes in their namespaces only",
+			args: args{
+				routes: []Route{{Source: frontRef, Target: apiRef, Ports: []int32{8080}}},
+				pods:   []*types.Pod{front, api, dns},
+			},
+			expectedPolicies: []expectedPolicy{
+				{Namespace: "back", Name: "default-deny", Selector: nil, Peers: []string{}},
+				{Namespace: "back", Name: "allow-ingress-to-api", Selector: map[string]string{"app": "api"},
+					Peers: []string{"web app=front [8080]"}},
+				{Namespace: "web", Name: "default-deny", Selector: nil, Peers: []string{}},
+				{Namespace: "web", Name: "allow-egress-from-front", Selector: map[string]string{"app": "front"},
+					Peers: []string{"back app=api [8080]"}},
+			},
+			expectedSkipped: []string{},
+			expectedWarnings: []string{
+				"ports are only allowed over TCP: routes over other protocols are denied by the generated policies",
+			},
+		},
+		{
+			name: "allows dns and skips routes involving pods without labels",
+			args: args{
+				routes:  []Route{{Source: unlabeledRef, Target: apiRef, Ports: nil}},
+				pods:    []*types.Pod{api, unlabeled},
+				options: Options{AllowDNS: true},
+			},
+			expectedPolicies: []expectedPolicy{
+				{Namespace: "back", Name: "default-deny", Selector: nil, Peers: []string{}},
+				{Namespace: "back", Name: "allow-dns", Selector: nil, Peers: []string{" k8s-app=kube-dns [53 53]"}},
+			},
+			expectedSkipped: []string{
+				"route back/job -> back/api-0: pods without labels cannot be selected by a network policy",
+			},
+			expectedWarnings: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Synthesize(tt.args.routes, tt.args.pods, tt.args.options)
+			policies := make([]expectedPolicy, 0)
+			for _, policy := range result.Policies {
+				policies = append(policies, expectedPolicy{Namespace: policy.Namespace, Name: policy.Name,
+					Selector: policy.Spec.PodSelector.MatchLabels, Peers: describePeers(policy)})
+			}
+			if diff := cmp.Diff(tt.expectedPolicies, policies); diff != "" {
+				t.Errorf("Synthesize() policies mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.expectedSkipped, result.Skipped); diff != "" {
+				t.Errorf("Synthesize() skipped mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.expectedWarnings, result.Warnings); diff != "" {
+				t.Errorf("Synthesize() warnings mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func describePeers(policy *networkingv1.NetworkPolicy) []string {
+	result := make([]string, 0)
+	describe := func(peers []networkingv1.NetworkPolicyPeer, ports []networkingv1.NetworkPolicyPort) {
+		portValues := make([]int32, 0)
+		for _, port := range ports {
+			portValues = append(portValues, port.Port.IntVal)
+		}
+		for _, peer := range peers {
+			labels := ""
+			for key, value := range peer.PodSelector.MatchLabels {
+				labels += key + "=" + value
+			}
+			result = append(result, peer.NamespaceSelector.MatchLabels[namespaceNameLabel]+" "+labels+" "+
+				fmtPorts(portValues))
+		}
+	}
+	for _, rule := range policy.Spec.Ingress {
+		describe(rule.From, rule.Ports)
+	}
+	for _, rule := range policy.Spec.Egress {
+		describe(rule.To, rule.Ports)
+	}
+	return result
+}
+
+func fmtPorts(ports []int32) string {
+	return fmt.Sprint(ports)
+}
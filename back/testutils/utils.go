@@ -4,9 +4,11 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"time"
 )
 
 type NamespaceBuilder struct {
@@ -44,7 +46,13 @@ type PodBuilder struct {
 	namespace         string
 	ownerUID          string
 	labels            map[string]string
+	initContainers    []corev1.Container
+	containers        []corev1.Container
 	containerStatuses []corev1.ContainerStatus
+	ip                string
+	creationTime      time.Time
+	serviceAccount    string
+	hostNetwork       bool
 }
 
 func NewPodBuilder() *PodBuilder {
@@ -75,6 +83,16 @@ func (podBuilder *PodBuilder) WithOwnerUID(ownerUID string) *PodBuilder {
 	return podBuilder
 }
 
+func (podBuilder *PodBuilder) WithInitContainer(container corev1.Container) *PodBuilder {
+	podBuilder.initContainers = append(podBuilder.initContainers, container)
+	return podBuilder
+}
+
+func (podBuilder *PodBuilder) WithContainer(container corev1.Container) *PodBuilder {
+	podBuilder.containers = append(podBuilder.containers, container)
+	return podBuilder
+}
+
 func (podBuilder *PodBuilder) WithContainerStatus(isRunning bool, isReady bool, restartCount int32) *PodBuilder {
 	containerStatus := corev1.ContainerStatus{
 		State:        corev1.ContainerState{},
@@ -90,22 +108,137 @@ func (podBuilder *PodBuilder) WithContainerStatus(isRunning bool, isReady bool,
 	return podBuilder
 }
 
+func (podBuilder *PodBuilder) WithServiceAccount(serviceAccount string) *PodBuilder {
+	podBuilder.serviceAccount = serviceAccount
+	return podBuilder
+}
+
+func (podBuilder *PodBuilder) WithIP(ip string) *PodBuilder {
+	podBuilder.ip = ip
+	return podBuilder
+}
+
+func (podBuilder *PodBuilder) WithCreationTime(creationTime time.Time) *PodBuilder {
+	podBuilder.creationTime = creationTime
+	return podBuilder
+}
+
+func (podBuilder *PodBuilder) WithHostNetwork() *PodBuilder {
+	podBuilder.hostNetwork = true
+	return podBuilder
+}
+
 func (podBuilder *PodBuilder) Build() *corev1.Pod {
 	return &corev1.Pod{
 		ObjectMeta: v1.ObjectMeta{
-			Name:      podBuilder.name,
-			Namespace: podBuilder.namespace,
-			Labels:    podBuilder.labels,
+			Name:              podBuilder.name,
+			Namespace:         podBuilder.namespace,
+			Labels:            podBuilder.labels,
+			CreationTimestamp: v1.NewTime(podBuilder.creationTime),
 			OwnerReferences: []v1.OwnerReference{
 				{UID: types.UID(podBuilder.ownerUID)},
 			},
 		},
+		Spec: corev1.PodSpec{
+			InitContainers:     podBuilder.initContainers,
+			Containers:         podBuilder.containers,
+			ServiceAccountName: podBuilder.serviceAccount,
+			HostNetwork:        podBuilder.hostNetwork,
+		},
 		Status: corev1.PodStatus{
+			PodIP:             podBuilder.ip,
 			ContainerStatuses: podBuilder.containerStatuses,
 		},
 	}
 }
 
+type ContainerBuilder struct {
+	name            string
+	image           string
+	imagePullPolicy corev1.PullPolicy
+	livenessProbe   *corev1.Probe
+	readinessProbe  *corev1.Probe
+	startupProbe    *corev1.Probe
+	requests        corev1.ResourceList
+	limits          corev1.ResourceList
+	ports           []corev1.ContainerPort
+}
+
+func NewContainerBuilder() *ContainerBuilder {
+	return &ContainerBuilder{
+		image:           "image:1.0",
+		imagePullPolicy: corev1.PullIfNotPresent,
+		requests:        corev1.ResourceList{},
+		limits:          corev1.ResourceList{},
+	}
+}
+
+func (containerBuilder *ContainerBuilder) WithName(name string) *ContainerBuilder {
+	containerBuilder.name = name
+	return containerBuilder
+}
+
+func (containerBuilder *ContainerBuilder) WithImage(image string) *ContainerBuilder {
+	containerBuilder.image = image
+	return containerBuilder
+}
+
+func (containerBuilder *ContainerBuilder) WithImagePullPolicy(imagePullPolicy corev1.PullPolicy) *ContainerBuilder {
+	containerBuilder.imagePullPolicy = imagePullPolicy
+	return containerBuilder
+}
+
+func (containerBuilder *ContainerBuilder) WithLivenessProbe() *ContainerBuilder {
+	containerBuilder.livenessProbe = &corev1.Probe{}
+	return containerBuilder
+}
+
+func (containerBuilder *ContainerBuilder) WithLivenessProbeInitialDelay(seconds int32) *ContainerBuilder {
+	containerBuilder.livenessProbe = &corev1.Probe{InitialDelaySeconds: seconds}
+	return containerBuilder
+}
+
+func (containerBuilder *ContainerBuilder) WithStartupProbe() *ContainerBuilder {
+	containerBuilder.startupProbe = &corev1.Probe{}
+	return containerBuilder
+}
+
+func (containerBuilder *ContainerBuilder) WithReadinessProbe() *ContainerBuilder {
+	containerBuilder.readinessProbe = &corev1.Probe{}
+	return containerBuilder
+}
+
+func (containerBuilder *ContainerBuilder) WithRequest(resourceName corev1.ResourceName, quantity string) *ContainerBuilder {
+	containerBuilder.requests[resourceName] = resource.MustParse(quantity)
+	return containerBuilder
+}
+
+func (containerBuilder *ContainerBuilder) WithLimit(resourceName corev1.ResourceName, quantity string) *ContainerBuilder {
+	containerBuilder.limits[resourceName] = resource.MustParse(quantity)
+	return containerBuilder
+}
+
+func (containerBuilder *ContainerBuilder) WithPort(name string, port int32) *ContainerBuilder {
+	containerBuilder.ports = append(containerBuilder.ports, corev1.ContainerPort{Name: name, ContainerPort: port})
+	return containerBuilder
+}
+
+func (containerBuilder *ContainerBuilder) Build() corev1.Container {
+	return corev1.Container{
+		Name:            containerBuilder.name,
+		Image:           containerBuilder.image,
+		ImagePullPolicy: containerBuilder.imagePullPolicy,
+		LivenessProbe:   containerBuilder.livenessProbe,
+		ReadinessProbe:  containerBuilder.readinessProbe,
+		StartupProbe:    containerBuilder.startupProbe,
+		Ports:           containerBuilder.ports,
+		Resources: corev1.ResourceRequirements{
+			Requests: containerBuilder.requests,
+			Limits:   containerBuilder.limits,
+		},
+	}
+}
+
 type NetworkPolicyBuilder struct {
 	name        string
 	namespace   string
@@ -203,9 +336,11 @@ func (labelSelectorBuilder *LabelSelectorBuilder) Build() *metav1.LabelSelector
 }
 
 type ServiceBuilder struct {
-	name      string
-	namespace string
-	selector  map[string]string
+	name         string
+	namespace    string
+	selector     map[string]string
+	externalName string
+	serviceType  corev1.ServiceType
 }
 
 func NewServiceBuilder() *ServiceBuilder {
@@ -230,16 +365,32 @@ func (serviceBuilder *ServiceBuilder) WithSelectorLabel(key string, value string
 	return serviceBuilder
 }
 
+func (serviceBuilder *ServiceBuilder) WithExternalName(externalName string) *ServiceBuilder {
+	serviceBuilder.externalName = externalName
+	return serviceBuilder
+}
+
+func (serviceBuilder *ServiceBuilder) WithType(serviceType corev1.ServiceType) *ServiceBuilder {
+	serviceBuilder.serviceType = serviceType
+	return serviceBuilder
+}
+
 func (serviceBuilder *ServiceBuilder) Build() *corev1.Service {
-	return &corev1.Service{
+	service := &corev1.Service{
 		ObjectMeta: v1.ObjectMeta{
 			Name:      serviceBuilder.name,
 			Namespace: serviceBuilder.namespace,
 		},
 		Spec: corev1.ServiceSpec{
 			Selector: serviceBuilder.selector,
+			Type:     serviceBuilder.serviceType,
 		},
 	}
+	if serviceBuilder.externalName != "" {
+		service.Spec.Type = corev1.ServiceTypeExternalName
+		service.Spec.ExternalName = serviceBuilder.externalName
+	}
+	return service
 }
 
 type ReplicaSetBuilder struct {
@@ -303,6 +454,7 @@ type StatefulSetBuilder struct {
 	namespace       string
 	uid             string
 	desiredReplicas int32
+	status          appsv1.StatefulSetStatus
 }
 
 func NewStatefulSetBuilder() *StatefulSetBuilder {
@@ -332,6 +484,18 @@ func (statefulSetBuilder *StatefulSetBuilder) WithDesiredReplicas(replicas int32
 	return statefulSetBuilder
 }
 
+func (statefulSetBuilder *StatefulSetBuilder) WithReplicaStatus(updated int32, available int32) *StatefulSetBuilder {
+	statefulSetBuilder.status.UpdatedReplicas = updated
+	statefulSetBuilder.status.AvailableReplicas = available
+	return statefulSetBuilder
+}
+
+func (statefulSetBuilder *StatefulSetBuilder) WithRevisions(current string, update string) *StatefulSetBuilder {
+	statefulSetBuilder.status.CurrentRevision = current
+	statefulSetBuilder.status.UpdateRevision = update
+	return statefulSetBuilder
+}
+
 func (statefulSetBuilder *StatefulSetBuilder) Build() *appsv1.StatefulSet {
 	return &appsv1.StatefulSet{
 		ObjectMeta: v1.ObjectMeta{
@@ -342,6 +506,7 @@ func (statefulSetBuilder *StatefulSetBuilder) Build() *appsv1.StatefulSet {
 		Spec: appsv1.StatefulSetSpec{
 			Replicas: &statefulSetBuilder.desiredReplicas,
 		},
+		Status: statefulSetBuilder.status,
 	}
 }
 
@@ -349,6 +514,7 @@ type DaemonSetBuilder struct {
 	name      string
 	namespace string
 	uid       string
+	status    appsv1.DaemonSetStatus
 }
 
 func NewDaemonSetBuilder() *DaemonSetBuilder {
@@ -372,6 +538,17 @@ func (daemonSetBuilder *DaemonSetBuilder) WithUID(UID string) *DaemonSetBuilder
 	return daemonSetBuilder
 }
 
+func (daemonSetBuilder *DaemonSetBuilder) WithScheduledStatus(
+	desired int32,
+	updated int32,
+	available int32,
+) *DaemonSetBuilder {
+	daemonSetBuilder.status.DesiredNumberScheduled = desired
+	daemonSetBuilder.status.UpdatedNumberScheduled = updated
+	daemonSetBuilder.status.NumberAvailable = available
+	return daemonSetBuilder
+}
+
 func (daemonSetBuilder *DaemonSetBuilder) Build() *appsv1.DaemonSet {
 	return &appsv1.DaemonSet{
 		ObjectMeta: v1.ObjectMeta{
@@ -379,6 +556,7 @@ func (daemonSetBuilder *DaemonSetBuilder) Build() *appsv1.DaemonSet {
 			Namespace: daemonSetBuilder.namespace,
 			UID:       types.UID(daemonSetBuilder.uid),
 		},
+		Status: daemonSetBuilder.status,
 	}
 }
 
@@ -438,9 +616,11 @@ func (ingressBuilder *IngressBuilder) Build() *networkingv1.Ingress {
 }
 
 type DeploymentBuilder struct {
-	name      string
-	namespace string
-	uid       string
+	name            string
+	namespace       string
+	uid             string
+	desiredReplicas *int32
+	status          appsv1.DeploymentStatus
 }
 
 func NewDeploymentBuilder() *DeploymentBuilder {
@@ -464,6 +644,31 @@ func (deploymentBuilder *DeploymentBuilder) WithUID(UID string) *DeploymentBuild
 	return deploymentBuilder
 }
 
+func (deploymentBuilder *DeploymentBuilder) WithDesiredReplicas(replicas int32) *DeploymentBuilder {
+	deploymentBuilder.desiredReplicas = &replicas
+	return deploymentBuilder
+}
+
+func (deploymentBuilder *DeploymentBuilder) WithReplicaStatus(
+	replicas int32,
+	updated int32,
+	available int32,
+) *DeploymentBuilder {
+	deploymentBuilder.status.Replicas = replicas
+	deploymentBuilder.status.UpdatedReplicas = updated
+	deploymentBuilder.status.AvailableReplicas = available
+	return deploymentBuilder
+}
+
+func (deploymentBuilder *DeploymentBuilder) WithProgressDeadlineExceeded() *DeploymentBuilder {
+	deploymentBuilder.status.Conditions = append(deploymentBuilder.status.Conditions, appsv1.DeploymentCondition{
+		Type:   appsv1.DeploymentProgressing,
+		Status: corev1.ConditionFalse,
+		Reason: "ProgressDeadlineExceeded",
+	})
+	return deploymentBuilder
+}
+
 func (deploymentBuilder *DeploymentBuilder) Build() *appsv1.Deployment {
 	return &appsv1.Deployment{
 		ObjectMeta: v1.ObjectMeta{
@@ -471,5 +676,76 @@ func (deploymentBuilder *DeploymentBuilder) Build() *appsv1.Deployment {
 			Namespace: deploymentBuilder.namespace,
 			UID:       types.UID(deploymentBuilder.uid),
 		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: deploymentBuilder.desiredReplicas,
+		},
+		Status: deploymentBuilder.status,
+	}
+}
+
+type EventBuilder struct {
+	eventType      string
+	reason         string
+	message        string
+	count          int32
+	involvedObject corev1.ObjectReference
+	firstTimestamp time.Time
+	lastTimestamp  time.Time
+}
+
+func NewEventBuilder() *EventBuilder {
+	return &EventBuilder{
+		eventType: corev1.EventTypeWarning,
+		count:     1,
+	}
+}
+
+func (eventBuilder *EventBuilder) WithType(eventType string) *EventBuilder {
+	eventBuilder.eventType = eventType
+	return eventBuilder
+}
+
+func (eventBuilder *EventBuilder) WithReason(reason string) *EventBuilder {
+	eventBuilder.reason = reason
+	return eventBuilder
+}
+
+func (eventBuilder *EventBuilder) WithMessage(message string) *EventBuilder {
+	eventBuilder.message = message
+	return eventBuilder
+}
+
+func (eventBuilder *EventBuilder) WithCount(count int32) *EventBuilder {
+	eventBuilder.count = count
+	return eventBuilder
+}
+
+func (eventBuilder *EventBuilder) WithInvolvedObject(kind string, namespace string, name string) *EventBuilder {
+	eventBuilder.involvedObject = corev1.ObjectReference{
+		Kind:      kind,
+		Namespace: namespace,
+		Name:      name,
+	}
+	return eventBuilder
+}
+
+func (eventBuilder *EventBuilder) WithTimestamps(first time.Time, last time.Time) *EventBuilder {
+	eventBuilder.firstTimestamp = first
+	eventBuilder.lastTimestamp = last
+	return eventBuilder
+}
+
+func (eventBuilder *EventBuilder) Build() *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta: v1.ObjectMeta{
+			Namespace: eventBuilder.involvedObject.Namespace,
+		},
+		InvolvedObject: eventBuilder.involvedObject,
+		Reason:         eventBuilder.reason,
+		Message:        eventBuilder.message,
+		FirstTimestamp: v1.NewTime(eventBuilder.firstTimestamp),
+		LastTimestamp:  v1.NewTime(eventBuilder.lastTimestamp),
+		Count:          eventBuilder.count,
+		Type:           eventBuilder.eventType,
 	}
 }
@@ -0,0 +1,44 @@
+package types
+
+func (portRange PortRange) Contains(port int32) bool {
+	return portRange.Start <= port && port <= portRange.End
+}
+
+func (route *AllowedRoute) AllowsPort(port int32) bool {
+	return portsAllow(route.Ports, route.PortRanges, route.DeniedPorts, route.DeniedPortRanges, port)
+}
+
+func (route *ExternalRoute) AllowsPort(port int32) bool {
+	return portsAllow(route.Ports, route.PortRanges, route.DeniedPorts, route.DeniedPortRanges, port)
+}
+
+func (access *ExternalAccess) AllowsPort(port int32) bool {
+	return portsAllow(access.Ports, access.PortRanges, access.DeniedPorts, access.DeniedPortRanges, port)
+}
+
+func portsAllow(
+	ports []int32,
+	portRanges []PortRange,
+	deniedPorts []int32,
+	deniedPortRanges []PortRange,
+	port int32,
+) bool {
+	if ports == nil {
+		return !containsPort(deniedPorts, deniedPortRanges, port)
+	}
+	return containsPort(ports, portRanges, port)
+}
+
+func containsPort(ports []int32, portRanges []PortRange, port int32) bool {
+	for _, candidate := range ports {
+		if candidate == port {
+			return true
+		}
+	}
+	for _, portRange := range portRanges {
+		if portRange.Contains(port) {
+			return true
+		}
+	}
+	return false
+}
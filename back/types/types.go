@@ -4,18 +4,46 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	"karto/crds/calico"
+	"karto/crds/cilium"
+	"karto/crds/istio"
+	"karto/crds/mcs"
+	"karto/crds/policyapi"
+	"time"
 )
 
 type ClusterState struct {
-	Namespaces      []*corev1.Namespace
-	Pods            []*corev1.Pod
-	Services        []*corev1.Service
-	Ingresses       []*networkingv1.Ingress
-	ReplicaSets     []*appsv1.ReplicaSet
-	StatefulSets    []*appsv1.StatefulSet
-	DaemonSets      []*appsv1.DaemonSet
-	Deployments     []*appsv1.Deployment
-	NetworkPolicies []*networkingv1.NetworkPolicy
+	Cluster               string                          `json:"cluster,omitempty"`
+	Namespaces            []*corev1.Namespace             `json:"namespaces"`
+	Pods                  []*corev1.Pod                   `json:"pods"`
+	Services              []*corev1.Service               `json:"services"`
+	Ingresses             []*networkingv1.Ingress         `json:"ingresses"`
+	ReplicaSets           []*appsv1.ReplicaSet            `json:"replicaSets"`
+	StatefulSets          []*appsv1.StatefulSet           `json:"statefulSets"`
+	DaemonSets            []*appsv1.DaemonSet             `json:"daemonSets"`
+	Deployments           []*appsv1.Deployment            `json:"deployments"`
+	NetworkPolicies       []*networkingv1.NetworkPolicy   `json:"networkPolicies"`
+	Events                []*corev1.Event                 `json:"events"`
+	CiliumNetworkPolicies []*cilium.NetworkPolicy         `json:"ciliumNetworkPolicies"`
+	CalicoNetworkPolicies []*calico.NetworkPolicy         `json:"calicoNetworkPolicies"`
+	CalicoTiers           []*calico.Tier                  `json:"calicoTiers"`
+	AdminNetworkPolicies  []*policyapi.AdminNetworkPolicy `json:"adminNetworkPolicies"`
+	AuthorizationPolicies []*istio.AuthorizationPolicy    `json:"authorizationPolicies"`
+	PeerAuthentications   []*istio.PeerAuthentication     `json:"peerAuthentications"`
+	ServiceEntries        []*istio.ServiceEntry           `json:"serviceEntries"`
+	ServiceExports        []*mcs.ServiceExport            `json:"serviceExports"`
+	ServiceImports        []*mcs.ServiceImport            `json:"serviceImports"`
+	ForbiddenResources    []ForbiddenResource             `json:"forbiddenResources,omitempty"`
+}
+
+type ForbiddenResource struct {
+	Resource  string `json:"resource"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+type Capabilities struct {
+	ForbiddenResources []ForbiddenResource `json:"forbiddenResources"`
+	PartialAnalyses    []string            `json:"partialAnalyses"`
 }
 
 type Pod struct {
@@ -33,20 +61,125 @@ type PodIsolation struct {
 	Pod               PodRef `json:"pod"`
 	IsIngressIsolated bool   `json:"isIngressIsolated"`
 	IsEgressIsolated  bool   `json:"isEgressIsolated"`
+	HostNetwork       bool   `json:"hostNetwork,omitempty"`
 }
 
 type NetworkPolicy struct {
+	Kind      string            `json:"kind,omitempty"`
 	Name      string            `json:"name"`
 	Namespace string            `json:"namespace"`
 	Labels    map[string]string `json:"labels"`
 }
 
+type PortRange struct {
+	Start int32 `json:"start"`
+	End   int32 `json:"end"`
+}
+
 type AllowedRoute struct {
-	SourcePod       PodRef          `json:"sourcePod"`
-	EgressPolicies  []NetworkPolicy `json:"egressPolicies"`
-	TargetPod       PodRef          `json:"targetPod"`
-	IngressPolicies []NetworkPolicy `json:"ingressPolicies"`
-	Ports           []int32         `json:"ports"`
+	SourcePod        PodRef          `json:"sourcePod"`
+	EgressPolicies   []NetworkPolicy `json:"egressPolicies"`
+	TargetPod        PodRef          `json:"targetPod"`
+	IngressPolicies  []NetworkPolicy `json:"ingressPolicies"`
+	Ports            []int32         `json:"ports"`
+	PortRanges       []PortRange     `json:"portRanges,omitempty"`
+	DeniedPorts      []int32         `json:"deniedPorts,omitempty"`
+	DeniedPortRanges []PortRange     `json:"deniedPortRanges,omitempty"`
+	EgressTiers      []string        `json:"egressTiers,omitempty"`
+	IngressTiers     []string        `json:"ingressTiers,omitempty"`
+}
+
+type ExternalPeerKind string
+
+const (
+	ExternalPeerEntity ExternalPeerKind = "entity"
+	ExternalPeerFQDN   ExternalPeerKind = "fqdn"
+	ExternalPeerCIDR   ExternalPeerKind = "cidr"
+)
+
+type ExternalPeer struct {
+	Kind ExternalPeerKind `json:"kind"`
+	Name string           `json:"name"`
+}
+
+type TrafficDirection string
+
+const (
+	TrafficIngress TrafficDirection = "ingress"
+	TrafficEgress  TrafficDirection = "egress"
+)
+
+type ExternalRoute struct {
+	Pod              PodRef           `json:"pod"`
+	Direction        TrafficDirection `json:"direction"`
+	Peer             ExternalPeer     `json:"peer"`
+	Policies         []NetworkPolicy  `json:"policies"`
+	Ports            []int32          `json:"ports"`
+	PortRanges       []PortRange      `json:"portRanges,omitempty"`
+	DeniedPorts      []int32          `json:"deniedPorts,omitempty"`
+	DeniedPortRanges []PortRange      `json:"deniedPortRanges,omitempty"`
+	Tiers            []string         `json:"tiers,omitempty"`
+}
+
+type ExternalNode struct {
+	Kind      ExternalPeerKind `json:"kind"`
+	Name      string           `json:"name"`
+	Addresses []string         `json:"addresses,omitempty"`
+	Ports     []int32          `json:"ports,omitempty"`
+	Sources   []ObjectRef      `json:"sources"`
+}
+
+type ExternalAccess struct {
+	Pod              PodRef          `json:"pod"`
+	Node             ExternalPeer    `json:"node"`
+	Policies         []NetworkPolicy `json:"policies"`
+	Ports            []int32         `json:"ports"`
+	PortRanges       []PortRange     `json:"portRanges,omitempty"`
+	DeniedPorts      []int32         `json:"deniedPorts,omitempty"`
+	DeniedPortRanges []PortRange     `json:"deniedPortRanges,omitempty"`
+	Tiers            []string        `json:"tiers,omitempty"`
+}
+
+type CrossClusterMechanism string
+
+const (
+	CrossClusterServiceExport CrossClusterMechanism = "serviceExport"
+	CrossClusterGlobalService CrossClusterMechanism = "globalService"
+)
+
+type CrossClusterRoute struct {
+	Mechanism       CrossClusterMechanism `json:"mechanism"`
+	Service         ServiceRef            `json:"service"`
+	SourceCluster   string                `json:"sourceCluster"`
+	SourcePod       PodRef                `json:"sourcePod"`
+	EgressPolicies  []NetworkPolicy       `json:"egressPolicies"`
+	TargetCluster   string                `json:"targetCluster"`
+	TargetPod       PodRef                `json:"targetPod"`
+	IngressPolicies []NetworkPolicy       `json:"ingressPolicies"`
+	Ports           []int32               `json:"ports"`
+}
+
+type MeshDecision string
+
+const (
+	MeshDecisionAllowed     MeshDecision = "allowed"
+	MeshDecisionDenied      MeshDecision = "denied"
+	MeshDecisionConditional MeshDecision = "conditional"
+)
+
+type MeshRoute struct {
+	SourcePod        PodRef          `json:"sourcePod"`
+	TargetPod        PodRef          `json:"targetPod"`
+	Decision         MeshDecision    `json:"decision"`
+	MTLSMode         string          `json:"mtlsMode"`
+	MutualTLS        bool            `json:"mutualTls"`
+	Policies         []NetworkPolicy `json:"policies"`
+	Ports            []int32         `json:"ports"`
+	PortRanges       []PortRange     `json:"portRanges,omitempty"`
+	DeniedPorts      []int32         `json:"deniedPorts,omitempty"`
+	DeniedPortRanges []PortRange     `json:"deniedPortRanges,omitempty"`
+	Conditions       []string        `json:"conditions,omitempty"`
+	Reason           string          `json:"reason,omitempty"`
 }
 
 type Service struct {
@@ -89,23 +222,75 @@ type ReplicaSetRef struct {
 	Namespace string `json:"namespace"`
 }
 
+type DeploymentRef struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+type StatefulSetRef struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+type DaemonSetRef struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
 type Deployment struct {
 	Name              string          `json:"name"`
 	Namespace         string          `json:"namespace"`
 	TargetReplicaSets []ReplicaSetRef `json:"targetReplicaSets"`
 }
 
+type ExposureRisk string
+
+const (
+	ExposureRiskCritical ExposureRisk = "critical"
+	ExposureRiskHigh     ExposureRisk = "high"
+	ExposureRiskMedium   ExposureRisk = "medium"
+	ExposureRiskLow      ExposureRisk = "low"
+)
+
+type ExposedPod struct {
+	Pod         PodRef       `json:"pod"`
+	Hops        int          `json:"hops"`
+	EntryPoints []ObjectRef  `json:"entryPoints"`
+	Path        []PodRef     `json:"path"`
+	Secrets     []string     `json:"secrets"`
+	Privileged  bool         `json:"privileged"`
+	Risk        ExposureRisk `json:"risk"`
+}
+
 type AnalysisResult struct {
-	Pods          []*Pod          `json:"pods"`
-	PodIsolations []*PodIsolation `json:"podIsolations"`
-	AllowedRoutes []*AllowedRoute `json:"allowedRoutes"`
-	Services      []*Service      `json:"services"`
-	Ingresses     []*Ingress      `json:"ingresses"`
-	ReplicaSets   []*ReplicaSet   `json:"replicaSets"`
-	StatefulSets  []*StatefulSet  `json:"statefulSets"`
-	DaemonSets    []*DaemonSet    `json:"daemonSets"`
-	Deployments   []*Deployment   `json:"deployments"`
-	PodHealths    []*PodHealth    `json:"podHealths"`
+	Cluster                string                   `json:"cluster,omitempty"`
+	Pods                   []*Pod                   `json:"pods"`
+	PodIsolations          []*PodIsolation          `json:"podIsolations"`
+	AllowedRoutes          []*AllowedRoute          `json:"allowedRoutes"`
+	ExternalRoutes         []*ExternalRoute         `json:"externalRoutes"`
+	ExternalNodes          []*ExternalNode          `json:"externalNodes"`
+	ExternalAccesses       []*ExternalAccess        `json:"externalAccesses"`
+	MeshRoutes             []*MeshRoute             `json:"meshRoutes"`
+	CrossClusterRoutes     []*CrossClusterRoute     `json:"crossClusterRoutes,omitempty"`
+	ExposedPods            []*ExposedPod            `json:"exposedPods,omitempty"`
+	Services               []*Service               `json:"services"`
+	Ingresses              []*Ingress               `json:"ingresses"`
+	ReplicaSets            []*ReplicaSet            `json:"replicaSets"`
+	StatefulSets           []*StatefulSet           `json:"statefulSets"`
+	DaemonSets             []*DaemonSet             `json:"daemonSets"`
+	Deployments            []*Deployment            `json:"deployments"`
+	PodHealths             []*PodHealth             `json:"podHealths"`
+	DeploymentHealths      []*DeploymentHealth      `json:"deploymentHealths"`
+	StatefulSetHealths     []*StatefulSetHealth     `json:"statefulSetHealths"`
+	DaemonSetHealths       []*DaemonSetHealth       `json:"daemonSetHealths"`
+	ServiceHealths         []*ServiceHealth         `json:"serviceHealths"`
+	Warnings               []*ObjectWarnings        `json:"warnings"`
+	PodConfigurations      []*PodConfiguration      `json:"podConfigurations"`
+	WorkloadConfigurations []*WorkloadConfiguration `json:"workloadConfigurations"`
+	PodSecurities          []*PodSecurity           `json:"podSecurities,omitempty"`
+	Findings               []*Finding               `json:"findings"`
+	Compliance             *ComplianceReport        `json:"compliance"`
+	Capabilities           *Capabilities            `json:"capabilities,omitempty"`
 }
 
 type PodHealth struct {
@@ -115,3 +300,151 @@ type PodHealth struct {
 	ContainersReady          int32  `json:"containersReady"`
 	ContainersWithoutRestart int32  `json:"containersWithoutRestart"`
 }
+
+type HealthStatus string
+
+const (
+	HealthStatusHealthy  HealthStatus = "healthy"
+	HealthStatusDegraded HealthStatus = "degraded"
+	HealthStatusDown     HealthStatus = "down"
+)
+
+type DeploymentHealth struct {
+	Deployment        DeploymentRef `json:"deployment"`
+	DesiredReplicas   int32         `json:"desiredReplicas"`
+	AvailableReplicas int32         `json:"availableReplicas"`
+	RolloutInProgress bool          `json:"rolloutInProgress"`
+	RolloutStuck      bool          `json:"rolloutStuck"`
+	Status            HealthStatus  `json:"status"`
+}
+
+type StatefulSetHealth struct {
+	StatefulSet       StatefulSetRef `json:"statefulSet"`
+	DesiredReplicas   int32          `json:"desiredReplicas"`
+	AvailableReplicas int32          `json:"availableReplicas"`
+	RolloutInProgress bool           `json:"rolloutInProgress"`
+	Status            HealthStatus   `json:"status"`
+}
+
+type DaemonSetHealth struct {
+	DaemonSet         DaemonSetRef `json:"daemonSet"`
+	DesiredReplicas   int32        `json:"desiredReplicas"`
+	AvailableReplicas int32        `json:"availableReplicas"`
+	RolloutInProgress bool         `json:"rolloutInProgress"`
+	Status            HealthStatus `json:"status"`
+}
+
+type ServiceHealth struct {
+	Service            ServiceRef   `json:"service"`
+	Endpoints          int32        `json:"endpoints"`
+	EndpointsAvailable int32        `json:"endpointsAvailable"`
+	Status             HealthStatus `json:"status"`
+}
+
+type ObjectRef struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+type Warning struct {
+	Reason         string    `json:"reason"`
+	Message        string    `json:"message"`
+	Count          int32     `json:"count"`
+	FirstTimestamp time.Time `json:"firstTimestamp"`
+	LastTimestamp  time.Time `json:"lastTimestamp"`
+}
+
+type ObjectWarnings struct {
+	Object   ObjectRef `json:"object"`
+	Warnings []Warning `json:"warnings"`
+}
+
+type FindingSeverity string
+
+const (
+	FindingSeverityInfo    FindingSeverity = "info"
+	FindingSeverityWarning FindingSeverity = "warning"
+	FindingSeverityError   FindingSeverity = "error"
+)
+
+type ConfigurationFinding struct {
+	Code      string          `json:"code"`
+	Severity  FindingSeverity `json:"severity"`
+	Container string          `json:"container"`
+	Message   string          `json:"message"`
+}
+
+type PodConfiguration struct {
+	Pod      PodRef                 `json:"pod"`
+	QoSClass string                 `json:"qosClass"`
+	Findings []ConfigurationFinding `json:"findings"`
+}
+
+type PodSecurityLevel string
+
+const (
+	PodSecurityLevelPrivileged PodSecurityLevel = "privileged"
+	PodSecurityLevelBaseline   PodSecurityLevel = "baseline"
+	PodSecurityLevelRestricted PodSecurityLevel = "restricted"
+)
+
+type PodSecurityViolation struct {
+	Check     string           `json:"check"`
+	Level     PodSecurityLevel `json:"level"`
+	Container string           `json:"container"`
+	Message   string           `json:"message"`
+}
+
+type PodSecurity struct {
+	Pod             PodRef                      `json:"pod"`
+	Level           PodSecurityLevel            `json:"level"`
+	HostNetwork     bool                        `json:"hostNetwork"`
+	Violations      []PodSecurityViolation      `json:"violations"`
+	NamespaceLevels map[string]PodSecurityLevel `json:"namespaceLevels"`
+	ViolatedModes   []string                    `json:"violatedModes"`
+}
+
+type WorkloadConfiguration struct {
+	Workload ObjectRef              `json:"workload"`
+	Pods     int32                  `json:"pods"`
+	Findings []ConfigurationFinding `json:"findings"`
+}
+
+type Finding struct {
+	ID       string          `json:"id"`
+	Severity FindingSeverity `json:"severity"`
+	Objects  []ObjectRef     `json:"objects"`
+	Message  string          `json:"message"`
+}
+
+type IsolationCoverage struct {
+	Pods                           int      `json:"pods"`
+	IngressIsolatedPods            int      `json:"ingressIsolatedPods"`
+	EgressIsolatedPods             int      `json:"egressIsolatedPods"`
+	IngressIsolatedPercentage      float64  `json:"ingressIsolatedPercentage"`
+	EgressIsolatedPercentage       float64  `json:"egressIsolatedPercentage"`
+	PodsReachableFromAllNamespaces []PodRef `json:"podsReachableFromAllNamespaces"`
+	PodsWithUnrestrictedEgress     []PodRef `json:"podsWithUnrestrictedEgress"`
+}
+
+type NamespaceCompliance struct {
+	Namespace             string            `json:"namespace"`
+	HasIngressDefaultDeny bool              `json:"hasIngressDefaultDeny"`
+	HasEgressDefaultDeny  bool              `json:"hasEgressDefaultDeny"`
+	Coverage              IsolationCoverage `json:"coverage"`
+}
+
+type ComplianceReport struct {
+	Coverage                            IsolationCoverage      `json:"coverage"`
+	NamespacesWithoutIngressDefaultDeny []string               `json:"namespacesWithoutIngressDefaultDeny"`
+	NamespacesWithoutEgressDefaultDeny  []string               `json:"namespacesWithoutEgressDefaultDeny"`
+	Namespaces                          []*NamespaceCompliance `json:"namespaces"`
+}
+
+type HistoryEntry struct {
+	Timestamp     time.Time `json:"timestamp"`
+	Pods          int       `json:"pods"`
+	AllowedRoutes int       `json:"allowedRoutes"`
+	Findings      int       `json:"findings"`
+}
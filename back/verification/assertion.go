@@ -0,0 +1,139 @@
+package verification
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"path"
+	"strings"
+)
+
+type Expectation string
+
+const (
+	ExpectAllowed     Expectation = "allowed"
+	ExpectDenied      Expectation = "denied"
+	wildcard                      = "*"
+	endpointSeparator             = "/"
+)
+
+type Endpoint struct {
+	Namespace string            `json:"namespace"`
+	Pod       string            `json:"pod"`
+	Labels    map[string]string `json:"labels"`
+}
+
+type Assertion struct {
+	Name   string      `json:"name"`
+	From   Endpoint    `json:"from"`
+	To     Endpoint    `json:"to"`
+	Ports  []int32     `json:"ports"`
+	Expect Expectation `json:"expect"`
+}
+
+type assertionFile struct {
+	Assertions []Assertion `json:"assertions"`
+}
+
+func (endpoint *Endpoint) UnmarshalJSON(data []byte) error {
+	var shorthand string
+	if json.Unmarshal(data, &shorthand) == nil {
+		*endpoint = ParseEndpoint(shorthand)
+		return nil
+	}
+	type rawEndpoint Endpoint
+	raw := rawEndpoint{}
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+	*endpoint = Endpoint(raw)
+	if endpoint.Namespace == "" {
+		endpoint.Namespace = wildcard
+	}
+	if endpoint.Pod == "" {
+		endpoint.Pod = wildcard
+	}
+	return nil
+}
+
+func (endpoint Endpoint) String() string {
+	result := endpoint.Namespace + endpointSeparator + endpoint.Pod
+	if len(endpoint.Labels) != 0 {
+		labels := make([]string, 0, len(endpoint.Labels))
+		for key, value := range endpoint.Labels {
+			labels = append(labels, key+"="+value)
+		}
+		result += "{" + strings.Join(sortedStrings(labels), ",") + "}"
+	}
+	return result
+}
+
+func ParseEndpoint(shorthand string) Endpoint {
+	namespace, pod, hasPod := strings.Cut(shorthand, endpointSeparator)
+	if !hasPod {
+		pod = wildcard
+	}
+	return Endpoint{Namespace: namespace, Pod: pod}
+}
+
+func LoadAssertions(filePaths []string) ([]Assertion, error) {
+	assertions := make([]Assertion, 0)
+	for _, filePath := range filePaths {
+		content, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		file := assertionFile{}
+		err = yaml.UnmarshalStrict(content, &file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filePath, err)
+		}
+		for i, assertion := range file.Assertions {
+			err = validate(assertion)
+			if err != nil {
+				return nil, fmt.Errorf("%s: assertion #%d: %w", filePath, i+1, err)
+			}
+			if assertion.Name == "" {
+				file.Assertions[i].Name = defaultName(assertion)
+			}
+		}
+		assertions = append(assertions, file.Assertions...)
+	}
+	return assertions, nil
+}
+
+func validate(assertion Assertion) error {
+	if assertion.Expect != ExpectAllowed && assertion.Expect != ExpectDenied {
+		return fmt.Errorf("expect must be %s or %s, got %q", ExpectAllowed, ExpectDenied, assertion.Expect)
+	}
+	for _, endpoint := range []Endpoint{assertion.From, assertion.To} {
+		if endpoint.Namespace == "" {
+			return fmt.Errorf("from and to are required")
+		}
+		for _, pattern := range []string{endpoint.Namespace, endpoint.Pod} {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+			}
+		}
+	}
+	for _, port := range assertion.Ports {
+		if port < 1 || port > 65535 {
+			return fmt.Errorf("invalid port %d", port)
+		}
+	}
+	return nil
+}
+
+func defaultName(assertion Assertion) string {
+	verb := "can reach"
+	if assertion.Expect == ExpectDenied {
+		verb = "cannot reach"
+	}
+	name := fmt.Sprintf("%s %s %s", assertion.From, verb, assertion.To)
+	if len(assertion.Ports) != 0 {
+		name += " on ports " + formatPorts(assertion.Ports)
+	}
+	return name
+}
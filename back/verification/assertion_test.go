@@ -0,0 +1,74 @@
+package verification
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadAssertions(t *testing.T) {
+	tests := []struct {
+		name               string
+		content            string
+		expectedAssertions []Assertion
+		expectedError      bool
+	}{
+		{
+			name: "reads shorthand and detailed endpoints and names unnamed assertions",
+			content: "" +
+				"assertions:\n" +
+				"  - name: front reaches api\n" +
+				"    from: frontend/*\n" +
+				"    to: backend/api\n" +
+				"    ports: [8080]\n" +
+				"    expect: allowed\n" +
+				"  - from: dev\n" +
+				"    to:\n" +
+				"      namespace: payments\n" +
+				"      labels: {app: billing}\n" +
+				"    expect: denied\n",
+			expectedAssertions: []Assertion{
+				{Name: "front reaches api", From: Endpoint{Namespace: "frontend", Pod: "*"},
+					To: Endpoint{Namespace: "backend", Pod: "api"}, Ports: []int32{8080}, Expect: ExpectAllowed},
+				{Name: "dev/* cannot reach payments/*{app=billing}", From: Endpoint{Namespace: "dev", Pod: "*"},
+					To:     Endpoint{Namespace: "payments", Pod: "*", Labels: map[string]string{"app": "billing"}},
+					Expect: ExpectDenied},
+			},
+		},
+		{
+			name: "rejects an unknown expectation",
+			content: "" +
+				"assertions:\n" +
+				"  - from: a/*\n" +
+				"    to: b/*\n" +
+				"    expect: maybe\n",
+			expectedError: true,
+		},
+		{
+			name: "rejects unknown fields",
+			content: "" +
+				"assertions:\n" +
+				"  - from: a/*\n" +
+				"    to: b/*\n" +
+				"    expected: allowed\n",
+			expectedError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "assertions.yaml")
+			err := os.WriteFile(filePath, []byte(tt.content), 0o600)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertions, err := LoadAssertions([]string{filePath})
+			if diff := cmp.Diff(tt.expectedError, err != nil); diff != "" {
+				t.Errorf("LoadAssertions() error mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.expectedAssertions, assertions); diff != "" {
+				t.Errorf("LoadAssertions() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
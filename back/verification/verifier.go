@@ -0,0 +1,180 @@
+package verification
+
+import (
+	"fmt"
+	"karto/types"
+	"path"
+	"sort"
+	"strings"
+)
+
+type AssertionResult struct {
+	Name       string   `json:"name"`
+	Passed     bool     `json:"passed"`
+	Message    string   `json:"message"`
+	Violations []string `json:"violations"`
+}
+
+type Report struct {
+	Passed  int                `json:"passed"`
+	Failed  int                `json:"failed"`
+	Results []*AssertionResult `json:"results"`
+}
+
+type routeKey struct {
+	source types.PodRef
+	target types.PodRef
+}
+
+func Verify(assertions []Assertion, pods []*types.Pod, allowedRoutes []*types.AllowedRoute) *Report {
+	routes := make(map[routeKey]*types.AllowedRoute, len(allowedRoutes))
+	for _, allowedRoute := range allowedRoutes {
+		routes[routeKey{source: allowedRoute.SourcePod, target: allowedRoute.TargetPod}] = allowedRoute
+	}
+	report := &Report{
+		Results: make([]*AssertionResult, 0, len(assertions)),
+	}
+	for _, assertion := range assertions {
+		result := verifyAssertion(assertion, pods, routes)
+		if result.Passed {
+			report.Passed++
+		} else {
+			report.Failed++
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report
+}
+
+func verifyAssertion(assertion Assertion, pods []*types.Pod, routes map[routeKey]*types.AllowedRoute) *AssertionResult {
+	sourcePods := SelectPods(assertion.From, pods)
+	targetPods := SelectPods(assertion.To, pods)
+	result := &AssertionResult{
+		Name:       assertion.Name,
+		Violations: make([]string, 0),
+	}
+	if assertion.Expect == ExpectAllowed && (len(sourcePods) == 0 || len(targetPods) == 0) {
+		result.Message = "no pod matches the source or the target of the assertion"
+		return result
+	}
+	pairs := 0
+	for _, sourcePod := range sourcePods {
+		for _, targetPod := range targetPods {
+			if sourcePod == targetPod {
+				continue
+			}
+			pairs++
+			route := routes[routeKey{source: sourcePod, target: targetPod}]
+			violation := checkRoute(assertion, route)
+			if violation != "" {
+				result.Violations = append(result.Violations, fmt.Sprintf("%s/%s -> %s/%s: %s",
+					sourcePod.Namespace, sourcePod.Name, targetPod.Namespace, targetPod.Name, violation))
+			}
+		}
+	}
+	result.Passed = len(result.Violations) == 0
+	if result.Passed {
+		result.Message = fmt.Sprintf("%d route(s) checked", pairs)
+	} else {
+		result.Message = fmt.Sprintf("%d of %d route(s) violate the assertion", len(result.Violations), pairs)
+	}
+	return result
+}
+
+func checkRoute(assertion Assertion, route *types.AllowedRoute) string {
+	if assertion.Expect == ExpectAllowed {
+		if route == nil {
+			return "traffic is denied"
+		}
+		deniedPorts := make([]int32, 0)
+		for _, port := range assertion.Ports {
+			if !route.AllowsPort(port) {
+				deniedPorts = append(deniedPorts, port)
+			}
+		}
+		if len(deniedPorts) != 0 {
+			return "traffic is denied on ports " + formatPorts(deniedPorts)
+		}
+		return ""
+	}
+	if route == nil {
+		return ""
+	}
+	if len(assertion.Ports) == 0 {
+		if route.Ports == nil && (len(route.DeniedPorts) != 0 || len(route.DeniedPortRanges) != 0) {
+			return "traffic is allowed on all ports except " + formatPortRanges(route.DeniedPorts, route.DeniedPortRanges)
+		} else if route.Ports == nil {
+			return "traffic is allowed on all ports"
+		}
+		return "traffic is allowed on ports " + formatPortRanges(route.Ports, route.PortRanges)
+	}
+	allowedPorts := make([]int32, 0)
+	for _, port := range assertion.Ports {
+		if route.AllowsPort(port) {
+			allowedPorts = append(allowedPorts, port)
+		}
+	}
+	if len(allowedPorts) != 0 {
+		return "traffic is allowed on ports " + formatPorts(allowedPorts)
+	}
+	return ""
+}
+
+func (endpoint Endpoint) Matches(pod *types.Pod) bool {
+	return matches(endpoint.Namespace, pod.Namespace) && matches(endpoint.Pod, pod.Name) &&
+		labelsMatch(endpoint.Labels, pod.Labels)
+}
+
+func SelectPods(endpoint Endpoint, pods []*types.Pod) []types.PodRef {
+	result := make([]types.PodRef, 0)
+	for _, pod := range pods {
+		if endpoint.Matches(pod) {
+			result = append(result, types.PodRef{Name: pod.Name, Namespace: pod.Namespace})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Namespace != result[j].Namespace {
+			return result[i].Namespace < result[j].Namespace
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+func matches(pattern string, value string) bool {
+	isMatching, _ := path.Match(pattern, value)
+	return isMatching
+}
+
+func labelsMatch(expectedLabels map[string]string, labels map[string]string) bool {
+	for key, value := range expectedLabels {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+func formatPorts(ports []int32) string {
+	result := make([]string, 0, len(ports))
+	for _, port := range ports {
+		result = append(result, fmt.Sprint(port))
+	}
+	return strings.Join(result, ",")
+}
+
+func formatPortRanges(ports []int32, portRanges []types.PortRange) string {
+	result := make([]string, 0, len(ports)+len(portRanges))
+	for _, port := range ports {
+		result = append(result, fmt.Sprint(port))
+	}
+	for _, portRange := range portRanges {
+		result = append(result, fmt.Sprintf("%d-%d", portRange.Start, portRange.End))
+	}
+	return strings.Join(result, ",")
+}
+
+func sortedStrings(values []string) []string {
+	sort.Strings(values)
+	return values
+}
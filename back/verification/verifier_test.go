@@ -0,0 +1,140 @@
+package verification
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"karto/types"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	type args struct {
+		assertions    []Assertion
+		pods          []*types.Pod
+		allowedRoutes []*types.AllowedRoute
+	}
+	front := &types.Pod{Name: "front-1", Namespace: "frontend", Labels: map[string]string{"app": "front"}}
+	api := &types.Pod{Name: "api-1", Namespace: "backend", Labels: map[string]string{"app": "api"}}
+	dev := &types.Pod{Name: "tool", Namespace: "dev", Labels: map[string]string{}}
+	payments := &types.Pod{Name: "billing", Namespace: "payments", Labels: map[string]string{}}
+	frontRef := types.PodRef{Name: front.Name, Namespace: front.Namespace}
+	apiRef := types.PodRef{Name: api.Name, Namespace: api.Namespace}
+	devRef := types.PodRef{Name: dev.Name, Namespace: dev.Namespace}
+	paymentsRef := types.PodRef{Name: payments.Name, Namespace: payments.Namespace}
+	pods := []*types.Pod{front, api, dev, payments}
+	tests := []struct {
+		name           string
+		args           args
+		expectedReport *Report
+	}{
+		{
+			name: "passes when allowed routes cover the expected ports",
+			args: args{
+				assertions: []Assertion{
+					{Name: "front reaches api", From: Endpoint{Namespace: "frontend", Pod: "*"},
+						To: Endpoint{Namespace: "backend", Pod: "api-*"}, Ports: []int32{8080},
+						Expect: ExpectAllowed},
+				},
+				pods:          pods,
+				allowedRoutes: []*types.AllowedRoute{{SourcePod: frontRef, TargetPod: apiRef, Ports: []int32{8080}}},
+			},
+			expectedReport: &Report{
+				Passed: 1,
+				Results: []*AssertionResult{
+					{Name: "front reaches api", Passed: true, Message: "1 route(s) checked", Violations: []string{}},
+				},
+			},
+		},
+		{
+			name: "fails when an expected route is denied on some ports",
+			args: args{
+				assertions: []Assertion{
+					{Name: "front reaches api", From: Endpoint{Namespace: "frontend", Pod: "*"},
+						To:    Endpoint{Namespace: "*", Pod: "*", Labels: map[string]string{"app": "api"}},
+						Ports: []int32{80, 8080}, Expect: ExpectAllowed},
+				},
+				pods:          pods,
+				allowedRoutes: []*types.AllowedRoute{{SourcePod: frontRef, TargetPod: apiRef, Ports: []int32{80}}},
+			},
+			expectedReport: &Report{
+				Failed: 1,
+				Results: []*AssertionResult{
+					{Name: "front reaches api", Passed: false, Message: "1 of 1 route(s) violate the assertion",
+						Violations: []string{"frontend/front-1 -> backend/api-1: traffic is denied on ports 8080"}},
+				},
+			},
+		},
+		{
+			name: "fails when an expected port is denied on a route allowing all other ports",
+			args: args{
+				assertions: []Assertion{
+					{Name: "front reaches api", From: Endpoint{Namespace: "frontend", Pod: "*"},
+						To: Endpoint{Namespace: "backend", Pod: "*"}, Ports: []int32{22, 8080}, Expect: ExpectAllowed},
+				},
+				pods: pods,
+				allowedRoutes: []*types.AllowedRoute{
+					{SourcePod: frontRef, TargetPod: apiRef, Ports: nil, DeniedPorts: []int32{22}},
+				},
+			},
+			expectedReport: &Report{
+				Failed: 1,
+				Results: []*AssertionResult{
+					{Name: "front reaches api", Passed: false, Message: "1 of 1 route(s) violate the assertion",
+						Violations: []string{"frontend/front-1 -> backend/api-1: traffic is denied on ports 22"}},
+				},
+			},
+		},
+		{
+			name: "fails when an allowed assertion matches no pod",
+			args: args{
+				assertions: []Assertion{
+					{Name: "missing", From: Endpoint{Namespace: "frontend", Pod: "*"},
+						To: Endpoint{Namespace: "unknown", Pod: "*"}, Expect: ExpectAllowed},
+				},
+				pods:          pods,
+				allowedRoutes: []*types.AllowedRoute{},
+			},
+			expectedReport: &Report{
+				Failed: 1,
+				Results: []*AssertionResult{
+					{Name: "missing", Passed: false,
+						Message:    "no pod matches the source or the target of the assertion",
+						Violations: []string{}},
+				},
+			},
+		},
+		{
+			name: "fails when a denied route is allowed",
+			args: args{
+				assertions: []Assertion{
+					{Name: "dev isolated from payments", From: Endpoint{Namespace: "dev", Pod: "*"},
+						To: Endpoint{Namespace: "payments", Pod: "*"}, Expect: ExpectDenied},
+					{Name: "payments isolated from dev on 22", From: Endpoint{Namespace: "payments", Pod: "*"},
+						To: Endpoint{Namespace: "dev", Pod: "*"}, Ports: []int32{22}, Expect: ExpectDenied},
+				},
+				pods: pods,
+				allowedRoutes: []*types.AllowedRoute{
+					{SourcePod: devRef, TargetPod: paymentsRef, Ports: nil},
+					{SourcePod: paymentsRef, TargetPod: devRef, Ports: []int32{443}},
+				},
+			},
+			expectedReport: &Report{
+				Passed: 1,
+				Failed: 1,
+				Results: []*AssertionResult{
+					{Name: "dev isolated from payments", Passed: false, Message: "1 of 1 route(s) violate the assertion",
+						Violations: []string{"dev/tool -> payments/billing: traffic is allowed on all ports"}},
+					{Name: "payments isolated from dev on 22", Passed: true, Message: "1 route(s) checked",
+						Violations: []string{}},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := Verify(tt.args.assertions, tt.args.pods, tt.args.allowedRoutes)
+			if diff := cmp.Diff(tt.expectedReport, report); diff != "" {
+				t.Errorf("Verify() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
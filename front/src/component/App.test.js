@@ -1,6 +1,6 @@
 import { act, fireEvent, render, screen } from '@testing-library/react';
 import '@testing-library/jest-dom/extend-expect';
-import { computeDataSet, fetchAnalysisResult } from '../service/analysisResultService';
+import { computeDataSet, fetchAnalysisResult, subscribeToAnalysisResults } from '../service/analysisResultService';
 import { labelSelectorOperators, maxRecommendedAllowedRoutes, maxRecommendedPods } from '../constants';
 import ClusterGraph from './graph/ClusterGraph';
 import NetworkPolicyGraph from './graph/NetworkPolicyGraph';
@@ -125,20 +125,25 @@ describe('App component', () => {
         expect(screen.queryByText('Analyzing your cluster...')).not.toBeInTheDocument();
     });
 
-    it('refreshes analysis results every 2 seconds when auto refresh is on', async () => {
+    it('subscribes to analysis result updates when auto refresh is on', async () => {
+        let onAnalysisResult;
+        const unsubscribe = jest.fn();
+        subscribeToAnalysisResults.mockImplementation(callback => {
+            onAnalysisResult = callback;
+            return unsubscribe;
+        });
         render(<App/>);
         fireEvent.click(screen.getByText('Auto refresh'));
         await waitForComponentUpdate();
+        expect(subscribeToAnalysisResults).toHaveBeenCalledTimes(1);
 
-        fetchAnalysisResult.mockClear();
-        act(() => jest.advanceTimersByTime(2000));
-        await waitForComponentUpdate();
-        expect(fetchAnalysisResult).toHaveBeenCalledTimes(1);
+        const pushedAnalysisResult = { pods: [{ name: 'pod1' }] };
+        act(() => onAnalysisResult(pushedAnalysisResult));
+        expect(computeDataSet).toHaveBeenLastCalledWith(pushedAnalysisResult, expect.anything());
 
-        fetchAnalysisResult.mockClear();
-        act(() => jest.advanceTimersByTime(2000));
+        fireEvent.click(screen.getByText('Auto refresh'));
         await waitForComponentUpdate();
-        expect(fetchAnalysisResult).toHaveBeenCalledTimes(1);
+        expect(unsubscribe).toHaveBeenCalledTimes(1);
     });
 
     it('displays message when dataset is empty', async () => {
@@ -4,7 +4,7 @@ import Typography from '@mui/material/Typography';
 import SwitchControl from './control/SwitchControl';
 import MultiSelectControl from './control/MultiSelectControl';
 import { getControls, storeControls } from '../service/storageService';
-import { computeDataSet, fetchAnalysisResult } from '../service/analysisResultService';
+import { computeDataSet, fetchAnalysisResult, subscribeToAnalysisResults } from '../service/analysisResultService';
 import InputControl from './control/InputControl';
 import AllowedRouteDetails from './detail/AllowedRouteDetails';
 import PodDetails from './detail/PodDetails';
@@ -96,8 +96,7 @@ const Content = () => {
     const allLabels = state.analysisResult ? state.analysisResult.allLabels : {};
 
     useEffect(() => {
-        const fetchAndUpdate = async () => {
-            const analysisResult = await fetchAnalysisResult();
+        const update = analysisResult => {
             setState(oldState => ({
                 ...oldState,
                 isLoading: false,
@@ -105,13 +104,13 @@ const Content = () => {
                 dataSet: computeDataSet(analysisResult, oldState.controls)
             }));
         };
+        const fetchAndUpdate = async () => {
+            update(await fetchAnalysisResult());
+        };
         fetchAndUpdate();
 
         if (state.controls.autoRefresh) {
-            const interval = setInterval(() => {
-                fetchAndUpdate();
-            }, 2000);
-            return () => clearInterval(interval);
+            return subscribeToAnalysisResults(update);
         }
     }, [state.controls.autoRefresh]);
 
@@ -4,7 +4,57 @@ export async function fetchAnalysisResult() {
         console.error(`Could not fetch analysis result: error code : ${response.status}`);
         return;
     }
-    const result = await response.json();
+    return withPodsMetadata(await response.json());
+}
+
+export function subscribeToAnalysisResults(onAnalysisResult) {
+    if (typeof EventSource === 'undefined') {
+        const fetchAndNotify = async () => onAnalysisResult(await fetchAnalysisResult());
+        const interval = setInterval(fetchAndNotify, 2000);
+        return () => clearInterval(interval);
+    }
+    let analysisResult = null;
+    let sequence = null;
+    let eventSource = null;
+    const connect = () => {
+        eventSource = new EventSource('./api/analysisResult/stream');
+        eventSource.addEventListener('full', event => {
+            const message = JSON.parse(event.data);
+            sequence = message.sequence;
+            analysisResult = message.result;
+            onAnalysisResult(withPodsMetadata({ ...analysisResult }));
+        });
+        eventSource.addEventListener('delta', event => {
+            const delta = JSON.parse(event.data);
+            if (analysisResult == null || delta.sequence !== sequence + 1) {
+                // A delta was missed, reconnect to start again from a full analysis result
+                eventSource.close();
+                connect();
+                return;
+            }
+            sequence = delta.sequence;
+            analysisResult = applyAnalysisResultDelta(analysisResult, delta);
+            onAnalysisResult(withPodsMetadata({ ...analysisResult }));
+        });
+    };
+    connect();
+    return () => eventSource.close();
+}
+
+export function applyAnalysisResultDelta(analysisResult, delta) {
+    const result = { ...analysisResult };
+    (delta.unset || []).forEach(key => delete result[key]);
+    Object.entries(delta.set || {}).forEach(([key, value]) => {
+        result[key] = value;
+    });
+    Object.entries(delta.lists || {}).forEach(([key, { removed, added }]) => {
+        const removedIndexes = new Set(removed);
+        result[key] = (result[key] || []).filter((_, index) => !removedIndexes.has(index)).concat(added);
+    });
+    return result;
+}
+
+function withPodsMetadata(result) {
     result.allNamespaces = allNamespacesOfPods(result.pods);
     result.allLabels = allLabelsOfPods(result.pods);
     return result;
@@ -1,4 +1,4 @@
-import { computeDataSet, fetchAnalysisResult } from './analysisResultService';
+import { applyAnalysisResultDelta, computeDataSet, fetchAnalysisResult } from './analysisResultService';
 
 describe('fetchAnalysisResult', () => {
 
@@ -71,6 +71,42 @@ describe('fetchAnalysisResult', () => {
     });
 });
 
+describe('applyAnalysisResultDelta', () => {
+
+    it('removes elements by index and appends added elements of lists', () => {
+        const analysisResult = {
+            pods: [{ name: 'pod1' }, { name: 'pod2' }, { name: 'pod3' }],
+            services: [{ name: 'service1' }]
+        };
+        const delta = {
+            sequence: 2,
+            lists: { pods: { removed: [0, 2], added: [{ name: 'pod4' }] } }
+        };
+
+        const actual = applyAnalysisResultDelta(analysisResult, delta);
+
+        expect(actual.pods).toEqual([{ name: 'pod2' }, { name: 'pod4' }]);
+        expect(actual.services).toEqual([{ name: 'service1' }]);
+        expect(analysisResult.pods).toHaveLength(3);
+    });
+
+    it('sets and unsets fields', () => {
+        const analysisResult = {
+            compliance: { coverage: { pods: 1 } },
+            capabilities: { partialAnalyses: ['health'] }
+        };
+        const delta = {
+            sequence: 2,
+            set: { compliance: { coverage: { pods: 2 } } },
+            unset: ['capabilities']
+        };
+
+        const actual = applyAnalysisResultDelta(analysisResult, delta);
+
+        expect(actual).toEqual({ compliance: { coverage: { pods: 2 } } });
+    });
+});
+
 describe('computeDataSet', () => {
     const emptyAnalysisResult = {
         pods: [],